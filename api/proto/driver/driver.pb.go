@@ -23,11 +23,14 @@ const (
 )
 
 type Location struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	DriverId      string                 `protobuf:"bytes,1,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
-	Latitude      float64                `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty"`
-	Longitude     float64                `protobuf:"fixed64,3,opt,name=longitude,proto3" json:"longitude,omitempty"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	DriverId  string                 `protobuf:"bytes,1,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
+	Latitude  float64                `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude float64                `protobuf:"fixed64,3,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// vehicle_type selects the speed limits used to reject GPS glitches, e.g. "bike" or
+	// "car". Unknown or empty types use the default limit.
+	VehicleType   string `protobuf:"bytes,5,opt,name=vehicle_type,json=vehicleType,proto3" json:"vehicle_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,9 +93,20 @@ func (x *Location) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Location) GetVehicleType() string {
+	if x != nil {
+		return x.VehicleType
+	}
+	return ""
+}
+
 type UpdateLocationAck struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// accepted counts points that were stored.
+	Accepted uint32 `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	// dropped counts duplicate, stale and future-dated points that were skipped.
+	Dropped       uint32 `protobuf:"varint,3,opt,name=dropped,proto3" json:"dropped,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -134,20 +148,335 @@ func (x *UpdateLocationAck) GetMessage() string {
 	return ""
 }
 
+func (x *UpdateLocationAck) GetAccepted() uint32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *UpdateLocationAck) GetDropped() uint32 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+// LocationUpdate is one point on a StreamLocations stream. seq is chosen by the client
+// and echoed in the matching LocationResult.
+type LocationUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Location      *Location              `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LocationUpdate) Reset() {
+	*x = LocationUpdate{}
+	mi := &file_api_proto_driver_driver_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocationUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocationUpdate) ProtoMessage() {}
+
+func (x *LocationUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_driver_driver_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocationUpdate.ProtoReflect.Descriptor instead.
+func (*LocationUpdate) Descriptor() ([]byte, []int) {
+	return file_api_proto_driver_driver_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LocationUpdate) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *LocationUpdate) GetLocation() *Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+// LocationResult acknowledges or rejects the update with the same seq.
+type LocationResult struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Seq      uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Accepted bool                   `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	// reason explains a rejection.
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// retryable is set when the point was valid but could not be stored; resending it
+	// later may succeed.
+	Retryable bool `protobuf:"varint,4,opt,name=retryable,proto3" json:"retryable,omitempty"`
+	// outcome classifies the result: "accepted", "invalid", "duplicate", "stale",
+	// "future_timestamp", "implausible_speed" or "failed".
+	Outcome       string `protobuf:"bytes,5,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LocationResult) Reset() {
+	*x = LocationResult{}
+	mi := &file_api_proto_driver_driver_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocationResult) ProtoMessage() {}
+
+func (x *LocationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_driver_driver_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocationResult.ProtoReflect.Descriptor instead.
+func (*LocationResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_driver_driver_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LocationResult) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *LocationResult) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *LocationResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *LocationResult) GetRetryable() bool {
+	if x != nil {
+		return x.Retryable
+	}
+	return false
+}
+
+func (x *LocationResult) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+type GetLocationHistoryRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	DriverId string                 `protobuf:"bytes,1,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
+	// from is inclusive and to exclusive; either may be omitted.
+	From *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// max_points, when set, downsamples the range to at most this many evenly spaced points.
+	MaxPoints     int32  `protobuf:"varint,4,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`
+	PageSize      int32  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLocationHistoryRequest) Reset() {
+	*x = GetLocationHistoryRequest{}
+	mi := &file_api_proto_driver_driver_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLocationHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLocationHistoryRequest) ProtoMessage() {}
+
+func (x *GetLocationHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_driver_driver_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLocationHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetLocationHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_driver_driver_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetLocationHistoryRequest) GetDriverId() string {
+	if x != nil {
+		return x.DriverId
+	}
+	return ""
+}
+
+func (x *GetLocationHistoryRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetLocationHistoryRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetLocationHistoryRequest) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+func (x *GetLocationHistoryRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *GetLocationHistoryRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type GetLocationHistoryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// points are ordered oldest first.
+	Points        []*Location `protobuf:"bytes,1,rep,name=points,proto3" json:"points,omitempty"`
+	NextPageToken string      `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLocationHistoryResponse) Reset() {
+	*x = GetLocationHistoryResponse{}
+	mi := &file_api_proto_driver_driver_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLocationHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLocationHistoryResponse) ProtoMessage() {}
+
+func (x *GetLocationHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_driver_driver_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLocationHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetLocationHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_driver_driver_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetLocationHistoryResponse) GetPoints() []*Location {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+func (x *GetLocationHistoryResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 var File_api_proto_driver_driver_proto protoreflect.FileDescriptor
 
 const file_api_proto_driver_driver_proto_rawDesc = "" +
 	"\n" +
-	"\x1dapi/proto/driver/driver.proto\x12\x06driver\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9b\x01\n" +
+	"\x1dapi/proto/driver/driver.proto\x12\x06driver\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbe\x01\n" +
 	"\bLocation\x12\x1b\n" +
 	"\tdriver_id\x18\x01 \x01(\tR\bdriverId\x12\x1a\n" +
 	"\blatitude\x18\x02 \x01(\x01R\blatitude\x12\x1c\n" +
 	"\tlongitude\x18\x03 \x01(\x01R\tlongitude\x128\n" +
-	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"-\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12!\n" +
+	"\fvehicle_type\x18\x05 \x01(\tR\vvehicleType\"c\n" +
 	"\x11UpdateLocationAck\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage2P\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\rR\baccepted\x12\x18\n" +
+	"\adropped\x18\x03 \x01(\rR\adropped\"P\n" +
+	"\x0eLocationUpdate\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12,\n" +
+	"\blocation\x18\x02 \x01(\v2\x10.driver.LocationR\blocation\"\x8e\x01\n" +
+	"\x0eLocationResult\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\bR\baccepted\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1c\n" +
+	"\tretryable\x18\x04 \x01(\bR\tretryable\x12\x18\n" +
+	"\aoutcome\x18\x05 \x01(\tR\aoutcome\"\xef\x01\n" +
+	"\x19GetLocationHistoryRequest\x12\x1b\n" +
+	"\tdriver_id\x18\x01 \x01(\tR\bdriverId\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x04 \x01(\x05R\tmaxPoints\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\"n\n" +
+	"\x1aGetLocationHistoryResponse\x12(\n" +
+	"\x06points\x18\x01 \x03(\v2\x10.driver.LocationR\x06points\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken2\xf4\x01\n" +
 	"\rDriverService\x12?\n" +
-	"\x0eUpdateLocation\x12\x10.driver.Location\x1a\x19.driver.UpdateLocationAck(\x01B%Z#wayfinder/api/proto/driver;driverpbb\x06proto3"
+	"\x0eUpdateLocation\x12\x10.driver.Location\x1a\x19.driver.UpdateLocationAck(\x01\x12E\n" +
+	"\x0fStreamLocations\x12\x16.driver.LocationUpdate\x1a\x16.driver.LocationResult(\x010\x01\x12[\n" +
+	"\x12GetLocationHistory\x12!.driver.GetLocationHistoryRequest\x1a\".driver.GetLocationHistoryResponseB%Z#wayfinder/api/proto/driver;driverpbb\x06proto3"
 
 var (
 	file_api_proto_driver_driver_proto_rawDescOnce sync.Once
@@ -161,21 +490,33 @@ func file_api_proto_driver_driver_proto_rawDescGZIP() []byte {
 	return file_api_proto_driver_driver_proto_rawDescData
 }
 
-var file_api_proto_driver_driver_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_api_proto_driver_driver_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_api_proto_driver_driver_proto_goTypes = []any{
-	(*Location)(nil),              // 0: driver.Location
-	(*UpdateLocationAck)(nil),     // 1: driver.UpdateLocationAck
-	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+	(*Location)(nil),                   // 0: driver.Location
+	(*UpdateLocationAck)(nil),          // 1: driver.UpdateLocationAck
+	(*LocationUpdate)(nil),             // 2: driver.LocationUpdate
+	(*LocationResult)(nil),             // 3: driver.LocationResult
+	(*GetLocationHistoryRequest)(nil),  // 4: driver.GetLocationHistoryRequest
+	(*GetLocationHistoryResponse)(nil), // 5: driver.GetLocationHistoryResponse
+	(*timestamppb.Timestamp)(nil),      // 6: google.protobuf.Timestamp
 }
 var file_api_proto_driver_driver_proto_depIdxs = []int32{
-	2, // 0: driver.Location.timestamp:type_name -> google.protobuf.Timestamp
-	0, // 1: driver.DriverService.UpdateLocation:input_type -> driver.Location
-	1, // 2: driver.DriverService.UpdateLocation:output_type -> driver.UpdateLocationAck
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	6, // 0: driver.Location.timestamp:type_name -> google.protobuf.Timestamp
+	0, // 1: driver.LocationUpdate.location:type_name -> driver.Location
+	6, // 2: driver.GetLocationHistoryRequest.from:type_name -> google.protobuf.Timestamp
+	6, // 3: driver.GetLocationHistoryRequest.to:type_name -> google.protobuf.Timestamp
+	0, // 4: driver.GetLocationHistoryResponse.points:type_name -> driver.Location
+	0, // 5: driver.DriverService.UpdateLocation:input_type -> driver.Location
+	2, // 6: driver.DriverService.StreamLocations:input_type -> driver.LocationUpdate
+	4, // 7: driver.DriverService.GetLocationHistory:input_type -> driver.GetLocationHistoryRequest
+	1, // 8: driver.DriverService.UpdateLocation:output_type -> driver.UpdateLocationAck
+	3, // 9: driver.DriverService.StreamLocations:output_type -> driver.LocationResult
+	5, // 10: driver.DriverService.GetLocationHistory:output_type -> driver.GetLocationHistoryResponse
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_driver_driver_proto_init() }
@@ -189,7 +530,7 @@ func file_api_proto_driver_driver_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_driver_driver_proto_rawDesc), len(file_api_proto_driver_driver_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
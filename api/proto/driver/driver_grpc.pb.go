@@ -19,7 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DriverService_UpdateLocation_FullMethodName = "/driver.DriverService/UpdateLocation"
+	DriverService_UpdateLocation_FullMethodName     = "/driver.DriverService/UpdateLocation"
+	DriverService_StreamLocations_FullMethodName    = "/driver.DriverService/StreamLocations"
+	DriverService_GetLocationHistory_FullMethodName = "/driver.DriverService/GetLocationHistory"
 )
 
 // DriverServiceClient is the client API for DriverService service.
@@ -28,6 +30,11 @@ const (
 type DriverServiceClient interface {
 	// Client-streaming endpoint for driver location updates.
 	UpdateLocation(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Location, UpdateLocationAck], error)
+	// Bidirectional endpoint that acknowledges or rejects every update individually.
+	// Rejected points do not end the stream.
+	StreamLocations(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LocationUpdate, LocationResult], error)
+	// Returns a driver's stored location history, oldest first.
+	GetLocationHistory(ctx context.Context, in *GetLocationHistoryRequest, opts ...grpc.CallOption) (*GetLocationHistoryResponse, error)
 }
 
 type driverServiceClient struct {
@@ -51,12 +58,40 @@ func (c *driverServiceClient) UpdateLocation(ctx context.Context, opts ...grpc.C
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type DriverService_UpdateLocationClient = grpc.ClientStreamingClient[Location, UpdateLocationAck]
 
+func (c *driverServiceClient) StreamLocations(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LocationUpdate, LocationResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DriverService_ServiceDesc.Streams[1], DriverService_StreamLocations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LocationUpdate, LocationResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DriverService_StreamLocationsClient = grpc.BidiStreamingClient[LocationUpdate, LocationResult]
+
+func (c *driverServiceClient) GetLocationHistory(ctx context.Context, in *GetLocationHistoryRequest, opts ...grpc.CallOption) (*GetLocationHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLocationHistoryResponse)
+	err := c.cc.Invoke(ctx, DriverService_GetLocationHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DriverServiceServer is the server API for DriverService service.
 // All implementations must embed UnimplementedDriverServiceServer
 // for forward compatibility.
 type DriverServiceServer interface {
 	// Client-streaming endpoint for driver location updates.
 	UpdateLocation(grpc.ClientStreamingServer[Location, UpdateLocationAck]) error
+	// Bidirectional endpoint that acknowledges or rejects every update individually.
+	// Rejected points do not end the stream.
+	StreamLocations(grpc.BidiStreamingServer[LocationUpdate, LocationResult]) error
+	// Returns a driver's stored location history, oldest first.
+	GetLocationHistory(context.Context, *GetLocationHistoryRequest) (*GetLocationHistoryResponse, error)
 	mustEmbedUnimplementedDriverServiceServer()
 }
 
@@ -70,6 +105,12 @@ type UnimplementedDriverServiceServer struct{}
 func (UnimplementedDriverServiceServer) UpdateLocation(grpc.ClientStreamingServer[Location, UpdateLocationAck]) error {
 	return status.Error(codes.Unimplemented, "method UpdateLocation not implemented")
 }
+func (UnimplementedDriverServiceServer) StreamLocations(grpc.BidiStreamingServer[LocationUpdate, LocationResult]) error {
+	return status.Error(codes.Unimplemented, "method StreamLocations not implemented")
+}
+func (UnimplementedDriverServiceServer) GetLocationHistory(context.Context, *GetLocationHistoryRequest) (*GetLocationHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetLocationHistory not implemented")
+}
 func (UnimplementedDriverServiceServer) mustEmbedUnimplementedDriverServiceServer() {}
 func (UnimplementedDriverServiceServer) testEmbeddedByValue()                       {}
 
@@ -98,19 +139,55 @@ func _DriverService_UpdateLocation_Handler(srv interface{}, stream grpc.ServerSt
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type DriverService_UpdateLocationServer = grpc.ClientStreamingServer[Location, UpdateLocationAck]
 
+func _DriverService_StreamLocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DriverServiceServer).StreamLocations(&grpc.GenericServerStream[LocationUpdate, LocationResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DriverService_StreamLocationsServer = grpc.BidiStreamingServer[LocationUpdate, LocationResult]
+
+func _DriverService_GetLocationHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLocationHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServiceServer).GetLocationHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DriverService_GetLocationHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServiceServer).GetLocationHistory(ctx, req.(*GetLocationHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DriverService_ServiceDesc is the grpc.ServiceDesc for DriverService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var DriverService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "driver.DriverService",
 	HandlerType: (*DriverServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLocationHistory",
+			Handler:    _DriverService_GetLocationHistory_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "UpdateLocation",
 			Handler:       _DriverService_UpdateLocation_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "StreamLocations",
+			Handler:       _DriverService_StreamLocations_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "api/proto/driver/driver.proto",
 }
@@ -0,0 +1,715 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.2
+// source: api/proto/geofence/geofence.proto
+
+package geofencepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LatLng struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Latitude      float64                `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatLng) Reset() {
+	*x = LatLng{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatLng) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatLng) ProtoMessage() {}
+
+func (x *LatLng) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatLng.ProtoReflect.Descriptor instead.
+func (*LatLng) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LatLng) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *LatLng) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+type Circle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Center        *LatLng                `protobuf:"bytes,1,opt,name=center,proto3" json:"center,omitempty"`
+	RadiusMeters  float64                `protobuf:"fixed64,2,opt,name=radius_meters,json=radiusMeters,proto3" json:"radius_meters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Circle) Reset() {
+	*x = Circle{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Circle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Circle) ProtoMessage() {}
+
+func (x *Circle) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Circle.ProtoReflect.Descriptor instead.
+func (*Circle) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Circle) GetCenter() *LatLng {
+	if x != nil {
+		return x.Center
+	}
+	return nil
+}
+
+func (x *Circle) GetRadiusMeters() float64 {
+	if x != nil {
+		return x.RadiusMeters
+	}
+	return 0
+}
+
+// Polygon is a closed ring; the last vertex connects back to the first.
+type Polygon struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vertices      []*LatLng              `protobuf:"bytes,1,rep,name=vertices,proto3" json:"vertices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Polygon) Reset() {
+	*x = Polygon{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Polygon) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Polygon) ProtoMessage() {}
+
+func (x *Polygon) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Polygon.ProtoReflect.Descriptor instead.
+func (*Polygon) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Polygon) GetVertices() []*LatLng {
+	if x != nil {
+		return x.Vertices
+	}
+	return nil
+}
+
+// Geofence is a named area. Exactly one of circle and polygon is set.
+type Geofence struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is assigned by the server.
+	Id      string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name    string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Circle  *Circle  `protobuf:"bytes,3,opt,name=circle,proto3" json:"circle,omitempty"`
+	Polygon *Polygon `protobuf:"bytes,4,opt,name=polygon,proto3" json:"polygon,omitempty"`
+	// dwell_seconds, when positive, emits a dwell event once a driver has stayed inside
+	// this long.
+	DwellSeconds  float64                `protobuf:"fixed64,5,opt,name=dwell_seconds,json=dwellSeconds,proto3" json:"dwell_seconds,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Geofence) Reset() {
+	*x = Geofence{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Geofence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Geofence) ProtoMessage() {}
+
+func (x *Geofence) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Geofence.ProtoReflect.Descriptor instead.
+func (*Geofence) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Geofence) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Geofence) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Geofence) GetCircle() *Circle {
+	if x != nil {
+		return x.Circle
+	}
+	return nil
+}
+
+func (x *Geofence) GetPolygon() *Polygon {
+	if x != nil {
+		return x.Polygon
+	}
+	return nil
+}
+
+func (x *Geofence) GetDwellSeconds() float64 {
+	if x != nil {
+		return x.DwellSeconds
+	}
+	return 0
+}
+
+func (x *Geofence) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type CreateGeofenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Geofence      *Geofence              `protobuf:"bytes,1,opt,name=geofence,proto3" json:"geofence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGeofenceRequest) Reset() {
+	*x = CreateGeofenceRequest{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGeofenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGeofenceRequest) ProtoMessage() {}
+
+func (x *CreateGeofenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGeofenceRequest.ProtoReflect.Descriptor instead.
+func (*CreateGeofenceRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateGeofenceRequest) GetGeofence() *Geofence {
+	if x != nil {
+		return x.Geofence
+	}
+	return nil
+}
+
+type CreateGeofenceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Geofence      *Geofence              `protobuf:"bytes,1,opt,name=geofence,proto3" json:"geofence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGeofenceResponse) Reset() {
+	*x = CreateGeofenceResponse{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGeofenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGeofenceResponse) ProtoMessage() {}
+
+func (x *CreateGeofenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGeofenceResponse.ProtoReflect.Descriptor instead.
+func (*CreateGeofenceResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateGeofenceResponse) GetGeofence() *Geofence {
+	if x != nil {
+		return x.Geofence
+	}
+	return nil
+}
+
+type GetGeofenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGeofenceRequest) Reset() {
+	*x = GetGeofenceRequest{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGeofenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGeofenceRequest) ProtoMessage() {}
+
+func (x *GetGeofenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGeofenceRequest.ProtoReflect.Descriptor instead.
+func (*GetGeofenceRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetGeofenceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetGeofenceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Geofence      *Geofence              `protobuf:"bytes,1,opt,name=geofence,proto3" json:"geofence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGeofenceResponse) Reset() {
+	*x = GetGeofenceResponse{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGeofenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGeofenceResponse) ProtoMessage() {}
+
+func (x *GetGeofenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGeofenceResponse.ProtoReflect.Descriptor instead.
+func (*GetGeofenceResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetGeofenceResponse) GetGeofence() *Geofence {
+	if x != nil {
+		return x.Geofence
+	}
+	return nil
+}
+
+type ListGeofencesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGeofencesRequest) Reset() {
+	*x = ListGeofencesRequest{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGeofencesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGeofencesRequest) ProtoMessage() {}
+
+func (x *ListGeofencesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGeofencesRequest.ProtoReflect.Descriptor instead.
+func (*ListGeofencesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{8}
+}
+
+type ListGeofencesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// geofences are ordered oldest first.
+	Geofences     []*Geofence `protobuf:"bytes,1,rep,name=geofences,proto3" json:"geofences,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGeofencesResponse) Reset() {
+	*x = ListGeofencesResponse{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGeofencesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGeofencesResponse) ProtoMessage() {}
+
+func (x *ListGeofencesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGeofencesResponse.ProtoReflect.Descriptor instead.
+func (*ListGeofencesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListGeofencesResponse) GetGeofences() []*Geofence {
+	if x != nil {
+		return x.Geofences
+	}
+	return nil
+}
+
+type DeleteGeofenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteGeofenceRequest) Reset() {
+	*x = DeleteGeofenceRequest{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteGeofenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGeofenceRequest) ProtoMessage() {}
+
+func (x *DeleteGeofenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGeofenceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteGeofenceRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteGeofenceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteGeofenceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteGeofenceResponse) Reset() {
+	*x = DeleteGeofenceResponse{}
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteGeofenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGeofenceResponse) ProtoMessage() {}
+
+func (x *DeleteGeofenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_geofence_geofence_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGeofenceResponse.ProtoReflect.Descriptor instead.
+func (*DeleteGeofenceResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_geofence_geofence_proto_rawDescGZIP(), []int{11}
+}
+
+var File_api_proto_geofence_geofence_proto protoreflect.FileDescriptor
+
+const file_api_proto_geofence_geofence_proto_rawDesc = "" +
+	"\n" +
+	"!api/proto/geofence/geofence.proto\x12\bgeofence\x1a\x1fgoogle/protobuf/timestamp.proto\"B\n" +
+	"\x06LatLng\x12\x1a\n" +
+	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\"W\n" +
+	"\x06Circle\x12(\n" +
+	"\x06center\x18\x01 \x01(\v2\x10.geofence.LatLngR\x06center\x12#\n" +
+	"\rradius_meters\x18\x02 \x01(\x01R\fradiusMeters\"7\n" +
+	"\aPolygon\x12,\n" +
+	"\bvertices\x18\x01 \x03(\v2\x10.geofence.LatLngR\bvertices\"\xe5\x01\n" +
+	"\bGeofence\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12(\n" +
+	"\x06circle\x18\x03 \x01(\v2\x10.geofence.CircleR\x06circle\x12+\n" +
+	"\apolygon\x18\x04 \x01(\v2\x11.geofence.PolygonR\apolygon\x12#\n" +
+	"\rdwell_seconds\x18\x05 \x01(\x01R\fdwellSeconds\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"G\n" +
+	"\x15CreateGeofenceRequest\x12.\n" +
+	"\bgeofence\x18\x01 \x01(\v2\x12.geofence.GeofenceR\bgeofence\"H\n" +
+	"\x16CreateGeofenceResponse\x12.\n" +
+	"\bgeofence\x18\x01 \x01(\v2\x12.geofence.GeofenceR\bgeofence\"$\n" +
+	"\x12GetGeofenceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"E\n" +
+	"\x13GetGeofenceResponse\x12.\n" +
+	"\bgeofence\x18\x01 \x01(\v2\x12.geofence.GeofenceR\bgeofence\"\x16\n" +
+	"\x14ListGeofencesRequest\"I\n" +
+	"\x15ListGeofencesResponse\x120\n" +
+	"\tgeofences\x18\x01 \x03(\v2\x12.geofence.GeofenceR\tgeofences\"'\n" +
+	"\x15DeleteGeofenceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x18\n" +
+	"\x16DeleteGeofenceResponse2\xd9\x02\n" +
+	"\x0fGeofenceService\x12S\n" +
+	"\x0eCreateGeofence\x12\x1f.geofence.CreateGeofenceRequest\x1a .geofence.CreateGeofenceResponse\x12J\n" +
+	"\vGetGeofence\x12\x1c.geofence.GetGeofenceRequest\x1a\x1d.geofence.GetGeofenceResponse\x12P\n" +
+	"\rListGeofences\x12\x1e.geofence.ListGeofencesRequest\x1a\x1f.geofence.ListGeofencesResponse\x12S\n" +
+	"\x0eDeleteGeofence\x12\x1f.geofence.DeleteGeofenceRequest\x1a .geofence.DeleteGeofenceResponseB)Z'wayfinder/api/proto/geofence;geofencepbb\x06proto3"
+
+var (
+	file_api_proto_geofence_geofence_proto_rawDescOnce sync.Once
+	file_api_proto_geofence_geofence_proto_rawDescData []byte
+)
+
+func file_api_proto_geofence_geofence_proto_rawDescGZIP() []byte {
+	file_api_proto_geofence_geofence_proto_rawDescOnce.Do(func() {
+		file_api_proto_geofence_geofence_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_proto_geofence_geofence_proto_rawDesc), len(file_api_proto_geofence_geofence_proto_rawDesc)))
+	})
+	return file_api_proto_geofence_geofence_proto_rawDescData
+}
+
+var file_api_proto_geofence_geofence_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_api_proto_geofence_geofence_proto_goTypes = []any{
+	(*LatLng)(nil),                 // 0: geofence.LatLng
+	(*Circle)(nil),                 // 1: geofence.Circle
+	(*Polygon)(nil),                // 2: geofence.Polygon
+	(*Geofence)(nil),               // 3: geofence.Geofence
+	(*CreateGeofenceRequest)(nil),  // 4: geofence.CreateGeofenceRequest
+	(*CreateGeofenceResponse)(nil), // 5: geofence.CreateGeofenceResponse
+	(*GetGeofenceRequest)(nil),     // 6: geofence.GetGeofenceRequest
+	(*GetGeofenceResponse)(nil),    // 7: geofence.GetGeofenceResponse
+	(*ListGeofencesRequest)(nil),   // 8: geofence.ListGeofencesRequest
+	(*ListGeofencesResponse)(nil),  // 9: geofence.ListGeofencesResponse
+	(*DeleteGeofenceRequest)(nil),  // 10: geofence.DeleteGeofenceRequest
+	(*DeleteGeofenceResponse)(nil), // 11: geofence.DeleteGeofenceResponse
+	(*timestamppb.Timestamp)(nil),  // 12: google.protobuf.Timestamp
+}
+var file_api_proto_geofence_geofence_proto_depIdxs = []int32{
+	0,  // 0: geofence.Circle.center:type_name -> geofence.LatLng
+	0,  // 1: geofence.Polygon.vertices:type_name -> geofence.LatLng
+	1,  // 2: geofence.Geofence.circle:type_name -> geofence.Circle
+	2,  // 3: geofence.Geofence.polygon:type_name -> geofence.Polygon
+	12, // 4: geofence.Geofence.created_at:type_name -> google.protobuf.Timestamp
+	3,  // 5: geofence.CreateGeofenceRequest.geofence:type_name -> geofence.Geofence
+	3,  // 6: geofence.CreateGeofenceResponse.geofence:type_name -> geofence.Geofence
+	3,  // 7: geofence.GetGeofenceResponse.geofence:type_name -> geofence.Geofence
+	3,  // 8: geofence.ListGeofencesResponse.geofences:type_name -> geofence.Geofence
+	4,  // 9: geofence.GeofenceService.CreateGeofence:input_type -> geofence.CreateGeofenceRequest
+	6,  // 10: geofence.GeofenceService.GetGeofence:input_type -> geofence.GetGeofenceRequest
+	8,  // 11: geofence.GeofenceService.ListGeofences:input_type -> geofence.ListGeofencesRequest
+	10, // 12: geofence.GeofenceService.DeleteGeofence:input_type -> geofence.DeleteGeofenceRequest
+	5,  // 13: geofence.GeofenceService.CreateGeofence:output_type -> geofence.CreateGeofenceResponse
+	7,  // 14: geofence.GeofenceService.GetGeofence:output_type -> geofence.GetGeofenceResponse
+	9,  // 15: geofence.GeofenceService.ListGeofences:output_type -> geofence.ListGeofencesResponse
+	11, // 16: geofence.GeofenceService.DeleteGeofence:output_type -> geofence.DeleteGeofenceResponse
+	13, // [13:17] is the sub-list for method output_type
+	9,  // [9:13] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_api_proto_geofence_geofence_proto_init() }
+func file_api_proto_geofence_geofence_proto_init() {
+	if File_api_proto_geofence_geofence_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_geofence_geofence_proto_rawDesc), len(file_api_proto_geofence_geofence_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_proto_geofence_geofence_proto_goTypes,
+		DependencyIndexes: file_api_proto_geofence_geofence_proto_depIdxs,
+		MessageInfos:      file_api_proto_geofence_geofence_proto_msgTypes,
+	}.Build()
+	File_api_proto_geofence_geofence_proto = out.File
+	file_api_proto_geofence_geofence_proto_goTypes = nil
+	file_api_proto_geofence_geofence_proto_depIdxs = nil
+}
@@ -0,0 +1,237 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v6.33.2
+// source: api/proto/geofence/geofence.proto
+
+package geofencepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GeofenceService_CreateGeofence_FullMethodName = "/geofence.GeofenceService/CreateGeofence"
+	GeofenceService_GetGeofence_FullMethodName    = "/geofence.GeofenceService/GetGeofence"
+	GeofenceService_ListGeofences_FullMethodName  = "/geofence.GeofenceService/ListGeofences"
+	GeofenceService_DeleteGeofence_FullMethodName = "/geofence.GeofenceService/DeleteGeofence"
+)
+
+// GeofenceServiceClient is the client API for GeofenceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GeofenceServiceClient interface {
+	CreateGeofence(ctx context.Context, in *CreateGeofenceRequest, opts ...grpc.CallOption) (*CreateGeofenceResponse, error)
+	GetGeofence(ctx context.Context, in *GetGeofenceRequest, opts ...grpc.CallOption) (*GetGeofenceResponse, error)
+	ListGeofences(ctx context.Context, in *ListGeofencesRequest, opts ...grpc.CallOption) (*ListGeofencesResponse, error)
+	// Drivers inside a deleted fence are forgotten without exit events.
+	DeleteGeofence(ctx context.Context, in *DeleteGeofenceRequest, opts ...grpc.CallOption) (*DeleteGeofenceResponse, error)
+}
+
+type geofenceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGeofenceServiceClient(cc grpc.ClientConnInterface) GeofenceServiceClient {
+	return &geofenceServiceClient{cc}
+}
+
+func (c *geofenceServiceClient) CreateGeofence(ctx context.Context, in *CreateGeofenceRequest, opts ...grpc.CallOption) (*CreateGeofenceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateGeofenceResponse)
+	err := c.cc.Invoke(ctx, GeofenceService_CreateGeofence_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geofenceServiceClient) GetGeofence(ctx context.Context, in *GetGeofenceRequest, opts ...grpc.CallOption) (*GetGeofenceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetGeofenceResponse)
+	err := c.cc.Invoke(ctx, GeofenceService_GetGeofence_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geofenceServiceClient) ListGeofences(ctx context.Context, in *ListGeofencesRequest, opts ...grpc.CallOption) (*ListGeofencesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGeofencesResponse)
+	err := c.cc.Invoke(ctx, GeofenceService_ListGeofences_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geofenceServiceClient) DeleteGeofence(ctx context.Context, in *DeleteGeofenceRequest, opts ...grpc.CallOption) (*DeleteGeofenceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteGeofenceResponse)
+	err := c.cc.Invoke(ctx, GeofenceService_DeleteGeofence_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GeofenceServiceServer is the server API for GeofenceService service.
+// All implementations must embed UnimplementedGeofenceServiceServer
+// for forward compatibility.
+type GeofenceServiceServer interface {
+	CreateGeofence(context.Context, *CreateGeofenceRequest) (*CreateGeofenceResponse, error)
+	GetGeofence(context.Context, *GetGeofenceRequest) (*GetGeofenceResponse, error)
+	ListGeofences(context.Context, *ListGeofencesRequest) (*ListGeofencesResponse, error)
+	// Drivers inside a deleted fence are forgotten without exit events.
+	DeleteGeofence(context.Context, *DeleteGeofenceRequest) (*DeleteGeofenceResponse, error)
+	mustEmbedUnimplementedGeofenceServiceServer()
+}
+
+// UnimplementedGeofenceServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGeofenceServiceServer struct{}
+
+func (UnimplementedGeofenceServiceServer) CreateGeofence(context.Context, *CreateGeofenceRequest) (*CreateGeofenceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateGeofence not implemented")
+}
+func (UnimplementedGeofenceServiceServer) GetGeofence(context.Context, *GetGeofenceRequest) (*GetGeofenceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetGeofence not implemented")
+}
+func (UnimplementedGeofenceServiceServer) ListGeofences(context.Context, *ListGeofencesRequest) (*ListGeofencesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListGeofences not implemented")
+}
+func (UnimplementedGeofenceServiceServer) DeleteGeofence(context.Context, *DeleteGeofenceRequest) (*DeleteGeofenceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteGeofence not implemented")
+}
+func (UnimplementedGeofenceServiceServer) mustEmbedUnimplementedGeofenceServiceServer() {}
+func (UnimplementedGeofenceServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeGeofenceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GeofenceServiceServer will
+// result in compilation errors.
+type UnsafeGeofenceServiceServer interface {
+	mustEmbedUnimplementedGeofenceServiceServer()
+}
+
+func RegisterGeofenceServiceServer(s grpc.ServiceRegistrar, srv GeofenceServiceServer) {
+	// If the following call panics, it indicates UnimplementedGeofenceServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GeofenceService_ServiceDesc, srv)
+}
+
+func _GeofenceService_CreateGeofence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGeofenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeofenceServiceServer).CreateGeofence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeofenceService_CreateGeofence_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeofenceServiceServer).CreateGeofence(ctx, req.(*CreateGeofenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeofenceService_GetGeofence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGeofenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeofenceServiceServer).GetGeofence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeofenceService_GetGeofence_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeofenceServiceServer).GetGeofence(ctx, req.(*GetGeofenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeofenceService_ListGeofences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGeofencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeofenceServiceServer).ListGeofences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeofenceService_ListGeofences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeofenceServiceServer).ListGeofences(ctx, req.(*ListGeofencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeofenceService_DeleteGeofence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteGeofenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeofenceServiceServer).DeleteGeofence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GeofenceService_DeleteGeofence_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeofenceServiceServer).DeleteGeofence(ctx, req.(*DeleteGeofenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GeofenceService_ServiceDesc is the grpc.ServiceDesc for GeofenceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GeofenceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "geofence.GeofenceService",
+	HandlerType: (*GeofenceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateGeofence",
+			Handler:    _GeofenceService_CreateGeofence_Handler,
+		},
+		{
+			MethodName: "GetGeofence",
+			Handler:    _GeofenceService_GetGeofence_Handler,
+		},
+		{
+			MethodName: "ListGeofences",
+			Handler:    _GeofenceService_ListGeofences_Handler,
+		},
+		{
+			MethodName: "DeleteGeofence",
+			Handler:    _GeofenceService_DeleteGeofence_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/proto/geofence/geofence.proto",
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -39,6 +41,69 @@ type ObservabilityConfig struct {
 	Addr string
 }
 
+// WebSocketConfig holds optional live location WebSocket settings.
+type WebSocketConfig struct {
+	Path           string
+	SendBuffer     *int
+	WriteTimeout   *time.Duration
+	PingInterval   *time.Duration
+	PongTimeout    *time.Duration
+	AllowedOrigins []string
+}
+
+// LocationBatchConfig holds optional settings for batched location history writes.
+type LocationBatchConfig struct {
+	Size     *int
+	Interval *time.Duration
+	Buffer   *int
+}
+
+// IngestConfig holds optional location ingest validation settings. Speeds are in km/h.
+type IngestConfig struct {
+	MaxFutureSkew      *time.Duration
+	HistoryTTL         *time.Duration
+	MaxSpeedKmh        *float64
+	SpeedJitterMeters  *float64
+	VehicleMaxSpeedKmh map[string]float64
+}
+
+// GeofenceConfig holds geofence evaluation and event sink settings.
+type GeofenceConfig struct {
+	RefreshInterval *time.Duration
+	// Sinks lists where events are sent: "redis" for a Redis stream and "broadcast" for
+	// live WebSocket clients.
+	Sinks        []string
+	Stream       string
+	StreamMaxLen *int
+}
+
+// LocationSpoolConfig holds settings for the on-disk spool that absorbs failed location
+// writes. The spool is disabled when Dir is empty.
+type LocationSpoolConfig struct {
+	Dir            string
+	MaxBytes       *int
+	SegmentBytes   *int
+	ReplayInterval *time.Duration
+}
+
+// TracingConfig holds OpenTelemetry trace export settings.
+type TracingConfig struct {
+	// Exporter is "none", "stdout", "file" or "otlp".
+	Exporter     string
+	File         string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	ServiceName  string
+	SampleRatio  *float64
+}
+
+// LoggingConfig holds log output settings.
+type LoggingConfig struct {
+	// Format is "text" or "json".
+	Format string
+	Level  slog.Level
+}
+
 // LoadRedis reads Redis config from env.
 func LoadRedis() (RedisConfig, error) {
 	cfg := RedisConfig{
@@ -121,6 +186,195 @@ func LoadObservability() (ObservabilityConfig, error) {
 	return ObservabilityConfig{Addr: addr}, nil
 }
 
+// LoadWebSocket reads live location WebSocket settings from env. The path defaults to
+// /ws/locations on the observability server.
+func LoadWebSocket() (WebSocketConfig, error) {
+	cfg := WebSocketConfig{Path: strings.TrimSpace(os.Getenv("WS_PATH"))}
+	if cfg.Path == "" {
+		cfg.Path = "/ws/locations"
+	}
+	if !strings.HasPrefix(cfg.Path, "/") {
+		return cfg, errors.New("WS_PATH must start with /")
+	}
+
+	var err error
+	if cfg.SendBuffer, err = optionalInt("WS_SEND_BUFFER"); err != nil {
+		return cfg, err
+	}
+	if cfg.WriteTimeout, err = optionalDuration("WS_WRITE_TIMEOUT"); err != nil {
+		return cfg, err
+	}
+	if cfg.PingInterval, err = optionalDuration("WS_PING_INTERVAL"); err != nil {
+		return cfg, err
+	}
+	if cfg.PongTimeout, err = optionalDuration("WS_PONG_TIMEOUT"); err != nil {
+		return cfg, err
+	}
+	for _, origin := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+		}
+	}
+	return cfg, nil
+}
+
+// LoadIngest reads location ingest validation settings from env.
+func LoadIngest() (IngestConfig, error) {
+	var (
+		cfg IngestConfig
+		err error
+	)
+	if cfg.MaxFutureSkew, err = optionalDuration("INGEST_MAX_FUTURE_SKEW"); err != nil {
+		return cfg, err
+	}
+	if cfg.HistoryTTL, err = optionalDuration("INGEST_HISTORY_TTL"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxSpeedKmh, err = optionalFloat("INGEST_MAX_SPEED_KMH"); err != nil {
+		return cfg, err
+	}
+	if cfg.SpeedJitterMeters, err = optionalFloat("INGEST_SPEED_JITTER_METERS"); err != nil {
+		return cfg, err
+	}
+	// INGEST_VEHICLE_SPEED_LIMITS is a comma-separated list of type=km/h, e.g. "bike=40,car=200".
+	for _, entry := range strings.Split(os.Getenv("INGEST_VEHICLE_SPEED_LIMITS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		vehicle, raw, ok := strings.Cut(entry, "=")
+		vehicle = strings.TrimSpace(vehicle)
+		speed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if !ok || vehicle == "" || err != nil || speed <= 0 {
+			return cfg, fmt.Errorf("INGEST_VEHICLE_SPEED_LIMITS: invalid entry %q", entry)
+		}
+		if cfg.VehicleMaxSpeedKmh == nil {
+			cfg.VehicleMaxSpeedKmh = make(map[string]float64)
+		}
+		cfg.VehicleMaxSpeedKmh[vehicle] = speed
+	}
+	return cfg, nil
+}
+
+// LoadLocationBatch reads location history batching settings from env.
+func LoadLocationBatch() (LocationBatchConfig, error) {
+	var (
+		cfg LocationBatchConfig
+		err error
+	)
+	if cfg.Size, err = optionalInt("LOCATION_BATCH_SIZE"); err != nil {
+		return cfg, err
+	}
+	if cfg.Interval, err = optionalDuration("LOCATION_BATCH_INTERVAL"); err != nil {
+		return cfg, err
+	}
+	if cfg.Buffer, err = optionalInt("LOCATION_BATCH_BUFFER"); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// LoadGeofence reads geofence settings from env. GEOFENCE_SINKS is a comma-separated
+// list of "redis" and "broadcast", defaulting to "redis"; "none" disables events.
+func LoadGeofence() (GeofenceConfig, error) {
+	cfg := GeofenceConfig{Stream: strings.TrimSpace(os.Getenv("GEOFENCE_STREAM"))}
+
+	var err error
+	if cfg.RefreshInterval, err = optionalDuration("GEOFENCE_REFRESH_INTERVAL"); err != nil {
+		return cfg, err
+	}
+	if cfg.StreamMaxLen, err = optionalInt("GEOFENCE_STREAM_MAXLEN"); err != nil {
+		return cfg, err
+	}
+
+	raw := strings.TrimSpace(os.Getenv("GEOFENCE_SINKS"))
+	if raw == "" {
+		raw = "redis"
+	}
+	for _, sink := range strings.Split(raw, ",") {
+		switch sink = strings.TrimSpace(sink); sink {
+		case "":
+		case "none":
+			cfg.Sinks = nil
+			return cfg, nil
+		case "redis", "broadcast":
+			cfg.Sinks = append(cfg.Sinks, sink)
+		default:
+			return cfg, fmt.Errorf("GEOFENCE_SINKS: unknown sink %q", sink)
+		}
+	}
+	return cfg, nil
+}
+
+// LoadLocationSpool reads location spool settings from env.
+func LoadLocationSpool() (LocationSpoolConfig, error) {
+	cfg := LocationSpoolConfig{Dir: strings.TrimSpace(os.Getenv("LOCATION_SPOOL_DIR"))}
+
+	var err error
+	if cfg.MaxBytes, err = optionalInt("LOCATION_SPOOL_MAX_BYTES"); err != nil {
+		return cfg, err
+	}
+	if cfg.SegmentBytes, err = optionalInt("LOCATION_SPOOL_SEGMENT_BYTES"); err != nil {
+		return cfg, err
+	}
+	if cfg.ReplayInterval, err = optionalDuration("LOCATION_SPOOL_REPLAY_INTERVAL"); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// LoadTracing reads trace export settings from env. TRACING_EXPORTER defaults to
+// "none"; the "file" exporter needs TRACING_FILE.
+func LoadTracing() (TracingConfig, error) {
+	cfg := TracingConfig{
+		Exporter:     strings.ToLower(strings.TrimSpace(os.Getenv("TRACING_EXPORTER"))),
+		File:         strings.TrimSpace(os.Getenv("TRACING_FILE")),
+		OTLPEndpoint: strings.TrimSpace(os.Getenv("TRACING_OTLP_ENDPOINT")),
+		ServiceName:  strings.TrimSpace(os.Getenv("TRACING_SERVICE_NAME")),
+	}
+	switch cfg.Exporter {
+	case "":
+		cfg.Exporter = "none"
+	case "none", "stdout", "otlp":
+	case "file":
+		if cfg.File == "" {
+			return cfg, errors.New("TRACING_FILE is required for the file exporter")
+		}
+	default:
+		return cfg, fmt.Errorf("TRACING_EXPORTER: unknown exporter %q", cfg.Exporter)
+	}
+
+	var err error
+	if cfg.OTLPInsecure, err = optionalBool("TRACING_OTLP_INSECURE"); err != nil {
+		return cfg, err
+	}
+	if cfg.SampleRatio, err = optionalFloat("TRACING_SAMPLE_RATIO"); err != nil {
+		return cfg, err
+	}
+	if cfg.SampleRatio != nil && *cfg.SampleRatio > 1 {
+		return cfg, errors.New("TRACING_SAMPLE_RATIO must be <= 1")
+	}
+	return cfg, nil
+}
+
+// LoadLogging reads log settings from env. LOG_FORMAT is "text" (the default) or "json";
+// LOG_LEVEL is debug, info (the default), warn or error.
+func LoadLogging() (LoggingConfig, error) {
+	cfg := LoggingConfig{Format: strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))}
+	switch cfg.Format {
+	case "":
+		cfg.Format = "text"
+	case "text", "json":
+	default:
+		return cfg, fmt.Errorf("LOG_FORMAT: unknown format %q", cfg.Format)
+	}
+	if raw := strings.TrimSpace(os.Getenv("LOG_LEVEL")); raw != "" {
+		if err := cfg.Level.UnmarshalText([]byte(raw)); err != nil {
+			return cfg, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
 func loadRedisTLSFromEnv() (*tls.Config, error) {
 	caFile := strings.TrimSpace(os.Getenv("REDIS_TLS_CA_FILE"))
 	certFile := strings.TrimSpace(os.Getenv("REDIS_TLS_CERT_FILE"))
@@ -186,6 +440,21 @@ func optionalDuration(name string) (*time.Duration, error) {
 	return &val, nil
 }
 
+func optionalFloat(name string) (*float64, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return nil, nil
+	}
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if val < 0 || math.IsNaN(val) || math.IsInf(val, 0) {
+		return nil, fmt.Errorf("%s must be a finite number >= 0", name)
+	}
+	return &val, nil
+}
+
 func optionalInt(name string) (*int, error) {
 	raw := strings.TrimSpace(os.Getenv(name))
 	if raw == "" {
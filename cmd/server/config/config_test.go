@@ -1,6 +1,7 @@
 package config
 
 import (
+	"log/slog"
 	"testing"
 	"time"
 )
@@ -30,6 +31,220 @@ func TestLoadObservability(t *testing.T) {
 	}
 }
 
+func TestLoadWebSocket(t *testing.T) {
+	t.Setenv("WS_PATH", "")
+	cfg, err := LoadWebSocket()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Path != "/ws/locations" || cfg.SendBuffer != nil || cfg.PingInterval != nil || len(cfg.AllowedOrigins) != 0 {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+
+	t.Setenv("WS_PATH", "/live")
+	t.Setenv("WS_SEND_BUFFER", "16")
+	t.Setenv("WS_WRITE_TIMEOUT", "5s")
+	t.Setenv("WS_PING_INTERVAL", "20s")
+	t.Setenv("WS_PONG_TIMEOUT", "45s")
+	t.Setenv("WS_ALLOWED_ORIGINS", "https://app.example.com, ops.example.com,")
+	cfg, err = LoadWebSocket()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Path != "/live" || *cfg.SendBuffer != 16 || *cfg.WriteTimeout != 5*time.Second ||
+		*cfg.PingInterval != 20*time.Second || *cfg.PongTimeout != 45*time.Second ||
+		len(cfg.AllowedOrigins) != 2 || cfg.AllowedOrigins[1] != "ops.example.com" {
+		t.Fatalf("unexpected ws cfg: %+v", cfg)
+	}
+
+	t.Setenv("WS_PATH", "live")
+	if _, err := LoadWebSocket(); err == nil {
+		t.Fatalf("expected error for relative path")
+	}
+	t.Setenv("WS_PATH", "")
+	t.Setenv("WS_SEND_BUFFER", "lots")
+	if _, err := LoadWebSocket(); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func TestLoadLocationBatch(t *testing.T) {
+	t.Setenv("LOCATION_BATCH_SIZE", "")
+	t.Setenv("LOCATION_BATCH_INTERVAL", "")
+	t.Setenv("LOCATION_BATCH_BUFFER", "")
+	cfg, err := LoadLocationBatch()
+	if err != nil || cfg.Size != nil || cfg.Interval != nil || cfg.Buffer != nil {
+		t.Fatalf("unexpected defaults: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("LOCATION_BATCH_SIZE", "250")
+	t.Setenv("LOCATION_BATCH_INTERVAL", "100ms")
+	t.Setenv("LOCATION_BATCH_BUFFER", "5000")
+	if cfg, err = LoadLocationBatch(); err != nil || *cfg.Size != 250 || *cfg.Interval != 100*time.Millisecond || *cfg.Buffer != 5000 {
+		t.Fatalf("unexpected batch cfg: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("LOCATION_BATCH_SIZE", "-1")
+	if _, err := LoadLocationBatch(); err == nil {
+		t.Fatalf("expected error for negative size")
+	}
+}
+
+func TestLoadLocationSpool(t *testing.T) {
+	t.Setenv("LOCATION_SPOOL_DIR", "")
+	t.Setenv("LOCATION_SPOOL_MAX_BYTES", "")
+	t.Setenv("LOCATION_SPOOL_SEGMENT_BYTES", "")
+	t.Setenv("LOCATION_SPOOL_REPLAY_INTERVAL", "")
+	cfg, err := LoadLocationSpool()
+	if err != nil || cfg.Dir != "" || cfg.MaxBytes != nil || cfg.SegmentBytes != nil || cfg.ReplayInterval != nil {
+		t.Fatalf("unexpected defaults: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("LOCATION_SPOOL_DIR", " /var/spool/wayfinder ")
+	t.Setenv("LOCATION_SPOOL_MAX_BYTES", "1048576")
+	t.Setenv("LOCATION_SPOOL_SEGMENT_BYTES", "65536")
+	t.Setenv("LOCATION_SPOOL_REPLAY_INTERVAL", "2s")
+	if cfg, err = LoadLocationSpool(); err != nil || cfg.Dir != "/var/spool/wayfinder" || *cfg.MaxBytes != 1048576 ||
+		*cfg.SegmentBytes != 65536 || *cfg.ReplayInterval != 2*time.Second {
+		t.Fatalf("unexpected spool cfg: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("LOCATION_SPOOL_MAX_BYTES", "lots")
+	if _, err := LoadLocationSpool(); err == nil {
+		t.Fatalf("expected error for invalid max bytes")
+	}
+}
+
+func TestLoadLogging(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_LEVEL", "")
+	cfg, err := LoadLogging()
+	if err != nil || cfg.Format != "text" || cfg.Level != slog.LevelInfo {
+		t.Fatalf("unexpected defaults: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("LOG_FORMAT", " JSON ")
+	t.Setenv("LOG_LEVEL", "debug")
+	if cfg, err = LoadLogging(); err != nil || cfg.Format != "json" || cfg.Level != slog.LevelDebug {
+		t.Fatalf("unexpected logging cfg: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("LOG_LEVEL", "loud")
+	if _, err := LoadLogging(); err == nil {
+		t.Fatalf("expected error for unknown level")
+	}
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("LOG_FORMAT", "xml")
+	if _, err := LoadLogging(); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestLoadTracing(t *testing.T) {
+	for _, name := range []string{"TRACING_EXPORTER", "TRACING_FILE", "TRACING_OTLP_ENDPOINT", "TRACING_OTLP_INSECURE", "TRACING_SERVICE_NAME", "TRACING_SAMPLE_RATIO"} {
+		t.Setenv(name, "")
+	}
+	cfg, err := LoadTracing()
+	if err != nil || cfg.Exporter != "none" || cfg.SampleRatio != nil || cfg.OTLPInsecure {
+		t.Fatalf("unexpected defaults: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("TRACING_EXPORTER", " OTLP ")
+	t.Setenv("TRACING_OTLP_ENDPOINT", "collector:4317")
+	t.Setenv("TRACING_OTLP_INSECURE", "true")
+	t.Setenv("TRACING_SERVICE_NAME", "wayfinder-eu")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	if cfg, err = LoadTracing(); err != nil || cfg.Exporter != "otlp" || cfg.OTLPEndpoint != "collector:4317" || !cfg.OTLPInsecure ||
+		cfg.ServiceName != "wayfinder-eu" || *cfg.SampleRatio != 0.25 {
+		t.Fatalf("unexpected tracing cfg: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("TRACING_SAMPLE_RATIO", "1.5")
+	if _, err := LoadTracing(); err == nil {
+		t.Fatalf("expected error for sample ratio above 1")
+	}
+	t.Setenv("TRACING_SAMPLE_RATIO", "")
+
+	t.Setenv("TRACING_EXPORTER", "file")
+	if _, err := LoadTracing(); err == nil {
+		t.Fatalf("expected error for file exporter without TRACING_FILE")
+	}
+	t.Setenv("TRACING_FILE", "/tmp/spans.json")
+	if cfg, err = LoadTracing(); err != nil || cfg.File != "/tmp/spans.json" {
+		t.Fatalf("unexpected file cfg: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	if _, err := LoadTracing(); err == nil {
+		t.Fatalf("expected error for unknown exporter")
+	}
+}
+
+func TestLoadGeofence(t *testing.T) {
+	t.Setenv("GEOFENCE_REFRESH_INTERVAL", "")
+	t.Setenv("GEOFENCE_SINKS", "")
+	t.Setenv("GEOFENCE_STREAM", "")
+	t.Setenv("GEOFENCE_STREAM_MAXLEN", "")
+	cfg, err := LoadGeofence()
+	if err != nil || cfg.RefreshInterval != nil || cfg.StreamMaxLen != nil || len(cfg.Sinks) != 1 || cfg.Sinks[0] != "redis" {
+		t.Fatalf("unexpected defaults: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("GEOFENCE_REFRESH_INTERVAL", "1m")
+	t.Setenv("GEOFENCE_SINKS", "redis, broadcast")
+	t.Setenv("GEOFENCE_STREAM", "fences")
+	t.Setenv("GEOFENCE_STREAM_MAXLEN", "10000")
+	if cfg, err = LoadGeofence(); err != nil || *cfg.RefreshInterval != time.Minute || len(cfg.Sinks) != 2 || cfg.Sinks[1] != "broadcast" ||
+		cfg.Stream != "fences" || *cfg.StreamMaxLen != 10000 {
+		t.Fatalf("unexpected geofence cfg: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("GEOFENCE_SINKS", "none")
+	if cfg, err = LoadGeofence(); err != nil || len(cfg.Sinks) != 0 {
+		t.Fatalf("expected no sinks, got %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("GEOFENCE_SINKS", "kafka")
+	if _, err := LoadGeofence(); err == nil {
+		t.Fatalf("expected error for unknown sink")
+	}
+}
+
+func TestLoadIngest(t *testing.T) {
+	t.Setenv("INGEST_MAX_FUTURE_SKEW", "")
+	t.Setenv("INGEST_HISTORY_TTL", "")
+	t.Setenv("INGEST_MAX_SPEED_KMH", "")
+	t.Setenv("INGEST_SPEED_JITTER_METERS", "")
+	t.Setenv("INGEST_VEHICLE_SPEED_LIMITS", "")
+	cfg, err := LoadIngest()
+	if err != nil || cfg.MaxFutureSkew != nil || cfg.HistoryTTL != nil || cfg.MaxSpeedKmh != nil || cfg.VehicleMaxSpeedKmh != nil {
+		t.Fatalf("unexpected defaults: %+v err=%v", cfg, err)
+	}
+
+	t.Setenv("INGEST_MAX_FUTURE_SKEW", "45s")
+	t.Setenv("INGEST_HISTORY_TTL", "5m")
+	t.Setenv("INGEST_MAX_SPEED_KMH", "180")
+	t.Setenv("INGEST_SPEED_JITTER_METERS", "50")
+	t.Setenv("INGEST_VEHICLE_SPEED_LIMITS", "bike=40, scooter = 80,")
+	if cfg, err = LoadIngest(); err != nil || *cfg.MaxFutureSkew != 45*time.Second || *cfg.HistoryTTL != 5*time.Minute ||
+		*cfg.MaxSpeedKmh != 180 || *cfg.SpeedJitterMeters != 50 || len(cfg.VehicleMaxSpeedKmh) != 2 || cfg.VehicleMaxSpeedKmh["scooter"] != 80 {
+		t.Fatalf("unexpected ingest cfg: %+v err=%v", cfg, err)
+	}
+
+	for name, value := range map[string]string{
+		"INGEST_MAX_FUTURE_SKEW":      "soon",
+		"INGEST_HISTORY_TTL":          "forever",
+		"INGEST_MAX_SPEED_KMH":        "fast",
+		"INGEST_VEHICLE_SPEED_LIMITS": "bike",
+	} {
+		t.Setenv(name, value)
+		if _, err := LoadIngest(); err == nil {
+			t.Fatalf("%s=%s: expected parse error", name, value)
+		}
+		t.Setenv(name, "")
+	}
+}
+
 func TestLoadRedis(t *testing.T) {
 	t.Setenv("REDIS_URL", "redis://localhost:6379/0")
 	t.Setenv("REDIS_STREAM", "s")
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+
+	"wayfinder/cmd/server/config"
+	ingestdb "wayfinder/internal/db/ingest"
+	"wayfinder/internal/ingest"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// buildGeofencing opens the geofence store and the sinks events are sent to.
+func buildGeofencing(ctx context.Context, cfg config.GeofenceConfig, broadcaster ingest.Broadcaster) (ingest.GeofenceStore, ingest.GeofenceSink, func(), error) {
+	databaseURL := strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	if databaseURL == "" {
+		return nil, nil, nil, errors.New("DATABASE_URL is required")
+	}
+	db, err := openLocationDB(databaseURL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	store, err := ingestdb.NewPostgresGeofenceStoreWithSchema(ctx, db)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, nil, err
+	}
+
+	var (
+		sinks  ingest.GeofenceSinks
+		client *redis.Client
+	)
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "redis":
+			url, err := config.GetRedisURL()
+			if err != nil {
+				_ = db.Close()
+				return nil, nil, nil, err
+			}
+			opts, err := redis.ParseURL(url)
+			if err != nil {
+				_ = db.Close()
+				return nil, nil, nil, err
+			}
+			client = redis.NewClient(opts)
+			var maxLen int64
+			if cfg.StreamMaxLen != nil {
+				maxLen = int64(*cfg.StreamMaxLen)
+			}
+			sinks = append(sinks, ingest.NewRedisGeofenceSink(client, cfg.Stream, maxLen))
+		case "broadcast":
+			sinks = append(sinks, ingest.NewBroadcastGeofenceSink(broadcaster))
+		}
+	}
+
+	cleanup := func() {
+		if client != nil {
+			if err := client.Close(); err != nil {
+				slog.Warn("close geofence redis", "error", err)
+			}
+		}
+		if err := db.Close(); err != nil {
+			slog.Warn("close geofence db", "error", err)
+		}
+	}
+	return store, sinks, cleanup, nil
+}
+
+func geofenceMonitorConfig(cfg config.GeofenceConfig, ingestCfg config.IngestConfig) ingest.GeofenceConfig {
+	var out ingest.GeofenceConfig
+	if cfg.RefreshInterval != nil {
+		out.RefreshInterval = *cfg.RefreshInterval
+	}
+	// Drivers age out of fences on the same idle TTL that ingest forgets them on.
+	if ingestCfg.HistoryTTL != nil {
+		out.IdleTTL = *ingestCfg.HistoryTTL
+	}
+	return out
+}
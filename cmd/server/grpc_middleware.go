@@ -2,15 +2,83 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
+	"wayfinder/internal/logging"
 	"wayfinder/internal/observability"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
+// requestIDHeader is the metadata key a caller's request ID is read from and echoed in.
+const requestIDHeader = "x-request-id"
+
+// requestID returns the caller's request ID when it is a plausible one, so it cannot
+// forge log lines, and a new ID otherwise.
+func requestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(requestIDHeader); len(ids) > 0 && validRequestID(ids[0]) {
+		return ids[0]
+	}
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', strings.ContainsRune("-_.:", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type requestIDServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestIDServerStream) Context() context.Context { return s.ctx }
+
+// requestIDUnaryInterceptor attaches the request ID to the context every later log line
+// is written with and returns it in the response headers.
+func requestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := requestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id))
+		return handler(logging.WithRequestID(ctx, id), req)
+	}
+}
+
+func requestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := requestID(stream.Context())
+		_ = stream.SetHeader(metadata.Pairs(requestIDHeader, id))
+		return handler(srv, &requestIDServerStream{ServerStream: stream, ctx: logging.WithRequestID(stream.Context(), id)})
+	}
+}
+
+// logCallError logs a failed call, as an error when the fault is likely ours and as a
+// warning when the caller sent something we refused.
+func logCallError(ctx context.Context, method string, start time.Time, err error) {
+	level := slog.LevelWarn
+	switch status.Code(err) {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded:
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, "grpc call failed", "method", method, "duration", time.Since(start), "error", err)
+}
+
 type rateLimiter interface {
 	Wait(ctx context.Context) error
 }
@@ -45,7 +113,7 @@ func rateLimitUnaryInterceptor(limiter rateLimiter, metrics *observability.Metri
 		resp, err := handler(ctx, req)
 		span.End(err)
 		if err != nil && shouldTrackMethod(info.FullMethod) {
-			log.Printf("grpc unary %s error after %v: %v", info.FullMethod, time.Since(start), err)
+			logCallError(ctx, info.FullMethod, start, err)
 		}
 		return resp, err
 	}
@@ -62,7 +130,7 @@ func rateLimitStreamInterceptor(limiter rateLimiter, metrics *observability.Metr
 			err := handler(srv, stream)
 			span.End(err)
 			if err != nil && shouldTrackMethod(info.FullMethod) {
-				log.Printf("grpc stream %s error after %v: %v", info.FullMethod, time.Since(start), err)
+				logCallError(stream.Context(), info.FullMethod, start, err)
 			}
 			return err
 		}
@@ -73,7 +141,7 @@ func rateLimitStreamInterceptor(limiter rateLimiter, metrics *observability.Metr
 		err := handler(srv, wrapped)
 		span.End(err)
 		if err != nil && shouldTrackMethod(info.FullMethod) {
-			log.Printf("grpc stream %s error after %v: %v", info.FullMethod, time.Since(start), err)
+			logCallError(stream.Context(), info.FullMethod, start, err)
 		}
 		return err
 	}
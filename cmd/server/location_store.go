@@ -2,39 +2,53 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"wayfinder/cmd/server/config"
 	ingestdb "wayfinder/internal/db/ingest"
+	"wayfinder/internal/db/postgres"
 	"wayfinder/internal/ingest"
+	"wayfinder/internal/logging"
 
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
-var openLocationDB = func(driver, dsn string) (*sql.DB, error) {
-	return sql.Open(driver, dsn)
-}
+var openLocationDB = postgres.Open
 
-func buildLocationStore(ctx context.Context) (ingest.LocationStore, func(), error) {
+// buildLocationStore returns the store locations are written to and the reader that
+// serves their history. When LOCATION_SPOOL_DIR is set, each backend gets its own
+// on-disk spool so an outage of one neither loses points nor duplicates them in the
+// other; onSpoolDepth receives the depth of each spool by name.
+func buildLocationStore(ctx context.Context, onSpoolDepth func(name string, records, bytes int64)) (ingest.LocationStore, ingest.HistoryReader, func(), error) {
 	cfg, err := config.LoadRedis()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+	batchCfg, err := config.LoadLocationBatch()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	spoolCfg, err := config.LoadLocationSpool()
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	databaseURL := strings.TrimSpace(os.Getenv("DATABASE_URL"))
 	if databaseURL == "" {
-		return nil, nil, errors.New("DATABASE_URL is required")
+		return nil, nil, nil, errors.New("DATABASE_URL is required")
 	}
 
 	opts, err := redis.ParseURL(cfg.URL)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if cfg.DialTimeout != nil {
 		opts.DialTimeout = *cfg.DialTimeout
@@ -62,11 +76,11 @@ func buildLocationStore(ctx context.Context) (ingest.LocationStore, func(), erro
 	if cfg.EnableOTel {
 		if err := redisotel.InstrumentTracing(client); err != nil {
 			_ = client.Close()
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		if err := redisotel.InstrumentMetrics(client); err != nil {
 			_ = client.Close()
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
@@ -81,33 +95,137 @@ func buildLocationStore(ctx context.Context) (ingest.LocationStore, func(), erro
 	}
 	if err := client.Ping(pingCtx).Err(); err != nil {
 		_ = client.Close()
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	db, err := openLocationDB("pgx", databaseURL)
+	db, err := openLocationDB(databaseURL)
 	if err != nil {
 		_ = client.Close()
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	historyStore, err := ingestdb.NewPostgresLocationStoreWithSchema(ctx, db)
 	if err != nil {
 		_ = client.Close()
 		_ = db.Close()
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
+	// The batch writer may report a failure before the spool is open.
+	var historySpool atomic.Pointer[ingest.SpoolStore]
+	historyBatchCfg := locationBatchConfig(batchCfg)
+	if spoolCfg.Dir != "" {
+		// Batched writes fail after Update has returned, so failed batches are
+		// handed to the spool here rather than through SpoolStore.Update.
+		historyBatchCfg.OnBatchError = func(batch []ingest.Location, err error) {
+			if spool := historySpool.Load(); spool != nil {
+				spoolErr := spool.Append(batch...)
+				if spoolErr == nil {
+					return
+				}
+				err = errors.Join(err, spoolErr)
+			}
+			slog.Error("location batch failed", "points", len(batch), "error", err)
+		}
+	}
+	batchedHistory := ingestdb.NewBatchedLocationStore(historyStore, historyBatchCfg)
 	latestStore := ingest.NewRedisLocationStore(redisClientAdapter{client: client}, cfg.Stream, time.Duration(cfg.LocationTTL), cfg.StreamMaxLen)
-	store := ingest.NewMultiLocationStore(historyStore, latestStore)
-	cleanup := func() {
+	closeBackends := func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := batchedHistory.Close(flushCtx); err != nil {
+			slog.Warn("flush location history", "error", err)
+		}
+		if spool := historySpool.Load(); spool != nil {
+			if err := spool.Close(); err != nil {
+				slog.Warn("close history spool", "error", err)
+			}
+		}
 		if err := client.Close(); err != nil {
-			log.Printf("close redis: %v", err)
+			slog.Warn("close redis", "error", err)
 		}
 		if err := db.Close(); err != nil {
-			log.Printf("close locations db: %v", err)
+			slog.Warn("close locations db", "error", err)
+		}
+	}
+
+	var history, latest ingest.LocationStore = batchedHistory, latestStore
+	var latestSpool *ingest.SpoolStore
+	stopReplay := func() {}
+	if spoolCfg.Dir != "" {
+		// The batched store accepts every point and fails later, so history replays
+		// through the unbatched store, whose errors stop replay.
+		spool, err := openLocationSpool(batchedHistory, historyStore, spoolCfg, "history", onSpoolDepth)
+		if err != nil {
+			closeBackends()
+			return nil, nil, nil, err
+		}
+		historySpool.Store(spool)
+		if latestSpool, err = openLocationSpool(latestStore, nil, spoolCfg, "redis", onSpoolDepth); err != nil {
+			closeBackends()
+			return nil, nil, nil, err
+		}
+		replayCtx, cancelReplay := context.WithCancel(ctx)
+		var replays sync.WaitGroup
+		for _, s := range []*ingest.SpoolStore{spool, latestSpool} {
+			replays.Add(1)
+			go func() {
+				defer replays.Done()
+				s.Run(replayCtx)
+			}()
+		}
+		stopReplay = func() {
+			cancelReplay()
+			replays.Wait()
+		}
+		history, latest = spool, latestSpool
+	}
+
+	store := ingest.NewMultiLocationStore(history, latest)
+	cleanup := func() {
+		// Replay writes to the backends, so it must stop before they close.
+		stopReplay()
+		if latestSpool != nil {
+			if err := latestSpool.Close(); err != nil {
+				slog.Warn("close redis spool", "error", err)
+			}
 		}
+		closeBackends()
+	}
+	return store, historyStore, cleanup, nil
+}
+
+func locationBatchConfig(cfg config.LocationBatchConfig) ingestdb.BatchConfig {
+	var out ingestdb.BatchConfig
+	if cfg.Size != nil {
+		out.MaxBatch = *cfg.Size
+	}
+	if cfg.Interval != nil {
+		out.FlushInterval = *cfg.Interval
+	}
+	if cfg.Buffer != nil {
+		out.Buffer = *cfg.Buffer
+	}
+	return out
+}
+
+// openLocationSpool opens the spool for one backend in its own subdirectory. A non-nil
+// replay store receives replayed records in place of store.
+func openLocationSpool(store, replay ingest.LocationStore, cfg config.LocationSpoolConfig, name string, onDepth func(name string, records, bytes int64)) (*ingest.SpoolStore, error) {
+	out := ingest.SpoolConfig{Dir: filepath.Join(cfg.Dir, name), ReplayTo: replay}
+	if cfg.MaxBytes != nil {
+		out.MaxBytes = int64(*cfg.MaxBytes)
+	}
+	if cfg.SegmentBytes != nil {
+		out.SegmentBytes = int64(*cfg.SegmentBytes)
+	}
+	if cfg.ReplayInterval != nil {
+		out.ReplayInterval = *cfg.ReplayInterval
+	}
+	if onDepth != nil {
+		out.OnDepth = func(records, bytes int64) { onDepth(name, records, bytes) }
 	}
-	return store, cleanup, nil
+	return ingest.OpenSpoolStore(store, out, logging.Printf(slog.Default(), slog.LevelWarn))
 }
 
 type redisClientAdapter struct {
@@ -130,6 +248,14 @@ func (p redisPipelineAdapter) Expire(ctx context.Context, key string, expiration
 	return p.pipe.Expire(ctx, key, expiration)
 }
 
+func (p redisPipelineAdapter) GeoAdd(ctx context.Context, key string, geoLocation ...*redis.GeoLocation) *redis.IntCmd {
+	return p.pipe.GeoAdd(ctx, key, geoLocation...)
+}
+
+func (p redisPipelineAdapter) ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd {
+	return p.pipe.ZAdd(ctx, key, members...)
+}
+
 func (p redisPipelineAdapter) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
 	return p.pipe.XAdd(ctx, a)
 }
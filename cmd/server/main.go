@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -13,18 +13,23 @@ import (
 	"time"
 
 	driverpb "wayfinder/api/proto/driver"
+	geofencepb "wayfinder/api/proto/geofence"
 	orderpb "wayfinder/api/proto/order"
 	"wayfinder/cmd/server/config"
 	"wayfinder/internal/adapters/grpc"
+	"wayfinder/internal/adapters/ws"
 	"wayfinder/internal/ingest"
+	"wayfinder/internal/logging"
 	"wayfinder/internal/observability"
 	"wayfinder/internal/orders"
 
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	grpcpkg "google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/stats"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/redis/go-redis/v9"
@@ -33,8 +38,10 @@ import (
 var (
 	runFunc                      = run
 	buildLocationStoreFunc       = buildLocationStore
-	buildOrderServiceFunc        = orders.BuildOrderService
+	buildGeofencingFunc          = buildGeofencing
+	buildOrderServiceFunc        = orders.BuildOrderServiceWithHooks
 	startObservabilityServerFunc = startObservabilityServer
+	setupTracingFunc             = observability.SetupTracing
 	listenFunc                   = net.Listen
 )
 
@@ -45,27 +52,95 @@ func main() {
 	defer stop()
 
 	if err := runFunc(ctx); err != nil {
-		log.Fatalf("server error: %v", err)
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
 	}
 }
 
 func run(ctx context.Context) error {
-	locationStore, cleanupStore, err := buildLocationStoreFunc(ctx)
+	logCfg, err := config.LoadLogging()
 	if err != nil {
 		return err
 	}
-	defer cleanupStore()
+	logger, err := logging.New(os.Stderr, logging.Config{Format: logCfg.Format, Level: logCfg.Level})
+	if err != nil {
+		return err
+	}
+	// Also routes the standard log package, and so every logf callback, through logger.
+	slog.SetDefault(logger)
+	warnf := logging.Printf(logger, slog.LevelWarn)
 
-	publisher := ingest.NewFanoutPublisher(ingest.NewStorePublisher(locationStore), nil)
-	ingestService := ingest.NewIngestService(publisher)
+	tracingCfg, err := config.LoadTracing()
+	if err != nil {
+		return err
+	}
+	shutdownTracing, err := setupTracingFunc(ctx, tracingConfig(tracingCfg))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Flushes spans still queued in the batcher.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Warn("tracing shutdown", "error", err)
+		}
+	}()
 
 	metrics := observability.NewMetrics()
+	locationStore, locationHistory, cleanupStore, err := buildLocationStoreFunc(ctx, metrics.SetSpoolDepth)
+	if err != nil {
+		return err
+	}
+	defer cleanupStore()
 
-	orderService, cleanup, err := buildOrderServiceFunc(ctx, os.Getenv("DATABASE_URL"), log.Printf)
+	orderService, cleanup, err := buildOrderServiceFunc(ctx, os.Getenv("DATABASE_URL"), logger, orders.ReliabilityHooks{
+		OnLimiterWait:  metrics.AddEgressRateLimitWait,
+		OnStateChange:  metrics.AddCircuitTransition,
+		OnReject:       metrics.AddCircuitRejection,
+		OnRetryAttempt: metrics.AddRetryAttempt,
+	})
 	if err != nil {
 		return err
 	}
 	defer cleanup()
+
+	wsCfg, err := config.LoadWebSocket()
+	if err != nil {
+		return err
+	}
+	liveLocations := ws.NewHub(hubConfig(wsCfg), orderDriverResolver(orderService), warnf)
+	defer func() {
+		// No-op after a graceful shutdown; covers the early-return paths.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = liveLocations.Shutdown(shutdownCtx)
+	}()
+
+	geofenceCfg, err := config.LoadGeofence()
+	if err != nil {
+		return err
+	}
+	geofenceStore, geofenceSink, cleanupGeofences, err := buildGeofencingFunc(ctx, geofenceCfg, liveLocations)
+	if err != nil {
+		return err
+	}
+	defer cleanupGeofences()
+
+	ingestCfg, err := config.LoadIngest()
+	if err != nil {
+		return err
+	}
+
+	fanout := ingest.NewFanoutPublisher(ingest.NewStorePublisher(locationStore), liveLocations)
+	geofences := ingest.NewGeofenceMonitor(fanout, geofenceStore, geofenceSink, geofenceMonitorConfig(geofenceCfg, ingestCfg), warnf)
+	if err := geofences.Refresh(ctx); err != nil {
+		return err
+	}
+	go geofences.Run(ctx)
+
+	ingestService := ingest.NewIngestServiceWithConfig(geofences, ingestServiceConfig(ingestCfg, metrics))
+
 	orderAdapter := grpc.NewOrderServer(orderService)
 
 	lis, err := listenFunc("tcp", ":50051")
@@ -80,25 +155,30 @@ func run(ctx context.Context) error {
 	limiter := orders.NewRateLimiterWithHook(grpcCfg.RateLimitInterval, grpcCfg.RateLimitBurst, metrics.AddRateLimitWait)
 
 	server := grpcpkg.NewServer(
-		grpcpkg.UnaryInterceptor(rateLimitUnaryInterceptor(limiter, metrics)),
-		grpcpkg.StreamInterceptor(rateLimitStreamInterceptor(limiter, metrics)),
+		grpcpkg.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(func(info *stats.RPCTagInfo) bool {
+			return shouldTrackMethod(info.FullMethodName)
+		}))),
+		grpcpkg.ChainUnaryInterceptor(requestIDUnaryInterceptor(), rateLimitUnaryInterceptor(limiter, metrics)),
+		grpcpkg.ChainStreamInterceptor(requestIDStreamInterceptor(), rateLimitStreamInterceptor(limiter, metrics)),
 	)
-	driverpb.RegisterDriverServiceServer(server, grpc.NewServer(ingestService))
+	driverpb.RegisterDriverServiceServer(server, grpc.NewServerWithHistory(ingestService, ingest.NewHistoryService(locationHistory)))
 	orderpb.RegisterOrderServiceServer(server, orderAdapter)
+	geofencepb.RegisterGeofenceServiceServer(server, grpc.NewGeofenceServer(ingest.NewGeofenceService(geofenceStore, geofences)))
 
 	healthServer := health.NewServer()
 	healthpb.RegisterHealthServer(server, healthServer)
 	healthServer.SetServingStatus(driverpb.DriverService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
 	healthServer.SetServingStatus(orderpb.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(geofencepb.GeofenceService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
 	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
 
 	if env := os.Getenv("APP_ENV"); env != "production" {
 		reflection.Register(server)
-		log.Println("gRPC reflection enabled (APP_ENV=", env, ")")
+		slog.Info("gRPC reflection enabled", "app_env", env)
 	}
 
-	log.Println("Server running on :50051...")
-	obsSrv, obsErr := startObservabilityServerFunc(ctx, metrics)
+	slog.Info("server running", "addr", ":50051")
+	obsSrv, obsErr := startObservabilityServerFunc(ctx, metrics, map[string]http.Handler{wsCfg.Path: liveLocations})
 	if obsErr != nil {
 		return obsErr
 	}
@@ -112,14 +192,20 @@ func run(ctx context.Context) error {
 	case <-ctx.Done():
 		healthServer.SetServingStatus(driverpb.DriverService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
 		healthServer.SetServingStatus(orderpb.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+		healthServer.SetServingStatus(geofencepb.GeofenceService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
 		healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
 		if metrics != nil {
 			metrics.MarkShutdown(metrics.Snapshot().InFlight)
 		}
 		server.GracefulStop()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		// http.Server.Shutdown does not wait for hijacked connections, so WebSocket
+		// clients are closed first.
+		if err := liveLocations.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("websocket shutdown", "error", err)
+		}
 		if obsSrv != nil {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			defer cancel()
 			_ = obsSrv.Shutdown(shutdownCtx)
 		}
 		return nil
@@ -128,13 +214,17 @@ func run(ctx context.Context) error {
 	}
 }
 
-func startObservabilityServer(ctx context.Context, metrics *observability.Metrics) (*http.Server, error) {
+func startObservabilityServer(ctx context.Context, metrics *observability.Metrics, handlers map[string]http.Handler) (*http.Server, error) {
 	cfg, err := config.LoadObservability()
 	if err != nil {
 		return nil, err
 	}
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", observability.Handler(metrics))
+	mux.Handle("/metrics/prometheus", observability.PrometheusHandler(metrics))
+	for path, handler := range handlers {
+		mux.Handle(path, handler)
+	}
 	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
 		if err := readinessCheck(r.Context()); err != nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
@@ -151,13 +241,79 @@ func startObservabilityServer(ctx context.Context, metrics *observability.Metric
 	}
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("observability server error: %v", err)
+			slog.Error("observability server failed", "error", err)
 		}
 	}()
 
 	return srv, nil
 }
 
+func ingestServiceConfig(cfg config.IngestConfig, metrics *observability.Metrics) ingest.IngestConfig {
+	out := ingest.IngestConfig{OnReject: metrics.AddIngestRejection}
+	if cfg.MaxFutureSkew != nil {
+		out.MaxFutureSkew = *cfg.MaxFutureSkew
+	}
+	if cfg.HistoryTTL != nil {
+		out.HistoryTTL = *cfg.HistoryTTL
+	}
+
+	fallback := ingest.DefaultSpeedLimit
+	if cfg.MaxSpeedKmh != nil {
+		fallback.MaxSpeedMps = *cfg.MaxSpeedKmh / 3.6
+	}
+	if cfg.SpeedJitterMeters != nil {
+		fallback.JitterMeters = *cfg.SpeedJitterMeters
+	}
+	byType := make(map[string]ingest.SpeedLimit, len(cfg.VehicleMaxSpeedKmh))
+	for vehicle, kmh := range cfg.VehicleMaxSpeedKmh {
+		byType[vehicle] = ingest.SpeedLimit{MaxSpeedMps: kmh / 3.6, JitterMeters: fallback.JitterMeters}
+	}
+	out.Validators = []ingest.LocationValidator{ingest.NewSpeedValidator(fallback, byType)}
+	return out
+}
+
+func tracingConfig(cfg config.TracingConfig) observability.TracingConfig {
+	return observability.TracingConfig{
+		Exporter:     cfg.Exporter,
+		File:         cfg.File,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		OTLPInsecure: cfg.OTLPInsecure,
+		ServiceName:  cfg.ServiceName,
+		SampleRatio:  cfg.SampleRatio,
+	}
+}
+
+// orderDriverResolver lets WebSocket clients follow the driver assigned to an order.
+func orderDriverResolver(service *orders.OrderService) ws.DriverResolver {
+	return func(ctx context.Context, orderID string) (string, error) {
+		view, err := service.GetOrder(ctx, orderID)
+		if errors.Is(err, orders.ErrOrderNotFound) {
+			return "", ws.ErrUnknownOrder
+		}
+		if err != nil {
+			return "", err
+		}
+		return view.DriverID, nil
+	}
+}
+
+func hubConfig(cfg config.WebSocketConfig) ws.Config {
+	hub := ws.Config{AllowedOrigins: cfg.AllowedOrigins}
+	if cfg.SendBuffer != nil {
+		hub.SendBuffer = *cfg.SendBuffer
+	}
+	if cfg.WriteTimeout != nil {
+		hub.WriteTimeout = *cfg.WriteTimeout
+	}
+	if cfg.PingInterval != nil {
+		hub.PingInterval = *cfg.PingInterval
+	}
+	if cfg.PongTimeout != nil {
+		hub.PongTimeout = *cfg.PongTimeout
+	}
+	return hub
+}
+
 func readinessCheck(ctx context.Context) error {
 	redisURL, err := config.GetRedisURL()
 	if err != nil {
@@ -185,7 +341,7 @@ func readinessCheck(ctx context.Context) error {
 	}
 	defer func() {
 		if cerr := db.Close(); cerr != nil {
-			log.Printf("readiness db close: %v", cerr)
+			slog.Warn("readiness db close", "error", cerr)
 		}
 	}()
 	if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
@@ -0,0 +1,144 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	geofencepb "wayfinder/api/proto/geofence"
+	"wayfinder/internal/geo"
+	"wayfinder/internal/ingest"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GeofenceService defines the geofence management needed by the gRPC adapter.
+type GeofenceService interface {
+	CreateGeofence(ctx context.Context, fence ingest.Geofence) (ingest.Geofence, error)
+	GetGeofence(ctx context.Context, id string) (ingest.Geofence, error)
+	ListGeofences(ctx context.Context) ([]ingest.Geofence, error)
+	DeleteGeofence(ctx context.Context, id string) error
+}
+
+// GeofenceServer adapts GeofenceService to gRPC.
+type GeofenceServer struct {
+	geofencepb.UnimplementedGeofenceServiceServer
+	service GeofenceService
+}
+
+// NewGeofenceServer constructs a GeofenceServer.
+func NewGeofenceServer(svc GeofenceService) *GeofenceServer {
+	return &GeofenceServer{service: svc}
+}
+
+// CreateGeofence stores a new fence and returns it with its assigned ID.
+func (s *GeofenceServer) CreateGeofence(ctx context.Context, req *geofencepb.CreateGeofenceRequest) (*geofencepb.CreateGeofenceResponse, error) {
+	fence, err := fromGeofenceProto(req.GetGeofence())
+	if err != nil {
+		return nil, mapGeofenceError(err)
+	}
+	created, err := s.service.CreateGeofence(ctx, fence)
+	if err != nil {
+		return nil, mapGeofenceError(err)
+	}
+	return &geofencepb.CreateGeofenceResponse{Geofence: toGeofenceProto(created)}, nil
+}
+
+// GetGeofence returns a single fence.
+func (s *GeofenceServer) GetGeofence(ctx context.Context, req *geofencepb.GetGeofenceRequest) (*geofencepb.GetGeofenceResponse, error) {
+	fence, err := s.service.GetGeofence(ctx, req.GetId())
+	if err != nil {
+		return nil, mapGeofenceError(err)
+	}
+	return &geofencepb.GetGeofenceResponse{Geofence: toGeofenceProto(fence)}, nil
+}
+
+// ListGeofences returns every fence.
+func (s *GeofenceServer) ListGeofences(ctx context.Context, _ *geofencepb.ListGeofencesRequest) (*geofencepb.ListGeofencesResponse, error) {
+	fences, err := s.service.ListGeofences(ctx)
+	if err != nil {
+		return nil, mapGeofenceError(err)
+	}
+	resp := &geofencepb.ListGeofencesResponse{Geofences: make([]*geofencepb.Geofence, 0, len(fences))}
+	for _, fence := range fences {
+		resp.Geofences = append(resp.Geofences, toGeofenceProto(fence))
+	}
+	return resp, nil
+}
+
+// DeleteGeofence removes a fence.
+func (s *GeofenceServer) DeleteGeofence(ctx context.Context, req *geofencepb.DeleteGeofenceRequest) (*geofencepb.DeleteGeofenceResponse, error) {
+	if err := s.service.DeleteGeofence(ctx, req.GetId()); err != nil {
+		return nil, mapGeofenceError(err)
+	}
+	return &geofencepb.DeleteGeofenceResponse{}, nil
+}
+
+var errInvalidDwellSeconds = errors.New("dwell_seconds must be a finite, non-negative number")
+
+func fromGeofenceProto(msg *geofencepb.Geofence) (ingest.Geofence, error) {
+	dwell := msg.GetDwellSeconds()
+	if dwell < 0 || math.IsNaN(dwell) || math.IsInf(dwell, 0) {
+		return ingest.Geofence{}, errInvalidDwellSeconds
+	}
+	fence := ingest.Geofence{
+		Name:       msg.GetName(),
+		DwellAfter: time.Duration(dwell * float64(time.Second)),
+	}
+	if c := msg.GetCircle(); c != nil {
+		fence.Circle = &ingest.Circle{
+			Center:       geo.Point{Lat: c.GetCenter().GetLatitude(), Long: c.GetCenter().GetLongitude()},
+			RadiusMeters: c.GetRadiusMeters(),
+		}
+	}
+	if p := msg.GetPolygon(); p != nil {
+		fence.Polygon = make(ingest.Polygon, 0, len(p.GetVertices()))
+		for _, v := range p.GetVertices() {
+			fence.Polygon = append(fence.Polygon, geo.Point{Lat: v.GetLatitude(), Long: v.GetLongitude()})
+		}
+	}
+	return fence, nil
+}
+
+func toGeofenceProto(fence ingest.Geofence) *geofencepb.Geofence {
+	out := &geofencepb.Geofence{
+		Id:           fence.ID,
+		Name:         fence.Name,
+		DwellSeconds: fence.DwellAfter.Seconds(),
+		CreatedAt:    timestampOrNil(fence.CreatedAt),
+	}
+	if fence.Circle != nil {
+		out.Circle = &geofencepb.Circle{
+			Center:       &geofencepb.LatLng{Latitude: fence.Circle.Center.Lat, Longitude: fence.Circle.Center.Long},
+			RadiusMeters: fence.Circle.RadiusMeters,
+		}
+	}
+	if fence.Polygon != nil {
+		out.Polygon = &geofencepb.Polygon{Vertices: make([]*geofencepb.LatLng, 0, len(fence.Polygon))}
+		for _, v := range fence.Polygon {
+			out.Polygon.Vertices = append(out.Polygon.Vertices, &geofencepb.LatLng{Latitude: v.Lat, Longitude: v.Long})
+		}
+	}
+	return out
+}
+
+func mapGeofenceError(err error) error {
+	switch {
+	case errors.Is(err, errInvalidDwellSeconds),
+		errors.Is(err, ingest.ErrGeofenceIDRequired),
+		errors.Is(err, ingest.ErrGeofenceNameRequired),
+		errors.Is(err, ingest.ErrGeofenceShape),
+		errors.Is(err, ingest.ErrInvalidCircle),
+		errors.Is(err, ingest.ErrInvalidPolygon),
+		errors.Is(err, ingest.ErrInvalidDwell):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ingest.ErrGeofenceNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Errorf(codes.Internal, "geofence: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	geofencepb "wayfinder/api/proto/geofence"
+	"wayfinder/internal/geo"
+	"wayfinder/internal/ingest"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGeofenceServerImplementsGeofenceServiceServer(t *testing.T) {
+	var _ geofencepb.GeofenceServiceServer = (*GeofenceServer)(nil)
+}
+
+type spyGeofenceService struct {
+	created ingest.Geofence
+	fences  []ingest.Geofence
+	id      string
+	err     error
+}
+
+func (s *spyGeofenceService) CreateGeofence(ctx context.Context, fence ingest.Geofence) (ingest.Geofence, error) {
+	s.created = fence
+	if s.err != nil {
+		return ingest.Geofence{}, s.err
+	}
+	fence.ID = "fence-1"
+	fence.CreatedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return fence, nil
+}
+
+func (s *spyGeofenceService) GetGeofence(ctx context.Context, id string) (ingest.Geofence, error) {
+	s.id = id
+	if s.err != nil || len(s.fences) == 0 {
+		return ingest.Geofence{}, s.err
+	}
+	return s.fences[0], nil
+}
+
+func (s *spyGeofenceService) ListGeofences(ctx context.Context) ([]ingest.Geofence, error) {
+	return s.fences, s.err
+}
+
+func (s *spyGeofenceService) DeleteGeofence(ctx context.Context, id string) error {
+	s.id = id
+	return s.err
+}
+
+func TestGeofenceServer_CreatePolygon(t *testing.T) {
+	svc := &spyGeofenceService{}
+	server := NewGeofenceServer(svc)
+
+	resp, err := server.CreateGeofence(context.Background(), &geofencepb.CreateGeofenceRequest{Geofence: &geofencepb.Geofence{
+		Id:           "client-chosen",
+		Name:         "Airport",
+		DwellSeconds: 300,
+		Polygon: &geofencepb.Polygon{Vertices: []*geofencepb.LatLng{
+			{Latitude: 52.36, Longitude: 13.5}, {Latitude: 52.36, Longitude: 13.516}, {Latitude: 52.37, Longitude: 13.516},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if svc.created.Name != "Airport" || svc.created.ID != "" || svc.created.DwellAfter != 5*time.Minute || len(svc.created.Polygon) != 3 || svc.created.Circle != nil {
+		t.Fatalf("unexpected fence passed to service: %+v", svc.created)
+	}
+	got := resp.GetGeofence()
+	if got.GetId() != "fence-1" || got.GetDwellSeconds() != 300 || got.GetCreatedAt() == nil || len(got.GetPolygon().GetVertices()) != 3 || got.GetCircle() != nil {
+		t.Fatalf("unexpected response: %+v", got)
+	}
+}
+
+func TestGeofenceServer_GetCircle(t *testing.T) {
+	svc := &spyGeofenceService{fences: []ingest.Geofence{{
+		ID: "pickup", Name: "Pickup", Circle: &ingest.Circle{Center: geo.Point{Lat: 52.52, Long: 13.405}, RadiusMeters: 150},
+	}}}
+	server := NewGeofenceServer(svc)
+
+	resp, err := server.GetGeofence(context.Background(), &geofencepb.GetGeofenceRequest{Id: "pickup"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := resp.GetGeofence().GetCircle()
+	if svc.id != "pickup" || c.GetRadiusMeters() != 150 || c.GetCenter().GetLatitude() != 52.52 || resp.GetGeofence().GetPolygon() != nil {
+		t.Fatalf("unexpected response: %+v", resp.GetGeofence())
+	}
+}
+
+func TestGeofenceServer_ListAndDelete(t *testing.T) {
+	svc := &spyGeofenceService{fences: []ingest.Geofence{{ID: "a"}, {ID: "b"}}}
+	server := NewGeofenceServer(svc)
+
+	list, err := server.ListGeofences(context.Background(), &geofencepb.ListGeofencesRequest{})
+	if err != nil || len(list.GetGeofences()) != 2 || list.GetGeofences()[1].GetId() != "b" {
+		t.Fatalf("unexpected list: %+v %v", list, err)
+	}
+	if _, err := server.DeleteGeofence(context.Background(), &geofencepb.DeleteGeofenceRequest{Id: "a"}); err != nil || svc.id != "a" {
+		t.Fatalf("unexpected delete: %v", err)
+	}
+}
+
+func TestGeofenceServer_ErrorMapping(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"shape", ingest.ErrGeofenceShape, codes.InvalidArgument},
+		{"polygon", ingest.ErrInvalidPolygon, codes.InvalidArgument},
+		{"not found", ingest.ErrGeofenceNotFound, codes.NotFound},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded},
+		{"db", errors.New("db down"), codes.Internal},
+	}
+	for _, tc := range cases {
+		server := NewGeofenceServer(&spyGeofenceService{err: tc.err})
+		if _, err := server.DeleteGeofence(context.Background(), &geofencepb.DeleteGeofenceRequest{Id: "x"}); status.Code(err) != tc.code {
+			t.Fatalf("%s: got %v, want %v", tc.name, status.Code(err), tc.code)
+		}
+	}
+
+	server := NewGeofenceServer(&spyGeofenceService{})
+	_, err := server.CreateGeofence(context.Background(), &geofencepb.CreateGeofenceRequest{Geofence: &geofencepb.Geofence{Name: "x", DwellSeconds: math.Inf(1)}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for infinite dwell, got %v", err)
+	}
+}
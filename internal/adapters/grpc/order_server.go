@@ -4,17 +4,28 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	orderpb "wayfinder/api/proto/order"
+	"wayfinder/internal/geo"
 	"wayfinder/internal/orders"
+	"wayfinder/internal/orders/money"
+	"wayfinder/internal/orders/saga"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // OrderService defines the behavior needed by the gRPC adapter.
 type OrderService interface {
-	CreateOrder(ctx context.Context, userID string, amount float64, idempotencyKey string) (string, error)
+	CreateOrder(ctx context.Context, userID string, amount money.Money, pickup geo.Point, idempotencyKey string) (string, error)
+	GetOrder(ctx context.Context, orderID string) (saga.OrderView, error)
+	ListOrders(ctx context.Context, query orders.ListOrdersQuery) (orders.OrderPage, error)
+	CancelOrder(ctx context.Context, orderID, reason string) error
+	CompleteOrder(ctx context.Context, orderID string) error
+	GetOrderTrip(ctx context.Context, orderID string, toleranceMeters float64) (saga.Trip, error)
+	WatchOrder(ctx context.Context, orderID string, send func(saga.Event) error) error
 }
 
 // OrderServer adapts OrderService to gRPC.
@@ -30,7 +41,7 @@ func NewOrderServer(svc OrderService) *OrderServer {
 
 // CreateOrder handles the gRPC request and maps domain errors to gRPC status codes.
 func (s *OrderServer) CreateOrder(ctx context.Context, req *orderpb.CreateOrderRequest) (*orderpb.CreateOrderResponse, error) {
-	orderID, err := s.service.CreateOrder(ctx, req.GetUserId(), req.GetAmount(), req.GetIdempotencyKey())
+	orderID, err := s.service.CreateOrder(ctx, req.GetUserId(), amountFromRequest(req), fromLatLng(req.GetPickup()), req.GetIdempotencyKey())
 	if err != nil {
 		return nil, mapOrderError(err)
 	}
@@ -42,6 +53,184 @@ func (s *OrderServer) CreateOrder(ctx context.Context, req *orderpb.CreateOrderR
 	}, nil
 }
 
+// GetOrder returns the full view of a single order.
+func (s *OrderServer) GetOrder(ctx context.Context, req *orderpb.GetOrderRequest) (*orderpb.GetOrderResponse, error) {
+	view, err := s.service.GetOrder(ctx, req.GetOrderId())
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+
+	return &orderpb.GetOrderResponse{Order: toOrderProto(view)}, nil
+}
+
+// ListOrders returns a filtered page of orders.
+func (s *OrderServer) ListOrders(ctx context.Context, req *orderpb.ListOrdersRequest) (*orderpb.ListOrdersResponse, error) {
+	query := orders.ListOrdersQuery{
+		UserID:    req.GetUserId(),
+		Status:    saga.SagaStatus(req.GetStatus()),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	}
+	if req.GetCreatedAfter() != nil {
+		if !req.GetCreatedAfter().IsValid() {
+			return nil, status.Error(codes.InvalidArgument, "invalid created_after")
+		}
+		query.CreatedAfter = req.GetCreatedAfter().AsTime()
+	}
+	if req.GetCreatedBefore() != nil {
+		if !req.GetCreatedBefore().IsValid() {
+			return nil, status.Error(codes.InvalidArgument, "invalid created_before")
+		}
+		query.CreatedBefore = req.GetCreatedBefore().AsTime()
+	}
+
+	page, err := s.service.ListOrders(ctx, query)
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+
+	resp := &orderpb.ListOrdersResponse{
+		Orders:        make([]*orderpb.Order, 0, len(page.Orders)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, view := range page.Orders {
+		resp.Orders = append(resp.Orders, toOrderProto(view))
+	}
+	return resp, nil
+}
+
+// CancelOrder cancels a succeeded order, refunding the payment and releasing the driver.
+func (s *OrderServer) CancelOrder(ctx context.Context, req *orderpb.CancelOrderRequest) (*orderpb.CancelOrderResponse, error) {
+	if err := s.service.CancelOrder(ctx, req.GetOrderId(), req.GetReason()); err != nil {
+		return nil, mapOrderError(err)
+	}
+
+	return &orderpb.CancelOrderResponse{
+		OrderId: req.GetOrderId(),
+		Status:  string(saga.SagaStatusCancelled),
+		Message: "order cancelled",
+	}, nil
+}
+
+// CompleteOrder marks a delivered order completed and ends its trip.
+func (s *OrderServer) CompleteOrder(ctx context.Context, req *orderpb.CompleteOrderRequest) (*orderpb.CompleteOrderResponse, error) {
+	if err := s.service.CompleteOrder(ctx, req.GetOrderId()); err != nil {
+		return nil, mapOrderError(err)
+	}
+
+	return &orderpb.CompleteOrderResponse{
+		OrderId: req.GetOrderId(),
+		Status:  string(saga.SagaStatusCompleted),
+	}, nil
+}
+
+// GetOrderTrip returns the driver's trip for an order.
+func (s *OrderServer) GetOrderTrip(ctx context.Context, req *orderpb.GetOrderTripRequest) (*orderpb.GetOrderTripResponse, error) {
+	trip, err := s.service.GetOrderTrip(ctx, req.GetOrderId(), req.GetSimplifyToleranceMeters())
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+
+	return &orderpb.GetOrderTripResponse{Trip: toTripProto(trip, time.Now())}, nil
+}
+
+// WatchOrder streams the order's timeline until it reaches a terminal status.
+func (s *OrderServer) WatchOrder(req *orderpb.WatchOrderRequest, stream orderpb.OrderService_WatchOrderServer) error {
+	err := s.service.WatchOrder(stream.Context(), req.GetOrderId(), func(ev saga.Event) error {
+		return stream.Send(&orderpb.OrderEvent{
+			OrderId:    ev.OrderID,
+			Type:       string(ev.Kind),
+			Step:       ev.Step,
+			Status:     ev.Status,
+			Detail:     ev.Detail,
+			OccurredAt: timestampOrNil(ev.At),
+			Replay:     ev.Replay,
+		})
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return mapOrderError(err)
+	}
+	return nil
+}
+
+// amountFromRequest prefers the Money total and falls back to the deprecated float amount,
+// which predates currencies and is read as money.DefaultCurrency.
+func amountFromRequest(req *orderpb.CreateOrderRequest) money.Money {
+	if total := req.GetTotal(); total != nil {
+		return money.Money{Units: total.GetUnits(), Currency: total.GetCurrency()}
+	}
+	return money.FromMajor(req.GetAmount(), money.DefaultCurrency)
+}
+
+func fromLatLng(p *orderpb.LatLng) geo.Point {
+	return geo.Point{Lat: p.GetLatitude(), Long: p.GetLongitude()}
+}
+
+func toLatLngProto(p geo.Point) *orderpb.LatLng {
+	if p.IsZero() {
+		return nil
+	}
+	return &orderpb.LatLng{Latitude: p.Lat, Longitude: p.Long}
+}
+
+func toMoneyProto(m money.Money) *orderpb.Money {
+	if m.IsZero() {
+		return nil
+	}
+	return &orderpb.Money{Units: m.Units, Currency: m.Currency}
+}
+
+func toOrderProto(view saga.OrderView) *orderpb.Order {
+	return &orderpb.Order{
+		OrderId:      view.OrderID,
+		UserId:       view.UserID,
+		Amount:       view.Amount.Major(),
+		Total:        toMoneyProto(view.Amount),
+		Pickup:       toLatLngProto(view.Pickup),
+		Status:       string(view.Status),
+		DriverId:     view.DriverID,
+		AssignedAt:   timestampOrNil(view.AssignedAt),
+		ChargedAt:    timestampOrNil(view.ChargedAt),
+		Refunded:     view.Refunded(),
+		RefundAmount: view.RefundAmount.Major(),
+		RefundTotal:  toMoneyProto(view.RefundAmount),
+		RefundedAt:   timestampOrNil(view.RefundedAt),
+		CreatedAt:    timestampOrNil(view.CreatedAt),
+		UpdatedAt:    timestampOrNil(view.UpdatedAt),
+	}
+}
+
+func toTripProto(trip saga.Trip, now time.Time) *orderpb.Trip {
+	out := &orderpb.Trip{
+		OrderId:         trip.OrderID,
+		DriverId:        trip.DriverID,
+		StartedAt:       timestampOrNil(trip.StartedAt),
+		EndedAt:         timestampOrNil(trip.EndedAt),
+		EndReason:       trip.EndReason,
+		DistanceMeters:  trip.DistanceMeters,
+		DurationSeconds: trip.Duration(now).Seconds(),
+		Points:          make([]*orderpb.TripPoint, 0, len(trip.Points)),
+	}
+	for _, p := range trip.Points {
+		out.Points = append(out.Points, &orderpb.TripPoint{
+			Latitude:   p.Lat,
+			Longitude:  p.Long,
+			RecordedAt: timestampOrNil(p.RecordedAt),
+		})
+	}
+	return out
+}
+
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
 func mapOrderError(err error) error {
 	if errors.Is(err, context.Canceled) {
 		return status.Error(codes.Canceled, err.Error())
@@ -49,10 +238,32 @@ func mapOrderError(err error) error {
 	if errors.Is(err, context.DeadlineExceeded) {
 		return status.Error(codes.DeadlineExceeded, err.Error())
 	}
-	if errors.Is(err, orders.ErrIdempotencyKeyRequired) {
+	if errors.Is(err, orders.ErrIdempotencyKeyRequired) ||
+		errors.Is(err, orders.ErrOrderIDRequired) ||
+		errors.Is(err, orders.ErrInvalidPageToken) ||
+		errors.Is(err, orders.ErrInvalidStatusFilter) ||
+		errors.Is(err, orders.ErrInvalidCreatedRange) ||
+		errors.Is(err, orders.ErrInvalidAmount) ||
+		errors.Is(err, orders.ErrInvalidCurrency) ||
+		errors.Is(err, orders.ErrPickupRequired) ||
+		errors.Is(err, orders.ErrInvalidPickup) ||
+		errors.Is(err, orders.ErrInvalidSimplifyTolerance) {
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
-	if errors.Is(err, orders.ErrIdempotencyConflict) {
+	if errors.Is(err, orders.ErrOrderNotFound) || errors.Is(err, orders.ErrTripNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, orders.ErrNoDriverAvailable) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if errors.Is(err, orders.ErrWatchLagged) || errors.Is(err, orders.ErrOrderBusy) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	if errors.Is(err, orders.ErrOrderReadsDisabled) || errors.Is(err, orders.ErrOrderWatchDisabled) || errors.Is(err, orders.ErrTripsDisabled) {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	if errors.Is(err, orders.ErrIdempotencyConflict) || errors.Is(err, orders.ErrOrderNotCancellable) || errors.Is(err, orders.ErrOrderNotCompletable) ||
+		errors.Is(err, orders.ErrDispatchNotConfigured) {
 		return status.Error(codes.FailedPrecondition, err.Error())
 	}
 	if strings.Contains(strings.ToLower(err.Error()), "payment failed") {
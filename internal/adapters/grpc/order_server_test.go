@@ -3,13 +3,20 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	orderpb "wayfinder/api/proto/order"
+	"wayfinder/internal/geo"
 	"wayfinder/internal/orders"
+	"wayfinder/internal/orders/money"
+	"wayfinder/internal/orders/saga"
 
+	grpcpkg "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestOrderServerImplementsOrderServiceServer(t *testing.T) {
@@ -19,19 +26,65 @@ func TestOrderServerImplementsOrderServiceServer(t *testing.T) {
 type spyOrderService struct {
 	orderID string
 	err     error
+	view    saga.OrderView
+	page    orders.OrderPage
+	query   orders.ListOrdersQuery
+	events  []saga.Event
+	amount  money.Money
+	pickup  geo.Point
+	trip    saga.Trip
+	tol     float64
 }
 
-func (s *spyOrderService) CreateOrder(ctx context.Context, userID string, amount float64, idempotencyKey string) (string, error) {
+func (s *spyOrderService) CreateOrder(ctx context.Context, userID string, amount money.Money, pickup geo.Point, idempotencyKey string) (string, error) {
+	s.amount = amount
+	s.pickup = pickup
 	return s.orderID, s.err
 }
 
+func (s *spyOrderService) GetOrder(ctx context.Context, orderID string) (saga.OrderView, error) {
+	s.orderID = orderID
+	return s.view, s.err
+}
+
+func (s *spyOrderService) CancelOrder(ctx context.Context, orderID, reason string) error {
+	s.orderID = orderID
+	return s.err
+}
+
+func (s *spyOrderService) CompleteOrder(ctx context.Context, orderID string) error {
+	s.orderID = orderID
+	return s.err
+}
+
+func (s *spyOrderService) GetOrderTrip(ctx context.Context, orderID string, toleranceMeters float64) (saga.Trip, error) {
+	s.orderID = orderID
+	s.tol = toleranceMeters
+	return s.trip, s.err
+}
+
+func (s *spyOrderService) WatchOrder(ctx context.Context, orderID string, send func(saga.Event) error) error {
+	for _, ev := range s.events {
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func (s *spyOrderService) ListOrders(ctx context.Context, query orders.ListOrdersQuery) (orders.OrderPage, error) {
+	s.query = query
+	return s.page, s.err
+}
+
 func TestCreateOrder_Success(t *testing.T) {
 	svc := &spyOrderService{orderID: "order-123"}
 	server := NewOrderServer(svc)
 
 	resp, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
 		UserId:         "user-1",
-		Amount:         12.34,
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
+		Pickup:         &orderpb.LatLng{Latitude: 52.52, Longitude: 13.405},
 		IdempotencyKey: "idem-1",
 	})
 	if err != nil {
@@ -41,6 +94,70 @@ func TestCreateOrder_Success(t *testing.T) {
 	if resp.OrderId != "order-123" || resp.Status != "ok" {
 		t.Fatalf("unexpected response: %+v", resp)
 	}
+	if svc.amount != (money.Money{Units: 1234, Currency: "USD"}) {
+		t.Fatalf("unexpected amount: %+v", svc.amount)
+	}
+	if svc.pickup != (geo.Point{Lat: 52.52, Long: 13.405}) {
+		t.Fatalf("unexpected pickup: %+v", svc.pickup)
+	}
+}
+
+func TestCreateOrder_LegacyFloatAmountConvertsToMinorUnits(t *testing.T) {
+	svc := &spyOrderService{orderID: "order-123"}
+	server := NewOrderServer(svc)
+
+	if _, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
+		UserId:         "user-1",
+		Amount:         19.99,
+		IdempotencyKey: "idem-1",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if svc.amount != (money.Money{Units: 1999, Currency: money.DefaultCurrency}) {
+		t.Fatalf("unexpected amount: %+v", svc.amount)
+	}
+}
+
+func TestCreateOrder_InvalidAmountMapsToInvalidArgument(t *testing.T) {
+	for _, err := range []error{orders.ErrInvalidAmount, orders.ErrInvalidCurrency} {
+		server := NewOrderServer(&spyOrderService{err: err})
+		_, got := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
+			UserId:         "user-1",
+			Total:          &orderpb.Money{Units: -5, Currency: "USD"},
+			IdempotencyKey: "idem-1",
+		})
+		if status.Code(got) != codes.InvalidArgument {
+			t.Fatalf("%v: unexpected status code: %v", err, status.Code(got))
+		}
+	}
+}
+
+func TestCreateOrder_InvalidPickupMapsToInvalidArgument(t *testing.T) {
+	for _, err := range []error{orders.ErrPickupRequired, orders.ErrInvalidPickup} {
+		server := NewOrderServer(&spyOrderService{err: err})
+		_, got := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
+			UserId:         "user-1",
+			Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
+			IdempotencyKey: "idem-1",
+		})
+		if status.Code(got) != codes.InvalidArgument {
+			t.Fatalf("%v: unexpected status code: %v", err, status.Code(got))
+		}
+	}
+}
+
+func TestCreateOrder_NoDriverAvailableMapsToUnavailable(t *testing.T) {
+	server := NewOrderServer(&spyOrderService{err: fmt.Errorf("assign: %w", orders.ErrNoDriverAvailable)})
+
+	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
+		UserId:         "user-1",
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
+		Pickup:         &orderpb.LatLng{Latitude: 52.52, Longitude: 13.405},
+		IdempotencyKey: "idem-1",
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
 }
 
 func TestCreateOrder_PaymentFailureMapsToFailedPrecondition(t *testing.T) {
@@ -49,7 +166,7 @@ func TestCreateOrder_PaymentFailureMapsToFailedPrecondition(t *testing.T) {
 
 	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
 		UserId:         "user-1",
-		Amount:         12.34,
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
 		IdempotencyKey: "idem-1",
 	})
 	if err == nil {
@@ -67,7 +184,7 @@ func TestCreateOrder_GenericErrorMapsToInternal(t *testing.T) {
 
 	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
 		UserId:         "user-1",
-		Amount:         12.34,
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
 		IdempotencyKey: "idem-1",
 	})
 	if err == nil {
@@ -85,7 +202,7 @@ func TestCreateOrder_IdempotencyMissingMapsToInvalidArgument(t *testing.T) {
 
 	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
 		UserId:         "user-1",
-		Amount:         12.34,
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
 		IdempotencyKey: "",
 	})
 	if err == nil {
@@ -97,13 +214,26 @@ func TestCreateOrder_IdempotencyMissingMapsToInvalidArgument(t *testing.T) {
 	}
 }
 
+func TestCreateOrder_DispatchNotConfiguredMapsToFailedPrecondition(t *testing.T) {
+	server := NewOrderServer(&spyOrderService{err: orders.ErrDispatchNotConfigured})
+
+	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
+		UserId:         "user-1",
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
+		IdempotencyKey: "idem-1",
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
+}
+
 func TestCreateOrder_IdempotencyConflictMapsToFailedPrecondition(t *testing.T) {
 	svc := &spyOrderService{err: orders.ErrIdempotencyConflict}
 	server := NewOrderServer(svc)
 
 	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
 		UserId:         "user-1",
-		Amount:         12.34,
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
 		IdempotencyKey: "idem-1",
 	})
 	if err == nil {
@@ -121,7 +251,7 @@ func TestCreateOrder_ContextCanceledMapsToCanceled(t *testing.T) {
 
 	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
 		UserId:         "user-1",
-		Amount:         12.34,
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
 		IdempotencyKey: "idem-1",
 	})
 	if err == nil {
@@ -139,7 +269,7 @@ func TestCreateOrder_DeadlineExceededMapsToDeadlineExceeded(t *testing.T) {
 
 	_, err := server.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
 		UserId:         "user-1",
-		Amount:         12.34,
+		Total:          &orderpb.Money{Units: 1234, Currency: "USD"},
 		IdempotencyKey: "idem-1",
 	})
 	if err == nil {
@@ -150,3 +280,253 @@ func TestCreateOrder_DeadlineExceededMapsToDeadlineExceeded(t *testing.T) {
 		t.Fatalf("unexpected status code: %v", status.Code(err))
 	}
 }
+
+func TestGetOrder_MapsView(t *testing.T) {
+	assignedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &spyOrderService{view: saga.OrderView{
+		OrderID:    "order-1",
+		UserID:     "user-1",
+		Amount:     money.Money{Units: 1234, Currency: "USD"},
+		Pickup:     geo.Point{Lat: 52.52, Long: 13.405},
+		Status:     saga.SagaStatusSucceeded,
+		DriverID:   "driver-1",
+		AssignedAt: assignedAt,
+		CreatedAt:  assignedAt.Add(-time.Minute),
+	}}
+	server := NewOrderServer(svc)
+
+	resp, err := server.GetOrder(context.Background(), &orderpb.GetOrderRequest{OrderId: "order-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := resp.GetOrder()
+	if got.GetOrderId() != "order-1" || got.GetDriverId() != "driver-1" || got.GetStatus() != "succeeded" {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+	if !got.GetAssignedAt().AsTime().Equal(assignedAt) {
+		t.Fatalf("unexpected assigned_at: %v", got.GetAssignedAt())
+	}
+	if got.GetTotal().GetUnits() != 1234 || got.GetTotal().GetCurrency() != "USD" || got.GetAmount() != 12.34 {
+		t.Fatalf("unexpected total: %+v", got.GetTotal())
+	}
+	if got.GetPickup().GetLatitude() != 52.52 || got.GetPickup().GetLongitude() != 13.405 {
+		t.Fatalf("unexpected pickup: %+v", got.GetPickup())
+	}
+	if got.GetRefunded() || got.GetRefundedAt() != nil || got.GetChargedAt() != nil || got.GetRefundTotal() != nil {
+		t.Fatalf("expected unset refund and charge fields, got %+v", got)
+	}
+}
+
+func TestGetOrder_NotFoundMapsToNotFound(t *testing.T) {
+	svc := &spyOrderService{err: orders.ErrOrderNotFound}
+	server := NewOrderServer(svc)
+
+	_, err := server.GetOrder(context.Background(), &orderpb.GetOrderRequest{OrderId: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
+}
+
+func TestListOrders_PassesFiltersAndToken(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := &spyOrderService{page: orders.OrderPage{
+		Orders:        []saga.OrderView{{OrderID: "order-2"}, {OrderID: "order-1"}},
+		NextPageToken: "next",
+	}}
+	server := NewOrderServer(svc)
+
+	resp, err := server.ListOrders(context.Background(), &orderpb.ListOrdersRequest{
+		UserId:       "user-1",
+		Status:       "refunded",
+		CreatedAfter: timestamppb.New(from),
+		PageSize:     2,
+		PageToken:    "tok",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.GetOrders()) != 2 || resp.GetNextPageToken() != "next" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	want := orders.ListOrdersQuery{UserID: "user-1", Status: saga.SagaStatusRefunded, CreatedAfter: from, PageSize: 2, PageToken: "tok"}
+	if svc.query != want {
+		t.Fatalf("unexpected query: %+v", svc.query)
+	}
+}
+
+func TestListOrders_InvalidPageTokenMapsToInvalidArgument(t *testing.T) {
+	svc := &spyOrderService{err: orders.ErrInvalidPageToken}
+	server := NewOrderServer(svc)
+
+	_, err := server.ListOrders(context.Background(), &orderpb.ListOrdersRequest{PageToken: "garbage"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
+}
+
+func TestCancelOrder_Success(t *testing.T) {
+	svc := &spyOrderService{}
+	server := NewOrderServer(svc)
+
+	resp, err := server.CancelOrder(context.Background(), &orderpb.CancelOrderRequest{OrderId: "order-1", Reason: "changed mind"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.GetOrderId() != "order-1" || resp.GetStatus() != "cancelled" || svc.orderID != "order-1" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestCancelOrder_NotCancellableMapsToFailedPrecondition(t *testing.T) {
+	svc := &spyOrderService{err: orders.ErrOrderNotCancellable}
+	server := NewOrderServer(svc)
+
+	_, err := server.CancelOrder(context.Background(), &orderpb.CancelOrderRequest{OrderId: "order-1"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
+}
+
+func TestCancelOrder_BusyMapsToAborted(t *testing.T) {
+	server := NewOrderServer(&spyOrderService{err: orders.ErrOrderBusy})
+
+	_, err := server.CancelOrder(context.Background(), &orderpb.CancelOrderRequest{OrderId: "order-1"})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
+}
+
+func TestCompleteOrder_Success(t *testing.T) {
+	svc := &spyOrderService{}
+	server := NewOrderServer(svc)
+
+	resp, err := server.CompleteOrder(context.Background(), &orderpb.CompleteOrderRequest{OrderId: "order-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.GetOrderId() != "order-1" || resp.GetStatus() != "completed" || svc.orderID != "order-1" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestCompleteOrder_NotCompletableMapsToFailedPrecondition(t *testing.T) {
+	server := NewOrderServer(&spyOrderService{err: fmt.Errorf("%w: status refunded", orders.ErrOrderNotCompletable)})
+
+	_, err := server.CompleteOrder(context.Background(), &orderpb.CompleteOrderRequest{OrderId: "order-1"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
+}
+
+func TestGetOrderTrip_MapsTrip(t *testing.T) {
+	started := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	svc := &spyOrderService{trip: saga.Trip{
+		OrderID:        "order-1",
+		DriverID:       "driver-1",
+		StartedAt:      started,
+		EndedAt:        started.Add(25 * time.Minute),
+		EndReason:      saga.TripEndCompleted,
+		DistanceMeters: 5300,
+		Points: []saga.TripPoint{
+			{Point: geo.Point{Lat: 52.52, Long: 13.405}, RecordedAt: started},
+			{Point: geo.Point{Lat: 52.53, Long: 13.41}, RecordedAt: started.Add(time.Minute)},
+		},
+	}}
+	server := NewOrderServer(svc)
+
+	resp, err := server.GetOrderTrip(context.Background(), &orderpb.GetOrderTripRequest{OrderId: "order-1", SimplifyToleranceMeters: 15})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if svc.orderID != "order-1" || svc.tol != 15 {
+		t.Fatalf("unexpected service args: %s %f", svc.orderID, svc.tol)
+	}
+	trip := resp.GetTrip()
+	if trip.GetDriverId() != "driver-1" || trip.GetEndReason() != "completed" || trip.GetDistanceMeters() != 5300 || trip.GetDurationSeconds() != 1500 {
+		t.Fatalf("unexpected trip: %+v", trip)
+	}
+	if !trip.GetEndedAt().AsTime().Equal(started.Add(25*time.Minute)) || len(trip.GetPoints()) != 2 {
+		t.Fatalf("unexpected trip: %+v", trip)
+	}
+	if p := trip.GetPoints()[1]; p.GetLatitude() != 52.53 || p.GetLongitude() != 13.41 || !p.GetRecordedAt().AsTime().Equal(started.Add(time.Minute)) {
+		t.Fatalf("unexpected point: %+v", p)
+	}
+}
+
+func TestGetOrderTrip_ActiveTripLeavesEndedAtUnset(t *testing.T) {
+	server := NewOrderServer(&spyOrderService{trip: saga.Trip{OrderID: "order-1", StartedAt: time.Now().Add(-time.Minute)}})
+
+	resp, err := server.GetOrderTrip(context.Background(), &orderpb.GetOrderTripRequest{OrderId: "order-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.GetTrip().GetEndedAt() != nil || resp.GetTrip().GetDurationSeconds() < 60 {
+		t.Fatalf("unexpected active trip: %+v", resp.GetTrip())
+	}
+}
+
+func TestGetOrderTrip_ErrorMapping(t *testing.T) {
+	cases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{orders.ErrTripNotFound, codes.NotFound},
+		{orders.ErrTripsDisabled, codes.Unimplemented},
+		{orders.ErrInvalidSimplifyTolerance, codes.InvalidArgument},
+	}
+	for _, tc := range cases {
+		server := NewOrderServer(&spyOrderService{err: tc.err})
+		if _, err := server.GetOrderTrip(context.Background(), &orderpb.GetOrderTripRequest{OrderId: "order-1"}); status.Code(err) != tc.code {
+			t.Fatalf("%v: got %v, want %v", tc.err, status.Code(err), tc.code)
+		}
+	}
+}
+
+type fakeOrderEventStream struct {
+	grpcpkg.ServerStream
+	ctx  context.Context
+	sent []*orderpb.OrderEvent
+}
+
+func (f *fakeOrderEventStream) Context() context.Context { return f.ctx }
+
+func (f *fakeOrderEventStream) Send(ev *orderpb.OrderEvent) error {
+	f.sent = append(f.sent, ev)
+	return nil
+}
+
+func TestWatchOrder_SendsEvents(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &spyOrderService{events: []saga.Event{
+		{OrderID: "order-1", Kind: saga.EventKindStep, Step: "charge", Status: "succeeded", At: at, Replay: true},
+		{OrderID: "order-1", Kind: saga.EventKindStatus, Status: "cancelled"},
+	}}
+	server := NewOrderServer(svc)
+	stream := &fakeOrderEventStream{ctx: context.Background()}
+
+	if err := server.WatchOrder(&orderpb.WatchOrderRequest{OrderId: "order-1"}, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stream.sent) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(stream.sent))
+	}
+	first := stream.sent[0]
+	if first.GetType() != "step" || first.GetStep() != "charge" || !first.GetReplay() || !first.GetOccurredAt().AsTime().Equal(at) {
+		t.Fatalf("unexpected first event: %+v", first)
+	}
+	if stream.sent[1].GetType() != "status" || stream.sent[1].GetOccurredAt() != nil {
+		t.Fatalf("unexpected second event: %+v", stream.sent[1])
+	}
+}
+
+func TestWatchOrder_LaggedMapsToAborted(t *testing.T) {
+	svc := &spyOrderService{err: orders.ErrWatchLagged}
+	server := NewOrderServer(svc)
+
+	err := server.WatchOrder(&orderpb.WatchOrderRequest{OrderId: "order-1"}, &fakeOrderEventStream{ctx: context.Background()})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("unexpected status code: %v", status.Code(err))
+	}
+}
@@ -2,15 +2,19 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"time"
 
 	driverpb "wayfinder/api/proto/driver"
 	"wayfinder/internal/ingest"
+	"wayfinder/internal/logging"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // IngestService exposes the ingest behavior needed by the gRPC adapter.
@@ -18,45 +22,199 @@ type IngestService interface {
 	Ingest(ctx context.Context, loc ingest.Location) error
 }
 
+// HistoryService exposes the location history reads needed by the gRPC adapter.
+type HistoryService interface {
+	GetLocationHistory(ctx context.Context, query ingest.HistoryQuery) (ingest.HistoryPage, error)
+}
+
 // Server adapts DriverService to gRPC.
 type Server struct {
 	driverpb.UnimplementedDriverServiceServer
-	ingest IngestService
+	ingest  IngestService
+	history HistoryService
 }
 
-// NewServer constructs a Server with the given ingest service.
+// NewServer constructs a Server with the given ingest service. History reads are
+// unimplemented.
 func NewServer(ingest IngestService) *Server {
 	return &Server{ingest: ingest}
 }
 
+// NewServerWithHistory constructs a Server that also serves location history.
+func NewServerWithHistory(ingest IngestService, history HistoryService) *Server {
+	return &Server{ingest: ingest, history: history}
+}
+
 // UpdateLocation receives streamed locations and forwards them to the ingest service.
+// Duplicate, stale and future-dated points are skipped and counted in the ack.
 func (s *Server) UpdateLocation(stream driverpb.DriverService_UpdateLocationServer) error {
+	ack := &driverpb.UpdateLocationAck{Message: "ok"}
 	for {
 		msg, err := stream.Recv()
 		if err == io.EOF {
-			return stream.SendAndClose(&driverpb.UpdateLocationAck{Message: "ok"})
+			return stream.SendAndClose(ack)
 		}
 		if err != nil {
-			log.Printf("UpdateLocation recv error: %v", err)
+			slog.ErrorContext(stream.Context(), "UpdateLocation recv failed", "error", err)
 			return status.Errorf(codes.Internal, "recv: %v", err)
 		}
 
-		ts := time.Time{}
-		if msg.GetTimestamp() != nil {
-			if !msg.GetTimestamp().IsValid() {
-				log.Printf("UpdateLocation invalid timestamp: %v", msg.GetTimestamp())
-				return status.Errorf(codes.InvalidArgument, "invalid timestamp")
-			}
-			ts = msg.GetTimestamp().AsTime()
+		ctx := logging.With(stream.Context(), "driver_id", msg.GetDriverId())
+		loc, err := fromLocationProto(msg)
+		if errors.Is(err, errInvalidTimestamp) {
+			slog.WarnContext(ctx, "UpdateLocation invalid timestamp", "timestamp", msg.GetTimestamp())
+			return status.Error(codes.InvalidArgument, err.Error())
 		}
-
-		loc, err := ingest.NewLocation(msg.GetDriverId(), msg.GetLatitude(), msg.GetLongitude(), ts)
 		if err != nil {
+			slog.WarnContext(ctx, "UpdateLocation invalid location", "error", err)
 			return status.Errorf(codes.InvalidArgument, "invalid location: %v", err)
 		}
 
-		if err := s.ingest.Ingest(stream.Context(), loc); err != nil {
+		err = s.ingest.Ingest(ctx, loc)
+		switch {
+		case err == nil:
+			ack.Accepted++
+		case ingest.IsRejected(err):
+			slog.DebugContext(ctx, "location dropped", "reason", ingest.RejectionReason(err), "error", err)
+			ack.Dropped++
+		default:
+			slog.ErrorContext(ctx, "ingest failed", "error", err)
 			return status.Errorf(codes.Internal, "ingest: %v", err)
 		}
 	}
 }
+
+// StreamLocations acknowledges every update with a LocationResult carrying its seq.
+// Invalid points and ingest failures are rejected individually and the stream stays
+// open, so the client can resend exactly the points that were lost.
+func (s *Server) StreamLocations(stream driverpb.DriverService_StreamLocationsServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctxErr := stream.Context().Err(); ctxErr != nil {
+				return status.FromContextError(ctxErr).Err()
+			}
+			slog.ErrorContext(stream.Context(), "StreamLocations recv failed", "error", err)
+			return status.Errorf(codes.Internal, "recv: %v", err)
+		}
+
+		if err := stream.Send(s.ingestUpdate(stream.Context(), msg)); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) ingestUpdate(ctx context.Context, msg *driverpb.LocationUpdate) *driverpb.LocationResult {
+	result := &driverpb.LocationResult{Seq: msg.GetSeq()}
+
+	ctx = logging.With(ctx, "driver_id", msg.GetLocation().GetDriverId())
+	loc, err := fromLocationProto(msg.GetLocation())
+	if err != nil {
+		slog.WarnContext(ctx, "StreamLocations invalid location", "seq", msg.GetSeq(), "error", err)
+		result.Outcome = outcomeInvalid
+		result.Reason = fmt.Sprintf("invalid location: %v", err)
+		return result
+	}
+	if err := s.ingest.Ingest(ctx, loc); err != nil {
+		result.Outcome = ingest.RejectionReason(err)
+		result.Reason = err.Error()
+		if result.Outcome == "" {
+			slog.ErrorContext(ctx, "ingest failed", "seq", msg.GetSeq(), "error", err)
+			result.Outcome = outcomeFailed
+			result.Retryable = true
+		} else {
+			slog.DebugContext(ctx, "location dropped", "seq", msg.GetSeq(), "reason", result.Outcome, "error", err)
+		}
+		return result
+	}
+	result.Accepted = true
+	result.Outcome = outcomeAccepted
+	return result
+}
+
+// LocationResult outcomes besides the ingest rejection reasons.
+const (
+	outcomeAccepted = "accepted"
+	outcomeInvalid  = ingest.RejectInvalid
+	outcomeFailed   = "failed"
+)
+
+// GetLocationHistory returns a page of a driver's stored locations.
+func (s *Server) GetLocationHistory(ctx context.Context, req *driverpb.GetLocationHistoryRequest) (*driverpb.GetLocationHistoryResponse, error) {
+	if s.history == nil {
+		return nil, status.Error(codes.Unimplemented, "location history not configured")
+	}
+
+	query := ingest.HistoryQuery{
+		DriverID:  req.GetDriverId(),
+		MaxPoints: int(req.GetMaxPoints()),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	}
+	if req.GetFrom() != nil {
+		if !req.GetFrom().IsValid() {
+			return nil, status.Error(codes.InvalidArgument, "invalid from")
+		}
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		if !req.GetTo().IsValid() {
+			return nil, status.Error(codes.InvalidArgument, "invalid to")
+		}
+		query.To = req.GetTo().AsTime()
+	}
+
+	page, err := s.history.GetLocationHistory(ctx, query)
+	if err != nil {
+		return nil, mapHistoryError(err)
+	}
+
+	resp := &driverpb.GetLocationHistoryResponse{
+		Points:        make([]*driverpb.Location, 0, len(page.Points)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, loc := range page.Points {
+		resp.Points = append(resp.Points, &driverpb.Location{
+			DriverId:  loc.DriverID,
+			Latitude:  loc.Lat,
+			Longitude: loc.Long,
+			Timestamp: timestamppb.New(loc.Timestamp),
+		})
+	}
+	return resp, nil
+}
+
+func mapHistoryError(err error) error {
+	switch {
+	case errors.Is(err, ingest.ErrHistoryDriverRequired),
+		errors.Is(err, ingest.ErrInvalidHistoryRange),
+		errors.Is(err, ingest.ErrInvalidMaxPoints),
+		errors.Is(err, ingest.ErrInvalidPageToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Errorf(codes.Internal, "location history: %v", err)
+	}
+}
+
+var errInvalidTimestamp = errors.New("invalid timestamp")
+
+func fromLocationProto(msg *driverpb.Location) (ingest.Location, error) {
+	ts := time.Time{}
+	if msg.GetTimestamp() != nil {
+		if !msg.GetTimestamp().IsValid() {
+			return ingest.Location{}, errInvalidTimestamp
+		}
+		ts = msg.GetTimestamp().AsTime()
+	}
+	loc, err := ingest.NewLocation(msg.GetDriverId(), msg.GetLatitude(), msg.GetLongitude(), ts)
+	if err != nil {
+		return ingest.Location{}, err
+	}
+	loc.VehicleType = msg.GetVehicleType()
+	return loc, nil
+}
@@ -1,14 +1,20 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net"
+	"strings"
 	"testing"
+	"time"
 
 	driverpb "wayfinder/api/proto/driver"
 	"wayfinder/internal/ingest"
+	"wayfinder/internal/logging"
 
 	grpcpkg "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -282,3 +288,247 @@ func TestUpdateLocation_RecvError(t *testing.T) {
 		t.Fatalf("expected Internal, got %v", err)
 	}
 }
+
+// failingDriverIngest fails ingest for one driver and records everything else.
+type failingDriverIngest struct {
+	spyIngestService
+	failDriver string
+}
+
+func (s *failingDriverIngest) Ingest(ctx context.Context, loc ingest.Location) error {
+	if loc.DriverID == s.failDriver {
+		return errors.New("store down")
+	}
+	if loc.DriverID == "driver-late" {
+		return &ingest.RejectedError{Reason: ingest.RejectStale, Err: ingest.ErrStaleLocation}
+	}
+	return s.spyIngestService.Ingest(ctx, loc)
+}
+
+func TestStreamLocations_AcksEachUpdate(t *testing.T) {
+	t.Parallel()
+
+	lis := bufconn.Listen(1024 * 1024)
+	svc := &failingDriverIngest{failDriver: "driver-down"}
+	s := grpcpkg.NewServer()
+	driverpb.RegisterDriverServiceServer(s, NewServer(svc))
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(func() {
+		s.Stop()
+		_ = lis.Close()
+	})
+
+	conn, err := grpcpkg.NewClient(
+		"passthrough:///bufnet",
+		grpcpkg.WithContextDialer(bufDialer(lis)),
+		grpcpkg.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial bufnet: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	stream, err := driverpb.NewDriverServiceClient(conn).StreamLocations(context.Background())
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+
+	updates := []*driverpb.LocationUpdate{
+		{Seq: 1, Location: &driverpb.Location{DriverId: "driver-1", Latitude: 1, Longitude: 2}},
+		{Seq: 2, Location: &driverpb.Location{DriverId: "driver-1", Latitude: 91}},
+		{Seq: 3},
+		{Seq: 4, Location: &driverpb.Location{DriverId: "driver-1", Timestamp: &timestamppb.Timestamp{Seconds: -1, Nanos: -1}}},
+		{Seq: 5, Location: &driverpb.Location{DriverId: "driver-down", Latitude: 1, Longitude: 2}},
+		{Seq: 6, Location: &driverpb.Location{DriverId: "driver-late", Latitude: 1, Longitude: 2}},
+		{Seq: 7, Location: &driverpb.Location{DriverId: "driver-1", Latitude: 3, Longitude: 4, VehicleType: "bike"}},
+	}
+	for _, update := range updates {
+		if err := stream.Send(update); err != nil {
+			t.Fatalf("send seq %d: %v", update.Seq, err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("close send: %v", err)
+	}
+
+	want := []struct {
+		accepted, retryable bool
+		outcome, reason     string
+	}{
+		{accepted: true, outcome: "accepted"},
+		{outcome: "invalid", reason: "latitude"},
+		{outcome: "invalid", reason: "driver id"},
+		{outcome: "invalid", reason: "timestamp"},
+		{outcome: "failed", retryable: true, reason: "store down"},
+		{outcome: "stale", reason: "older"},
+		{accepted: true, outcome: "accepted"},
+	}
+	for i, w := range want {
+		result, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("recv result %d: %v", i, err)
+		}
+		if result.GetSeq() != updates[i].Seq || result.GetAccepted() != w.accepted || result.GetRetryable() != w.retryable || result.GetOutcome() != w.outcome {
+			t.Fatalf("unexpected result for seq %d: %+v", updates[i].Seq, result)
+		}
+		if !strings.Contains(result.GetReason(), w.reason) {
+			t.Fatalf("seq %d: reason %q does not mention %q", updates[i].Seq, result.GetReason(), w.reason)
+		}
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected clean end of stream, got %v", err)
+	}
+	if len(svc.received) != 2 || svc.received[1].Lat != 3 || svc.received[1].VehicleType != "bike" {
+		t.Fatalf("expected only the valid points ingested, got %+v", svc.received)
+	}
+}
+
+func TestStreamLocations_CancelledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stream := &stubStreamLocationsStream{ctx: ctx, recvErr: context.Canceled}
+
+	err := NewServer(&spyIngestService{}).StreamLocations(stream)
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("expected Canceled, got %v", err)
+	}
+}
+
+type stubStreamLocationsStream struct {
+	grpcpkg.ServerStream
+	ctx     context.Context
+	recvErr error
+}
+
+func (s *stubStreamLocationsStream) Recv() (*driverpb.LocationUpdate, error) { return nil, s.recvErr }
+func (s *stubStreamLocationsStream) Send(*driverpb.LocationResult) error     { return nil }
+func (s *stubStreamLocationsStream) Context() context.Context                { return s.ctx }
+
+func TestUpdateLocation_SkipsRejectedPoints(t *testing.T) {
+	t.Parallel()
+
+	svc := &failingDriverIngest{}
+	stream := &stubUpdateLocationStream{
+		msgs: []*driverpb.Location{
+			{DriverId: "driver-1", Latitude: 1, Longitude: 2},
+			{DriverId: "driver-late", Latitude: 1, Longitude: 2},
+			{DriverId: "driver-1", Latitude: 3, Longitude: 4},
+		},
+	}
+
+	if err := NewServer(svc).UpdateLocation(stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stream.ack.GetAccepted() != 2 || stream.ack.GetDropped() != 1 || stream.ack.GetMessage() != "ok" {
+		t.Fatalf("unexpected ack: %+v", stream.ack)
+	}
+}
+
+func TestUpdateLocation_LogsDriverAndRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Config{Format: logging.FormatJSON, Level: slog.LevelDebug})
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	prev := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	stream := &stubUpdateLocationStream{
+		ctx: logging.WithRequestID(context.Background(), "req-1"),
+		msgs: []*driverpb.Location{
+			{DriverId: "driver-late", Latitude: 1, Longitude: 2},
+			{DriverId: "driver-down", Latitude: 1, Longitude: 2},
+		},
+	}
+	if err := NewServer(&failingDriverIngest{failDriver: "driver-down"}).UpdateLocation(stream); status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal, got %v", err)
+	}
+
+	want := []struct{ msg, driver, level string }{
+		{"location dropped", "driver-late", "DEBUG"},
+		{"ingest failed", "driver-down", "ERROR"},
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("unexpected log lines:\n%s", buf.String())
+	}
+	for i, raw := range lines {
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("decode %q: %v", raw, err)
+		}
+		if line["msg"] != want[i].msg || line["driver_id"] != want[i].driver || line["level"] != want[i].level || line["request_id"] != "req-1" {
+			t.Fatalf("unexpected line %d: %s", i, raw)
+		}
+	}
+}
+
+type stubHistoryService struct {
+	query ingest.HistoryQuery
+	page  ingest.HistoryPage
+	err   error
+}
+
+func (s *stubHistoryService) GetLocationHistory(_ context.Context, query ingest.HistoryQuery) (ingest.HistoryPage, error) {
+	s.query = query
+	return s.page, s.err
+}
+
+func TestGetLocationHistory(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	history := &stubHistoryService{page: ingest.HistoryPage{
+		Points:        []ingest.Location{{DriverID: "driver-1", Lat: 1.5, Long: 2.5, Timestamp: from.Add(time.Minute)}},
+		NextPageToken: "next",
+	}}
+	server := NewServerWithHistory(&spyIngestService{}, history)
+
+	resp, err := server.GetLocationHistory(context.Background(), &driverpb.GetLocationHistoryRequest{
+		DriverId:  "driver-1",
+		From:      timestamppb.New(from),
+		To:        timestamppb.New(from.Add(time.Hour)),
+		MaxPoints: 100,
+		PageSize:  20,
+		PageToken: "tok",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := ingest.HistoryQuery{DriverID: "driver-1", From: from, To: from.Add(time.Hour), MaxPoints: 100, PageSize: 20, PageToken: "tok"}
+	if history.query != want {
+		t.Fatalf("unexpected query: %+v", history.query)
+	}
+	if resp.GetNextPageToken() != "next" || len(resp.GetPoints()) != 1 || resp.GetPoints()[0].GetLatitude() != 1.5 ||
+		!resp.GetPoints()[0].GetTimestamp().AsTime().Equal(from.Add(time.Minute)) {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestGetLocationHistory_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewServer(&spyIngestService{}).GetLocationHistory(context.Background(), &driverpb.GetLocationHistoryRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected Unimplemented without history, got %v", err)
+	}
+
+	bad := &driverpb.GetLocationHistoryRequest{DriverId: "d", From: &timestamppb.Timestamp{Seconds: -1, Nanos: -1}}
+	if _, err := NewServerWithHistory(&spyIngestService{}, &stubHistoryService{}).GetLocationHistory(context.Background(), bad); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for bad timestamp, got %v", err)
+	}
+
+	for err, want := range map[error]codes.Code{
+		ingest.ErrHistoryDriverRequired: codes.InvalidArgument,
+		ingest.ErrInvalidPageToken:      codes.InvalidArgument,
+		context.DeadlineExceeded:        codes.DeadlineExceeded,
+		errors.New("db down"):           codes.Internal,
+	} {
+		server := NewServerWithHistory(&spyIngestService{}, &stubHistoryService{err: err})
+		if _, got := server.GetLocationHistory(context.Background(), &driverpb.GetLocationHistoryRequest{DriverId: "d"}); status.Code(got) != want {
+			t.Fatalf("%v: expected %s, got %v", err, want, got)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/ingest"
+)
+
+var (
+	// ErrUnknownOrder is returned by a DriverResolver for orders that do not exist.
+	ErrUnknownOrder = errors.New("unknown order")
+	// ErrOrderNotAssigned is returned by a DriverResolver for orders without a driver.
+	ErrOrderNotAssigned = errors.New("order has no assigned driver")
+	// ErrOrderFilterDisabled is returned for order filters when the hub has no resolver.
+	ErrOrderFilterDisabled = errors.New("order filters are not available")
+	errInvalidFilter       = errors.New("invalid filter")
+)
+
+// DriverResolver returns the driver assigned to an order.
+type DriverResolver func(ctx context.Context, orderID string) (string, error)
+
+// parseFilter builds a subscriber's filter from the upgrade request's query:
+//
+//	driver_id=a,b       only these drivers (repeatable)
+//	order_id=x          the driver assigned to order x (repeatable)
+//	bbox=minLat,minLong,maxLat,maxLong
+//	near=lat,long,radiusMeters
+//
+// Driver and order IDs are combined into one driver set; the set and the area criteria
+// must all match. Order IDs are resolved once, when the client connects.
+func parseFilter(ctx context.Context, query url.Values, resolve DriverResolver) (ingest.LocationFilter, error) {
+	var filter ingest.LocationFilter
+
+	addDriver := func(id string) {
+		if filter.DriverIDs == nil {
+			filter.DriverIDs = make(map[string]bool)
+		}
+		filter.DriverIDs[id] = true
+	}
+	for _, id := range splitValues(query["driver_id"]) {
+		addDriver(id)
+	}
+	for _, orderID := range splitValues(query["order_id"]) {
+		if resolve == nil {
+			return ingest.LocationFilter{}, ErrOrderFilterDisabled
+		}
+		driverID, err := resolve(ctx, orderID)
+		if err != nil {
+			return ingest.LocationFilter{}, fmt.Errorf("order %s: %w", orderID, err)
+		}
+		if driverID == "" {
+			return ingest.LocationFilter{}, fmt.Errorf("order %s: %w", orderID, ErrOrderNotAssigned)
+		}
+		addDriver(driverID)
+	}
+
+	if raw := query.Get("bbox"); raw != "" {
+		v, err := parseFloats("bbox", raw, 4)
+		if err != nil {
+			return ingest.LocationFilter{}, err
+		}
+		filter.Box = &ingest.BoundingBox{MinLat: v[0], MinLong: v[1], MaxLat: v[2], MaxLong: v[3]}
+	}
+	if raw := query.Get("near"); raw != "" {
+		v, err := parseFloats("near", raw, 3)
+		if err != nil {
+			return ingest.LocationFilter{}, err
+		}
+		filter.Near = &ingest.Circle{Center: geo.Point{Lat: v[0], Long: v[1]}, RadiusMeters: v[2]}
+	}
+	if err := filter.Validate(); err != nil {
+		return ingest.LocationFilter{}, fmt.Errorf("%w: %v", errInvalidFilter, err)
+	}
+	return filter, nil
+}
+
+// splitValues flattens repeated and comma-separated query values, dropping blanks.
+func splitValues(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func parseFloats(name, raw string, n int) ([]float64, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("%w: %s needs %d comma-separated numbers", errInvalidFilter, name, n)
+	}
+	out := make([]float64, n)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err == nil && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			err = errors.New("not a finite number")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", errInvalidFilter, name, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
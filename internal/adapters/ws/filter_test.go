@@ -0,0 +1,72 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"wayfinder/internal/ingest"
+)
+
+func stubResolver(assignments map[string]string) DriverResolver {
+	return func(_ context.Context, orderID string) (string, error) {
+		driverID, ok := assignments[orderID]
+		if !ok {
+			return "", ErrUnknownOrder
+		}
+		return driverID, nil
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	resolve := stubResolver(map[string]string{"order-1": "driver-9", "order-2": ""})
+	parse := func(raw string) (ingest.LocationFilter, error) {
+		query, err := url.ParseQuery(raw)
+		if err != nil {
+			t.Fatalf("parse query: %v", err)
+		}
+		return parseFilter(context.Background(), query, resolve)
+	}
+
+	filter, err := parse("driver_id=driver-1,driver-2&driver_id=driver-3&order_id=order-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filter.DriverIDs) != 4 || !filter.DriverIDs["driver-3"] || !filter.DriverIDs["driver-9"] {
+		t.Fatalf("unexpected drivers: %v", filter.DriverIDs)
+	}
+
+	filter, err = parse("bbox=52.3,13.0,52.7,13.8&near=52.52,13.405,1500")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Box == nil || filter.Box.MaxLong != 13.8 || filter.Near == nil || filter.Near.RadiusMeters != 1500 {
+		t.Fatalf("unexpected area filter: %+v %+v", filter.Box, filter.Near)
+	}
+
+	if filter, err := parse(""); err != nil || !filter.IsZero() {
+		t.Fatalf("expected empty filter, got %+v err=%v", filter, err)
+	}
+
+	for raw, want := range map[string]error{
+		"bbox=1,2,3":            errInvalidFilter,
+		"bbox=10,0,5,1":         errInvalidFilter,
+		"near=1,2,NaN":          errInvalidFilter,
+		"near=1,2,0":            errInvalidFilter,
+		"near=a,b,c":            errInvalidFilter,
+		"order_id=order-2":      ErrOrderNotAssigned,
+		"order_id=order-absent": ErrUnknownOrder,
+	} {
+		if _, err := parse(raw); !errors.Is(err, want) {
+			t.Fatalf("%s: expected %v, got %v", raw, want, err)
+		}
+	}
+
+	query, _ := url.ParseQuery("order_id=order-1")
+	if _, err := parseFilter(context.Background(), query, nil); !errors.Is(err, ErrOrderFilterDisabled) {
+		t.Fatalf("expected order filters disabled without resolver, got %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+// Package ws serves live driver locations to WebSocket clients.
+package ws
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"wayfinder/internal/ingest"
+	"wayfinder/internal/logging"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrHubClosed is returned for upgrades attempted after Shutdown.
+var ErrHubClosed = errors.New("websocket hub closed")
+
+// Config tunes per-client buffering and keepalive.
+type Config struct {
+	// SendBuffer is how many messages may queue for a client before it is evicted as a
+	// slow consumer.
+	SendBuffer int
+	// WriteTimeout bounds each frame write, including pings and the close frame.
+	WriteTimeout time.Duration
+	// PingInterval is how often idle connections are pinged.
+	PingInterval time.Duration
+	// PongTimeout is how long a client may stay silent before it is dropped. It must
+	// exceed PingInterval.
+	PongTimeout time.Duration
+	// MaxMessageSize limits frames read from clients.
+	MaxMessageSize int64
+	// AllowedOrigins lists Origin hosts permitted to connect; "*" allows any. Empty
+	// allows only same-origin requests.
+	AllowedOrigins []string
+}
+
+// Hub fans broadcast messages out to connected clients. Clients may subscribe with a
+// filter (see parseFilter) and then only receive locations matching it. Broadcasts never
+// block: a client whose buffer is full is disconnected instead of slowing everyone else.
+type Hub struct {
+	cfg      Config
+	upgrader websocket.Upgrader
+	resolve  DriverResolver
+	logf     func(format string, args ...any)
+
+	mu      sync.RWMutex
+	clients map[*client]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewHub constructs a Hub with defaults for unset config. resolve maps order filters to
+// the assigned driver; when nil, order filters are rejected.
+func NewHub(cfg Config, resolve DriverResolver, logf func(format string, args ...any)) *Hub {
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = 64
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 10 * time.Second
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 30 * time.Second
+	}
+	if cfg.PongTimeout <= cfg.PingInterval {
+		cfg.PongTimeout = cfg.PingInterval * 2
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = 4096
+	}
+	if logf == nil {
+		logf = logging.Printf(slog.Default(), slog.LevelWarn)
+	}
+
+	h := &Hub{cfg: cfg, resolve: resolve, logf: logf, clients: make(map[*client]struct{})}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     originChecker(cfg.AllowedOrigins),
+	}
+	return h
+}
+
+// client is one connection. send is closed by nobody; done signals the write pump to
+// send a close frame and exit.
+type client struct {
+	conn   *websocket.Conn
+	addr   string
+	filter ingest.LocationFilter
+	send   chan []byte
+
+	closeOnce   sync.Once
+	done        chan struct{}
+	closeCode   int
+	closeReason string
+}
+
+func (c *client) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
+
+// ServeHTTP upgrades the request and streams broadcasts to the connection until the
+// client disconnects, falls behind, or the hub shuts down.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		http.Error(w, ErrHubClosed.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, err := parseFilter(r.Context(), r.URL.Query(), h.resolve)
+	if err != nil {
+		http.Error(w, err.Error(), filterStatus(err))
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an HTTP error response.
+		return
+	}
+
+	c := &client{
+		conn:   conn,
+		addr:   conn.RemoteAddr().String(),
+		filter: filter,
+		send:   make(chan []byte, h.cfg.SendBuffer),
+		done:   make(chan struct{}),
+	}
+	if !h.register(c) {
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(h.cfg.WriteTimeout))
+		_ = conn.Close()
+		return
+	}
+
+	go h.readPump(c)
+	go h.writePump(c)
+}
+
+func (h *Hub) register(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	h.wg.Add(1)
+	return true
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+// Broadcast queues msg for every unfiltered client, evicting those whose buffers are
+// full. Filtered clients only receive messages sent through BroadcastLocation.
+func (h *Hub) Broadcast(msg []byte) {
+	h.fanout(msg, func(c *client) bool { return c.filter.IsZero() })
+}
+
+// BroadcastLocation queues msg for every client whose filter matches loc.
+func (h *Hub) BroadcastLocation(loc ingest.Location, msg []byte) {
+	h.fanout(msg, func(c *client) bool { return c.filter.Matches(loc) })
+}
+
+func (h *Hub) fanout(msg []byte, wants func(*client) bool) {
+	var slow []*client
+
+	h.mu.RLock()
+	for c := range h.clients {
+		if !wants(c) {
+			continue
+		}
+		select {
+		case c.send <- msg:
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		h.unregister(c)
+		c.close(websocket.CloseTryAgainLater, "slow consumer")
+		h.logf("websocket: evicted slow client %s", c.addr)
+	}
+}
+
+// Clients returns the number of connected clients.
+func (h *Hub) Clients() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Shutdown stops accepting clients, sends every client a going-away close frame and
+// waits for their connections to finish or ctx to end.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	clients := make([]*client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// Force the stragglers off; their pumps exit once the socket is gone.
+		for _, c := range clients {
+			_ = c.conn.Close()
+		}
+		return ctx.Err()
+	}
+}
+
+// readPump consumes client frames so control frames (pongs, close) are processed, and
+// drops the client when it goes silent for longer than PongTimeout.
+func (h *Hub) readPump(c *client) {
+	defer func() {
+		h.unregister(c)
+		c.close(websocket.CloseNormalClosure, "")
+	}()
+
+	c.conn.SetReadLimit(h.cfg.MaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(h.cfg.PongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(h.cfg.PongTimeout))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump is the only writer on the connection. It owns closing the socket.
+func (h *Hub) writePump(c *client) {
+	ticker := time.NewTicker(h.cfg.PingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+		h.wg.Done()
+	}()
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(h.cfg.WriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				h.unregister(c)
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.cfg.WriteTimeout)); err != nil {
+				h.unregister(c)
+				return
+			}
+		case <-c.done:
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeReason),
+				time.Now().Add(h.cfg.WriteTimeout))
+			return
+		}
+	}
+}
+
+func filterStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownOrder):
+		return http.StatusNotFound
+	case errors.Is(err, ErrOrderNotAssigned):
+		return http.StatusConflict
+	case errors.Is(err, errInvalidFilter), errors.Is(err, ErrOrderFilterDisabled):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func originChecker(allowed []string) func(r *http.Request) bool {
+	if len(allowed) == 0 {
+		return nil // gorilla's same-origin default
+	}
+	hosts := make(map[string]bool, len(allowed))
+	for _, origin := range allowed {
+		origin = strings.TrimSpace(origin)
+		if origin == "*" {
+			return func(*http.Request) bool { return true }
+		}
+		if u, err := url.Parse(origin); err == nil && u.Host != "" {
+			origin = u.Host
+		}
+		hosts[strings.ToLower(origin)] = true
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		return hosts[strings.ToLower(u.Host)]
+	}
+}
@@ -0,0 +1,350 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wayfinder/internal/ingest"
+
+	"github.com/gorilla/websocket"
+)
+
+func newTestHub(t *testing.T, cfg Config) (*Hub, *httptest.Server) {
+	t.Helper()
+	return newResolvingTestHub(t, cfg, nil)
+}
+
+func newResolvingTestHub(t *testing.T, cfg Config, resolve DriverResolver) (*Hub, *httptest.Server) {
+	t.Helper()
+
+	hub := NewHub(cfg, resolve, func(string, ...any) {})
+	srv := httptest.NewServer(hub)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = hub.Shutdown(ctx)
+		srv.Close()
+	})
+	return hub, srv
+}
+
+func dial(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	t.Helper()
+	return dialQuery(t, srv, "")
+}
+
+func dialQuery(t *testing.T, srv *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv, query), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func wsURL(srv *httptest.Server, query string) string {
+	u := "ws" + strings.TrimPrefix(srv.URL, "http")
+	if query != "" {
+		u += "/?" + query
+	}
+	return u
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func readText(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	kind, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if kind != websocket.TextMessage {
+		t.Fatalf("expected text frame, got %d", kind)
+	}
+	return string(msg)
+}
+
+func TestHub_BroadcastReachesEveryClient(t *testing.T) {
+	t.Parallel()
+
+	hub, srv := newTestHub(t, Config{})
+	a, b := dial(t, srv), dial(t, srv)
+	waitFor(t, "clients to register", func() bool { return hub.Clients() == 2 })
+
+	hub.Broadcast([]byte(`{"type":"location","driver_id":"driver-1"}`))
+	hub.Broadcast([]byte(`{"type":"location","driver_id":"driver-2"}`))
+
+	for _, conn := range []*websocket.Conn{a, b} {
+		if got := readText(t, conn); !strings.Contains(got, "driver-1") {
+			t.Fatalf("unexpected first message: %s", got)
+		}
+		if got := readText(t, conn); !strings.Contains(got, "driver-2") {
+			t.Fatalf("unexpected second message: %s", got)
+		}
+	}
+}
+
+func TestHub_ClientDisconnectUnregisters(t *testing.T) {
+	t.Parallel()
+
+	hub, srv := newTestHub(t, Config{})
+	conn := dial(t, srv)
+	waitFor(t, "client to register", func() bool { return hub.Clients() == 1 })
+
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	_ = conn.Close()
+	waitFor(t, "client to unregister", func() bool { return hub.Clients() == 0 })
+}
+
+func TestHub_FiltersLocationsPerClient(t *testing.T) {
+	t.Parallel()
+
+	hub, srv := newResolvingTestHub(t, Config{}, stubResolver(map[string]string{"order-1": "driver-2"}))
+	all := dial(t, srv)
+	byDriver := dialQuery(t, srv, "driver_id=driver-1")
+	byOrder := dialQuery(t, srv, "order_id=order-1")
+	byArea := dialQuery(t, srv, "bbox=52,13,53,14")
+	waitFor(t, "clients to register", func() bool { return hub.Clients() == 4 })
+
+	send := func(loc ingest.Location) {
+		hub.BroadcastLocation(loc, []byte(`{"driver_id":"`+loc.DriverID+`"}`))
+	}
+	send(ingest.Location{DriverID: "driver-2", Lat: 48.1, Long: 11.5})
+	send(ingest.Location{DriverID: "driver-1", Lat: 52.5, Long: 13.4})
+	hub.Broadcast([]byte(`{"type":"notice"}`))
+
+	if got := readText(t, all); !strings.Contains(got, "driver-2") {
+		t.Fatalf("unfiltered client: unexpected first message %s", got)
+	}
+	if got := readText(t, all); !strings.Contains(got, "driver-1") {
+		t.Fatalf("unfiltered client: unexpected second message %s", got)
+	}
+	if got := readText(t, all); !strings.Contains(got, "notice") {
+		t.Fatalf("unfiltered client: expected plain broadcast, got %s", got)
+	}
+	if got := readText(t, byDriver); !strings.Contains(got, "driver-1") {
+		t.Fatalf("driver filter: unexpected message %s", got)
+	}
+	if got := readText(t, byOrder); !strings.Contains(got, "driver-2") {
+		t.Fatalf("order filter: unexpected message %s", got)
+	}
+	if got := readText(t, byArea); !strings.Contains(got, "driver-1") {
+		t.Fatalf("area filter: unexpected message %s", got)
+	}
+
+	// Nothing else may be queued for the filtered clients.
+	send(ingest.Location{DriverID: "driver-3", Lat: 0, Long: 0})
+	for _, conn := range []*websocket.Conn{byDriver, byOrder, byArea} {
+		_ = conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		if _, msg, err := conn.ReadMessage(); err == nil {
+			t.Fatalf("filtered client received unrelated message %s", msg)
+		}
+	}
+}
+
+func TestHub_RejectsBadFilterBeforeUpgrade(t *testing.T) {
+	t.Parallel()
+
+	_, srv := newResolvingTestHub(t, Config{}, stubResolver(map[string]string{"order-1": ""}))
+	for query, want := range map[string]int{
+		"bbox=1,2":         http.StatusBadRequest,
+		"order_id=missing": http.StatusNotFound,
+		"order_id=order-1": http.StatusConflict,
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL(srv, query), nil)
+		if err == nil {
+			t.Fatalf("%s: expected dial to fail", query)
+		}
+		if resp == nil || resp.StatusCode != want {
+			t.Fatalf("%s: expected %d, got %+v", query, want, resp)
+		}
+	}
+}
+
+func TestHub_EvictsSlowConsumer(t *testing.T) {
+	t.Parallel()
+
+	var evictions atomic.Int32
+	hub := NewHub(Config{SendBuffer: 2}, nil, func(format string, args ...any) { evictions.Add(1) })
+	slow := &client{addr: "slow", send: make(chan []byte, 2), done: make(chan struct{})}
+	fast := &client{addr: "fast", send: make(chan []byte, 8), done: make(chan struct{})}
+	hub.clients[slow] = struct{}{}
+	hub.clients[fast] = struct{}{}
+
+	for i := 0; i < 3; i++ {
+		hub.Broadcast([]byte("msg"))
+	}
+
+	select {
+	case <-slow.done:
+	default:
+		t.Fatalf("expected slow client to be closed")
+	}
+	if slow.closeCode != websocket.CloseTryAgainLater {
+		t.Fatalf("unexpected close code %d", slow.closeCode)
+	}
+	if hub.Clients() != 1 || len(fast.send) != 3 {
+		t.Fatalf("expected fast client to keep receiving, clients=%d queued=%d", hub.Clients(), len(fast.send))
+	}
+	if evictions.Load() != 1 {
+		t.Fatalf("expected one eviction log, got %d", evictions.Load())
+	}
+}
+
+func TestHub_SlowConsumerReceivesCloseFrame(t *testing.T) {
+	t.Parallel()
+
+	hub, srv := newTestHub(t, Config{SendBuffer: 1})
+	conn := dial(t, srv)
+	waitFor(t, "client to register", func() bool { return hub.Clients() == 1 })
+
+	// The client never reads, so once the socket buffers fill the write pump stalls
+	// and broadcasts back up into the send buffer.
+	payload := []byte(strings.Repeat("x", 64*1024))
+	waitFor(t, "slow client eviction", func() bool {
+		hub.Broadcast(payload)
+		return hub.Clients() == 0
+	})
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+			t.Fatalf("expected try-again-later close, got %v", err)
+		}
+		break
+	}
+}
+
+func TestHub_PingsIdleClients(t *testing.T) {
+	t.Parallel()
+
+	hub, srv := newTestHub(t, Config{PingInterval: 20 * time.Millisecond, PongTimeout: time.Second})
+	conn := dial(t, srv)
+
+	var pings atomic.Int32
+	conn.SetPingHandler(func(data string) error {
+		pings.Add(1)
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	waitFor(t, "pings", func() bool { return pings.Load() >= 3 })
+	if hub.Clients() != 1 {
+		t.Fatalf("expected responsive client to stay connected")
+	}
+}
+
+func TestHub_DropsClientsThatStopPonging(t *testing.T) {
+	t.Parallel()
+
+	hub, srv := newTestHub(t, Config{PingInterval: 10 * time.Millisecond, PongTimeout: 50 * time.Millisecond})
+	// Never reading means pings are never answered.
+	_ = dial(t, srv)
+
+	waitFor(t, "client registration", func() bool { return hub.Clients() == 1 })
+	waitFor(t, "silent client to be dropped", func() bool { return hub.Clients() == 0 })
+}
+
+func TestHub_ShutdownClosesClientsAndRejectsNewOnes(t *testing.T) {
+	t.Parallel()
+
+	hub, srv := newTestHub(t, Config{})
+	conn := dial(t, srv)
+	waitFor(t, "client to register", func() bool { return hub.Clients() == 1 })
+
+	closed := make(chan error, 1)
+	go func() {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err := conn.ReadMessage()
+		// Echo the close so the server side finishes the handshake promptly.
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		closed <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-closed; !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("expected going-away close, got %v", err)
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err == nil {
+		t.Fatalf("expected dial after shutdown to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 after shutdown, got %+v", resp)
+	}
+}
+
+func TestHub_ShutdownHonoursContext(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(Config{}, nil, func(string, ...any) {})
+	hub.wg.Add(1) // a pump that never finishes
+	defer hub.wg.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := hub.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestOriginChecker(t *testing.T) {
+	t.Parallel()
+
+	req := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	if originChecker(nil) != nil {
+		t.Fatalf("expected default same-origin check without allowed origins")
+	}
+	check := originChecker([]string{"https://app.example.com", "ops.example.com"})
+	if !check(req("https://app.example.com")) || !check(req("http://OPS.example.com")) || !check(req("")) {
+		t.Fatalf("expected allowed origins to pass")
+	}
+	if check(req("https://evil.example.com")) {
+		t.Fatalf("expected unknown origin to be rejected")
+	}
+	if !originChecker([]string{"*"})(req("https://anywhere.test")) {
+		t.Fatalf("expected wildcard to allow any origin")
+	}
+}
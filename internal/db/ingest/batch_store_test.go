@@ -0,0 +1,253 @@
+package ingestdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"wayfinder/internal/ingest"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+var batchTS = time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+
+func batchLoc(driverID string) ingest.Location {
+	return ingest.Location{DriverID: driverID, Lat: 1.5, Long: 2.5, Timestamp: batchTS}
+}
+
+func insertRows(n int) string {
+	query := "INSERT INTO driver_locations (driver_id, lat, long, recorded_at, order_id) " +
+		"SELECT v.driver_id, v.lat, v.long, v.recorded_at, t.order_id FROM (VALUES "
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			query += ", "
+		}
+		query += fmt.Sprintf("($%d::text, $%d::double precision, $%d::double precision, $%d::timestamptz)", i*4+1, i*4+2, i*4+3, i*4+4)
+	}
+	query += ") AS v (driver_id, lat, long, recorded_at)"
+	return "^" + regexp.QuoteMeta(query) + `\s+LEFT JOIN LATERAL \(\s+SELECT order_id\s+FROM order_trips\s+WHERE driver_id = v.driver_id AND ended_at IS NULL`
+}
+
+func closeStore(t *testing.T, store *BatchedLocationStore) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := store.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestPostgresLocationStore_InsertBatch(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(insertRows(2)).
+		WithArgs("driver-1", 1.5, 2.5, batchTS, "driver-2", 1.5, 2.5, batchTS).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectClose()
+
+	store := NewPostgresLocationStore(db)
+	if err := store.InsertBatch(context.Background(), []ingest.Location{batchLoc("driver-1"), batchLoc("driver-2")}); err != nil {
+		t.Fatalf("InsertBatch: %v", err)
+	}
+	if err := store.InsertBatch(context.Background(), nil); err != nil {
+		t.Fatalf("empty batch: %v", err)
+	}
+}
+
+func TestBatchedLocationStore_FlushesBySizeAndOnClose(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(insertRows(2)).WithArgs("driver-1", 1.5, 2.5, batchTS, "driver-2", 1.5, 2.5, batchTS).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(insertRows(1)).WithArgs("driver-3", 1.5, 2.5, batchTS).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	store := NewBatchedLocationStore(NewPostgresLocationStore(db), BatchConfig{MaxBatch: 2, FlushInterval: time.Hour})
+	for _, id := range []string{"driver-1", "driver-2", "driver-3"} {
+		if err := store.Update(context.Background(), batchLoc(id)); err != nil {
+			t.Fatalf("update %s: %v", id, err)
+		}
+	}
+	closeStore(t, store)
+
+	if err := store.Update(context.Background(), batchLoc("driver-4")); !errors.Is(err, ErrStoreClosed) {
+		t.Fatalf("expected ErrStoreClosed after close, got %v", err)
+	}
+}
+
+func TestBatchedLocationStore_FlushesOnInterval(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(insertRows(1)).WithArgs("driver-1", 1.5, 2.5, batchTS).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	store := NewBatchedLocationStore(NewPostgresLocationStore(db), BatchConfig{MaxBatch: 100, FlushInterval: 10 * time.Millisecond})
+	t.Cleanup(func() { closeStore(t, store) })
+	if err := store.Update(context.Background(), batchLoc("driver-1")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mock.ExpectationsWereMet() != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for interval flush")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mock.ExpectClose()
+}
+
+func TestBatchedLocationStore_AppliesBackpressure(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(insertRows(1)).WithArgs("driver-1", 1.5, 2.5, batchTS).
+		WillDelayFor(200 * time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertRows(1)).WithArgs("driver-2", 1.5, 2.5, batchTS).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	store := NewBatchedLocationStore(NewPostgresLocationStore(db), BatchConfig{MaxBatch: 1, Buffer: 1, FlushInterval: time.Hour})
+	if err := store.Update(context.Background(), batchLoc("driver-1")); err != nil {
+		t.Fatalf("update 1: %v", err)
+	}
+	// Wait for the writer to pick up the first location and stall on the slow insert.
+	deadline := time.Now().Add(time.Second)
+	for len(store.queue) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("writer never dequeued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := store.Update(context.Background(), batchLoc("driver-2")); err != nil {
+		t.Fatalf("update 2: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := store.Update(ctx, batchLoc("driver-3")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected full buffer to block until deadline, got %v", err)
+	}
+	closeStore(t, store)
+}
+
+func TestBatchedLocationStore_ReportsBatchErrors(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(insertRows(2)).WillReturnError(errors.New("disk full"))
+	mock.ExpectClose()
+
+	var (
+		mu     sync.Mutex
+		failed [][]ingest.Location
+		errs   []error
+	)
+	store := NewBatchedLocationStore(NewPostgresLocationStore(db), BatchConfig{
+		MaxBatch:      10,
+		FlushInterval: time.Hour,
+		OnBatchError: func(batch []ingest.Location, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, batch)
+			errs = append(errs, err)
+		},
+	})
+	_ = store.Update(context.Background(), batchLoc("driver-1"))
+	_ = store.Update(context.Background(), batchLoc("driver-2"))
+	closeStore(t, store)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 1 || len(failed[0]) != 2 || failed[0][1].DriverID != "driver-2" || errs[0].Error() != "disk full" {
+		t.Fatalf("unexpected batch errors: %v %v", failed, errs)
+	}
+}
+
+func TestBatchedLocationStore_CloseHonoursContext(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(insertRows(1)).WillDelayFor(200 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	store := NewBatchedLocationStore(NewPostgresLocationStore(db), BatchConfig{FlushInterval: time.Hour})
+	_ = store.Update(context.Background(), batchLoc("driver-1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := store.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	closeStore(t, store) // a second Close waits for the final flush
+}
+
+func TestBatchedLocationStore_SpoolReplayStopsWhilePostgresIsDown(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	down := errors.New("connection refused")
+	mock.ExpectExec(insertRows(1)).WithArgs("driver-1", 1.5, 2.5, batchTS).WillReturnError(down) // batched write
+	mock.ExpectExec(insertRows(1)).WithArgs("driver-1", 1.5, 2.5, batchTS).WillReturnError(down) // first replay
+	mock.ExpectExec(insertRows(1)).WithArgs("driver-1", 1.5, 2.5, batchTS).
+		WillReturnResult(sqlmock.NewResult(0, 1)) // replay once Postgres is back
+	mock.ExpectClose()
+
+	// Wired as cmd/server does: writes go through the batched store, failed batches
+	// are spooled, and replay goes through the unbatched store.
+	history := NewPostgresLocationStore(db)
+	spooled := make(chan error, 1)
+	var spool *ingest.SpoolStore
+	batched := NewBatchedLocationStore(history, BatchConfig{
+		MaxBatch:      1,
+		FlushInterval: time.Hour,
+		OnBatchError:  func(batch []ingest.Location, err error) { spooled <- spool.Append(batch...) },
+	})
+	dir := t.TempDir()
+	spool, err := ingest.OpenSpoolStore(batched, ingest.SpoolConfig{Dir: dir, ReplayTo: history}, func(string, ...any) {})
+	if err != nil {
+		t.Fatalf("open spool: %v", err)
+	}
+	t.Cleanup(func() { _ = spool.Close() })
+
+	if err := spool.Update(context.Background(), batchLoc("driver-1")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	select {
+	case err := <-spooled:
+		if err != nil {
+			t.Fatalf("append failed batch: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("failed batch was never spooled")
+	}
+
+	n, err := spool.Replay(context.Background())
+	if !errors.Is(err, down) || n != 0 {
+		t.Fatalf("expected replay to stop on the insert error, got %d, %v", n, err)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if records, _ := spool.Depth(); records != 1 || len(segments) != 1 {
+		t.Fatalf("expected the segment kept with 1 record, got %d records in %v", records, segments)
+	}
+
+	if n, err := spool.Replay(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected replay once Postgres is back, got %d, %v", n, err)
+	}
+	if records, _ := spool.Depth(); records != 0 {
+		t.Fatalf("expected an empty spool, got %d records", records)
+	}
+	closeStore(t, batched)
+}
@@ -0,0 +1,168 @@
+package ingestdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/ingest"
+)
+
+// PostgresGeofenceStore persists geofences in Postgres. Circles use the center and
+// radius columns; polygons store their vertices as a JSON array of [lat, long] pairs.
+type PostgresGeofenceStore struct {
+	db *sql.DB
+}
+
+// NewPostgresGeofenceStore constructs a geofence store backed by Postgres.
+func NewPostgresGeofenceStore(db *sql.DB) *PostgresGeofenceStore {
+	return &PostgresGeofenceStore{db: db}
+}
+
+// NewPostgresGeofenceStoreWithSchema initializes the schema then returns the store.
+func NewPostgresGeofenceStoreWithSchema(ctx context.Context, db *sql.DB) (*PostgresGeofenceStore, error) {
+	store := NewPostgresGeofenceStore(db)
+	if err := store.InitSchema(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// InitSchema creates the geofences table if it does not exist.
+func (s *PostgresGeofenceStore) InitSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS geofences (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			center_lat DOUBLE PRECISION,
+			center_long DOUBLE PRECISION,
+			radius_meters DOUBLE PRECISION,
+			polygon JSONB,
+			dwell_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	return err
+}
+
+const geofenceSelect = `
+		SELECT id, name, center_lat, center_long, radius_meters, polygon, dwell_seconds, created_at
+		FROM geofences`
+
+// CreateGeofence inserts a new fence.
+func (s *PostgresGeofenceStore) CreateGeofence(ctx context.Context, fence ingest.Geofence) error {
+	var centerLat, centerLong, radius sql.NullFloat64
+	if fence.Circle != nil {
+		centerLat = sql.NullFloat64{Float64: fence.Circle.Center.Lat, Valid: true}
+		centerLong = sql.NullFloat64{Float64: fence.Circle.Center.Long, Valid: true}
+		radius = sql.NullFloat64{Float64: fence.Circle.RadiusMeters, Valid: true}
+	}
+	var polygon sql.NullString
+	if fence.Polygon != nil {
+		vertices := make([][2]float64, len(fence.Polygon))
+		for i, v := range fence.Polygon {
+			vertices[i] = [2]float64{v.Lat, v.Long}
+		}
+		data, err := json.Marshal(vertices)
+		if err != nil {
+			return err
+		}
+		polygon = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO geofences (id, name, center_lat, center_long, radius_meters, polygon, dwell_seconds, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		fence.ID, fence.Name, centerLat, centerLong, radius, polygon, fence.DwellAfter.Seconds(), fence.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// GetGeofence returns a single fence.
+func (s *PostgresGeofenceStore) GetGeofence(ctx context.Context, id string) (ingest.Geofence, error) {
+	fence, err := scanGeofence(s.db.QueryRowContext(ctx, geofenceSelect+`
+		WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ingest.Geofence{}, ingest.ErrGeofenceNotFound
+	}
+	return fence, err
+}
+
+// ListGeofences returns every fence, oldest first.
+func (s *PostgresGeofenceStore) ListGeofences(ctx context.Context) ([]ingest.Geofence, error) {
+	rows, err := s.db.QueryContext(ctx, geofenceSelect+`
+		ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fences []ingest.Geofence
+	for rows.Next() {
+		fence, err := scanGeofence(rows)
+		if err != nil {
+			return nil, err
+		}
+		fences = append(fences, fence)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return fences, nil
+}
+
+// DeleteGeofence removes a fence.
+func (s *PostgresGeofenceStore) DeleteGeofence(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM geofences WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ingest.ErrGeofenceNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGeofence(row rowScanner) (ingest.Geofence, error) {
+	var (
+		fence                         ingest.Geofence
+		centerLat, centerLong, radius sql.NullFloat64
+		polygon                       sql.NullString
+		dwellSeconds                  float64
+	)
+	if err := row.Scan(&fence.ID, &fence.Name, &centerLat, &centerLong, &radius, &polygon, &dwellSeconds, &fence.CreatedAt); err != nil {
+		return ingest.Geofence{}, err
+	}
+
+	if radius.Valid {
+		fence.Circle = &ingest.Circle{
+			Center:       geo.Point{Lat: centerLat.Float64, Long: centerLong.Float64},
+			RadiusMeters: radius.Float64,
+		}
+	}
+	if polygon.Valid {
+		var vertices [][2]float64
+		if err := json.Unmarshal([]byte(polygon.String), &vertices); err != nil {
+			return ingest.Geofence{}, err
+		}
+		fence.Polygon = make(ingest.Polygon, len(vertices))
+		for i, v := range vertices {
+			fence.Polygon[i] = geo.Point{Lat: v[0], Long: v[1]}
+		}
+	}
+	fence.DwellAfter = time.Duration(dwellSeconds * float64(time.Second))
+	return fence, nil
+}
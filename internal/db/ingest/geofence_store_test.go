@@ -0,0 +1,125 @@
+package ingestdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/ingest"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+var geofenceColumns = []string{"id", "name", "center_lat", "center_long", "radius_meters", "polygon", "dwell_seconds", "created_at"}
+
+func TestPostgresGeofenceStore_InitSchema(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS geofences").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectClose()
+
+	if _, err := NewPostgresGeofenceStoreWithSchema(context.Background(), db); err != nil {
+		t.Fatalf("InitSchema: %v", err)
+	}
+}
+
+func TestPostgresGeofenceStore_CreateCircleAndPolygon(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO geofences \\(id, name, center_lat, center_long, radius_meters, polygon, dwell_seconds, created_at\\)").
+		WithArgs("pickup", "Pickup", 52.52, 13.405, 150.0, nil, 120.0, created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO geofences").
+		WithArgs("ber", "Airport", nil, nil, nil, `[[52.36,13.5],[52.36,13.516],[52.37,13.516]]`, 0.0, created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	store := NewPostgresGeofenceStore(db)
+	ctx := context.Background()
+	circle := ingest.Geofence{
+		ID: "pickup", Name: "Pickup", CreatedAt: created, DwellAfter: 2 * time.Minute,
+		Circle: &ingest.Circle{Center: geo.Point{Lat: 52.52, Long: 13.405}, RadiusMeters: 150},
+	}
+	if err := store.CreateGeofence(ctx, circle); err != nil {
+		t.Fatalf("create circle: %v", err)
+	}
+	polygon := ingest.Geofence{
+		ID: "ber", Name: "Airport", CreatedAt: created,
+		Polygon: ingest.Polygon{{Lat: 52.36, Long: 13.5}, {Lat: 52.36, Long: 13.516}, {Lat: 52.37, Long: 13.516}},
+	}
+	if err := store.CreateGeofence(ctx, polygon); err != nil {
+		t.Fatalf("create polygon: %v", err)
+	}
+}
+
+func TestPostgresGeofenceStore_ListDecodesShapes(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("SELECT id, name, center_lat, center_long, radius_meters, polygon, dwell_seconds, created_at\\s+FROM geofences\\s+ORDER BY created_at, id").
+		WillReturnRows(sqlmock.NewRows(geofenceColumns).
+			AddRow("pickup", "Pickup", 52.52, 13.405, 150.0, nil, 90.0, created).
+			AddRow("ber", "Airport", nil, nil, nil, []byte(`[[52.36,13.5],[52.36,13.516],[52.37,13.516]]`), 0.0, created))
+	mock.ExpectClose()
+
+	fences, err := NewPostgresGeofenceStore(db).ListGeofences(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(fences) != 2 {
+		t.Fatalf("expected 2 fences, got %d", len(fences))
+	}
+	circle := fences[0]
+	if circle.Circle == nil || circle.Circle.RadiusMeters != 150 || circle.Circle.Center.Lat != 52.52 || circle.Polygon != nil || circle.DwellAfter != 90*time.Second {
+		t.Fatalf("unexpected circle fence: %+v", circle)
+	}
+	polygon := fences[1]
+	if polygon.Circle != nil || len(polygon.Polygon) != 3 || polygon.Polygon[2] != (geo.Point{Lat: 52.37, Long: 13.516}) {
+		t.Fatalf("unexpected polygon fence: %+v", polygon)
+	}
+	if err := polygon.Validate(); err != nil {
+		t.Fatalf("decoded fence should validate: %v", err)
+	}
+}
+
+func TestPostgresGeofenceStore_GetNotFound(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery("FROM geofences\\s+WHERE id = \\$1").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(geofenceColumns))
+	mock.ExpectClose()
+
+	if _, err := NewPostgresGeofenceStore(db).GetGeofence(context.Background(), "missing"); !errors.Is(err, ingest.ErrGeofenceNotFound) {
+		t.Fatalf("expected ErrGeofenceNotFound, got %v", err)
+	}
+}
+
+func TestPostgresGeofenceStore_Delete(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec("DELETE FROM geofences WHERE id = \\$1").
+		WithArgs("ber").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM geofences").
+		WithArgs("missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectClose()
+
+	store := NewPostgresGeofenceStore(db)
+	if err := store.DeleteGeofence(context.Background(), "ber"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.DeleteGeofence(context.Background(), "missing"); !errors.Is(err, ingest.ErrGeofenceNotFound) {
+		t.Fatalf("expected ErrGeofenceNotFound, got %v", err)
+	}
+}
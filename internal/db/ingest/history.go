@@ -0,0 +1,83 @@
+package ingestdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"wayfinder/internal/ingest"
+)
+
+// LocationHistory returns a driver's points oldest first, applying the range, optional
+// downsampling and keyset cursor in filter.
+//
+// Downsampling numbers every point in the range and keeps row i when i*N mod total < N,
+// which is exactly the first point of each of N equal-sized buckets. Because the
+// numbering spans the whole range, later pages see the same sample.
+func (s *PostgresLocationStore) LocationHistory(ctx context.Context, filter ingest.HistoryFilter) ([]ingest.HistoryPoint, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conds = append(conds, "driver_id = "+arg(filter.DriverID))
+	if !filter.From.IsZero() {
+		conds = append(conds, "recorded_at >= "+arg(filter.From.UTC()))
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "recorded_at < "+arg(filter.To.UTC()))
+	}
+
+	var (
+		query string
+		outer []string
+	)
+	if filter.MaxPoints > 0 {
+		n := arg(filter.MaxPoints)
+		query = `
+		WITH ranged AS (
+			SELECT id, lat, long, recorded_at,
+				row_number() OVER (ORDER BY recorded_at, id) - 1 AS idx,
+				count(*) OVER () AS total
+			FROM driver_locations
+			WHERE ` + strings.Join(conds, " AND ") + `
+		)
+		SELECT id, lat, long, recorded_at
+		FROM ranged`
+		outer = append(outer, fmt.Sprintf("(idx * %s) %% total < %s", n, n))
+	} else {
+		query = `
+		SELECT id, lat, long, recorded_at
+		FROM driver_locations`
+		outer = conds
+	}
+	if filter.After != nil {
+		outer = append(outer, fmt.Sprintf("(recorded_at, id) > (%s, %s)",
+			arg(filter.After.RecordedAt.UTC()), arg(filter.After.ID)))
+	}
+	query += "\n\t\tWHERE " + strings.Join(outer, " AND ")
+	query += "\n\t\tORDER BY recorded_at, id"
+	if filter.Limit > 0 {
+		query += "\n\t\tLIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []ingest.HistoryPoint
+	for rows.Next() {
+		point := ingest.HistoryPoint{Location: ingest.Location{DriverID: filter.DriverID}}
+		if err := rows.Scan(&point.ID, &point.Lat, &point.Long, &point.Timestamp); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,73 @@
+package ingestdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/ingest"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func historyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "lat", "long", "recorded_at"})
+}
+
+func TestPostgresLocationStore_LocationHistory(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	from := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	mock.ExpectQuery(`SELECT id, lat, long, recorded_at\s+FROM driver_locations\s+WHERE driver_id = \$1 AND recorded_at >= \$2 AND recorded_at < \$3\s+ORDER BY recorded_at, id\s+LIMIT \$4`).
+		WithArgs("driver-1", from, to, 3).
+		WillReturnRows(historyRows().
+			AddRow(int64(7), 1.5, 2.5, from.Add(time.Minute)).
+			AddRow(int64(9), 1.6, 2.6, from.Add(2*time.Minute)))
+	mock.ExpectClose()
+
+	points, err := NewPostgresLocationStore(db).LocationHistory(context.Background(), ingest.HistoryFilter{
+		DriverID: "driver-1", From: from, To: to, Limit: 3,
+	})
+	if err != nil {
+		t.Fatalf("LocationHistory: %v", err)
+	}
+	if len(points) != 2 || points[1].ID != 9 || points[1].DriverID != "driver-1" || points[1].Lat != 1.6 || !points[1].Timestamp.Equal(from.Add(2*time.Minute)) {
+		t.Fatalf("unexpected points: %+v", points)
+	}
+}
+
+func TestPostgresLocationStore_LocationHistoryDownsamplesAndPages(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	after := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`WITH ranged AS \(\s+SELECT id, lat, long, recorded_at,\s+row_number\(\) OVER \(ORDER BY recorded_at, id\) - 1 AS idx,\s+count\(\*\) OVER \(\) AS total\s+FROM driver_locations\s+WHERE driver_id = \$1\s+\)\s+SELECT id, lat, long, recorded_at\s+FROM ranged\s+WHERE \(idx \* \$2\) % total < \$2 AND \(recorded_at, id\) > \(\$3, \$4\)\s+ORDER BY recorded_at, id\s+LIMIT \$5`).
+		WithArgs("driver-1", 50, after, int64(42), 11).
+		WillReturnRows(historyRows())
+	mock.ExpectClose()
+
+	points, err := NewPostgresLocationStore(db).LocationHistory(context.Background(), ingest.HistoryFilter{
+		DriverID:  "driver-1",
+		MaxPoints: 50,
+		After:     &ingest.HistoryCursor{RecordedAt: after, ID: 42},
+		Limit:     11,
+	})
+	if err != nil || len(points) != 0 {
+		t.Fatalf("unexpected result: %+v err=%v", points, err)
+	}
+}
+
+func TestPostgresLocationStore_LocationHistoryError(t *testing.T) {
+	db, mock, cleanup := newLocationMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery("FROM driver_locations").WillReturnError(errors.New("query fail"))
+	mock.ExpectClose()
+
+	if _, err := NewPostgresLocationStore(db).LocationHistory(context.Background(), ingest.HistoryFilter{DriverID: "driver-1"}); err == nil {
+		t.Fatalf("expected query error")
+	}
+}
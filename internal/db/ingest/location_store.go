@@ -26,25 +26,33 @@ func NewPostgresLocationStoreWithSchema(ctx context.Context, db *sql.DB) (*Postg
 	return store, nil
 }
 
-// InitSchema creates the driver_locations table if it does not exist.
+// InitSchema creates the driver_locations table and its history and trip indexes if they
+// do not exist.
 func (s *PostgresLocationStore) InitSchema(ctx context.Context) error {
-	_, err := s.db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS driver_locations (
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS driver_locations (
 			id BIGSERIAL PRIMARY KEY,
 			driver_id TEXT NOT NULL,
 			lat DOUBLE PRECISION NOT NULL,
 			long DOUBLE PRECISION NOT NULL,
 			recorded_at TIMESTAMPTZ NOT NULL
-		)
-	`)
-	return err
+		)`,
+		`CREATE INDEX IF NOT EXISTS driver_locations_driver_recorded_idx
+			ON driver_locations (driver_id, recorded_at, id)`,
+		`ALTER TABLE driver_locations
+			ADD COLUMN IF NOT EXISTS order_id TEXT`,
+		`CREATE INDEX IF NOT EXISTS driver_locations_order_recorded_idx
+			ON driver_locations (order_id, recorded_at, id) WHERE order_id IS NOT NULL`,
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Update inserts a new location history row.
+// Update inserts a new location history row, tagged like InsertBatch rows.
 func (s *PostgresLocationStore) Update(ctx context.Context, loc ingest.Location) error {
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO driver_locations (driver_id, lat, long, recorded_at)
-		VALUES ($1, $2, $3, $4)
-	`, loc.DriverID, loc.Lat, loc.Long, loc.Timestamp.UTC())
-	return err
+	return s.InsertBatch(ctx, []ingest.Location{loc})
 }
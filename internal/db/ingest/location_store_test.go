@@ -38,6 +38,12 @@ func TestPostgresLocationStore_InitSchema(t *testing.T) {
 
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS driver_locations").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE INDEX IF NOT EXISTS driver_locations_driver_recorded_idx\s+ON driver_locations \(driver_id, recorded_at, id\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE driver_locations\s+ADD COLUMN IF NOT EXISTS order_id TEXT`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE INDEX IF NOT EXISTS driver_locations_order_recorded_idx`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectClose()
 
 	store := NewPostgresLocationStore(db)
@@ -51,7 +57,7 @@ func TestPostgresLocationStore_Update_InsertsRow(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	ts := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
-	mock.ExpectExec("INSERT INTO driver_locations").
+	mock.ExpectExec(`INSERT INTO driver_locations \(driver_id, lat, long, recorded_at, order_id\)[\s\S]+FROM order_trips`).
 		WithArgs("driver-1", 1.23, 4.56, ts).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectClose()
@@ -87,6 +93,12 @@ func TestPostgresLocationStoreWithSchema_Success(t *testing.T) {
 
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS driver_locations").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE INDEX IF NOT EXISTS driver_locations_driver_recorded_idx\s+ON driver_locations \(driver_id, recorded_at, id\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE driver_locations\s+ADD COLUMN IF NOT EXISTS order_id TEXT`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE INDEX IF NOT EXISTS driver_locations_order_recorded_idx`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectClose()
 
 	store, err := NewPostgresLocationStoreWithSchema(context.Background(), db)
@@ -3,7 +3,11 @@ package ordersdb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"wayfinder/internal/orders/saga"
 )
 
 // PostgresDriverClient persists driver assignments in Postgres.
@@ -25,26 +29,117 @@ func NewPostgresDriverClientWithSchema(ctx context.Context, db *sql.DB) (*Postgr
 	return client, nil
 }
 
-// InitSchema creates the order_assignments table if it does not exist.
+// InitSchema creates the order_assignments table if it does not exist. Trips are part of
+// the saga schema, see SagaStore.InitSchema.
 func (c *PostgresDriverClient) InitSchema(ctx context.Context) error {
-	_, err := c.db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS order_assignments (
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS order_assignments (
 			order_id TEXT PRIMARY KEY,
 			driver_id TEXT NOT NULL,
 			assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			FOREIGN KEY (order_id) REFERENCES order_sagas(order_id) ON DELETE CASCADE
-		)
-	`)
-	return err
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := c.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Assign stores a driver assignment for an order.
+// Assign stores a driver assignment for an order and starts its trip in the same
+// transaction. Repeating an assignment to the same driver is a no-op.
 func (c *PostgresDriverClient) Assign(ctx context.Context, orderID string, driverID string) error {
 	if orderID == "" || driverID == "" {
 		return fmt.Errorf("order and driver ids are required")
 	}
 
-	res, err := c.db.ExecContext(ctx, `INSERT INTO order_assignments (order_id, driver_id) VALUES ($1, $2) ON CONFLICT (order_id) DO NOTHING`, orderID, driverID)
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := assignInTx(ctx, tx, orderID, driverID); err != nil {
+		return err
+	}
+	if err := startTripInTx(ctx, tx, orderID, driverID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClaimDriver assigns a driver to an order and starts its trip, unless the driver is
+// busy as BusyDriverIDs defines it, in which case it reports false. Claims for the same
+// driver are serialized by a transaction-scoped advisory lock, so two orders cannot both
+// see the driver as free. Claiming the driver an order already has reports true.
+func (c *PostgresDriverClient) ClaimDriver(ctx context.Context, orderID, driverID string, within time.Duration) (bool, error) {
+	if orderID == "" || driverID == "" {
+		return false, fmt.Errorf("order and driver ids are required")
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, driverID); err != nil {
+		return false, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO order_assignments (order_id, driver_id)
+		SELECT $1, $2
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM order_assignments a
+			JOIN order_sagas s ON s.order_id = a.order_id
+			WHERE a.driver_id = $2
+				AND a.order_id <> $1
+				AND s.status IN ($3, $4)
+				AND a.assigned_at > NOW() - make_interval(secs => $5)
+		)
+		ON CONFLICT (order_id) DO NOTHING`,
+		orderID, driverID, saga.SagaStatusStarted, saga.SagaStatusSucceeded, within.Seconds(),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		var existing string
+		err := tx.QueryRowContext(ctx, `SELECT driver_id FROM order_assignments WHERE order_id = $1`, orderID).Scan(&existing)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, nil
+		case err != nil:
+			return false, err
+		case existing != driverID:
+			return false, fmt.Errorf("order already assigned to different driver")
+		}
+	}
+	if err := startTripInTx(ctx, tx, orderID, driverID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func startTripInTx(ctx context.Context, tx *sql.Tx, orderID, driverID string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO order_trips (order_id, driver_id)
+		VALUES ($1, $2)
+		ON CONFLICT (order_id) DO NOTHING`,
+		orderID, driverID,
+	)
+	return err
+}
+
+func assignInTx(ctx context.Context, tx *sql.Tx, orderID, driverID string) error {
+	res, err := tx.ExecContext(ctx, `INSERT INTO order_assignments (order_id, driver_id) VALUES ($1, $2) ON CONFLICT (order_id) DO NOTHING`, orderID, driverID)
 	if err != nil {
 		return err
 	}
@@ -58,7 +153,7 @@ func (c *PostgresDriverClient) Assign(ctx context.Context, orderID string, drive
 	}
 
 	var existing string
-	row := c.db.QueryRowContext(ctx, `SELECT driver_id FROM order_assignments WHERE order_id = $1`, orderID)
+	row := tx.QueryRowContext(ctx, `SELECT driver_id FROM order_assignments WHERE order_id = $1`, orderID)
 	switch scanErr := row.Scan(&existing); scanErr {
 	case nil:
 		if existing == driverID {
@@ -71,3 +166,58 @@ func (c *PostgresDriverClient) Assign(ctx context.Context, orderID string, drive
 		return scanErr
 	}
 }
+
+// Release removes the driver assignment for an order and ends its trip as cancelled.
+// Releasing an unassigned order is a no-op.
+func (c *PostgresDriverClient) Release(ctx context.Context, orderID string) error {
+	if orderID == "" {
+		return fmt.Errorf("order id is required")
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_assignments WHERE order_id = $1`, orderID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE order_trips
+		SET ended_at = NOW(), end_reason = $2
+		WHERE order_id = $1 AND ended_at IS NULL`,
+		orderID, saga.TripEndCancelled,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BusyDriverIDs returns drivers assigned within the given window to orders that are
+// still in progress. Completed orders free their driver immediately; the window bounds
+// orders that are never completed.
+func (c *PostgresDriverClient) BusyDriverIDs(ctx context.Context, within time.Duration) (map[string]bool, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT DISTINCT a.driver_id
+		FROM order_assignments a
+		JOIN order_sagas s ON s.order_id = a.order_id
+		WHERE s.status IN ($1, $2)
+			AND a.assigned_at > NOW() - make_interval(secs => $3)`,
+		saga.SagaStatusStarted, saga.SagaStatusSucceeded, within.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	busy := make(map[string]bool)
+	for rows.Next() {
+		var driverID string
+		if err := rows.Scan(&driverID); err != nil {
+			return nil, err
+		}
+		busy[driverID] = true
+	}
+	return busy, rows.Err()
+}
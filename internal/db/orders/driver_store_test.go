@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
+
+	"wayfinder/internal/orders/saga"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 )
@@ -47,9 +50,14 @@ func TestPostgresDriverClient_Assign_Inserts(t *testing.T) {
 	db, mock, cleanup := newDriverMockDB(t)
 	t.Cleanup(cleanup)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO order_assignments").
 		WithArgs("order-1", "driver-1").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO order_trips \(order_id, driver_id\)`).
+		WithArgs("order-1", "driver-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 	mock.ExpectClose()
 
 	client := NewPostgresDriverClient(db)
@@ -62,6 +70,7 @@ func TestPostgresDriverClient_Assign_Idempotent(t *testing.T) {
 	db, mock, cleanup := newDriverMockDB(t)
 	t.Cleanup(cleanup)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO order_assignments").
 		WithArgs("order-1", "driver-1").
 		WillReturnResult(sqlmock.NewResult(0, 0))
@@ -69,6 +78,10 @@ func TestPostgresDriverClient_Assign_Idempotent(t *testing.T) {
 	mock.ExpectQuery("SELECT driver_id FROM order_assignments").
 		WithArgs("order-1").
 		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}).AddRow("driver-1"))
+	mock.ExpectExec("INSERT INTO order_trips").
+		WithArgs("order-1", "driver-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
 	mock.ExpectClose()
 
 	client := NewPostgresDriverClient(db)
@@ -77,6 +90,90 @@ func TestPostgresDriverClient_Assign_Idempotent(t *testing.T) {
 	}
 }
 
+func expectClaimInsert(mock sqlmock.Sqlmock, affected int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\)`).
+		WithArgs("driver-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO order_assignments \(order_id, driver_id\)\s+SELECT \$1, \$2\s+WHERE NOT EXISTS`).
+		WithArgs("order-1", "driver-1", saga.SagaStatusStarted, saga.SagaStatusSucceeded, float64(3600)).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+func TestPostgresDriverClient_ClaimDriver_ClaimsFreeDriver(t *testing.T) {
+	db, mock, cleanup := newDriverMockDB(t)
+	t.Cleanup(cleanup)
+
+	expectClaimInsert(mock, 1)
+	mock.ExpectExec(`INSERT INTO order_trips \(order_id, driver_id\)`).
+		WithArgs("order-1", "driver-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	client := NewPostgresDriverClient(db)
+	claimed, err := client.ClaimDriver(context.Background(), "order-1", "driver-1", time.Hour)
+	if err != nil || !claimed {
+		t.Fatalf("expected claim, got %v, %v", claimed, err)
+	}
+}
+
+func TestPostgresDriverClient_ClaimDriver_RefusesBusyDriver(t *testing.T) {
+	db, mock, cleanup := newDriverMockDB(t)
+	t.Cleanup(cleanup)
+
+	expectClaimInsert(mock, 0)
+	mock.ExpectQuery("SELECT driver_id FROM order_assignments").
+		WithArgs("order-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	mock.ExpectClose()
+
+	client := NewPostgresDriverClient(db)
+	claimed, err := client.ClaimDriver(context.Background(), "order-1", "driver-1", time.Hour)
+	if err != nil || claimed {
+		t.Fatalf("expected the busy driver refused, got %v, %v", claimed, err)
+	}
+}
+
+func TestPostgresDriverClient_ClaimDriver_Idempotent(t *testing.T) {
+	db, mock, cleanup := newDriverMockDB(t)
+	t.Cleanup(cleanup)
+
+	expectClaimInsert(mock, 0)
+	mock.ExpectQuery("SELECT driver_id FROM order_assignments").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}).AddRow("driver-1"))
+	mock.ExpectExec("INSERT INTO order_trips").
+		WithArgs("order-1", "driver-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	client := NewPostgresDriverClient(db)
+	claimed, err := client.ClaimDriver(context.Background(), "order-1", "driver-1", time.Hour)
+	if err != nil || !claimed {
+		t.Fatalf("expected a repeated claim to succeed, got %v, %v", claimed, err)
+	}
+}
+
+func TestPostgresDriverClient_ClaimDriver_OrderHasAnotherDriver(t *testing.T) {
+	db, mock, cleanup := newDriverMockDB(t)
+	t.Cleanup(cleanup)
+
+	expectClaimInsert(mock, 0)
+	mock.ExpectQuery("SELECT driver_id FROM order_assignments").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}).AddRow("driver-2"))
+	mock.ExpectRollback()
+	mock.ExpectClose()
+
+	client := NewPostgresDriverClient(db)
+	if _, err := client.ClaimDriver(context.Background(), "order-1", "driver-1", time.Hour); err == nil {
+		t.Fatalf("expected error for an order assigned to another driver")
+	}
+}
+
 func TestPostgresDriverClient_WithSchema(t *testing.T) {
 	db, mock, cleanup := newDriverMockDB(t)
 	t.Cleanup(cleanup)
@@ -125,6 +222,7 @@ func TestPostgresDriverClient_Assign_ConflictDifferentDriver(t *testing.T) {
 	db, mock, cleanup := newDriverMockDB(t)
 	t.Cleanup(cleanup)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO order_assignments").
 		WithArgs("order-1", "driver-1").
 		WillReturnResult(sqlmock.NewResult(0, 0))
@@ -132,6 +230,7 @@ func TestPostgresDriverClient_Assign_ConflictDifferentDriver(t *testing.T) {
 	mock.ExpectQuery("SELECT driver_id FROM order_assignments").
 		WithArgs("order-1").
 		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}).AddRow("driver-2"))
+	mock.ExpectRollback()
 	mock.ExpectClose()
 
 	client := NewPostgresDriverClient(db)
@@ -144,6 +243,7 @@ func TestPostgresDriverClient_Assign_NotFoundAfterInsert(t *testing.T) {
 	db, mock, cleanup := newDriverMockDB(t)
 	t.Cleanup(cleanup)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO order_assignments").
 		WithArgs("order-1", "driver-1").
 		WillReturnResult(sqlmock.NewResult(0, 0))
@@ -151,6 +251,7 @@ func TestPostgresDriverClient_Assign_NotFoundAfterInsert(t *testing.T) {
 	mock.ExpectQuery("SELECT driver_id FROM order_assignments").
 		WithArgs("order-1").
 		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}))
+	mock.ExpectRollback()
 	mock.ExpectClose()
 
 	client := NewPostgresDriverClient(db)
@@ -163,9 +264,11 @@ func TestPostgresDriverClient_Assign_RowsAffectedError(t *testing.T) {
 	db, mock, cleanup := newDriverMockDB(t)
 	t.Cleanup(cleanup)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO order_assignments").
 		WithArgs("order-err", "driver-err").
 		WillReturnResult(sqlmock.NewErrorResult(errors.New("rows affected boom")))
+	mock.ExpectRollback()
 	mock.ExpectClose()
 
 	client := NewPostgresDriverClient(db)
@@ -173,3 +276,58 @@ func TestPostgresDriverClient_Assign_RowsAffectedError(t *testing.T) {
 		t.Fatalf("expected rows affected error")
 	}
 }
+
+func TestPostgresDriverClient_Release_DeletesAssignmentAndEndsTrip(t *testing.T) {
+	db, mock, cleanup := newDriverMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM order_assignments").
+		WithArgs("order-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE order_trips\s+SET ended_at = NOW\(\), end_reason = \$2`).
+		WithArgs("order-1", saga.TripEndCancelled).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	client := NewPostgresDriverClient(db)
+	if err := client.Release(context.Background(), "order-1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := client.Release(context.Background(), ""); err == nil {
+		t.Fatalf("expected error for empty order id")
+	}
+}
+
+func TestPostgresDriverClient_BusyDriverIDs(t *testing.T) {
+	db, mock, cleanup := newDriverMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery(`SELECT DISTINCT a.driver_id\s+FROM order_assignments a\s+JOIN order_sagas s`).
+		WithArgs(saga.SagaStatusStarted, saga.SagaStatusSucceeded, float64(3600)).
+		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}).AddRow("driver-1").AddRow("driver-2"))
+	mock.ExpectClose()
+
+	client := NewPostgresDriverClient(db)
+	busy, err := client.BusyDriverIDs(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("BusyDriverIDs: %v", err)
+	}
+	if len(busy) != 2 || !busy["driver-1"] || !busy["driver-2"] {
+		t.Fatalf("unexpected busy drivers: %v", busy)
+	}
+}
+
+func TestPostgresDriverClient_BusyDriverIDsQueryError(t *testing.T) {
+	db, mock, cleanup := newDriverMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery("SELECT DISTINCT a.driver_id").WillReturnError(errors.New("db down"))
+	mock.ExpectClose()
+
+	client := NewPostgresDriverClient(db)
+	if _, err := client.BusyDriverIDs(context.Background(), time.Hour); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,40 @@
+package ordersdb
+
+import (
+	"fmt"
+	"math"
+
+	"wayfinder/internal/orders/money"
+)
+
+// legacyAmountMigration returns a statement that moves table from a DOUBLE PRECISION
+// legacyCol to integer minor units in unitsCol plus a currency column. Rows written before
+// the migration are assumed to be in money.DefaultCurrency. The legacy column is kept,
+// nullable, so the backfill can be verified before it is dropped. The statement is a
+// no-op on tables created with the current schema.
+func legacyAmountMigration(table, unitsCol, legacyCol, currencyCol string, required bool) string {
+	scale := int64(math.Pow10(money.Exponent(money.DefaultCurrency)))
+	notNull := ""
+	if required {
+		notNull = fmt.Sprintf(`
+			ALTER TABLE %[1]s
+				ALTER COLUMN %[2]s SET NOT NULL,
+				ALTER COLUMN %[3]s SET NOT NULL;`, table, unitsCol, currencyCol)
+	}
+	return fmt.Sprintf(`DO $$
+		BEGIN
+			ALTER TABLE %[1]s
+				ADD COLUMN IF NOT EXISTS %[2]s BIGINT,
+				ADD COLUMN IF NOT EXISTS %[4]s TEXT;
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = '%[1]s' AND column_name = '%[3]s'
+			) THEN
+				UPDATE %[1]s
+				SET %[2]s = ROUND(%[3]s * %[5]d)::BIGINT,
+					%[4]s = COALESCE(%[4]s, '%[6]s')
+				WHERE %[2]s IS NULL AND %[3]s IS NOT NULL;
+				ALTER TABLE %[1]s ALTER COLUMN %[3]s DROP NOT NULL;
+			END IF;%[7]s
+		END $$`, table, unitsCol, legacyCol, currencyCol, scale, money.DefaultCurrency, notNull)
+}
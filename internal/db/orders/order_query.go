@@ -0,0 +1,129 @@
+package ordersdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/orders/money"
+	"wayfinder/internal/orders/saga"
+)
+
+const orderViewSelect = `
+		SELECT s.order_id, s.user_id, s.amount_units, s.currency, s.pickup_lat, s.pickup_long, s.status, s.created_at, s.updated_at,
+			a.driver_id, a.assigned_at, p.charged_at, p.refund_units, p.refunded_at
+		FROM order_sagas s
+		LEFT JOIN order_assignments a ON a.order_id = s.order_id
+		LEFT JOIN payments p ON p.order_id = s.order_id`
+
+// GetOrder returns the order view for a single order.
+func (s *SagaStore) GetOrder(ctx context.Context, orderID string) (saga.OrderView, error) {
+	row := s.db.QueryRowContext(ctx, orderViewSelect+`
+		WHERE s.order_id = $1`,
+		orderID,
+	)
+
+	view, err := scanOrderView(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return saga.OrderView{}, saga.ErrOrderNotFound
+		}
+		return saga.OrderView{}, err
+	}
+	return view, nil
+}
+
+// ListOrders returns order views newest first, applying the filter and keyset cursor.
+func (s *SagaStore) ListOrders(ctx context.Context, filter saga.OrderFilter) ([]saga.OrderView, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.UserID != "" {
+		conds = append(conds, "s.user_id = "+arg(filter.UserID))
+	}
+	if filter.Status != "" {
+		conds = append(conds, "s.status = "+arg(string(filter.Status)))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conds = append(conds, "s.created_at >= "+arg(filter.CreatedAfter.UTC()))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conds = append(conds, "s.created_at < "+arg(filter.CreatedBefore.UTC()))
+	}
+	if filter.After != nil {
+		conds = append(conds, fmt.Sprintf("(s.created_at, s.order_id) < (%s, %s)",
+			arg(filter.After.CreatedAt.UTC()), arg(filter.After.OrderID)))
+	}
+
+	query := orderViewSelect
+	if len(conds) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(conds, " AND ")
+	}
+	query += "\n\t\tORDER BY s.created_at DESC, s.order_id DESC"
+	if filter.Limit > 0 {
+		query += "\n\t\tLIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []saga.OrderView
+	for rows.Next() {
+		view, err := scanOrderView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrderView(row rowScanner) (saga.OrderView, error) {
+	var (
+		view        saga.OrderView
+		pickupLat   sql.NullFloat64
+		pickupLong  sql.NullFloat64
+		status      string
+		driverID    sql.NullString
+		assignedAt  sql.NullTime
+		chargedAt   sql.NullTime
+		refundUnits sql.NullInt64
+		refundedAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&view.OrderID, &view.UserID, &view.Amount.Units, &view.Amount.Currency, &pickupLat, &pickupLong, &status, &view.CreatedAt, &view.UpdatedAt,
+		&driverID, &assignedAt, &chargedAt, &refundUnits, &refundedAt,
+	); err != nil {
+		return saga.OrderView{}, err
+	}
+
+	view.Pickup = geo.Point{Lat: pickupLat.Float64, Long: pickupLong.Float64}
+	view.Status = saga.SagaStatus(status)
+	view.DriverID = driverID.String
+	view.AssignedAt = assignedAt.Time
+	view.ChargedAt = chargedAt.Time
+	if refundUnits.Valid {
+		view.RefundAmount = money.Money{Units: refundUnits.Int64, Currency: view.Amount.Currency}
+	}
+	view.RefundedAt = refundedAt.Time
+	return view, nil
+}
@@ -0,0 +1,116 @@
+package ordersdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/orders/saga"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+var orderViewColumns = []string{
+	"order_id", "user_id", "amount_units", "currency", "pickup_lat", "pickup_long", "status", "created_at", "updated_at",
+	"driver_id", "assigned_at", "charged_at", "refund_units", "refunded_at",
+}
+
+func TestSagaStore_GetOrder_JoinsAssignmentAndPayment(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	refunded := created.Add(time.Minute)
+	mock.ExpectQuery("SELECT s.order_id, s.user_id, s.amount_units, s.currency, s.pickup_lat, s.pickup_long, s.status").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows(orderViewColumns).
+			AddRow("order-1", "user-1", int64(1000), "USD", 52.52, 13.405, "refunded", created, refunded, nil, nil, created, int64(1000), refunded))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	view, err := store.GetOrder(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if view.Status != saga.SagaStatusRefunded || !view.Refunded() || view.RefundAmount != usd(1000) || view.Amount != usd(1000) {
+		t.Fatalf("unexpected view: %+v", view)
+	}
+	if view.Pickup != (geo.Point{Lat: 52.52, Long: 13.405}) {
+		t.Fatalf("unexpected pickup: %+v", view.Pickup)
+	}
+	if view.DriverID != "" || !view.AssignedAt.IsZero() {
+		t.Fatalf("expected no assignment, got %+v", view)
+	}
+	if !view.ChargedAt.Equal(created) {
+		t.Fatalf("unexpected charged_at: %v", view.ChargedAt)
+	}
+}
+
+func TestSagaStore_GetOrder_NotFound(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery("SELECT s.order_id").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(orderViewColumns))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	if _, err := store.GetOrder(context.Background(), "missing"); !errors.Is(err, saga.ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestSagaStore_ListOrders_AppliesFiltersAndCursor(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	cursorAt := from.Add(time.Hour)
+	mock.ExpectQuery(`WHERE s.user_id = \$1 AND s.status = \$2 AND s.created_at >= \$3 AND s.created_at < \$4 AND \(s.created_at, s.order_id\) < \(\$5, \$6\)\s+ORDER BY s.created_at DESC, s.order_id DESC\s+LIMIT \$7`).
+		WithArgs("user-1", "succeeded", from, to, cursorAt, "order-9", 3).
+		WillReturnRows(sqlmock.NewRows(orderViewColumns).
+			AddRow("order-8", "user-1", int64(500), "USD", nil, nil, "succeeded", from, from, "driver-1", from, from, nil, nil).
+			AddRow("order-7", "user-1", int64(600), "USD", nil, nil, "succeeded", from, from, "driver-2", from, from, nil, nil))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	views, err := store.ListOrders(context.Background(), saga.OrderFilter{
+		UserID:        "user-1",
+		Status:        saga.SagaStatusSucceeded,
+		CreatedAfter:  from,
+		CreatedBefore: to,
+		After:         &saga.OrderCursor{CreatedAt: cursorAt, OrderID: "order-9"},
+		Limit:         3,
+	})
+	if err != nil {
+		t.Fatalf("ListOrders: %v", err)
+	}
+	if len(views) != 2 || views[0].DriverID != "driver-1" || views[1].OrderID != "order-7" {
+		t.Fatalf("unexpected views: %+v", views)
+	}
+	if views[0].Refunded() {
+		t.Fatalf("expected order not refunded")
+	}
+}
+
+func TestSagaStore_ListOrders_NoFilters(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery(`LEFT JOIN payments p ON p.order_id = s.order_id\s+ORDER BY`).
+		WillReturnRows(sqlmock.NewRows(orderViewColumns))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	views, err := store.ListOrders(context.Background(), saga.OrderFilter{})
+	if err != nil {
+		t.Fatalf("ListOrders: %v", err)
+	}
+	if len(views) != 0 {
+		t.Fatalf("expected no views, got %d", len(views))
+	}
+}
@@ -0,0 +1,161 @@
+package ordersdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"wayfinder/internal/orders/outbox"
+	"wayfinder/internal/orders/saga"
+)
+
+type outboxPayload struct {
+	OrderID    string    `json:"order_id"`
+	Step       string    `json:"step,omitempty"`
+	Status     string    `json:"status"`
+	Detail     string    `json:"detail,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// decodeOutboxEvent turns an order_outbox row back into the saga event it records.
+func decodeOutboxEvent(id int64, eventType string, payload []byte) (saga.Event, error) {
+	var p outboxPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return saga.Event{}, fmt.Errorf("decode outbox event %d: %w", id, err)
+	}
+	kind := saga.EventKindStep
+	if eventType == outbox.TypeOrderStatus {
+		kind = saga.EventKindStatus
+	}
+	return saga.Event{
+		ID:      id,
+		OrderID: p.OrderID,
+		Kind:    kind,
+		Step:    p.Step,
+		Status:  p.Status,
+		Detail:  p.Detail,
+		At:      p.OccurredAt,
+	}, nil
+}
+
+// withOutbox runs write and records ev in order_outbox in one transaction.
+func (s *SagaStore) withOutbox(ctx context.Context, ev saga.Event, write func(tx *sql.Tx) error) error {
+	eventType := outbox.TypeOrderStep
+	if ev.Kind == saga.EventKindStatus {
+		eventType = outbox.TypeOrderStatus
+	}
+	payload, err := json.Marshal(outboxPayload{
+		OrderID:    ev.OrderID,
+		Step:       ev.Step,
+		Status:     ev.Status,
+		Detail:     ev.Detail,
+		OccurredAt: ev.At.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := write(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO order_outbox (order_id, event_type, payload)
+		VALUES ($1, $2, $3)`,
+		ev.OrderID, eventType, payload,
+	); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// OutboxStore reads pending order_outbox rows for the relay. The table itself is
+// created by SagaStore.InitSchema.
+type OutboxStore struct {
+	db *sql.DB
+}
+
+// NewOutboxStore constructs an OutboxStore backed by Postgres.
+func NewOutboxStore(db *sql.DB) *OutboxStore {
+	return &OutboxStore{db: db}
+}
+
+// Dispatch hands up to limit pending events to publish in id order and marks each
+// accepted event as published. A transaction-scoped advisory lock keeps a single relay
+// active at a time so per-order ordering holds across replicas; if another relay holds
+// it, Dispatch returns without publishing. Events published before a failed commit are
+// redelivered on the next call.
+func (o *OutboxStore) Dispatch(ctx context.Context, limit int, publish func(outbox.Event) error) (int, error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('order_outbox'))`).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	events, err := pendingOutboxEvents(ctx, tx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	for _, ev := range events {
+		if publishErr = publish(ev); publishErr != nil {
+			break
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE order_outbox
+			SET published_at = NOW()
+			WHERE id = $1`,
+			ev.ID,
+		); err != nil {
+			return 0, err
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return published, publishErr
+}
+
+func pendingOutboxEvents(ctx context.Context, tx *sql.Tx, limit int) ([]outbox.Event, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, order_id, event_type, payload, created_at
+		FROM order_outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []outbox.Event
+	for rows.Next() {
+		var ev outbox.Event
+		if err := rows.Scan(&ev.ID, &ev.OrderID, &ev.Type, &ev.Payload, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,145 @@
+package ordersdb
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/orders/outbox"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+// outboxPayloadArg matches the JSON payload written to order_outbox.
+type outboxPayloadArg struct {
+	step, status, detail string
+}
+
+func (a outboxPayloadArg) Match(v driver.Value) bool {
+	raw, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var p outboxPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return false
+	}
+	return p.OrderID == "order-1" && p.Step == a.step && p.Status == a.status && p.Detail == a.detail && !p.OccurredAt.IsZero()
+}
+
+func TestSagaStore_AddStep_RollsBackWhenOutboxWriteFails(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO order_saga_steps").
+		WithArgs("order-1", "charge", "started", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE order_sagas SET updated_at").
+		WithArgs("order-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_outbox").
+		WithArgs("order-1", "order.step", outboxPayloadArg{step: "charge", status: "started"}).
+		WillReturnError(errors.New("outbox down"))
+	mock.ExpectRollback()
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	if err := store.AddStep(context.Background(), "order-1", "charge", "started", ""); err == nil {
+		t.Fatalf("expected outbox error")
+	}
+}
+
+func outboxRows() *sqlmock.Rows {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return sqlmock.NewRows([]string{"id", "order_id", "event_type", "payload", "created_at"}).
+		AddRow(int64(1), "order-1", "order.step", []byte(`{"order_id":"order-1"}`), at).
+		AddRow(int64(2), "order-1", "order.status", []byte(`{"order_id":"order-1"}`), at).
+		AddRow(int64(3), "order-2", "order.step", []byte(`{"order_id":"order-2"}`), at)
+}
+
+func TestOutboxStore_DispatchPublishesInOrderAndMarks(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`SELECT id, order_id, event_type, payload, created_at\s+FROM order_outbox\s+WHERE published_at IS NULL\s+ORDER BY id`).
+		WithArgs(10).
+		WillReturnRows(outboxRows())
+	for _, id := range []int64{1, 2, 3} {
+		mock.ExpectExec(`UPDATE order_outbox\s+SET published_at = NOW\(\)`).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	var got []int64
+	n, err := NewOutboxStore(db).Dispatch(context.Background(), 10, func(ev outbox.Event) error {
+		got = append(got, ev.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if n != 3 || len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Fatalf("unexpected dispatch: n=%d ids=%v", n, got)
+	}
+}
+
+func TestOutboxStore_DispatchStopsAtFirstPublishError(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`SELECT id, order_id, event_type, payload, created_at`).
+		WithArgs(10).
+		WillReturnRows(outboxRows())
+	mock.ExpectExec(`UPDATE order_outbox`).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	sinkErr := errors.New("sink down")
+	var attempts int
+	n, err := NewOutboxStore(db).Dispatch(context.Background(), 10, func(ev outbox.Event) error {
+		attempts++
+		if ev.ID == 2 {
+			return sinkErr
+		}
+		return nil
+	})
+	if !errors.Is(err, sinkErr) {
+		t.Fatalf("expected sink error, got %v", err)
+	}
+	if n != 1 || attempts != 2 {
+		t.Fatalf("expected delivery to stop after event 2, n=%d attempts=%d", n, attempts)
+	}
+}
+
+func TestOutboxStore_DispatchSkipsWhenAnotherRelayHoldsLock(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectRollback()
+	mock.ExpectClose()
+
+	n, err := NewOutboxStore(db).Dispatch(context.Background(), 10, func(outbox.Event) error {
+		t.Fatalf("publish must not be called without the lock")
+		return nil
+	})
+	if err != nil || n != 0 {
+		t.Fatalf("unexpected result: n=%d err=%v", n, err)
+	}
+}
@@ -3,8 +3,10 @@ package ordersdb
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+
+	"wayfinder/internal/orders/money"
+	"wayfinder/internal/orders/saga"
 )
 
 // PostgresPaymentClient persists charges and refunds in Postgres.
@@ -26,35 +28,52 @@ func NewPostgresPaymentClientWithSchema(ctx context.Context, db *sql.DB) (*Postg
 	return client, nil
 }
 
-// InitSchema creates the payments table if it does not exist.
+// InitSchema creates the payments table if it does not exist and migrates legacy
+// float amounts to minor units.
 func (p *PostgresPaymentClient) InitSchema(ctx context.Context) error {
-	_, err := p.db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS payments (
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS payments (
 			order_id TEXT PRIMARY KEY,
-			amount DOUBLE PRECISION NOT NULL,
+			amount_units BIGINT NOT NULL,
+			currency TEXT NOT NULL,
 			charged_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			refunded_at TIMESTAMPTZ,
-			refund_amount DOUBLE PRECISION
-		)
-	`)
-	return err
+			refund_units BIGINT
+		)`,
+		legacyAmountMigration("payments", "amount_units", "amount", "currency", true),
+		legacyAmountMigration("payments", "refund_units", "refund_amount", "currency", false),
+	}
+
+	for _, stmt := range statements {
+		if _, err := p.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // ErrAlreadyCharged signals an order has already been charged.
-var ErrAlreadyCharged = errors.New("order already charged")
+var ErrAlreadyCharged = saga.ErrAlreadyCharged
 
 // ErrNotCharged signals an order has no recorded charge.
-var ErrNotCharged = errors.New("order not charged")
+var ErrNotCharged = saga.ErrNotCharged
 
 // ErrAlreadyRefunded signals an order has already been refunded.
-var ErrAlreadyRefunded = errors.New("order already refunded")
+var ErrAlreadyRefunded = saga.ErrAlreadyRefunded
+
+// ErrCurrencyMismatch signals a refund in a different currency than the charge.
+var ErrCurrencyMismatch = money.ErrCurrencyMismatch
 
-func (p *PostgresPaymentClient) Charge(ctx context.Context, orderID string, amount float64) error {
+func (p *PostgresPaymentClient) Charge(ctx context.Context, orderID string, amount money.Money) error {
 	if orderID == "" {
 		return fmt.Errorf("order id required")
 	}
+	if err := amount.Validate(); err != nil {
+		return err
+	}
 
-	res, err := p.db.ExecContext(ctx, `INSERT INTO payments (order_id, amount) VALUES ($1, $2) ON CONFLICT (order_id) DO NOTHING`, orderID, amount)
+	res, err := p.db.ExecContext(ctx, `INSERT INTO payments (order_id, amount_units, currency) VALUES ($1, $2, $3) ON CONFLICT (order_id) DO NOTHING`, orderID, amount.Units, amount.Currency)
 	if err != nil {
 		return err
 	}
@@ -70,12 +89,12 @@ func (p *PostgresPaymentClient) Charge(ctx context.Context, orderID string, amou
 	return nil
 }
 
-func (p *PostgresPaymentClient) Refund(ctx context.Context, orderID string, amount float64) error {
+func (p *PostgresPaymentClient) Refund(ctx context.Context, orderID string, amount money.Money) error {
 	if orderID == "" {
 		return fmt.Errorf("order id required")
 	}
 
-	res, err := p.db.ExecContext(ctx, `UPDATE payments SET refund_amount = $2, refunded_at = NOW() WHERE order_id = $1 AND refunded_at IS NULL`, orderID, amount)
+	res, err := p.db.ExecContext(ctx, `UPDATE payments SET refund_units = $2, refunded_at = NOW() WHERE order_id = $1 AND currency = $3 AND refunded_at IS NULL`, orderID, amount.Units, amount.Currency)
 	if err != nil {
 		return err
 	}
@@ -88,13 +107,19 @@ func (p *PostgresPaymentClient) Refund(ctx context.Context, orderID string, amou
 		return nil
 	}
 
-	var refunded bool
-	row := p.db.QueryRowContext(ctx, `SELECT refunded_at IS NOT NULL FROM payments WHERE order_id = $1`, orderID)
-	switch scanErr := row.Scan(&refunded); scanErr {
+	var (
+		refunded bool
+		currency string
+	)
+	row := p.db.QueryRowContext(ctx, `SELECT refunded_at IS NOT NULL, currency FROM payments WHERE order_id = $1`, orderID)
+	switch scanErr := row.Scan(&refunded, &currency); scanErr {
 	case nil:
 		if refunded {
 			return ErrAlreadyRefunded
 		}
+		if currency != amount.Currency {
+			return fmt.Errorf("%w: charged in %s, refund in %s", ErrCurrencyMismatch, currency, amount.Currency)
+		}
 		return ErrNotCharged
 	case sql.ErrNoRows:
 		return ErrNotCharged
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"testing"
 
+	"wayfinder/internal/orders/money"
+
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 )
 
@@ -29,12 +32,25 @@ func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, func()) {
 	return db, mock, cleanup
 }
 
+func usd(units int64) money.Money {
+	return money.Money{Units: units, Currency: "USD"}
+}
+
+// expectPaymentMigrations expects the legacy float column backfills run by InitSchema.
+func expectPaymentMigrations(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`ALTER TABLE payments\s+ADD COLUMN IF NOT EXISTS amount_units BIGINT`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE payments\s+ADD COLUMN IF NOT EXISTS refund_units BIGINT`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
 func TestPostgresPayment_InitSchema(t *testing.T) {
 	db, mock, cleanup := newMockDB(t)
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS payments").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectPaymentMigrations(mock)
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
@@ -49,6 +65,7 @@ func TestPostgresPayment_WithSchemaHelper(t *testing.T) {
 
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS payments").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectPaymentMigrations(mock)
 	mock.ExpectClose()
 
 	client, err := NewPostgresPaymentClientWithSchema(context.Background(), db)
@@ -82,21 +99,21 @@ func TestPostgresPayment_Charge_SucceedsOnce(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("INSERT INTO payments").
-		WithArgs("order-1", 9.99).
+		WithArgs("order-1", int64(999), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	mock.ExpectExec("INSERT INTO payments").
-		WithArgs("order-1", 9.99).
+		WithArgs("order-1", int64(999), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
 
-	if err := client.Charge(context.Background(), "order-1", 9.99); err != nil {
+	if err := client.Charge(context.Background(), "order-1", usd(999)); err != nil {
 		t.Fatalf("first charge: %v", err)
 	}
 
-	err := client.Charge(context.Background(), "order-1", 9.99)
+	err := client.Charge(context.Background(), "order-1", usd(999))
 	if err == nil {
 		t.Fatalf("expected duplicate charge error")
 	}
@@ -107,7 +124,7 @@ func TestPostgresPayment_Charge_SucceedsOnce(t *testing.T) {
 
 func TestPostgresPayment_Charge_EmptyOrderID(t *testing.T) {
 	client := NewPostgresPaymentClient(nil)
-	if err := client.Charge(context.Background(), "", 1.0); err == nil {
+	if err := client.Charge(context.Background(), "", usd(100)); err == nil {
 		t.Fatalf("expected error for empty order id")
 	}
 }
@@ -117,12 +134,12 @@ func TestPostgresPayment_Charge_RowsAffectedError(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("INSERT INTO payments").
-		WithArgs("order-err", 1.23).
+		WithArgs("order-err", int64(123), "USD").
 		WillReturnResult(sqlmock.NewErrorResult(errors.New("rows affected boom")))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
-	if err := client.Charge(context.Background(), "order-err", 1.23); err == nil {
+	if err := client.Charge(context.Background(), "order-err", usd(123)); err == nil {
 		t.Fatalf("expected rows affected error")
 	}
 }
@@ -132,12 +149,12 @@ func TestPostgresPayment_Charge_ExecError(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("INSERT INTO payments").
-		WithArgs("order-err", 1.23).
+		WithArgs("order-err", int64(123), "USD").
 		WillReturnError(errors.New("boom"))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
-	if err := client.Charge(context.Background(), "order-err", 1.23); err == nil {
+	if err := client.Charge(context.Background(), "order-err", usd(123)); err == nil {
 		t.Fatalf("expected exec error")
 	}
 }
@@ -146,14 +163,14 @@ func TestPostgresPayment_Refund_Succeeds(t *testing.T) {
 	db, mock, cleanup := newMockDB(t)
 	t.Cleanup(cleanup)
 
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-1", 9.99).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-1", int64(999), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
 
-	if err := client.Refund(context.Background(), "order-1", 9.99); err != nil {
+	if err := client.Refund(context.Background(), "order-1", usd(999)); err != nil {
 		t.Fatalf("refund: %v", err)
 	}
 }
@@ -162,18 +179,18 @@ func TestPostgresPayment_Refund_NotCharged(t *testing.T) {
 	db, mock, cleanup := newMockDB(t)
 	t.Cleanup(cleanup)
 
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-404", 5.0).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-404", int64(500), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	mock.ExpectQuery("SELECT refunded_at").
 		WithArgs("order-404").
-		WillReturnRows(sqlmock.NewRows([]string{"refunded"}))
+		WillReturnRows(sqlmock.NewRows([]string{"refunded", "currency"}))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
 
-	err := client.Refund(context.Background(), "order-404", 5.0)
+	err := client.Refund(context.Background(), "order-404", usd(500))
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -186,18 +203,18 @@ func TestPostgresPayment_Refund_AlreadyRefunded(t *testing.T) {
 	db, mock, cleanup := newMockDB(t)
 	t.Cleanup(cleanup)
 
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-1", 9.99).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-1", int64(999), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	mock.ExpectQuery("SELECT refunded_at").
 		WithArgs("order-1").
-		WillReturnRows(sqlmock.NewRows([]string{"refunded"}).AddRow(true))
+		WillReturnRows(sqlmock.NewRows([]string{"refunded", "currency"}).AddRow(true, "USD"))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
 
-	err := client.Refund(context.Background(), "order-1", 9.99)
+	err := client.Refund(context.Background(), "order-1", usd(999))
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -208,7 +225,7 @@ func TestPostgresPayment_Refund_AlreadyRefunded(t *testing.T) {
 
 func TestPostgresPayment_Refund_EmptyOrderID(t *testing.T) {
 	client := NewPostgresPaymentClient(nil)
-	if err := client.Refund(context.Background(), "", 1.0); err == nil {
+	if err := client.Refund(context.Background(), "", usd(100)); err == nil {
 		t.Fatalf("expected error for empty order id")
 	}
 }
@@ -217,13 +234,13 @@ func TestPostgresPayment_Refund_ExecError(t *testing.T) {
 	db, mock, cleanup := newMockDB(t)
 	t.Cleanup(cleanup)
 
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-err", 1.0).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-err", int64(100), "USD").
 		WillReturnError(errors.New("boom"))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
-	if err := client.Refund(context.Background(), "order-err", 1.0); err == nil {
+	if err := client.Refund(context.Background(), "order-err", usd(100)); err == nil {
 		t.Fatalf("expected exec error")
 	}
 }
@@ -232,13 +249,13 @@ func TestPostgresPayment_Refund_RowsAffectedError(t *testing.T) {
 	db, mock, cleanup := newMockDB(t)
 	t.Cleanup(cleanup)
 
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-err", 1.0).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-err", int64(100), "USD").
 		WillReturnResult(sqlmock.NewErrorResult(errors.New("rows affected boom")))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
-	if err := client.Refund(context.Background(), "order-err", 1.0); err == nil {
+	if err := client.Refund(context.Background(), "order-err", usd(100)); err == nil {
 		t.Fatalf("expected rows affected error")
 	}
 }
@@ -247,8 +264,8 @@ func TestPostgresPayment_Refund_ScanError(t *testing.T) {
 	db, mock, cleanup := newMockDB(t)
 	t.Cleanup(cleanup)
 
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-err", 1.0).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-err", int64(100), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	mock.ExpectQuery("SELECT refunded_at").
@@ -257,7 +274,7 @@ func TestPostgresPayment_Refund_ScanError(t *testing.T) {
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
-	if err := client.Refund(context.Background(), "order-err", 1.0); err == nil {
+	if err := client.Refund(context.Background(), "order-err", usd(100)); err == nil {
 		t.Fatalf("expected scan error")
 	}
 }
@@ -267,24 +284,75 @@ func TestPostgresPayment_Refund_IdempotentSameOrder(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	// First refund succeeds.
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-1", 5.0).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-1", int64(500), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	// Second refund hits the SELECT branch and finds refunded_at true.
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-1", 5.0).
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-1", int64(500), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectQuery("SELECT refunded_at").
 		WithArgs("order-1").
-		WillReturnRows(sqlmock.NewRows([]string{"refunded"}).AddRow(true))
+		WillReturnRows(sqlmock.NewRows([]string{"refunded", "currency"}).AddRow(true, "USD"))
 	mock.ExpectClose()
 
 	client := NewPostgresPaymentClient(db)
-	if err := client.Refund(context.Background(), "order-1", 5.0); err != nil {
+	if err := client.Refund(context.Background(), "order-1", usd(500)); err != nil {
 		t.Fatalf("first refund: %v", err)
 	}
-	if err := client.Refund(context.Background(), "order-1", 5.0); err == nil {
+	if err := client.Refund(context.Background(), "order-1", usd(500)); err == nil {
 		t.Fatalf("expected already refunded error")
 	}
 }
+
+func TestPostgresPayment_Charge_RejectsInvalidAmount(t *testing.T) {
+	client := NewPostgresPaymentClient(nil)
+	if err := client.Charge(context.Background(), "order-1", usd(0)); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Fatalf("expected invalid amount, got %v", err)
+	}
+	if err := client.Charge(context.Background(), "order-1", money.Money{Units: 100}); !errors.Is(err, money.ErrInvalidCurrency) {
+		t.Fatalf("expected invalid currency, got %v", err)
+	}
+}
+
+func TestPostgresPayment_Refund_CurrencyMismatch(t *testing.T) {
+	db, mock, cleanup := newMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-1", int64(500), "EUR").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT refunded_at IS NOT NULL, currency").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"refunded", "currency"}).AddRow(false, "USD"))
+	mock.ExpectClose()
+
+	client := NewPostgresPaymentClient(db)
+	err := client.Refund(context.Background(), "order-1", money.Money{Units: 500, Currency: "EUR"})
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected currency mismatch, got %v", err)
+	}
+}
+
+func TestLegacyAmountMigration(t *testing.T) {
+	stmt := legacyAmountMigration("payments", "amount_units", "amount", "currency", true)
+	for _, want := range []string{
+		"ADD COLUMN IF NOT EXISTS amount_units BIGINT",
+		"ADD COLUMN IF NOT EXISTS currency TEXT",
+		"column_name = 'amount'",
+		"SET amount_units = ROUND(amount * 100)::BIGINT",
+		"currency = COALESCE(currency, 'USD')",
+		"ALTER COLUMN amount DROP NOT NULL",
+		"ALTER COLUMN amount_units SET NOT NULL",
+	} {
+		if !strings.Contains(stmt, want) {
+			t.Fatalf("migration missing %q:\n%s", want, stmt)
+		}
+	}
+
+	optional := legacyAmountMigration("payments", "refund_units", "refund_amount", "currency", false)
+	if strings.Contains(optional, "SET NOT NULL") {
+		t.Fatalf("optional column must stay nullable:\n%s", optional)
+	}
+}
@@ -0,0 +1,103 @@
+package ordersdb
+
+import (
+	"context"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+)
+
+// ClaimStale leases up to limit sagas that have been started or cancelling for longer
+// than olderThan and are not leased by another owner. Rows locked by a concurrent claim are skipped,
+// so replicas never receive the same saga while its lease is live.
+func (s *SagaStore) ClaimStale(ctx context.Context, owner string, olderThan, lease time.Duration, limit int) ([]saga.SagaRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE order_sagas
+		SET lease_owner = $1, lease_until = NOW() + make_interval(secs => $2)
+		WHERE order_id IN (
+			SELECT order_id
+			FROM order_sagas
+			WHERE status IN ($3, $6)
+				AND updated_at < NOW() - make_interval(secs => $4)
+				AND (lease_until IS NULL OR lease_until < NOW())
+			ORDER BY updated_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status`,
+		owner, lease.Seconds(), saga.SagaStatusStarted, olderThan.Seconds(), limit, saga.SagaStatusCancelling,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []saga.SagaRecord
+	for rows.Next() {
+		record, err := scanSagaRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// ErrLeaseLost signals a saga is no longer leased by the caller, or no longer needs
+// recovery.
+var ErrLeaseLost = saga.ErrLeaseLost
+
+// AcquireLease leases the saga to owner for lease unless another owner holds a live
+// lease on it, reporting whether owner now holds it. Order writes take the lease while
+// they act on a saga so ClaimStale leaves it alone, however long a step takes.
+func (s *SagaStore) AcquireLease(ctx context.Context, orderID, owner string, lease time.Duration) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE order_sagas
+		SET lease_owner = $2, lease_until = NOW() + make_interval(secs => $3)
+		WHERE order_id = $1
+			AND (lease_owner = $2 OR lease_until IS NULL OR lease_until < NOW())`,
+		orderID, owner, lease.Seconds(),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// RenewLease extends owner's lease on a started or cancelling saga by lease. It returns
+// ErrLeaseLost when another owner has claimed the saga or it has left those statuses,
+// in which case the caller must stop acting on it.
+func (s *SagaStore) RenewLease(ctx context.Context, orderID, owner string, lease time.Duration) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE order_sagas
+		SET lease_until = NOW() + make_interval(secs => $3)
+		WHERE order_id = $1 AND lease_owner = $2 AND status IN ($4, $5)`,
+		orderID, owner, lease.Seconds(), saga.SagaStatusStarted, saga.SagaStatusCancelling,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// ReleaseLease clears the lease if it is still held by owner.
+func (s *SagaStore) ReleaseLease(ctx context.Context, orderID, owner string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE order_sagas
+		SET lease_owner = NULL, lease_until = NULL
+		WHERE order_id = $1 AND lease_owner = $2`,
+		orderID, owner,
+	)
+	return err
+}
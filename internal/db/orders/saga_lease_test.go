@@ -0,0 +1,89 @@
+package ordersdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSagaStore_ClaimStale(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery(`UPDATE order_sagas\s+SET lease_owner = \$1.*status IN \(\$3, \$6\).*FOR UPDATE SKIP LOCKED.*RETURNING order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status`).
+		WithArgs("replica-1", 60.0, "started", 300.0, 10, "cancelling").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount_units", "currency", "pickup_lat", "pickup_long", "status"}).
+			AddRow("order-1", "user-1", int64(999), "USD", 52.52, 13.405, "started"))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	records, err := store.ClaimStale(context.Background(), "replica-1", 5*time.Minute, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimStale: %v", err)
+	}
+	if len(records) != 1 || records[0].OrderID != "order-1" || records[0].Status != saga.SagaStatusStarted || records[0].Amount != usd(999) || records[0].Pickup.Lat != 52.52 {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+}
+
+func TestSagaStore_RenewLease(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(`UPDATE order_sagas\s+SET lease_until = NOW\(\) \+ make_interval\(secs => \$3\)\s+WHERE order_id = \$1 AND lease_owner = \$2 AND status IN \(\$4, \$5\)`).
+		WithArgs("order-1", "replica-1", 60.0, "started", "cancelling").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE order_sagas\s+SET lease_until`).
+		WithArgs("order-1", "replica-1", 60.0, "started", "cancelling").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	if err := store.RenewLease(context.Background(), "order-1", "replica-1", time.Minute); err != nil {
+		t.Fatalf("RenewLease: %v", err)
+	}
+	if err := store.RenewLease(context.Background(), "order-1", "replica-1", time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost once another owner holds the saga, got %v", err)
+	}
+}
+
+func TestSagaStore_AcquireLease(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(`UPDATE order_sagas\s+SET lease_owner = \$2, lease_until = NOW\(\) \+ make_interval\(secs => \$3\)\s+WHERE order_id = \$1\s+AND \(lease_owner = \$2 OR lease_until IS NULL OR lease_until < NOW\(\)\)`).
+		WithArgs("order-1", "replica-1", 60.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE order_sagas\s+SET lease_owner = \$2`).
+		WithArgs("order-1", "replica-2", 60.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	if acquired, err := store.AcquireLease(context.Background(), "order-1", "replica-1", time.Minute); err != nil || !acquired {
+		t.Fatalf("expected the free saga to be leased, got %v, %v", acquired, err)
+	}
+	if acquired, err := store.AcquireLease(context.Background(), "order-1", "replica-2", time.Minute); err != nil || acquired {
+		t.Fatalf("expected a live lease to keep other owners out, got %v, %v", acquired, err)
+	}
+}
+
+func TestSagaStore_ReleaseLease(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec(`UPDATE order_sagas\s+SET lease_owner = NULL, lease_until = NULL\s+WHERE order_id = \$1 AND lease_owner = \$2`).
+		WithArgs("order-1", "replica-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	if err := store.ReleaseLease(context.Background(), "order-1", "replica-1"); err != nil {
+		t.Fatalf("ReleaseLease: %v", err)
+	}
+}
@@ -5,18 +5,23 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
+	"wayfinder/internal/geo"
 	"wayfinder/internal/orders/saga"
 )
 
 // SagaStore persists idempotency keys and saga steps in Postgres.
+// Step and status writes are recorded in order_outbox within the same transaction,
+// which doubles as the event log that order watchers in every replica read.
 type SagaStore struct {
-	db *sql.DB
+	db  *sql.DB
+	now func() time.Time
 }
 
 // NewSagaStore constructs a SagaStore backed by Postgres.
 func NewSagaStore(db *sql.DB) *SagaStore {
-	return &SagaStore{db: db}
+	return &SagaStore{db: db, now: time.Now}
 }
 
 // NewSagaStoreWithSchema initializes the schema then returns the store.
@@ -28,14 +33,18 @@ func NewSagaStoreWithSchema(ctx context.Context, db *sql.DB) (*SagaStore, error)
 	return store, nil
 }
 
-// InitSchema creates saga tables if they do not exist.
+// InitSchema creates the saga, outbox and trip tables if they do not exist and migrates
+// legacy float amounts.
 func (s *SagaStore) InitSchema(ctx context.Context) error {
 	statements := []string{
 		`CREATE TABLE IF NOT EXISTS order_sagas (
 			order_id TEXT PRIMARY KEY,
 			idempotency_key TEXT UNIQUE NOT NULL,
 			user_id TEXT NOT NULL,
-			amount DOUBLE PRECISION NOT NULL,
+			amount_units BIGINT NOT NULL,
+			currency TEXT NOT NULL,
+			pickup_lat DOUBLE PRECISION,
+			pickup_long DOUBLE PRECISION,
 			status TEXT NOT NULL,
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
@@ -49,6 +58,37 @@ func (s *SagaStore) InitSchema(ctx context.Context) error {
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			FOREIGN KEY (order_id) REFERENCES order_sagas(order_id) ON DELETE CASCADE
 		)`,
+		`CREATE INDEX IF NOT EXISTS order_sagas_user_created_idx
+			ON order_sagas (user_id, created_at DESC, order_id DESC)`,
+		`ALTER TABLE order_sagas
+			ADD COLUMN IF NOT EXISTS lease_owner TEXT,
+			ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ`,
+		`CREATE INDEX IF NOT EXISTS order_sagas_status_updated_idx
+			ON order_sagas (status, updated_at)`,
+		`CREATE TABLE IF NOT EXISTS order_outbox (
+			id BIGSERIAL PRIMARY KEY,
+			order_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			published_at TIMESTAMPTZ
+		)`,
+		`CREATE INDEX IF NOT EXISTS order_outbox_pending_idx
+			ON order_outbox (id) WHERE published_at IS NULL`,
+		legacyAmountMigration("order_sagas", "amount_units", "amount", "currency", true),
+		`ALTER TABLE order_sagas
+			ADD COLUMN IF NOT EXISTS pickup_lat DOUBLE PRECISION,
+			ADD COLUMN IF NOT EXISTS pickup_long DOUBLE PRECISION`,
+		`CREATE TABLE IF NOT EXISTS order_trips (
+			order_id TEXT PRIMARY KEY REFERENCES order_sagas(order_id) ON DELETE CASCADE,
+			driver_id TEXT NOT NULL,
+			started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			ended_at TIMESTAMPTZ,
+			end_reason TEXT,
+			distance_meters DOUBLE PRECISION
+		)`,
+		`CREATE INDEX IF NOT EXISTS order_outbox_order_idx
+			ON order_outbox (order_id, id)`,
 	}
 
 	for _, stmt := range statements {
@@ -61,12 +101,20 @@ func (s *SagaStore) InitSchema(ctx context.Context) error {
 }
 
 // Start inserts a new saga or returns the existing one for the idempotency key.
-func (s *SagaStore) Start(ctx context.Context, idempotencyKey, orderID, userID string, amount float64) (saga.SagaRecord, bool, error) {
+func (s *SagaStore) Start(ctx context.Context, idempotencyKey string, record saga.SagaRecord) (saga.SagaRecord, bool, error) {
+	// A zero pickup is stored as NULL so location-less sagas look like legacy rows.
+	var pickupLat, pickupLong sql.NullFloat64
+	if !record.Pickup.IsZero() {
+		pickupLat = sql.NullFloat64{Float64: record.Pickup.Lat, Valid: true}
+		pickupLong = sql.NullFloat64{Float64: record.Pickup.Long, Valid: true}
+	}
+
 	res, err := s.db.ExecContext(ctx, `
-		INSERT INTO order_sagas (order_id, idempotency_key, user_id, amount, status)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO order_sagas (order_id, idempotency_key, user_id, amount_units, currency, pickup_lat, pickup_long, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (idempotency_key) DO NOTHING`,
-		orderID, idempotencyKey, userID, amount, saga.SagaStatusStarted,
+		record.OrderID, idempotencyKey, record.UserID, record.Amount.Units, record.Amount.Currency,
+		pickupLat, pickupLong, saga.SagaStatusStarted,
 	)
 	if err != nil {
 		return saga.SagaRecord{}, false, err
@@ -78,46 +126,231 @@ func (s *SagaStore) Start(ctx context.Context, idempotencyKey, orderID, userID s
 	}
 
 	row := s.db.QueryRowContext(ctx, `
-		SELECT order_id, user_id, amount, status
+		SELECT order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status
 		FROM order_sagas
 		WHERE idempotency_key = $1`,
 		idempotencyKey,
 	)
 
-	var record saga.SagaRecord
-	var status string
-	if err := row.Scan(&record.OrderID, &record.UserID, &record.Amount, &status); err != nil {
+	existing, err := scanSagaRecord(row)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return saga.SagaRecord{}, false, fmt.Errorf("saga not found after insert")
 		}
 		return saga.SagaRecord{}, false, err
 	}
-	record.Status = saga.SagaStatus(status)
 
-	if record.UserID != userID || record.Amount != amount {
+	if existing.UserID != record.UserID || existing.Amount != record.Amount || existing.Pickup != record.Pickup {
 		return saga.SagaRecord{}, false, saga.ErrIdempotencyConflict
 	}
 
-	return record, affected == 1, nil
+	return existing, affected == 1, nil
+}
+
+// scanSagaRecord scans order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status.
+func scanSagaRecord(row rowScanner) (saga.SagaRecord, error) {
+	var (
+		record     saga.SagaRecord
+		pickupLat  sql.NullFloat64
+		pickupLong sql.NullFloat64
+		status     string
+	)
+	if err := row.Scan(&record.OrderID, &record.UserID, &record.Amount.Units, &record.Amount.Currency, &pickupLat, &pickupLong, &status); err != nil {
+		return saga.SagaRecord{}, err
+	}
+	record.Pickup = geo.Point{Lat: pickupLat.Float64, Long: pickupLong.Float64}
+	record.Status = saga.SagaStatus(status)
+	return record, nil
 }
 
 // UpdateStatus updates the saga's status and timestamp.
 func (s *SagaStore) UpdateStatus(ctx context.Context, orderID string, status saga.SagaStatus) error {
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE order_sagas
-		SET status = $2, updated_at = NOW()
-		WHERE order_id = $1`,
-		orderID, status,
-	)
-	return err
+	ev := saga.Event{OrderID: orderID, Kind: saga.EventKindStatus, Status: string(status), At: s.now()}
+	return s.withOutbox(ctx, ev, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE order_sagas
+			SET status = $2, updated_at = NOW()
+			WHERE order_id = $1`,
+			orderID, status,
+		)
+		return err
+	})
 }
 
-// AddStep appends a saga step row.
+// errStatusChanged aborts a transition whose saga is no longer in the expected status.
+var errStatusChanged = errors.New("saga status changed")
+
+// TransitionStatus updates the saga's status to to only while it is still from. The
+// row lock taken by the UPDATE makes concurrent transitions from the same status
+// serialize, so only the first of them reports true.
+func (s *SagaStore) TransitionStatus(ctx context.Context, orderID string, from, to saga.SagaStatus) (bool, error) {
+	ev := saga.Event{OrderID: orderID, Kind: saga.EventKindStatus, Status: string(to), At: s.now()}
+	err := s.withOutbox(ctx, ev, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE order_sagas
+			SET status = $3, updated_at = NOW()
+			WHERE order_id = $1 AND status = $2`,
+			orderID, from, to,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return errStatusChanged
+		}
+		return nil
+	})
+	if errors.Is(err, errStatusChanged) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddStep appends a saga step row and touches the saga's updated_at, so a saga that is
+// still making progress never looks stale to ClaimStale.
 func (s *SagaStore) AddStep(ctx context.Context, orderID, step, status, detail string) error {
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO order_saga_steps (order_id, step, status, detail)
-		VALUES ($1, $2, $3, $4)`,
-		orderID, step, status, detail,
+	ev := saga.Event{OrderID: orderID, Kind: saga.EventKindStep, Step: step, Status: status, Detail: detail, At: s.now()}
+	return s.withOutbox(ctx, ev, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO order_saga_steps (order_id, step, status, detail)
+			VALUES ($1, $2, $3, $4)`,
+			orderID, step, status, detail,
+		); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE order_sagas
+			SET updated_at = NOW()
+			WHERE order_id = $1`,
+			orderID,
+		)
+		return err
+	})
+}
+
+// ListSteps returns the recorded steps for an order in write order.
+func (s *SagaStore) ListSteps(ctx context.Context, orderID string) ([]saga.Step, error) {
+	return listSteps(ctx, s.db, orderID)
+}
+
+// OrderHistory reads the order's view, steps and logged event IDs in one repeatable-read
+// transaction. Each event is logged in the same transaction as the write it records,
+// so the IDs are exactly the events the view and steps reflect.
+func (s *SagaStore) OrderHistory(ctx context.Context, orderID string) (saga.OrderHistory, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return saga.OrderHistory{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	view, err := scanOrderView(tx.QueryRowContext(ctx, orderViewSelect+`
+		WHERE s.order_id = $1`,
+		orderID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return saga.OrderHistory{}, saga.ErrOrderNotFound
+		}
+		return saga.OrderHistory{}, err
+	}
+	steps, err := listSteps(ctx, tx, orderID)
+	if err != nil {
+		return saga.OrderHistory{}, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM order_outbox WHERE order_id = $1`, orderID)
+	if err != nil {
+		return saga.OrderHistory{}, err
+	}
+	defer rows.Close()
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return saga.OrderHistory{}, err
+		}
+		ids[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return saga.OrderHistory{}, err
+	}
+	return saga.OrderHistory{View: view, Steps: steps, EventIDs: ids}, tx.Commit()
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func listSteps(ctx context.Context, q queryer, orderID string) ([]saga.Step, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT step, status, COALESCE(detail, ''), created_at
+		FROM order_saga_steps
+		WHERE order_id = $1
+		ORDER BY id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []saga.Step
+	for rows.Next() {
+		var step saga.Step
+		if err := rows.Scan(&step.Step, &step.Status, &step.Detail, &step.CreatedAt); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+// LatestEventID returns the ID of the newest order_outbox row, or 0 when it is empty.
+func (s *SagaStore) LatestEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM order_outbox`).Scan(&id)
+	return id, err
+}
+
+// EventsAfter returns up to limit step and status events from order_outbox with IDs
+// above afterID, oldest first. Rows stay in the table after the relay publishes them.
+func (s *SagaStore) EventsAfter(ctx context.Context, afterID int64, limit int) ([]saga.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, event_type, payload
+		FROM order_outbox
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`,
+		afterID, limit,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []saga.Event
+	for rows.Next() {
+		var (
+			id        int64
+			eventType string
+			payload   []byte
+		)
+		if err := rows.Scan(&id, &eventType, &payload); err != nil {
+			return nil, err
+		}
+		ev, err := decodeOutboxEvent(id, eventType, payload)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
 }
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
+	"wayfinder/internal/geo"
 	"wayfinder/internal/orders/saga"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
@@ -39,6 +41,24 @@ func TestSagaStore_InitSchema(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_saga_steps").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_user_created_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_status_updated_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_outbox").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_pending_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE order_sagas\s+ADD COLUMN IF NOT EXISTS amount_units BIGINT`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE order_sagas\s+ADD COLUMN IF NOT EXISTS pickup_lat`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_trips").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_order_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectClose()
 
 	store := NewSagaStore(db)
@@ -52,24 +72,25 @@ func TestSagaStore_Start_New(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("INSERT INTO order_sagas").
-		WithArgs("order-1", "idem-1", "user-1", 10.0, "started").
+		WithArgs("order-1", "idem-1", "user-1", int64(1000), "USD", 52.52, 13.405, "started").
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectQuery("SELECT order_id, user_id, amount, status").
+	mock.ExpectQuery("SELECT order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status").
 		WithArgs("idem-1").
-		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount", "status"}).
-			AddRow("order-1", "user-1", 10.0, "started"))
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount_units", "currency", "pickup_lat", "pickup_long", "status"}).
+			AddRow("order-1", "user-1", int64(1000), "USD", 52.52, 13.405, "started"))
 	mock.ExpectClose()
 
 	store := NewSagaStore(db)
-	record, created, err := store.Start(context.Background(), "idem-1", "order-1", "user-1", 10.0)
+	pickup := geo.Point{Lat: 52.52, Long: 13.405}
+	record, created, err := store.Start(context.Background(), "idem-1", saga.SagaRecord{OrderID: "order-1", UserID: "user-1", Amount: usd(1000), Pickup: pickup})
 	if err != nil {
 		t.Fatalf("Start: %v", err)
 	}
 	if !created {
 		t.Fatalf("expected created saga")
 	}
-	if record.OrderID != "order-1" {
-		t.Fatalf("unexpected order id: %s", record.OrderID)
+	if record.OrderID != "order-1" || record.Pickup != pickup {
+		t.Fatalf("unexpected record: %+v", record)
 	}
 }
 
@@ -78,16 +99,16 @@ func TestSagaStore_Start_IdempotencyConflict(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("INSERT INTO order_sagas").
-		WithArgs("order-1", "idem-1", "user-1", 10.0, "started").
+		WithArgs("order-1", "idem-1", "user-1", int64(1000), "USD", nil, nil, "started").
 		WillReturnResult(sqlmock.NewResult(0, 0))
-	mock.ExpectQuery("SELECT order_id, user_id, amount, status").
+	mock.ExpectQuery("SELECT order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status").
 		WithArgs("idem-1").
-		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount", "status"}).
-			AddRow("order-99", "user-1", 11.0, "started"))
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount_units", "currency", "pickup_lat", "pickup_long", "status"}).
+			AddRow("order-99", "user-1", int64(1000), "EUR", nil, nil, "started"))
 	mock.ExpectClose()
 
 	store := NewSagaStore(db)
-	_, _, err := store.Start(context.Background(), "idem-1", "order-1", "user-1", 10.0)
+	_, _, err := store.Start(context.Background(), "idem-1", saga.SagaRecord{OrderID: "order-1", UserID: "user-1", Amount: usd(1000)})
 	if err == nil {
 		t.Fatalf("expected conflict error")
 	}
@@ -104,6 +125,24 @@ func TestSagaStore_WithSchema_Success(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_saga_steps").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_user_created_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_status_updated_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_outbox").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_pending_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE order_sagas\s+ADD COLUMN IF NOT EXISTS amount_units BIGINT`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE order_sagas\s+ADD COLUMN IF NOT EXISTS pickup_lat`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_trips").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_order_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectClose()
 
 	store, err := NewSagaStoreWithSchema(context.Background(), db)
@@ -119,9 +158,14 @@ func TestSagaStore_UpdateStatus(t *testing.T) {
 	db, mock, cleanup := newSagaMockDB(t)
 	t.Cleanup(cleanup)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("UPDATE order_sagas SET status").
 		WithArgs("order-1", "done").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_outbox").
+		WithArgs("order-1", "order.status", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 	mock.ExpectClose()
 
 	store := NewSagaStore(db)
@@ -130,13 +174,53 @@ func TestSagaStore_UpdateStatus(t *testing.T) {
 	}
 }
 
+func TestSagaStore_TransitionStatus(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE order_sagas\s+SET status = \$3, updated_at = NOW\(\)\s+WHERE order_id = \$1 AND status = \$2`).
+		WithArgs("order-1", "succeeded", "cancelled").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_outbox").
+		WithArgs("order-1", "order.status", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	// The second caller finds the saga already cancelled and writes no event.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE order_sagas").
+		WithArgs("order-1", "succeeded", "cancelled").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	ok, err := store.TransitionStatus(context.Background(), "order-1", saga.SagaStatusSucceeded, saga.SagaStatusCancelled)
+	if err != nil || !ok {
+		t.Fatalf("first transition: ok=%v err=%v", ok, err)
+	}
+	ok, err = store.TransitionStatus(context.Background(), "order-1", saga.SagaStatusSucceeded, saga.SagaStatusCancelled)
+	if err != nil || ok {
+		t.Fatalf("second transition: ok=%v err=%v", ok, err)
+	}
+}
+
 func TestSagaStore_AddStep(t *testing.T) {
 	db, mock, cleanup := newSagaMockDB(t)
 	t.Cleanup(cleanup)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO order_saga_steps").
 		WithArgs("order-1", "assign-driver", "ok", "details").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	// The saga stays fresh for ClaimStale while steps keep arriving.
+	mock.ExpectExec(`UPDATE order_sagas\s+SET updated_at = NOW\(\)\s+WHERE order_id = \$1`).
+		WithArgs("order-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_outbox").
+		WithArgs("order-1", "order.step", outboxPayloadArg{step: "assign-driver", status: "ok", detail: "details"}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 	mock.ExpectClose()
 
 	store := NewSagaStore(db)
@@ -150,16 +234,16 @@ func TestSagaStore_Start_NotFoundAfterInsert(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("INSERT INTO order_sagas").
-		WithArgs("order-1", "idem-1", "user-1", 10.0, "started").
+		WithArgs("order-1", "idem-1", "user-1", int64(1000), "USD", nil, nil, "started").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
-	mock.ExpectQuery("SELECT order_id, user_id, amount, status").
+	mock.ExpectQuery("SELECT order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status").
 		WithArgs("idem-1").
-		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount", "status"}))
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount_units", "currency", "pickup_lat", "pickup_long", "status"}))
 	mock.ExpectClose()
 
 	store := NewSagaStore(db)
-	if _, _, err := store.Start(context.Background(), "idem-1", "order-1", "user-1", 10.0); err == nil {
+	if _, _, err := store.Start(context.Background(), "idem-1", saga.SagaRecord{OrderID: "order-1", UserID: "user-1", Amount: usd(1000)}); err == nil {
 		t.Fatalf("expected error when saga missing after insert")
 	}
 }
@@ -169,12 +253,130 @@ func TestSagaStore_Start_RowsAffectedError(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	mock.ExpectExec("INSERT INTO order_sagas").
-		WithArgs("order-err", "idem-err", "user-1", 10.0, "started").
+		WithArgs("order-err", "idem-err", "user-1", int64(1000), "USD", nil, nil, "started").
 		WillReturnResult(sqlmock.NewErrorResult(errors.New("rows affected boom")))
 	mock.ExpectClose()
 
 	store := NewSagaStore(db)
-	if _, _, err := store.Start(context.Background(), "idem-err", "order-err", "user-1", 10.0); err == nil {
+	if _, _, err := store.Start(context.Background(), "idem-err", saga.SagaRecord{OrderID: "order-err", UserID: "user-1", Amount: usd(1000)}); err == nil {
 		t.Fatalf("expected rows affected error")
 	}
 }
+
+func TestSagaStore_EventsAfterReadsTheOutboxAsAnEventLog(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(id\), 0\) FROM order_outbox`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(int64(41)))
+	mock.ExpectQuery(`SELECT id, event_type, payload\s+FROM order_outbox\s+WHERE id > \$1\s+ORDER BY id\s+LIMIT \$2`).
+		WithArgs(int64(41), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "payload"}).
+			AddRow(int64(42), "order.step", []byte(`{"order_id":"order-1","step":"cancel","status":"started","detail":"late","occurred_at":"2024-01-02T03:04:05Z"}`)).
+			AddRow(int64(43), "order.status", []byte(`{"order_id":"order-1","status":"cancelled","occurred_at":"2024-01-02T03:04:05Z"}`)))
+	mock.ExpectQuery(`SELECT id, event_type, payload`).
+		WithArgs(int64(43), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "payload"}).
+			AddRow(int64(44), "order.step", []byte(`not json`)))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	latest, err := store.LatestEventID(context.Background())
+	if err != nil || latest != 41 {
+		t.Fatalf("LatestEventID = %d, %v", latest, err)
+	}
+
+	events, err := store.EventsAfter(context.Background(), latest, 10)
+	if err != nil {
+		t.Fatalf("EventsAfter: %v", err)
+	}
+	want := []saga.Event{
+		{ID: 42, OrderID: "order-1", Kind: saga.EventKindStep, Step: "cancel", Status: "started", Detail: "late", At: at},
+		{ID: 43, OrderID: "order-1", Kind: saga.EventKindStatus, Status: "cancelled", At: at},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("event %d: got %+v, want %+v", i, events[i], want[i])
+		}
+	}
+
+	if _, err := store.EventsAfter(context.Background(), 43, 10); err == nil {
+		t.Fatalf("expected error for an undecodable payload")
+	}
+}
+
+func TestSagaStore_ListSteps(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("SELECT step, status, COALESCE\\(detail, ''\\), created_at").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"step", "status", "detail", "created_at"}).
+			AddRow("charge", "started", "", at).
+			AddRow("charge", "succeeded", "", at.Add(time.Second)))
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	steps, err := store.ListSteps(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("ListSteps: %v", err)
+	}
+	if len(steps) != 2 || steps[1].Status != "succeeded" || !steps[0].CreatedAt.Equal(at) {
+		t.Fatalf("unexpected steps: %+v", steps)
+	}
+}
+
+func TestSagaStore_OrderHistory_ReadsOneSnapshot(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT s.order_id, s.user_id, s.amount_units, s.currency, s.pickup_lat, s.pickup_long, s.status").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows(orderViewColumns).
+			AddRow("order-1", "user-1", int64(1000), "USD", nil, nil, "started", at, at, nil, nil, nil, nil, nil))
+	mock.ExpectQuery("SELECT step, status, COALESCE\\(detail, ''\\), created_at").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"step", "status", "detail", "created_at"}).
+			AddRow("charge", "started", "", at))
+	mock.ExpectQuery(`SELECT id FROM order_outbox WHERE order_id = \$1`).
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(41)).AddRow(int64(42)))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	history, err := store.OrderHistory(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("OrderHistory: %v", err)
+	}
+	if history.View.Status != saga.SagaStatusStarted || len(history.Steps) != 1 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if len(history.EventIDs) != 2 || !history.EventIDs[41] || !history.EventIDs[42] {
+		t.Fatalf("unexpected event ids: %v", history.EventIDs)
+	}
+}
+
+func TestSagaStore_OrderHistory_NotFound(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT s.order_id, s.user_id").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(orderViewColumns))
+	mock.ExpectRollback()
+	mock.ExpectClose()
+
+	store := NewSagaStore(db)
+	if _, err := store.OrderHistory(context.Background(), "missing"); !errors.Is(err, saga.ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package ordersdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"wayfinder/internal/orders/saga"
+)
+
+// GetTrip returns the trip for an order with the driver locations ingested while it was
+// in progress, which ingest tags with the order ID.
+func (s *SagaStore) GetTrip(ctx context.Context, orderID string) (saga.Trip, error) {
+	var (
+		trip      saga.Trip
+		endedAt   sql.NullTime
+		endReason sql.NullString
+		distance  sql.NullFloat64
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT order_id, driver_id, started_at, ended_at, end_reason, distance_meters
+		FROM order_trips
+		WHERE order_id = $1`,
+		orderID,
+	).Scan(&trip.OrderID, &trip.DriverID, &trip.StartedAt, &endedAt, &endReason, &distance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return saga.Trip{}, saga.ErrTripNotFound
+		}
+		return saga.Trip{}, err
+	}
+	if endedAt.Valid {
+		trip.EndedAt = endedAt.Time
+	}
+	trip.EndReason = endReason.String
+	trip.DistanceMeters = distance.Float64
+
+	points, err := s.tripPoints(ctx, orderID)
+	if err != nil {
+		return saga.Trip{}, err
+	}
+	trip.Points = points
+	return trip, nil
+}
+
+func (s *SagaStore) tripPoints(ctx context.Context, orderID string) ([]saga.TripPoint, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT lat, long, recorded_at
+		FROM driver_locations
+		WHERE order_id = $1
+		ORDER BY recorded_at, id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []saga.TripPoint
+	for rows.Next() {
+		var p saga.TripPoint
+		if err := rows.Scan(&p.Lat, &p.Long, &p.RecordedAt); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// EndTrip closes an in-progress trip with the given reason and final distance. Ending
+// a trip that has already ended is a no-op.
+func (s *SagaStore) EndTrip(ctx context.Context, orderID, reason string, distanceMeters float64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE order_trips
+		SET ended_at = NOW(), end_reason = $2, distance_meters = $3
+		WHERE order_id = $1 AND ended_at IS NULL`,
+		orderID, reason, distanceMeters,
+	)
+	return err
+}
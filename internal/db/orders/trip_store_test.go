@@ -0,0 +1,110 @@
+package ordersdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+var tripColumns = []string{"order_id", "driver_id", "started_at", "ended_at", "end_reason", "distance_meters"}
+
+func TestSagaStore_GetTrip_ActiveReadsPointsTaggedWithTheOrder(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	started := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT order_id, driver_id, started_at, ended_at, end_reason, distance_meters\\s+FROM order_trips").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows(tripColumns).AddRow("order-1", "driver-1", started, nil, nil, nil))
+	mock.ExpectQuery("SELECT lat, long, recorded_at\\s+FROM driver_locations\\s+WHERE order_id = \\$1\\s+ORDER BY recorded_at, id").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"lat", "long", "recorded_at"}).
+			AddRow(52.52, 13.405, started.Add(time.Minute)).
+			AddRow(52.53, 13.41, started.Add(2*time.Minute)))
+	mock.ExpectClose()
+
+	trip, err := NewSagaStore(db).GetTrip(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("GetTrip: %v", err)
+	}
+	if !trip.Active() || trip.DriverID != "driver-1" || trip.EndReason != "" || trip.DistanceMeters != 0 {
+		t.Fatalf("unexpected trip: %+v", trip)
+	}
+	if len(trip.Points) != 2 || trip.Points[1].Lat != 52.53 || !trip.Points[1].RecordedAt.Equal(started.Add(2*time.Minute)) {
+		t.Fatalf("unexpected points: %+v", trip.Points)
+	}
+}
+
+func TestSagaStore_GetTrip_Ended(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	started := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	ended := started.Add(30 * time.Minute)
+	mock.ExpectQuery("FROM order_trips").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows(tripColumns).AddRow("order-1", "driver-1", started, ended, saga.TripEndCompleted, 1234.5))
+	mock.ExpectQuery("FROM driver_locations").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"lat", "long", "recorded_at"}))
+	mock.ExpectClose()
+
+	trip, err := NewSagaStore(db).GetTrip(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("GetTrip: %v", err)
+	}
+	if trip.Active() || !trip.EndedAt.Equal(ended) || trip.EndReason != saga.TripEndCompleted || trip.DistanceMeters != 1234.5 {
+		t.Fatalf("unexpected trip: %+v", trip)
+	}
+	if len(trip.Points) != 0 {
+		t.Fatalf("expected no points, got %+v", trip.Points)
+	}
+}
+
+func TestSagaStore_GetTrip_NotFound(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery("FROM order_trips").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(tripColumns))
+	mock.ExpectClose()
+
+	if _, err := NewSagaStore(db).GetTrip(context.Background(), "missing"); !errors.Is(err, saga.ErrTripNotFound) {
+		t.Fatalf("expected ErrTripNotFound, got %v", err)
+	}
+}
+
+func TestSagaStore_GetTrip_PointsError(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectQuery("FROM order_trips").
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows(tripColumns).AddRow("order-1", "driver-1", time.Now(), nil, nil, nil))
+	mock.ExpectQuery("FROM driver_locations").WillReturnError(errors.New("db down"))
+	mock.ExpectClose()
+
+	if _, err := NewSagaStore(db).GetTrip(context.Background(), "order-1"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestSagaStore_EndTrip(t *testing.T) {
+	db, mock, cleanup := newSagaMockDB(t)
+	t.Cleanup(cleanup)
+
+	mock.ExpectExec("UPDATE order_trips\\s+SET ended_at = NOW\\(\\), end_reason = \\$2, distance_meters = \\$3\\s+WHERE order_id = \\$1 AND ended_at IS NULL").
+		WithArgs("order-1", saga.TripEndCompleted, 812.5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	if err := NewSagaStore(db).EndTrip(context.Background(), "order-1", saga.TripEndCompleted, 812.5); err != nil {
+		t.Fatalf("EndTrip: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Package postgres opens database/sql handles on pgx with query tracing.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "wayfinder/internal/db/postgres"
+
+// Open returns a database/sql handle for dsn whose queries are recorded as client spans
+// on the global tracer provider. Like sql.Open it does not connect.
+func Open(dsn string) (*sql.DB, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Tracer = QueryTracer{}
+	return stdlib.OpenDB(*cfg), nil
+}
+
+// QueryTracer is a pgx.QueryTracer that wraps each query in a span named after its SQL
+// operation. Arguments are not recorded.
+type QueryTracer struct{}
+
+type querySpanKey struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := sqlOperation(data.SQL)
+	ctx, span := otel.Tracer(tracerName).Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation is the leading keyword of query, e.g. "SELECT", used as the span name.
+func sqlOperation(query string) string {
+	query = strings.TrimSpace(query)
+	if end := strings.IndexAny(query, " \t\r\n("); end >= 0 {
+		query = query[:end]
+	}
+	if query == "" {
+		return "query"
+	}
+	return strings.ToUpper(query)
+}
@@ -0,0 +1,128 @@
+// Package dispatch picks drivers for new orders from their latest reported locations.
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/ingest"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNoDriverAvailable means no online, unassigned driver is within the search radius.
+var ErrNoDriverAvailable = errors.New("no driver available")
+
+// NearbyFinder returns drivers within a radius, nearest first. ingest.RedisGeoIndex
+// implements it.
+type NearbyFinder interface {
+	Nearby(ctx context.Context, lat, long, radiusMeters float64, limit int) ([]ingest.NearbyDriver, error)
+}
+
+// LocationReader reads the driver:<id> hashes written by ingest.RedisLocationStore.
+type LocationReader interface {
+	HGetAll(ctx context.Context, key string) *redis.MapStringStringCmd
+}
+
+// Assignments reports drivers that currently hold an assignment and claims free ones.
+// ClaimDriver must be atomic: of two concurrent claims on a free driver, only one
+// reports true.
+type Assignments interface {
+	BusyDriverIDs(ctx context.Context, within time.Duration) (map[string]bool, error)
+	ClaimDriver(ctx context.Context, orderID, driverID string, within time.Duration) (bool, error)
+}
+
+// Config bounds which drivers are eligible.
+type Config struct {
+	// MaxRadiusMeters is the furthest a driver may be from the pickup.
+	MaxRadiusMeters float64
+	// OnlineWindow is how recent a driver's last location must be to count as online.
+	OnlineWindow time.Duration
+	// AssignmentTTL is a safety net for orders that are never completed or cancelled:
+	// completing or cancelling frees the driver at once, and only older assignments are
+	// ignored. It must outlast the longest trip. Defaults to 12h.
+	AssignmentTTL time.Duration
+}
+
+// Selector picks the nearest online driver without an active assignment.
+type Selector struct {
+	nearby    NearbyFinder
+	locations LocationReader
+	busy      Assignments
+	cfg       Config
+	keyPrefix string
+	now       func() time.Time
+}
+
+// NewSelector constructs a Selector with defaults for unset config.
+func NewSelector(nearby NearbyFinder, locations LocationReader, busy Assignments, cfg Config) *Selector {
+	if cfg.MaxRadiusMeters <= 0 {
+		cfg.MaxRadiusMeters = 5000
+	}
+	if cfg.OnlineWindow <= 0 {
+		cfg.OnlineWindow = 2 * time.Minute
+	}
+	if cfg.AssignmentTTL <= 0 {
+		cfg.AssignmentTTL = 12 * time.Hour
+	}
+	return &Selector{nearby: nearby, locations: locations, busy: busy, cfg: cfg, keyPrefix: "driver:", now: time.Now}
+}
+
+// Select claims the closest eligible driver to pickup for orderID and returns its ID. A
+// driver claimed by a concurrent selection is skipped for the next-nearest candidate.
+func (s *Selector) Select(ctx context.Context, orderID string, pickup geo.Point) (string, error) {
+	if err := pickup.Validate(); err != nil {
+		return "", err
+	}
+
+	candidates, err := s.nearby.Nearby(ctx, pickup.Lat, pickup.Long, s.cfg.MaxRadiusMeters, 0)
+	if err != nil {
+		return "", fmt.Errorf("find nearby drivers: %w", err)
+	}
+	if len(candidates) == 0 {
+		return "", ErrNoDriverAvailable
+	}
+
+	busy, err := s.busy.BusyDriverIDs(ctx, s.cfg.AssignmentTTL)
+	if err != nil {
+		return "", fmt.Errorf("load busy drivers: %w", err)
+	}
+
+	for _, candidate := range candidates {
+		if busy[candidate.DriverID] {
+			continue
+		}
+		// The GEO index only ages out entries at the location TTL, so the hash
+		// timestamp decides whether the driver is still online.
+		fields, err := s.locations.HGetAll(ctx, s.keyPrefix+candidate.DriverID).Result()
+		if err != nil {
+			return "", fmt.Errorf("read driver %s: %w", candidate.DriverID, err)
+		}
+		at, ok := lastSeen(fields)
+		if !ok || s.now().Sub(at) > s.cfg.OnlineWindow {
+			continue
+		}
+		claimed, err := s.busy.ClaimDriver(ctx, orderID, candidate.DriverID, s.cfg.AssignmentTTL)
+		if err != nil {
+			return "", fmt.Errorf("claim driver %s: %w", candidate.DriverID, err)
+		}
+		if !claimed {
+			continue
+		}
+		return candidate.DriverID, nil
+	}
+	return "", ErrNoDriverAvailable
+}
+
+// lastSeen parses the timestamp of a location hash. Missing or malformed hashes, e.g. a key
+// that expired after the GEO query, report false.
+func lastSeen(fields map[string]string) (time.Time, bool) {
+	at, err := time.Parse(time.RFC3339Nano, fields["timestamp"])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return at, true
+}
@@ -0,0 +1,279 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/ingest"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// stubBusy serves a fixed busy snapshot and claims drivers atomically, so a driver
+// claimed after the snapshot was read is still refused.
+type stubBusy struct {
+	busy     map[string]bool
+	err      error
+	claimErr error
+
+	mu      sync.Mutex
+	within  time.Duration
+	claimed map[string]string // driver -> order
+}
+
+func (s *stubBusy) BusyDriverIDs(ctx context.Context, within time.Duration) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.within = within
+	return s.busy, s.err
+}
+
+func (s *stubBusy) ClaimDriver(ctx context.Context, orderID, driverID string, within time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claimErr != nil {
+		return false, s.claimErr
+	}
+	if s.claimed == nil {
+		s.claimed = make(map[string]string)
+	}
+	if owner, ok := s.claimed[driverID]; ok {
+		return owner == orderID, nil
+	}
+	s.claimed[driverID] = orderID
+	return true, nil
+}
+
+var (
+	pickup = geo.Point{Lat: 52.5200, Long: 13.4050}
+	now    = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+type fixture struct {
+	mr     *miniredis.Miniredis
+	client *redis.Client
+}
+
+func newTestSelector(t *testing.T, busy *stubBusy, cfg Config) (*Selector, *fixture) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	sel := NewSelector(ingest.NewRedisGeoIndex(client, 0), client, busy, cfg)
+	sel.now = func() time.Time { return now }
+	return sel, &fixture{mr: mr, client: client}
+}
+
+// putDriver writes the hash and GEO entry RedisLocationStore would write.
+func (f *fixture) putDriver(t *testing.T, driverID string, lat, long float64, at time.Time) {
+	t.Helper()
+
+	f.mr.HSet("driver:"+driverID,
+		"driver_id", driverID,
+		"lat", fmt.Sprint(lat),
+		"long", fmt.Sprint(long),
+		"timestamp", at.UTC().Format(time.RFC3339Nano),
+	)
+	f.indexOnly(t, driverID, lat, long)
+}
+
+// indexOnly adds a GEO entry without a hash, as left behind when the hash expires first.
+func (f *fixture) indexOnly(t *testing.T, driverID string, lat, long float64) {
+	t.Helper()
+
+	err := f.client.GeoAdd(context.Background(), ingest.DefaultGeoKey, &redis.GeoLocation{Name: driverID, Latitude: lat, Longitude: long}).Err()
+	if err != nil {
+		t.Fatalf("geoadd: %v", err)
+	}
+}
+
+func TestSelector_PicksNearestOnlineUnassignedDriver(t *testing.T) {
+	t.Parallel()
+
+	busy := &stubBusy{busy: map[string]bool{"closest-busy": true}}
+	sel, f := newTestSelector(t, busy, Config{MaxRadiusMeters: 5000, AssignmentTTL: 30 * time.Minute})
+
+	f.putDriver(t, "closest-busy", 52.5201, 13.4051, now)
+	f.putDriver(t, "closest-stale", 52.5201, 13.4050, now.Add(-10*time.Minute))
+	f.indexOnly(t, "closest-expired", 52.5202, 13.4050)
+	f.putDriver(t, "near", 52.5230, 13.4050, now.Add(-30*time.Second))
+	f.putDriver(t, "far", 52.5300, 13.4050, now)
+	f.putDriver(t, "outside-radius", 52.6000, 13.4050, now)
+
+	driverID, err := sel.Select(context.Background(), "order-1", pickup)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if driverID != "near" {
+		t.Fatalf("expected near, got %s", driverID)
+	}
+	if busy.within != 30*time.Minute {
+		t.Fatalf("expected assignment TTL passed to busy lookup, got %s", busy.within)
+	}
+	if busy.claimed["near"] != "order-1" {
+		t.Fatalf("expected near claimed for order-1, got %v", busy.claimed)
+	}
+}
+
+func TestSelector_SkipsDriverClaimedSinceBusyLookup(t *testing.T) {
+	t.Parallel()
+
+	busy := &stubBusy{claimed: map[string]string{"near": "order-other"}}
+	sel, f := newTestSelector(t, busy, Config{})
+	f.putDriver(t, "near", 52.5201, 13.4050, now)
+	f.putDriver(t, "next", 52.5230, 13.4050, now)
+
+	driverID, err := sel.Select(context.Background(), "order-1", pickup)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if driverID != "next" {
+		t.Fatalf("expected the next-nearest driver, got %s", driverID)
+	}
+}
+
+func TestSelector_ConcurrentSelectionsClaimDifferentDrivers(t *testing.T) {
+	t.Parallel()
+
+	// Every selection reads the same empty busy snapshot, as concurrent ones would.
+	busy := &stubBusy{}
+	sel, f := newTestSelector(t, busy, Config{})
+	const orders = 5
+	for i := 0; i < orders; i++ {
+		f.putDriver(t, fmt.Sprintf("driver-%d", i), 52.5201+float64(i)*0.001, 13.4050, now)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		drivers = make(map[string]string)
+		errs    []error
+	)
+	for i := 0; i < orders; i++ {
+		orderID := fmt.Sprintf("order-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			driverID, err := sel.Select(context.Background(), orderID, pickup)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			if other, ok := drivers[driverID]; ok {
+				errs = append(errs, fmt.Errorf("%s assigned to both %s and %s", driverID, other, orderID))
+			}
+			drivers[driverID] = orderID
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) != 0 || len(drivers) != orders {
+		t.Fatalf("expected %d distinct drivers, got %v (errors %v)", orders, drivers, errs)
+	}
+	if _, err := sel.Select(context.Background(), "order-late", pickup); !errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected ErrNoDriverAvailable once every driver is claimed, got %v", err)
+	}
+}
+
+func TestSelector_ClaimError(t *testing.T) {
+	t.Parallel()
+
+	sel, f := newTestSelector(t, &stubBusy{claimErr: errors.New("db down")}, Config{})
+	f.putDriver(t, "near", 52.5201, 13.4050, now)
+
+	_, err := sel.Select(context.Background(), "order-1", pickup)
+	if err == nil || errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected claim error, got %v", err)
+	}
+}
+
+func TestSelector_NoDriverWithinRadius(t *testing.T) {
+	t.Parallel()
+
+	sel, f := newTestSelector(t, &stubBusy{}, Config{MaxRadiusMeters: 1000})
+	f.putDriver(t, "far", 52.5300, 13.4050, now)
+
+	if _, err := sel.Select(context.Background(), "order-1", pickup); !errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected ErrNoDriverAvailable, got %v", err)
+	}
+}
+
+func TestSelector_NoDrivers(t *testing.T) {
+	t.Parallel()
+
+	sel, _ := newTestSelector(t, &stubBusy{}, Config{})
+
+	if _, err := sel.Select(context.Background(), "order-1", pickup); !errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected ErrNoDriverAvailable, got %v", err)
+	}
+}
+
+func TestSelector_AllCandidatesIneligible(t *testing.T) {
+	t.Parallel()
+
+	busy := &stubBusy{busy: map[string]bool{"busy": true}}
+	sel, f := newTestSelector(t, busy, Config{})
+	f.putDriver(t, "busy", 52.5201, 13.4050, now)
+	f.putDriver(t, "stale", 52.5202, 13.4050, now.Add(-time.Hour))
+
+	if _, err := sel.Select(context.Background(), "order-1", pickup); !errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected ErrNoDriverAvailable, got %v", err)
+	}
+}
+
+func TestSelector_RejectsInvalidPickup(t *testing.T) {
+	t.Parallel()
+
+	busy := &stubBusy{}
+	sel, _ := newTestSelector(t, busy, Config{})
+
+	if _, err := sel.Select(context.Background(), "order-1", geo.Point{}); !errors.Is(err, geo.ErrPointRequired) {
+		t.Fatalf("expected ErrPointRequired, got %v", err)
+	}
+	if busy.within != 0 {
+		t.Fatalf("expected no busy lookup for invalid pickup")
+	}
+}
+
+func TestSelector_BusyLookupError(t *testing.T) {
+	t.Parallel()
+
+	sel, f := newTestSelector(t, &stubBusy{err: errors.New("db down")}, Config{})
+	f.putDriver(t, "near", 52.5201, 13.4050, now)
+
+	_, err := sel.Select(context.Background(), "order-1", pickup)
+	if err == nil || errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected busy lookup error, got %v", err)
+	}
+}
+
+func TestSelector_RedisError(t *testing.T) {
+	t.Parallel()
+
+	sel, f := newTestSelector(t, &stubBusy{}, Config{})
+	f.mr.SetError("boom")
+
+	_, err := sel.Select(context.Background(), "order-1", pickup)
+	if err == nil || errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected redis error, got %v", err)
+	}
+}
+
+func TestNewSelector_Defaults(t *testing.T) {
+	t.Parallel()
+
+	sel := NewSelector(nil, nil, nil, Config{})
+	if sel.cfg.MaxRadiusMeters != 5000 || sel.cfg.OnlineWindow != 2*time.Minute || sel.cfg.AssignmentTTL != 12*time.Hour {
+		t.Fatalf("unexpected defaults: %+v", sel.cfg)
+	}
+}
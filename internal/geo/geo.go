@@ -0,0 +1,52 @@
+// Package geo holds coordinate types and great-circle distance helpers.
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadiusMeters is the mean Earth radius used for haversine distances.
+const EarthRadiusMeters = 6371008.8
+
+var (
+	ErrPointRequired = errors.New("coordinates are required")
+	ErrInvalidPoint  = errors.New("latitude must be within [-90, 90] and longitude within [-180, 180]")
+)
+
+// Point is a WGS84 coordinate in decimal degrees.
+type Point struct {
+	Lat  float64
+	Long float64
+}
+
+// IsZero reports whether p is unset. (0, 0) is treated as missing rather than a real location.
+func (p Point) IsZero() bool {
+	return p == Point{}
+}
+
+// Validate rejects unset and out-of-range coordinates.
+func (p Point) Validate() error {
+	if p.IsZero() {
+		return ErrPointRequired
+	}
+	if math.IsNaN(p.Lat) || math.IsNaN(p.Long) || p.Lat < -90 || p.Lat > 90 || p.Long < -180 || p.Long > 180 {
+		return ErrInvalidPoint
+	}
+	return nil
+}
+
+// Distance returns the haversine distance between a and b in meters.
+func Distance(a, b Point) float64 {
+	lat1 := radians(a.Lat)
+	lat2 := radians(b.Lat)
+	dLat := lat2 - lat1
+	dLong := radians(b.Long - a.Long)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,48 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b Point
+		want float64
+		tol  float64
+	}{
+		{"same point", Point{Lat: 40.7128, Long: -74.0060}, Point{Lat: 40.7128, Long: -74.0060}, 0, 0.001},
+		{"one degree of latitude", Point{Lat: 10, Long: 20}, Point{Lat: 11, Long: 20}, 111195, 5},
+		{"new york to london", Point{Lat: 40.7128, Long: -74.0060}, Point{Lat: 51.5074, Long: -0.1278}, 5570000, 5000},
+		{"across antimeridian", Point{Lat: 0, Long: 179.5}, Point{Lat: 0, Long: -179.5}, 111195, 5},
+	}
+	for _, tc := range cases {
+		got := Distance(tc.a, tc.b)
+		if math.Abs(got-tc.want) > tc.tol {
+			t.Fatalf("%s: got %.1f m, want %.1f m", tc.name, got, tc.want)
+		}
+		if back := Distance(tc.b, tc.a); math.Abs(back-got) > 1e-6 {
+			t.Fatalf("%s: distance not symmetric: %f vs %f", tc.name, got, back)
+		}
+	}
+}
+
+func TestPoint_Validate(t *testing.T) {
+	cases := []struct {
+		p    Point
+		want error
+	}{
+		{Point{Lat: 52.52, Long: 13.405}, nil},
+		{Point{}, ErrPointRequired},
+		{Point{Lat: 91, Long: 0}, ErrInvalidPoint},
+		{Point{Lat: 0, Long: -181}, ErrInvalidPoint},
+		{Point{Lat: math.NaN(), Long: 1}, ErrInvalidPoint},
+	}
+	for _, tc := range cases {
+		if err := tc.p.Validate(); !errors.Is(err, tc.want) {
+			t.Fatalf("%+v: got %v, want %v", tc.p, err, tc.want)
+		}
+	}
+}
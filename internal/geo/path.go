@@ -0,0 +1,89 @@
+package geo
+
+import "math"
+
+// PathLength returns the haversine length of the polyline through points in meters.
+func PathLength(points []Point) float64 {
+	var total float64
+	for i := 1; i < len(points); i++ {
+		total += Distance(points[i-1], points[i])
+	}
+	return total
+}
+
+// Simplify reduces a polyline with the Douglas–Peucker algorithm, dropping points that
+// lie within toleranceMeters of the simplified line. The first and last points are
+// always kept, and the result preserves the input order. A non-positive tolerance
+// returns points unchanged.
+func Simplify(points []Point, toleranceMeters float64) []Point {
+	if toleranceMeters <= 0 || len(points) < 3 {
+		return points
+	}
+	kept := SimplifyIndices(points, toleranceMeters)
+	out := make([]Point, len(kept))
+	for i, idx := range kept {
+		out[i] = points[idx]
+	}
+	return out
+}
+
+// SimplifyIndices is Simplify for callers that carry data alongside each point; it
+// returns the ascending indices of the points to keep.
+func SimplifyIndices(points []Point, toleranceMeters float64) []int {
+	if toleranceMeters <= 0 || len(points) < 3 {
+		out := make([]int, len(points))
+		for i := range out {
+			out[i] = i
+		}
+		return out
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	type span struct{ first, last int }
+	stack := []span{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		farthest, maxDist := -1, toleranceMeters
+		for i := s.first + 1; i < s.last; i++ {
+			if d := segmentDistance(points[i], points[s.first], points[s.last]); d > maxDist {
+				farthest, maxDist = i, d
+			}
+		}
+		if farthest < 0 {
+			continue
+		}
+		keep[farthest] = true
+		stack = append(stack, span{s.first, farthest}, span{farthest, s.last})
+	}
+
+	out := make([]int, 0, len(points))
+	for i, k := range keep {
+		if k {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// segmentDistance returns the distance in meters from p to the segment a–b, using an
+// equirectangular projection centred on a. That is accurate to well under a percent
+// over the few kilometres between trip points.
+func segmentDistance(p, a, b Point) float64 {
+	cosLat := math.Cos(radians(a.Lat))
+	project := func(q Point) (x, y float64) {
+		return radians(q.Long-a.Long) * cosLat * EarthRadiusMeters, radians(q.Lat-a.Lat) * EarthRadiusMeters
+	}
+	px, py := project(p)
+	bx, by := project(b)
+
+	lengthSq := bx*bx + by*by
+	if lengthSq == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/lengthSq))
+	return math.Hypot(px-t*bx, py-t*by)
+}
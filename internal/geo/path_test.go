@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPathLength(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := Point{Lat: 52.52, Long: 13.405}, Point{Lat: 52.53, Long: 13.405}, Point{Lat: 52.53, Long: 13.42}
+	want := Distance(a, b) + Distance(b, c)
+	if got := PathLength([]Point{a, b, c}); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("got %f, want %f", got, want)
+	}
+	if PathLength(nil) != 0 || PathLength([]Point{a}) != 0 {
+		t.Fatalf("expected zero length for fewer than two points")
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	t.Parallel()
+
+	// A straight northbound line with 5 m of wobble, then a sharp turn east.
+	line := []Point{
+		{Lat: 52.5000, Long: 13.4000},
+		{Lat: 52.5010, Long: 13.40007},
+		{Lat: 52.5020, Long: 13.39993},
+		{Lat: 52.5030, Long: 13.4000},
+		{Lat: 52.5030, Long: 13.4100},
+	}
+
+	got := Simplify(line, 20)
+	if len(got) != 3 || got[0] != line[0] || got[1] != line[3] || got[2] != line[4] {
+		t.Fatalf("expected wobble dropped and corner kept, got %v", got)
+	}
+	if kept := Simplify(line, 1); len(kept) != len(line) {
+		t.Fatalf("expected tight tolerance to keep every point, got %v", kept)
+	}
+	if same := Simplify(line, 0); len(same) != len(line) {
+		t.Fatalf("expected zero tolerance to return input")
+	}
+	if short := Simplify(line[:2], 1000); len(short) != 2 {
+		t.Fatalf("expected endpoints kept, got %v", short)
+	}
+	if idx := SimplifyIndices(line, 20); len(idx) != 3 || idx[0] != 0 || idx[1] != 3 || idx[2] != 4 {
+		t.Fatalf("unexpected kept indices %v", idx)
+	}
+	if idx := SimplifyIndices(line, 0); len(idx) != len(line) {
+		t.Fatalf("expected every index for zero tolerance, got %v", idx)
+	}
+}
+
+func TestSegmentDistance(t *testing.T) {
+	t.Parallel()
+
+	a, b := Point{Lat: 0, Long: 0}, Point{Lat: 0, Long: 1}
+	// ~1.11 km north of the segment midpoint.
+	if d := segmentDistance(Point{Lat: 0.01, Long: 0.5}, a, b); math.Abs(d-1111.95) > 1 {
+		t.Fatalf("unexpected perpendicular distance %f", d)
+	}
+	// Past the end of the segment the distance is to the endpoint.
+	if d := segmentDistance(Point{Lat: 0, Long: 1.01}, a, b); math.Abs(d-Distance(b, Point{Lat: 0, Long: 1.01})) > 1 {
+		t.Fatalf("unexpected endpoint distance %f", d)
+	}
+}
@@ -11,15 +11,25 @@ type Broadcaster interface {
 	Broadcast(msg []byte)
 }
 
+// LocationBroadcaster is implemented by broadcasters that filter per subscriber. They
+// receive the location alongside its encoded payload so unrelated messages can be
+// dropped before they reach a client.
+type LocationBroadcaster interface {
+	BroadcastLocation(loc Location, msg []byte)
+}
+
 // FanoutPublisher forwards locations to storage and broadcasts them.
 type FanoutPublisher struct {
 	storage     LocationPublisher
 	broadcaster Broadcaster
+	located     LocationBroadcaster
 }
 
 // NewFanoutPublisher constructs a publisher that fan-outs to storage and broadcaster.
+// Broadcasters that also implement LocationBroadcaster are sent locations through it.
 func NewFanoutPublisher(storage LocationPublisher, broadcaster Broadcaster) *FanoutPublisher {
-	return &FanoutPublisher{storage: storage, broadcaster: broadcaster}
+	located, _ := broadcaster.(LocationBroadcaster)
+	return &FanoutPublisher{storage: storage, broadcaster: broadcaster, located: located}
 }
 
 // Publish writes to storage then broadcasts the location.
@@ -47,7 +57,10 @@ func (p *FanoutPublisher) Publish(ctx context.Context, loc Location) error {
 		return err
 	}
 
-	if p.broadcaster != nil {
+	switch {
+	case p.located != nil:
+		p.located.BroadcastLocation(loc, data)
+	case p.broadcaster != nil:
 		p.broadcaster.Broadcast(data)
 	}
 
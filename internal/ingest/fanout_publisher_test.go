@@ -128,3 +128,31 @@ func TestFanoutPublisherReturnsMarshalError(t *testing.T) {
 		t.Fatalf("expected no broadcast on marshal error")
 	}
 }
+
+type spyLocationBroadcaster struct {
+	spyBroadcaster
+	loc Location
+}
+
+func (s *spyLocationBroadcaster) BroadcastLocation(loc Location, msg []byte) {
+	s.loc = loc
+	s.msg = msg
+}
+
+func TestFanoutPublisherPrefersLocationBroadcaster(t *testing.T) {
+	t.Parallel()
+
+	bcaster := &spyLocationBroadcaster{}
+	pub := NewFanoutPublisher(&spyPublisher{}, bcaster)
+
+	loc := Location{DriverID: "driver-7", Lat: 1, Long: 2, Timestamp: time.Unix(1700000000, 0)}
+	if err := pub.Publish(context.Background(), loc); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if bcaster.called {
+		t.Fatalf("expected the location-aware path instead of Broadcast")
+	}
+	if bcaster.loc != loc || len(bcaster.msg) == 0 {
+		t.Fatalf("unexpected broadcast: loc=%+v msg=%s", bcaster.loc, bcaster.msg)
+	}
+}
@@ -0,0 +1,74 @@
+package ingest
+
+import (
+	"errors"
+	"math"
+
+	"wayfinder/internal/geo"
+)
+
+var ErrInvalidCircle = errors.New("circle needs a valid center and positive radius")
+
+// Circle is a radius around a point.
+type Circle struct {
+	Center       geo.Point
+	RadiusMeters float64
+}
+
+// Validate checks the center coordinates and radius.
+func (c Circle) Validate() error {
+	if errors.Is(c.Center.Validate(), geo.ErrInvalidPoint) || !(c.RadiusMeters > 0) || math.IsInf(c.RadiusMeters, 1) {
+		return ErrInvalidCircle
+	}
+	return nil
+}
+
+// Contains reports whether the point lies within the circle.
+func (c Circle) Contains(lat, long float64) bool {
+	return geo.Distance(c.Center, geo.Point{Lat: lat, Long: long}) <= c.RadiusMeters
+}
+
+// LocationFilter selects the locations a broadcast subscriber receives. Every criterion
+// that is set must match; the zero filter matches everything.
+type LocationFilter struct {
+	// DriverIDs limits updates to these drivers.
+	DriverIDs map[string]bool
+	// Box limits updates to locations inside the bounding box.
+	Box *BoundingBox
+	// Near limits updates to locations within the circle.
+	Near *Circle
+}
+
+// IsZero reports whether the filter lets every location through.
+func (f LocationFilter) IsZero() bool {
+	return f.DriverIDs == nil && f.Box == nil && f.Near == nil
+}
+
+// Validate checks the area criteria.
+func (f LocationFilter) Validate() error {
+	if f.Box != nil {
+		if err := f.Box.Validate(); err != nil {
+			return err
+		}
+	}
+	if f.Near != nil {
+		if err := f.Near.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches reports whether loc passes every set criterion.
+func (f LocationFilter) Matches(loc Location) bool {
+	if f.DriverIDs != nil && !f.DriverIDs[loc.DriverID] {
+		return false
+	}
+	if f.Box != nil && !f.Box.Contains(loc.Lat, loc.Long) {
+		return false
+	}
+	if f.Near != nil && !f.Near.Contains(loc.Lat, loc.Long) {
+		return false
+	}
+	return true
+}
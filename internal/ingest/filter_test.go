@@ -0,0 +1,57 @@
+package ingest
+
+import (
+	"testing"
+
+	"wayfinder/internal/geo"
+)
+
+func TestLocationFilterMatches(t *testing.T) {
+	t.Parallel()
+
+	berlin := Location{DriverID: "driver-1", Lat: 52.52, Long: 13.405}
+	munich := Location{DriverID: "driver-2", Lat: 48.137, Long: 11.575}
+
+	cases := []struct {
+		name   string
+		filter LocationFilter
+		want   [2]bool
+	}{
+		{name: "zero matches all", want: [2]bool{true, true}},
+		{name: "drivers", filter: LocationFilter{DriverIDs: map[string]bool{"driver-2": true}}, want: [2]bool{false, true}},
+		{name: "empty driver set matches none", filter: LocationFilter{DriverIDs: map[string]bool{}}, want: [2]bool{false, false}},
+		{name: "box", filter: LocationFilter{Box: &BoundingBox{MinLat: 52, MinLong: 13, MaxLat: 53, MaxLong: 14}}, want: [2]bool{true, false}},
+		{name: "near", filter: LocationFilter{Near: &Circle{Center: geo.Point{Lat: 48.14, Long: 11.58}, RadiusMeters: 2000}}, want: [2]bool{false, true}},
+		{
+			name: "criteria are combined",
+			filter: LocationFilter{
+				DriverIDs: map[string]bool{"driver-1": true},
+				Box:       &BoundingBox{MinLat: 48, MinLong: 11, MaxLat: 49, MaxLong: 12},
+			},
+			want: [2]bool{false, false},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := [2]bool{tc.filter.Matches(berlin), tc.filter.Matches(munich)}; got != tc.want {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLocationFilterValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := (LocationFilter{Box: &BoundingBox{MinLat: 10, MaxLat: 5}}).Validate(); err != ErrInvalidBoundingBox {
+		t.Fatalf("expected invalid box, got %v", err)
+	}
+	if err := (LocationFilter{Near: &Circle{Center: geo.Point{Lat: 1, Long: 1}}}).Validate(); err != ErrInvalidCircle {
+		t.Fatalf("expected invalid circle, got %v", err)
+	}
+	if !(LocationFilter{}).IsZero() || (LocationFilter{DriverIDs: map[string]bool{}}).IsZero() {
+		t.Fatalf("unexpected IsZero result")
+	}
+}
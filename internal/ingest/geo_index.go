@@ -0,0 +1,203 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"wayfinder/internal/geo"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultGeoKey is the GEO set holding the latest position of every driver.
+	DefaultGeoKey = "driver_locations"
+	// seenSuffix names the sorted set scoring each GEO member by its last write in unix ms.
+	// GEO members cannot expire on their own, so this is what ages them out.
+	seenSuffix = ":seen"
+
+	maxGeoLatitude = 85.05112878
+	pruneBatch     = 1000
+)
+
+var (
+	ErrInvalidRadius      = errors.New("radius must be positive")
+	ErrInvalidBoundingBox = errors.New("bounding box must have min <= max within valid coordinates")
+)
+
+// NearbyDriver is a driver returned by a GEO index query.
+type NearbyDriver struct {
+	DriverID       string
+	Lat            float64
+	Long           float64
+	DistanceMeters float64
+}
+
+// BoundingBox is an axis-aligned latitude/longitude box. Boxes crossing the antimeridian
+// are not supported.
+type BoundingBox struct {
+	MinLat  float64
+	MinLong float64
+	MaxLat  float64
+	MaxLong float64
+}
+
+// Validate checks that the box is well-formed.
+func (b BoundingBox) Validate() error {
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLong < -180 || b.MaxLong > 180 ||
+		b.MinLat > b.MaxLat || b.MinLong > b.MaxLong {
+		return ErrInvalidBoundingBox
+	}
+	return nil
+}
+
+// Contains reports whether the point lies inside the box, edges included.
+func (b BoundingBox) Contains(lat, long float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && long >= b.MinLong && long <= b.MaxLong
+}
+
+// RedisGeoClient is the Redis surface used by RedisGeoIndex.
+type RedisGeoClient interface {
+	redis.Scripter
+	GeoRadius(ctx context.Context, key string, longitude, latitude float64, query *redis.GeoRadiusQuery) *redis.GeoLocationCmd
+}
+
+// RedisGeoIndex answers proximity queries over the GEO set maintained by RedisLocationStore.
+// Entries older than the location TTL are pruned before every query.
+type RedisGeoIndex struct {
+	client  RedisGeoClient
+	geoKey  string
+	seenKey string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewRedisGeoIndex constructs an index over DefaultGeoKey. ttl should match the
+// RedisLocationStore TTL; zero keeps entries until they are overwritten.
+func NewRedisGeoIndex(client RedisGeoClient, ttl time.Duration) *RedisGeoIndex {
+	return &RedisGeoIndex{
+		client:  client,
+		geoKey:  DefaultGeoKey,
+		seenKey: DefaultGeoKey + seenSuffix,
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// pruneScript removes up to ARGV[2] members last seen at or before ARGV[1] from both the
+// GEO set and the seen set, atomically so a concurrent update is never dropped.
+var pruneScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+if #expired > 0 then
+	redis.call('ZREM', KEYS[1], unpack(expired))
+	redis.call('ZREM', KEYS[2], unpack(expired))
+end
+return #expired
+`)
+
+// Prune removes entries whose last update is older than the TTL and returns how many
+// were removed.
+func (x *RedisGeoIndex) Prune(ctx context.Context) (int, error) {
+	if x.ttl <= 0 {
+		return 0, nil
+	}
+	cutoff := x.now().Add(-x.ttl).UnixMilli()
+
+	total := 0
+	for {
+		removed, err := pruneScript.Run(ctx, x.client, []string{x.geoKey, x.seenKey}, cutoff, pruneBatch).Int()
+		if err != nil {
+			return total, fmt.Errorf("prune geo index: %w", err)
+		}
+		total += removed
+		if removed < pruneBatch {
+			return total, nil
+		}
+	}
+}
+
+// Nearby returns up to limit drivers within radiusMeters of (lat, long), nearest first.
+// A limit of zero returns every match.
+func (x *RedisGeoIndex) Nearby(ctx context.Context, lat, long, radiusMeters float64, limit int) ([]NearbyDriver, error) {
+	if lat < -90 || lat > 90 {
+		return nil, ErrInvalidLatitude
+	}
+	if long < -180 || long > 180 {
+		return nil, ErrInvalidLongitude
+	}
+	if radiusMeters <= 0 {
+		return nil, ErrInvalidRadius
+	}
+	if _, err := x.Prune(ctx); err != nil {
+		return nil, err
+	}
+	return x.radius(ctx, lat, long, radiusMeters, limit)
+}
+
+// InBoundingBox returns up to limit drivers inside box, nearest to its center first.
+// A limit of zero returns every match.
+func (x *RedisGeoIndex) InBoundingBox(ctx context.Context, box BoundingBox, limit int) ([]NearbyDriver, error) {
+	if err := box.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := x.Prune(ctx); err != nil {
+		return nil, err
+	}
+
+	// Query the circle circumscribing the box, then trim to the box itself. The
+	// radius is padded because Redis uses a slightly different earth radius.
+	center := geo.Point{Lat: (box.MinLat + box.MaxLat) / 2, Long: (box.MinLong + box.MaxLong) / 2}
+	radius := 0.0
+	for _, corner := range []geo.Point{
+		{Lat: box.MinLat, Long: box.MinLong},
+		{Lat: box.MinLat, Long: box.MaxLong},
+		{Lat: box.MaxLat, Long: box.MinLong},
+		{Lat: box.MaxLat, Long: box.MaxLong},
+	} {
+		if d := geo.Distance(center, corner); d > radius {
+			radius = d
+		}
+	}
+	candidates, err := x.radius(ctx, center.Lat, center.Long, radius*1.01+1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	drivers := candidates[:0]
+	for _, d := range candidates {
+		if box.Contains(d.Lat, d.Long) {
+			drivers = append(drivers, d)
+		}
+	}
+	if limit > 0 && len(drivers) > limit {
+		drivers = drivers[:limit]
+	}
+	return drivers, nil
+}
+
+func (x *RedisGeoIndex) radius(ctx context.Context, lat, long, radiusMeters float64, limit int) ([]NearbyDriver, error) {
+	locations, err := x.client.GeoRadius(ctx, x.geoKey, long, lat, &redis.GeoRadiusQuery{
+		Radius:    radiusMeters,
+		Unit:      "m",
+		WithCoord: true,
+		WithDist:  true,
+		Count:     limit,
+		Sort:      "ASC",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("query geo index: %w", err)
+	}
+
+	drivers := make([]NearbyDriver, 0, len(locations))
+	for _, loc := range locations {
+		drivers = append(drivers, NearbyDriver{
+			DriverID:       loc.Name,
+			Lat:            loc.Latitude,
+			Long:           loc.Longitude,
+			DistanceMeters: loc.Dist,
+		})
+	}
+	return drivers, nil
+}
@@ -0,0 +1,236 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisClientPipeline struct {
+	client *redis.Client
+}
+
+func (c redisClientPipeline) Pipeline() RedisPipeliner { return c.client.Pipeline() }
+
+type geoFixture struct {
+	mr    *miniredis.Miniredis
+	store *RedisLocationStore
+	index *RedisGeoIndex
+	now   time.Time
+}
+
+func newGeoFixture(t *testing.T, ttl time.Duration) *geoFixture {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	f := &geoFixture{
+		mr:    mr,
+		store: NewRedisLocationStore(redisClientPipeline{client: client}, "location_events", ttl, 0),
+		index: NewRedisGeoIndex(client, ttl),
+		now:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	f.store.now = func() time.Time { return f.now }
+	f.index.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *geoFixture) update(t *testing.T, driverID string, lat, long float64) {
+	t.Helper()
+	if err := f.store.Update(context.Background(), Location{DriverID: driverID, Lat: lat, Long: long, Timestamp: f.now}); err != nil {
+		t.Fatalf("update %s: %v", driverID, err)
+	}
+}
+
+func driverIDs(drivers []NearbyDriver) []string {
+	ids := make([]string, len(drivers))
+	for i, d := range drivers {
+		ids[i] = d.DriverID
+	}
+	return ids
+}
+
+func TestRedisGeoIndex_NearbyOrdersByDistanceAndLimits(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, time.Minute)
+	f.update(t, "far", 52.5300, 13.4050)
+	f.update(t, "near", 52.5210, 13.4050)
+	f.update(t, "mid", 52.5250, 13.4050)
+	f.update(t, "outside", 52.6000, 13.4050)
+
+	drivers, err := f.index.Nearby(context.Background(), 52.5200, 13.4050, 2000, 0)
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if got := fmt.Sprint(driverIDs(drivers)); got != "[near mid far]" {
+		t.Fatalf("unexpected drivers: %s", got)
+	}
+	if d := drivers[0]; d.DistanceMeters < 100 || d.DistanceMeters > 120 || d.Lat < 52.52 || d.Long < 13.40 {
+		t.Fatalf("unexpected nearest driver: %+v", d)
+	}
+
+	drivers, err = f.index.Nearby(context.Background(), 52.5200, 13.4050, 2000, 2)
+	if err != nil {
+		t.Fatalf("Nearby with limit: %v", err)
+	}
+	if got := fmt.Sprint(driverIDs(drivers)); got != "[near mid]" {
+		t.Fatalf("unexpected limited drivers: %s", got)
+	}
+}
+
+func TestRedisGeoIndex_UpdateMovesDriver(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, time.Minute)
+	f.update(t, "driver-1", 52.5210, 13.4050)
+	f.update(t, "driver-1", 48.8566, 2.3522)
+
+	drivers, err := f.index.Nearby(context.Background(), 52.5200, 13.4050, 5000, 0)
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if len(drivers) != 0 {
+		t.Fatalf("expected driver to have moved away, got %+v", drivers)
+	}
+	drivers, err = f.index.Nearby(context.Background(), 48.8566, 2.3522, 100, 0)
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if len(drivers) != 1 || drivers[0].DriverID != "driver-1" {
+		t.Fatalf("expected driver near new position, got %+v", drivers)
+	}
+}
+
+func TestRedisGeoIndex_InBoundingBox(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, time.Minute)
+	f.update(t, "center", 52.5200, 13.4050)
+	f.update(t, "corner", 52.5290, 13.4190)
+	// Inside the circumscribing circle but outside the box.
+	f.update(t, "beside", 52.5200, 13.4230)
+	f.update(t, "away", 52.6000, 13.4050)
+
+	box := BoundingBox{MinLat: 52.5100, MinLong: 13.3900, MaxLat: 52.5300, MaxLong: 13.4200}
+	drivers, err := f.index.InBoundingBox(context.Background(), box, 0)
+	if err != nil {
+		t.Fatalf("InBoundingBox: %v", err)
+	}
+	if got := fmt.Sprint(driverIDs(drivers)); got != "[center corner]" {
+		t.Fatalf("unexpected drivers: %s", got)
+	}
+
+	drivers, err = f.index.InBoundingBox(context.Background(), box, 1)
+	if err != nil {
+		t.Fatalf("InBoundingBox with limit: %v", err)
+	}
+	if len(drivers) != 1 || drivers[0].DriverID != "center" {
+		t.Fatalf("unexpected limited drivers: %+v", drivers)
+	}
+}
+
+func TestRedisGeoIndex_ExpiresEntriesAfterTTL(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, time.Minute)
+	f.update(t, "stale", 52.5210, 13.4050)
+	f.now = f.now.Add(45 * time.Second)
+	f.update(t, "fresh", 52.5220, 13.4050)
+	f.now = f.now.Add(30 * time.Second)
+
+	drivers, err := f.index.Nearby(context.Background(), 52.5200, 13.4050, 1000, 0)
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if got := fmt.Sprint(driverIDs(drivers)); got != "[fresh]" {
+		t.Fatalf("expected only fresh driver, got %s", got)
+	}
+	if members, _ := f.mr.ZMembers(DefaultGeoKey); len(members) != 1 || members[0] != "fresh" {
+		t.Fatalf("expected stale driver removed from geo set, got %v", members)
+	}
+	if members, _ := f.mr.ZMembers(DefaultGeoKey + ":seen"); len(members) != 1 {
+		t.Fatalf("expected stale driver removed from seen set, got %v", members)
+	}
+}
+
+func TestRedisGeoIndex_PruneRunsInBatches(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, time.Minute)
+	for i := 0; i < pruneBatch+500; i++ {
+		f.update(t, fmt.Sprintf("driver-%d", i), 52.52, 13.405)
+	}
+	f.now = f.now.Add(2 * time.Minute)
+
+	removed, err := f.index.Prune(context.Background())
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != pruneBatch+500 {
+		t.Fatalf("expected %d removed, got %d", pruneBatch+500, removed)
+	}
+	if f.mr.Exists(DefaultGeoKey) {
+		t.Fatalf("expected geo set emptied")
+	}
+}
+
+func TestRedisGeoIndex_ZeroTTLKeepsEntries(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, 0)
+	f.update(t, "driver-1", 52.5210, 13.4050)
+	f.now = f.now.Add(24 * time.Hour)
+
+	drivers, err := f.index.Nearby(context.Background(), 52.5200, 13.4050, 1000, 0)
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if len(drivers) != 1 {
+		t.Fatalf("expected entry kept without ttl, got %+v", drivers)
+	}
+}
+
+func TestRedisGeoIndex_ValidatesQueries(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, time.Minute)
+	ctx := context.Background()
+
+	if _, err := f.index.Nearby(ctx, 91, 0, 100, 0); !errors.Is(err, ErrInvalidLatitude) {
+		t.Fatalf("expected ErrInvalidLatitude, got %v", err)
+	}
+	if _, err := f.index.Nearby(ctx, 0, 181, 100, 0); !errors.Is(err, ErrInvalidLongitude) {
+		t.Fatalf("expected ErrInvalidLongitude, got %v", err)
+	}
+	if _, err := f.index.Nearby(ctx, 0, 0, 0, 0); !errors.Is(err, ErrInvalidRadius) {
+		t.Fatalf("expected ErrInvalidRadius, got %v", err)
+	}
+	for _, box := range []BoundingBox{
+		{MinLat: 1, MaxLat: 0, MinLong: 0, MaxLong: 1},
+		{MinLat: 0, MaxLat: 1, MinLong: 1, MaxLong: 0},
+		{MinLat: -91, MaxLat: 1, MinLong: 0, MaxLong: 1},
+	} {
+		if _, err := f.index.InBoundingBox(ctx, box, 0); !errors.Is(err, ErrInvalidBoundingBox) {
+			t.Fatalf("%+v: expected ErrInvalidBoundingBox, got %v", box, err)
+		}
+	}
+}
+
+func TestRedisGeoIndex_RedisError(t *testing.T) {
+	t.Parallel()
+
+	f := newGeoFixture(t, time.Minute)
+	f.mr.SetError("boom")
+
+	if _, err := f.index.Nearby(context.Background(), 52.52, 13.405, 100, 0); err == nil {
+		t.Fatalf("expected redis error")
+	}
+}
@@ -0,0 +1,186 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"wayfinder/internal/geo"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrGeofenceIDRequired   = errors.New("geofence id required")
+	ErrGeofenceNameRequired = errors.New("geofence name required")
+	ErrGeofenceShape        = errors.New("geofence needs exactly one of circle or polygon")
+	ErrInvalidPolygon       = errors.New("polygon needs at least 3 valid vertices")
+	ErrInvalidDwell         = errors.New("dwell time must not be negative")
+	ErrGeofenceNotFound     = errors.New("geofence not found")
+)
+
+// Polygon is a closed ring of vertices; the last vertex connects back to the first.
+// Edges are straight in latitude/longitude, which is accurate for city-sized fences
+// that do not cross the antimeridian.
+type Polygon []geo.Point
+
+// Validate checks the vertex count and coordinates.
+func (p Polygon) Validate() error {
+	if len(p) < 3 {
+		return ErrInvalidPolygon
+	}
+	for _, v := range p {
+		if errors.Is(v.Validate(), geo.ErrInvalidPoint) {
+			return ErrInvalidPolygon
+		}
+	}
+	return nil
+}
+
+// Bounds returns the smallest bounding box containing every vertex.
+func (p Polygon) Bounds() BoundingBox {
+	box := BoundingBox{MinLat: math.Inf(1), MinLong: math.Inf(1), MaxLat: math.Inf(-1), MaxLong: math.Inf(-1)}
+	for _, v := range p {
+		box.MinLat = math.Min(box.MinLat, v.Lat)
+		box.MaxLat = math.Max(box.MaxLat, v.Lat)
+		box.MinLong = math.Min(box.MinLong, v.Long)
+		box.MaxLong = math.Max(box.MaxLong, v.Long)
+	}
+	return box
+}
+
+// Contains reports whether the point lies inside the polygon, using ray casting.
+func (p Polygon) Contains(lat, long float64) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Lat > lat) != (b.Lat > lat) &&
+			long < (b.Long-a.Long)*(lat-a.Lat)/(b.Lat-a.Lat)+a.Long {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Geofence is a named area whose boundary crossings are reported as events. Exactly one
+// of Circle and Polygon is set.
+type Geofence struct {
+	ID      string
+	Name    string
+	Circle  *Circle
+	Polygon Polygon
+	// DwellAfter, when positive, reports a dwell event once a driver has stayed inside
+	// for this long.
+	DwellAfter time.Duration
+	CreatedAt  time.Time
+}
+
+// Validate checks the name, shape and dwell time.
+func (f Geofence) Validate() error {
+	if f.Name == "" {
+		return ErrGeofenceNameRequired
+	}
+	switch {
+	case f.Circle != nil && f.Polygon == nil:
+		if err := f.Circle.Validate(); err != nil {
+			return err
+		}
+	case f.Circle == nil && f.Polygon != nil:
+		if err := f.Polygon.Validate(); err != nil {
+			return err
+		}
+	default:
+		return ErrGeofenceShape
+	}
+	if f.DwellAfter < 0 {
+		return ErrInvalidDwell
+	}
+	return nil
+}
+
+// Contains reports whether the point lies inside the fence.
+func (f Geofence) Contains(lat, long float64) bool {
+	if f.Circle != nil {
+		return f.Circle.Contains(lat, long)
+	}
+	return f.Polygon.Contains(lat, long)
+}
+
+// GeofenceLister lists every configured geofence.
+type GeofenceLister interface {
+	ListGeofences(ctx context.Context) ([]Geofence, error)
+}
+
+// GeofenceStore persists geofences. Get and Delete return ErrGeofenceNotFound for
+// unknown IDs.
+type GeofenceStore interface {
+	GeofenceLister
+	CreateGeofence(ctx context.Context, fence Geofence) error
+	GetGeofence(ctx context.Context, id string) (Geofence, error)
+	DeleteGeofence(ctx context.Context, id string) error
+}
+
+// GeofenceService manages geofences. Changes are applied to the monitor immediately so
+// this replica evaluates them from the next location; other replicas pick them up on
+// their next refresh.
+type GeofenceService struct {
+	store   GeofenceStore
+	monitor *GeofenceMonitor
+	newID   func() string
+	now     func() time.Time
+}
+
+// NewGeofenceService constructs a GeofenceService. monitor may be nil when this process
+// does not evaluate locations.
+func NewGeofenceService(store GeofenceStore, monitor *GeofenceMonitor) *GeofenceService {
+	return &GeofenceService{
+		store:   store,
+		monitor: monitor,
+		newID:   func() string { return uuid.Must(uuid.NewV7()).String() },
+		now:     time.Now,
+	}
+}
+
+// CreateGeofence validates and stores a new fence, assigning its ID and creation time.
+func (s *GeofenceService) CreateGeofence(ctx context.Context, fence Geofence) (Geofence, error) {
+	if err := fence.Validate(); err != nil {
+		return Geofence{}, err
+	}
+	fence.ID = s.newID()
+	fence.CreatedAt = s.now().UTC()
+	if err := s.store.CreateGeofence(ctx, fence); err != nil {
+		return Geofence{}, err
+	}
+	if s.monitor != nil {
+		s.monitor.putGeofence(fence)
+	}
+	return fence, nil
+}
+
+// GetGeofence returns a single fence.
+func (s *GeofenceService) GetGeofence(ctx context.Context, id string) (Geofence, error) {
+	if id == "" {
+		return Geofence{}, ErrGeofenceIDRequired
+	}
+	return s.store.GetGeofence(ctx, id)
+}
+
+// ListGeofences returns every fence, oldest first.
+func (s *GeofenceService) ListGeofences(ctx context.Context) ([]Geofence, error) {
+	return s.store.ListGeofences(ctx)
+}
+
+// DeleteGeofence removes a fence. Drivers inside it are forgotten without exit events.
+func (s *GeofenceService) DeleteGeofence(ctx context.Context, id string) error {
+	if id == "" {
+		return ErrGeofenceIDRequired
+	}
+	if err := s.store.DeleteGeofence(ctx, id); err != nil {
+		return err
+	}
+	if s.monitor != nil {
+		s.monitor.removeGeofence(id)
+	}
+	return nil
+}
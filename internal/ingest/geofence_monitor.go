@@ -0,0 +1,287 @@
+package ingest
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"wayfinder/internal/logging"
+)
+
+// Geofence event types.
+const (
+	GeofenceEnter = "enter"
+	GeofenceExit  = "exit"
+	GeofenceDwell = "dwell"
+)
+
+// GeofenceEvent reports a driver crossing or lingering in a geofence. Times are the
+// timestamps of the driver's locations, not server time.
+type GeofenceEvent struct {
+	Type      string
+	FenceID   string
+	FenceName string
+	DriverID  string
+	Lat       float64
+	Long      float64
+	At        time.Time
+	// EnteredAt is when the driver entered the fence; set on exit and dwell events.
+	EnteredAt time.Time
+}
+
+// GeofenceSink receives geofence events.
+type GeofenceSink interface {
+	PublishGeofenceEvent(ctx context.Context, ev GeofenceEvent) error
+}
+
+// GeofenceConfig tunes GeofenceMonitor.
+type GeofenceConfig struct {
+	// RefreshInterval is how often Run reloads fences from the store, picking up
+	// changes made through other replicas. Defaults to 30s.
+	RefreshInterval time.Duration
+	// IdleTTL is how long a driver who sends no locations is still considered inside
+	// their fences. Defaults to 10m, matching IngestConfig.HistoryTTL. Expired drivers
+	// are forgotten without exit events: going offline is not leaving, and a driver who
+	// comes back inside is reported as entering again, as after a restart.
+	IdleTTL time.Duration
+}
+
+type fenceEntry struct {
+	fence Geofence
+	box   BoundingBox
+}
+
+type presence struct {
+	enteredAt time.Time
+	dwelled   bool
+}
+
+// GeofenceMonitor is a LocationPublisher that evaluates every published location
+// against the configured geofences and reports enter, exit and dwell events to a sink.
+// Presence is kept in memory, so after a restart drivers already inside a fence are
+// reported as entering it again. Drivers silent for longer than IdleTTL are swept out
+// as new locations arrive.
+type GeofenceMonitor struct {
+	next   LocationPublisher
+	fences GeofenceLister
+	sink   GeofenceSink
+	cfg    GeofenceConfig
+	logf   func(format string, args ...any)
+	now    func() time.Time
+
+	mu        sync.Mutex
+	entries   []fenceEntry
+	inside    map[string]map[string]*presence
+	seen      map[string]time.Time
+	nextSweep time.Time
+}
+
+// NewGeofenceMonitor constructs a monitor that publishes to next before evaluating
+// fences. Call Refresh to load the fences before the first location arrives.
+func NewGeofenceMonitor(next LocationPublisher, fences GeofenceLister, sink GeofenceSink, cfg GeofenceConfig, logf func(format string, args ...any)) *GeofenceMonitor {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 30 * time.Second
+	}
+	if cfg.IdleTTL <= 0 {
+		cfg.IdleTTL = 10 * time.Minute
+	}
+	if logf == nil {
+		logf = logging.Printf(slog.Default(), slog.LevelWarn)
+	}
+	return &GeofenceMonitor{
+		next:   next,
+		fences: fences,
+		sink:   sink,
+		cfg:    cfg,
+		logf:   logf,
+		now:    time.Now,
+		inside: make(map[string]map[string]*presence),
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// Publish forwards loc to the next publisher and, once it is accepted, emits the
+// geofence events it causes. Sink failures are logged rather than returned because the
+// location itself has already been stored.
+func (m *GeofenceMonitor) Publish(ctx context.Context, loc Location) error {
+	if err := m.next.Publish(ctx, loc); err != nil {
+		return err
+	}
+	for _, ev := range m.evaluate(loc) {
+		if err := m.sink.PublishGeofenceEvent(ctx, ev); err != nil {
+			m.logf("geofence %s %s event for driver %s: %v", ev.FenceID, ev.Type, ev.DriverID, err)
+		}
+	}
+	return nil
+}
+
+func (m *GeofenceMonitor) evaluate(loc Location) []GeofenceEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	var events []GeofenceEvent
+	emit := func(kind string, fence Geofence, enteredAt time.Time) {
+		events = append(events, GeofenceEvent{
+			Type:      kind,
+			FenceID:   fence.ID,
+			FenceName: fence.Name,
+			DriverID:  loc.DriverID,
+			Lat:       loc.Lat,
+			Long:      loc.Long,
+			At:        loc.Timestamp,
+			EnteredAt: enteredAt,
+		})
+	}
+
+	current := m.inside[loc.DriverID]
+	for _, entry := range m.entries {
+		fence := entry.fence
+		in := entry.box.Contains(loc.Lat, loc.Long) && fence.Contains(loc.Lat, loc.Long)
+		p := current[fence.ID]
+		switch {
+		case in && p == nil:
+			if current == nil {
+				current = make(map[string]*presence)
+				m.inside[loc.DriverID] = current
+			}
+			p = &presence{enteredAt: loc.Timestamp}
+			current[fence.ID] = p
+			emit(GeofenceEnter, fence, time.Time{})
+		case !in && p != nil:
+			delete(current, fence.ID)
+			emit(GeofenceExit, fence, p.enteredAt)
+			continue
+		case !in:
+			continue
+		}
+		if fence.DwellAfter > 0 && !p.dwelled && loc.Timestamp.Sub(p.enteredAt) >= fence.DwellAfter {
+			p.dwelled = true
+			emit(GeofenceDwell, fence, p.enteredAt)
+		}
+	}
+	switch {
+	case current == nil:
+	case len(current) == 0:
+		m.forget(loc.DriverID)
+	default:
+		m.seen[loc.DriverID] = now
+	}
+	return events
+}
+
+// sweep forgets drivers with no location for IdleTTL. Like IngestService it walks the
+// map at most once per half TTL.
+func (m *GeofenceMonitor) sweep(now time.Time) {
+	if now.Before(m.nextSweep) {
+		return
+	}
+	m.nextSweep = now.Add(m.cfg.IdleTTL / 2)
+	for driverID, at := range m.seen {
+		if now.Sub(at) > m.cfg.IdleTTL {
+			m.forget(driverID)
+		}
+	}
+}
+
+func (m *GeofenceMonitor) forget(driverID string) {
+	delete(m.inside, driverID)
+	delete(m.seen, driverID)
+}
+
+// Refresh replaces the monitored fences with the store's current set. Drivers inside
+// fences that no longer exist are forgotten without exit events.
+func (m *GeofenceMonitor) Refresh(ctx context.Context) error {
+	fences, err := m.fences.ListGeofences(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = m.entries[:0]
+	for _, fence := range fences {
+		m.entries = append(m.entries, newFenceEntry(fence))
+	}
+	m.sortEntries()
+	m.prune()
+	return nil
+}
+
+// Run refreshes fences every RefreshInterval until ctx ends.
+func (m *GeofenceMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Refresh(ctx); err != nil && ctx.Err() == nil {
+				m.logf("geofence refresh: %v", err)
+			}
+		}
+	}
+}
+
+func (m *GeofenceMonitor) putGeofence(fence Geofence) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, entry := range m.entries {
+		if entry.fence.ID == fence.ID {
+			m.entries[i] = newFenceEntry(fence)
+			return
+		}
+	}
+	m.entries = append(m.entries, newFenceEntry(fence))
+	m.sortEntries()
+}
+
+func (m *GeofenceMonitor) removeGeofence(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, entry := range m.entries {
+		if entry.fence.ID == id {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			break
+		}
+	}
+	m.prune()
+}
+
+// sortEntries keeps evaluation, and so event order within one location, stable.
+func (m *GeofenceMonitor) sortEntries() {
+	sort.Slice(m.entries, func(i, j int) bool { return m.entries[i].fence.ID < m.entries[j].fence.ID })
+}
+
+func (m *GeofenceMonitor) prune() {
+	known := make(map[string]bool, len(m.entries))
+	for _, entry := range m.entries {
+		known[entry.fence.ID] = true
+	}
+	for driverID, fences := range m.inside {
+		for id := range fences {
+			if !known[id] {
+				delete(fences, id)
+			}
+		}
+		if len(fences) == 0 {
+			m.forget(driverID)
+		}
+	}
+}
+
+func newFenceEntry(fence Geofence) fenceEntry {
+	entry := fenceEntry{fence: fence}
+	if fence.Circle != nil {
+		// A haversine check is about as cheap as a box test, so circles skip the prefilter.
+		entry.box = BoundingBox{MinLat: -90, MinLong: -180, MaxLat: 90, MaxLong: 180}
+	} else {
+		entry.box = fence.Polygon.Bounds()
+	}
+	return entry
+}
@@ -0,0 +1,114 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GeofenceStreamClient is the Redis surface used by RedisGeofenceSink.
+type GeofenceStreamClient interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+}
+
+// RedisGeofenceSink appends geofence events to a Redis stream.
+type RedisGeofenceSink struct {
+	client GeofenceStreamClient
+	stream string
+	maxLen int64
+}
+
+// NewRedisGeofenceSink constructs a sink writing to stream, trimmed approximately to
+// maxLen entries when maxLen is positive.
+func NewRedisGeofenceSink(client GeofenceStreamClient, stream string, maxLen int64) *RedisGeofenceSink {
+	if stream == "" {
+		stream = "geofence_events"
+	}
+	return &RedisGeofenceSink{client: client, stream: stream, maxLen: maxLen}
+}
+
+// PublishGeofenceEvent appends ev to the stream.
+func (s *RedisGeofenceSink) PublishGeofenceEvent(ctx context.Context, ev GeofenceEvent) error {
+	values := map[string]any{
+		"type":       ev.Type,
+		"fence_id":   ev.FenceID,
+		"fence_name": ev.FenceName,
+		"driver_id":  ev.DriverID,
+		"lat":        strconv.FormatFloat(ev.Lat, 'f', -1, 64),
+		"long":       strconv.FormatFloat(ev.Long, 'f', -1, 64),
+		"at":         ev.At.UTC().Format(time.RFC3339Nano),
+	}
+	if !ev.EnteredAt.IsZero() {
+		values["entered_at"] = ev.EnteredAt.UTC().Format(time.RFC3339Nano)
+	}
+	args := &redis.XAddArgs{Stream: s.stream, Values: values}
+	if s.maxLen > 0 {
+		args.MaxLen = s.maxLen
+		args.Approx = true
+	}
+	return s.client.XAdd(ctx, args).Err()
+}
+
+// BroadcastGeofenceSink pushes geofence events to live clients as "geofence" messages.
+// Per-subscriber location filters do not apply; only unfiltered clients receive them.
+type BroadcastGeofenceSink struct {
+	broadcaster Broadcaster
+}
+
+// NewBroadcastGeofenceSink constructs a sink that broadcasts through b.
+func NewBroadcastGeofenceSink(b Broadcaster) *BroadcastGeofenceSink {
+	return &BroadcastGeofenceSink{broadcaster: b}
+}
+
+// PublishGeofenceEvent encodes ev as JSON and broadcasts it.
+func (s *BroadcastGeofenceSink) PublishGeofenceEvent(ctx context.Context, ev GeofenceEvent) error {
+	payload := struct {
+		Type      string     `json:"type"`
+		Event     string     `json:"event"`
+		FenceID   string     `json:"fence_id"`
+		FenceName string     `json:"fence_name"`
+		DriverID  string     `json:"driver_id"`
+		Lat       float64    `json:"lat"`
+		Long      float64    `json:"long"`
+		At        time.Time  `json:"at"`
+		EnteredAt *time.Time `json:"entered_at,omitempty"`
+	}{
+		Type:      "geofence",
+		Event:     ev.Type,
+		FenceID:   ev.FenceID,
+		FenceName: ev.FenceName,
+		DriverID:  ev.DriverID,
+		Lat:       ev.Lat,
+		Long:      ev.Long,
+		At:        ev.At,
+	}
+	if !ev.EnteredAt.IsZero() {
+		payload.EnteredAt = &ev.EnteredAt
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	s.broadcaster.Broadcast(data)
+	return nil
+}
+
+// GeofenceSinks publishes every event to each sink in turn, returning the joined
+// errors of the sinks that failed.
+type GeofenceSinks []GeofenceSink
+
+// PublishGeofenceEvent sends ev to every sink.
+func (s GeofenceSinks) PublishGeofenceEvent(ctx context.Context, ev GeofenceEvent) error {
+	var errs []error
+	for _, sink := range s {
+		if err := sink.PublishGeofenceEvent(ctx, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisGeofenceSink_AppendsToStream(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink := NewRedisGeofenceSink(client, "", 100)
+	ctx := context.Background()
+	if err := sink.PublishGeofenceEvent(ctx, GeofenceEvent{Type: GeofenceExit, FenceID: "ber", FenceName: "Airport", DriverID: "d1", Lat: 52.38, Long: 13.5, At: at, EnteredAt: at.Add(-time.Hour)}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if err := sink.PublishGeofenceEvent(ctx, GeofenceEvent{Type: GeofenceEnter, FenceID: "ber", DriverID: "d2", At: at}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	entries, err := client.XRange(ctx, "geofence_events", "-", "+").Result()
+	if err != nil {
+		t.Fatalf("xrange: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	first := entries[0].Values
+	if first["type"] != "exit" || first["fence_id"] != "ber" || first["fence_name"] != "Airport" || first["driver_id"] != "d1" ||
+		first["lat"] != "52.38" || first["at"] != "2024-01-02T03:04:05Z" || first["entered_at"] != "2024-01-02T02:04:05Z" {
+		t.Fatalf("unexpected entry: %v", first)
+	}
+	if _, ok := entries[1].Values["entered_at"]; ok {
+		t.Fatalf("expected no entered_at on enter events: %v", entries[1].Values)
+	}
+}
+
+func TestBroadcastGeofenceSink_EncodesEvent(t *testing.T) {
+	t.Parallel()
+
+	b := &spyBroadcaster{}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink := NewBroadcastGeofenceSink(b)
+	if err := sink.PublishGeofenceEvent(context.Background(), GeofenceEvent{Type: GeofenceDwell, FenceID: "ber", FenceName: "Airport", DriverID: "d1", Lat: 52.365, Long: 13.508, At: at, EnteredAt: at.Add(-5 * time.Minute)}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(b.msg, &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got["type"] != "geofence" || got["event"] != "dwell" || got["fence_id"] != "ber" || got["driver_id"] != "d1" || got["entered_at"] != "2024-01-02T02:59:05Z" {
+		t.Fatalf("unexpected payload: %s", b.msg)
+	}
+}
+
+func TestGeofenceSinks_PublishesToEveryoneAndJoinsErrors(t *testing.T) {
+	t.Parallel()
+
+	failing := &spyGeofenceSink{err: errors.New("down")}
+	ok := &spyGeofenceSink{}
+	err := GeofenceSinks{failing, ok}.PublishGeofenceEvent(context.Background(), GeofenceEvent{Type: GeofenceEnter})
+	if err == nil || len(failing.events) != 1 || len(ok.events) != 1 {
+		t.Fatalf("expected both sinks called and error returned, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"wayfinder/internal/geo"
+)
+
+// airport is a rough square around a point in Berlin, about 1.1 km on each side.
+var airport = Polygon{
+	{Lat: 52.360, Long: 13.500},
+	{Lat: 52.360, Long: 13.516},
+	{Lat: 52.370, Long: 13.516},
+	{Lat: 52.370, Long: 13.500},
+}
+
+func TestPolygonContains(t *testing.T) {
+	t.Parallel()
+
+	if !airport.Contains(52.365, 13.508) {
+		t.Fatalf("expected centre inside")
+	}
+	for _, p := range []geo.Point{{Lat: 52.355, Long: 13.508}, {Lat: 52.365, Long: 13.52}, {Lat: 52.375, Long: 13.49}} {
+		if airport.Contains(p.Lat, p.Long) {
+			t.Fatalf("expected %v outside", p)
+		}
+	}
+
+	// A concave "L": the notch at the top right is outside.
+	l := Polygon{{Lat: 0, Long: 0}, {Lat: 0, Long: 2}, {Lat: 1, Long: 2}, {Lat: 1, Long: 1}, {Lat: 2, Long: 1}, {Lat: 2, Long: 0}}
+	if !l.Contains(0.5, 1.5) || !l.Contains(1.5, 0.5) || l.Contains(1.5, 1.5) {
+		t.Fatalf("unexpected containment for concave polygon")
+	}
+
+	box := airport.Bounds()
+	if box != (BoundingBox{MinLat: 52.360, MinLong: 13.500, MaxLat: 52.370, MaxLong: 13.516}) {
+		t.Fatalf("unexpected bounds %+v", box)
+	}
+}
+
+func TestGeofenceValidate(t *testing.T) {
+	t.Parallel()
+
+	circle := &Circle{Center: geo.Point{Lat: 52.52, Long: 13.405}, RadiusMeters: 200}
+	cases := []struct {
+		name  string
+		fence Geofence
+		want  error
+	}{
+		{"circle", Geofence{Name: "pickup", Circle: circle, DwellAfter: time.Minute}, nil},
+		{"polygon", Geofence{Name: "airport", Polygon: airport}, nil},
+		{"missing name", Geofence{Circle: circle}, ErrGeofenceNameRequired},
+		{"no shape", Geofence{Name: "x"}, ErrGeofenceShape},
+		{"both shapes", Geofence{Name: "x", Circle: circle, Polygon: airport}, ErrGeofenceShape},
+		{"bad radius", Geofence{Name: "x", Circle: &Circle{Center: circle.Center, RadiusMeters: math.NaN()}}, ErrInvalidCircle},
+		{"too few vertices", Geofence{Name: "x", Polygon: airport[:2]}, ErrInvalidPolygon},
+		{"bad vertex", Geofence{Name: "x", Polygon: Polygon{{Lat: 0, Long: 0}, {Lat: 91, Long: 0}, {Lat: 1, Long: 1}}}, ErrInvalidPolygon},
+		{"negative dwell", Geofence{Name: "x", Circle: circle, DwellAfter: -time.Second}, ErrInvalidDwell},
+	}
+	for _, tc := range cases {
+		if err := tc.fence.Validate(); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+type memGeofenceStore struct {
+	fences  []Geofence
+	listErr error
+	created []Geofence
+	deleted []string
+}
+
+func (s *memGeofenceStore) ListGeofences(ctx context.Context) ([]Geofence, error) {
+	return s.fences, s.listErr
+}
+
+func (s *memGeofenceStore) CreateGeofence(ctx context.Context, fence Geofence) error {
+	s.created = append(s.created, fence)
+	s.fences = append(s.fences, fence)
+	return nil
+}
+
+func (s *memGeofenceStore) GetGeofence(ctx context.Context, id string) (Geofence, error) {
+	for _, fence := range s.fences {
+		if fence.ID == id {
+			return fence, nil
+		}
+	}
+	return Geofence{}, ErrGeofenceNotFound
+}
+
+func (s *memGeofenceStore) DeleteGeofence(ctx context.Context, id string) error {
+	for i, fence := range s.fences {
+		if fence.ID == id {
+			s.fences = append(s.fences[:i], s.fences[i+1:]...)
+			s.deleted = append(s.deleted, id)
+			return nil
+		}
+	}
+	return ErrGeofenceNotFound
+}
+
+type spyGeofenceSink struct {
+	events []GeofenceEvent
+	err    error
+}
+
+func (s *spyGeofenceSink) PublishGeofenceEvent(ctx context.Context, ev GeofenceEvent) error {
+	s.events = append(s.events, ev)
+	return s.err
+}
+
+func (s *spyGeofenceSink) types() []string {
+	out := make([]string, len(s.events))
+	for i, ev := range s.events {
+		out[i] = ev.FenceID + ":" + ev.Type
+	}
+	return out
+}
+
+func newMonitorFixture(t *testing.T, fences ...Geofence) (*GeofenceMonitor, *spyPublisher, *spyGeofenceSink) {
+	t.Helper()
+
+	next := &spyPublisher{}
+	sink := &spyGeofenceSink{}
+	monitor := NewGeofenceMonitor(next, &memGeofenceStore{fences: fences}, sink, GeofenceConfig{}, func(string, ...any) {})
+	if err := monitor.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	return monitor, next, sink
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGeofenceMonitor_EnterDwellExit(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	monitor, next, sink := newMonitorFixture(t, Geofence{ID: "ber", Name: "Airport", Polygon: airport, DwellAfter: 5 * time.Minute})
+	ctx := context.Background()
+
+	at := func(min int, lat, long float64) Location {
+		return Location{DriverID: "driver-1", Lat: lat, Long: long, Timestamp: start.Add(time.Duration(min) * time.Minute)}
+	}
+	steps := []struct {
+		loc  Location
+		want []string
+	}{
+		{at(0, 52.350, 13.508), nil},
+		{at(1, 52.365, 13.508), []string{"ber:enter"}},
+		{at(4, 52.366, 13.509), nil},
+		{at(6, 52.366, 13.509), []string{"ber:dwell"}},
+		{at(8, 52.366, 13.509), nil},
+		{at(9, 52.380, 13.508), []string{"ber:exit"}},
+	}
+	for i, step := range steps {
+		sink.events = nil
+		if err := monitor.Publish(ctx, step.loc); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if !equalStrings(sink.types(), step.want) {
+			t.Fatalf("step %d: got %v, want %v", i, sink.types(), step.want)
+		}
+		if next.loc != step.loc {
+			t.Fatalf("step %d: location not forwarded", i)
+		}
+	}
+
+	if ev := sink.events[0]; ev.DriverID != "driver-1" || ev.FenceName != "Airport" || !ev.At.Equal(start.Add(9*time.Minute)) || !ev.EnteredAt.Equal(start.Add(time.Minute)) {
+		t.Fatalf("unexpected exit event: %+v", ev)
+	}
+	if len(monitor.inside) != 0 {
+		t.Fatalf("expected presence cleared after exit, got %v", monitor.inside)
+	}
+}
+
+func TestGeofenceMonitor_TracksDriversAndFencesIndependently(t *testing.T) {
+	t.Parallel()
+
+	pickup := Geofence{ID: "a-pickup", Name: "Pickup", Circle: &Circle{Center: geo.Point{Lat: 52.365, Long: 13.508}, RadiusMeters: 100}}
+	monitor, _, sink := newMonitorFixture(t, Geofence{ID: "b-airport", Name: "Airport", Polygon: airport}, pickup)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	_ = monitor.Publish(ctx, Location{DriverID: "d1", Lat: 52.365, Long: 13.508, Timestamp: now})
+	_ = monitor.Publish(ctx, Location{DriverID: "d2", Lat: 52.369, Long: 13.515, Timestamp: now})
+	_ = monitor.Publish(ctx, Location{DriverID: "d1", Lat: 52.368, Long: 13.508, Timestamp: now.Add(time.Minute)})
+
+	want := []string{"a-pickup:enter", "b-airport:enter", "b-airport:enter", "a-pickup:exit"}
+	if !equalStrings(sink.types(), want) {
+		t.Fatalf("got %v, want %v", sink.types(), want)
+	}
+	if sink.events[2].DriverID != "d2" {
+		t.Fatalf("expected second airport entry from d2, got %+v", sink.events[2])
+	}
+}
+
+func TestGeofenceMonitor_PublishFailureSkipsEvaluation(t *testing.T) {
+	t.Parallel()
+
+	monitor, next, sink := newMonitorFixture(t, Geofence{ID: "ber", Name: "Airport", Polygon: airport})
+	next.err = errors.New("redis down")
+
+	if err := monitor.Publish(context.Background(), Location{DriverID: "d1", Lat: 52.365, Long: 13.508}); err == nil {
+		t.Fatalf("expected publish error")
+	}
+	if len(sink.events) != 0 || len(monitor.inside) != 0 {
+		t.Fatalf("expected no events for an unstored location")
+	}
+}
+
+func TestGeofenceMonitor_SinkFailureIsLogged(t *testing.T) {
+	t.Parallel()
+
+	var logged []string
+	sink := &spyGeofenceSink{err: errors.New("stream full")}
+	monitor := NewGeofenceMonitor(&spyPublisher{}, &memGeofenceStore{fences: []Geofence{{ID: "ber", Name: "Airport", Polygon: airport}}}, sink, GeofenceConfig{}, func(format string, args ...any) {
+		logged = append(logged, format)
+	})
+	if err := monitor.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	if err := monitor.Publish(context.Background(), Location{DriverID: "d1", Lat: 52.365, Long: 13.508}); err != nil {
+		t.Fatalf("sink errors must not fail the publish: %v", err)
+	}
+	if len(logged) != 1 {
+		t.Fatalf("expected sink failure logged once, got %v", logged)
+	}
+}
+
+func TestGeofenceMonitor_RefreshForgetsRemovedFences(t *testing.T) {
+	t.Parallel()
+
+	store := &memGeofenceStore{fences: []Geofence{{ID: "ber", Name: "Airport", Polygon: airport}}}
+	sink := &spyGeofenceSink{}
+	monitor := NewGeofenceMonitor(&spyPublisher{}, store, sink, GeofenceConfig{}, func(string, ...any) {})
+	ctx := context.Background()
+	if err := monitor.Refresh(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	_ = monitor.Publish(ctx, Location{DriverID: "d1", Lat: 52.365, Long: 13.508})
+
+	store.fences = nil
+	if err := monitor.Refresh(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	_ = monitor.Publish(ctx, Location{DriverID: "d1", Lat: 52.380, Long: 13.508})
+	if !equalStrings(sink.types(), []string{"ber:enter"}) || len(monitor.inside) != 0 {
+		t.Fatalf("expected removed fence forgotten without exit, got %v inside=%v", sink.types(), monitor.inside)
+	}
+
+	store.listErr = errors.New("db down")
+	if err := monitor.Refresh(ctx); err == nil {
+		t.Fatalf("expected refresh error")
+	}
+}
+
+func TestGeofenceMonitor_ForgetsIdleDriversWithoutExit(t *testing.T) {
+	t.Parallel()
+
+	store := &memGeofenceStore{fences: []Geofence{{ID: "ber", Name: "Airport", Polygon: airport}}}
+	sink := &spyGeofenceSink{}
+	monitor := NewGeofenceMonitor(&spyPublisher{}, store, sink, GeofenceConfig{IdleTTL: time.Minute}, func(string, ...any) {})
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	monitor.now = func() time.Time { return now }
+	ctx := context.Background()
+	if err := monitor.Refresh(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	_ = monitor.Publish(ctx, Location{DriverID: "d1", Lat: 52.365, Long: 13.508, Timestamp: now})
+	_ = monitor.Publish(ctx, Location{DriverID: "d2", Lat: 52.365, Long: 13.508, Timestamp: now})
+
+	// d1 goes offline; d2 keeps reporting and its points drive the sweep.
+	now = now.Add(45 * time.Second)
+	_ = monitor.Publish(ctx, Location{DriverID: "d2", Lat: 52.366, Long: 13.508, Timestamp: now})
+	now = now.Add(30 * time.Second)
+	_ = monitor.Publish(ctx, Location{DriverID: "d2", Lat: 52.366, Long: 13.509, Timestamp: now})
+
+	if _, kept := monitor.inside["d1"]; kept || len(monitor.inside) != 1 || len(monitor.seen) != 1 {
+		t.Fatalf("expected only d2 left, got inside=%v seen=%v", monitor.inside, monitor.seen)
+	}
+	if !equalStrings(sink.types(), []string{"ber:enter", "ber:enter"}) {
+		t.Fatalf("expected expiry without exit events, got %v", sink.types())
+	}
+
+	// Back online inside the fence, d1 is reported as entering again.
+	_ = monitor.Publish(ctx, Location{DriverID: "d1", Lat: 52.365, Long: 13.508, Timestamp: now})
+	if !equalStrings(sink.types(), []string{"ber:enter", "ber:enter", "ber:enter"}) {
+		t.Fatalf("expected a fresh enter for the returning driver, got %v", sink.types())
+	}
+}
+
+func TestGeofenceService_ManagesStoreAndMonitor(t *testing.T) {
+	t.Parallel()
+
+	store := &memGeofenceStore{}
+	sink := &spyGeofenceSink{}
+	monitor := NewGeofenceMonitor(&spyPublisher{}, store, sink, GeofenceConfig{}, func(string, ...any) {})
+	service := NewGeofenceService(store, monitor)
+	created := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	service.newID = func() string { return "fence-1" }
+	service.now = func() time.Time { return created }
+	ctx := context.Background()
+
+	if _, err := service.CreateGeofence(ctx, Geofence{Name: "x"}); !errors.Is(err, ErrGeofenceShape) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	fence, err := service.CreateGeofence(ctx, Geofence{ID: "ignored", Name: "Airport", Polygon: airport})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if fence.ID != "fence-1" || !fence.CreatedAt.Equal(created) || len(store.created) != 1 {
+		t.Fatalf("unexpected fence %+v", fence)
+	}
+
+	_ = monitor.Publish(ctx, Location{DriverID: "d1", Lat: 52.365, Long: 13.508})
+	if !equalStrings(sink.types(), []string{"fence-1:enter"}) {
+		t.Fatalf("expected new fence evaluated without refresh, got %v", sink.types())
+	}
+
+	if got, err := service.GetGeofence(ctx, "fence-1"); err != nil || got.Name != "Airport" {
+		t.Fatalf("get: %+v %v", got, err)
+	}
+	if _, err := service.GetGeofence(ctx, ""); !errors.Is(err, ErrGeofenceIDRequired) {
+		t.Fatalf("expected ErrGeofenceIDRequired, got %v", err)
+	}
+	if list, err := service.ListGeofences(ctx); err != nil || len(list) != 1 {
+		t.Fatalf("list: %+v %v", list, err)
+	}
+
+	if err := service.DeleteGeofence(ctx, "fence-1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(monitor.entries) != 0 || len(monitor.inside) != 0 {
+		t.Fatalf("expected fence removed from monitor")
+	}
+	if err := service.DeleteGeofence(ctx, "fence-1"); !errors.Is(err, ErrGeofenceNotFound) {
+		t.Fatalf("expected ErrGeofenceNotFound, got %v", err)
+	}
+}
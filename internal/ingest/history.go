@@ -0,0 +1,154 @@
+package ingest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// HistoryPoint is a stored location plus the row ID that orders points recorded at the
+// same instant.
+type HistoryPoint struct {
+	ID int64
+	Location
+}
+
+// HistoryCursor marks the position after which a history listing continues.
+type HistoryCursor struct {
+	RecordedAt time.Time
+	ID         int64
+}
+
+// HistoryFilter selects a driver's points oldest first. Zero times leave the range open.
+type HistoryFilter struct {
+	DriverID string
+	From     time.Time
+	To       time.Time
+	// MaxPoints, when positive, downsamples the whole [From, To) range to at most this
+	// many evenly spaced points before paging.
+	MaxPoints int
+	After     *HistoryCursor
+	Limit     int
+}
+
+// HistoryReader reads stored location history.
+type HistoryReader interface {
+	LocationHistory(ctx context.Context, filter HistoryFilter) ([]HistoryPoint, error)
+}
+
+const (
+	defaultHistoryPageSize = 500
+	maxHistoryPageSize     = 5000
+)
+
+var (
+	ErrHistoryDriverRequired = errors.New("driver id required")
+	ErrInvalidHistoryRange   = errors.New("from must be before to")
+	ErrInvalidMaxPoints      = errors.New("max points must not be negative")
+	ErrInvalidPageToken      = errors.New("invalid page token")
+)
+
+// HistoryQuery describes a page of a driver's location history.
+type HistoryQuery struct {
+	DriverID  string
+	From      time.Time
+	To        time.Time
+	MaxPoints int
+	PageSize  int
+	PageToken string
+}
+
+// HistoryPage is a page of points plus the token for the next page, if any.
+type HistoryPage struct {
+	Points        []Location
+	NextPageToken string
+}
+
+// HistoryService pages through stored location history.
+type HistoryService struct {
+	reader HistoryReader
+}
+
+// NewHistoryService constructs a HistoryService backed by reader.
+func NewHistoryService(reader HistoryReader) *HistoryService {
+	return &HistoryService{reader: reader}
+}
+
+// GetLocationHistory returns a driver's points oldest first, paginated with an opaque
+// page token. Follow-up pages must repeat the original range and MaxPoints.
+func (s *HistoryService) GetLocationHistory(ctx context.Context, query HistoryQuery) (HistoryPage, error) {
+	if query.DriverID == "" {
+		return HistoryPage{}, ErrHistoryDriverRequired
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return HistoryPage{}, ErrInvalidHistoryRange
+	}
+	if query.MaxPoints < 0 {
+		return HistoryPage{}, ErrInvalidMaxPoints
+	}
+
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultHistoryPageSize
+	}
+	if pageSize > maxHistoryPageSize {
+		pageSize = maxHistoryPageSize
+	}
+
+	filter := HistoryFilter{
+		DriverID:  query.DriverID,
+		From:      query.From,
+		To:        query.To,
+		MaxPoints: query.MaxPoints,
+		// Fetch one extra row to learn whether another page exists.
+		Limit: pageSize + 1,
+	}
+	if query.PageToken != "" {
+		cursor, err := decodeHistoryToken(query.PageToken)
+		if err != nil {
+			return HistoryPage{}, err
+		}
+		filter.After = &cursor
+	}
+
+	points, err := s.reader.LocationHistory(ctx, filter)
+	if err != nil {
+		return HistoryPage{}, err
+	}
+
+	var page HistoryPage
+	if len(points) > pageSize {
+		points = points[:pageSize]
+		last := points[pageSize-1]
+		page.NextPageToken = encodeHistoryToken(HistoryCursor{RecordedAt: last.Timestamp, ID: last.ID})
+	}
+	page.Points = make([]Location, 0, len(points))
+	for _, point := range points {
+		page.Points = append(page.Points, point.Location)
+	}
+	return page, nil
+}
+
+type historyToken struct {
+	RecordedAt time.Time `json:"t"`
+	ID         int64     `json:"i"`
+}
+
+func encodeHistoryToken(cursor HistoryCursor) string {
+	data, _ := json.Marshal(historyToken{RecordedAt: cursor.RecordedAt.UTC(), ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeHistoryToken(token string) (HistoryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return HistoryCursor{}, ErrInvalidPageToken
+	}
+	var decoded historyToken
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID <= 0 || decoded.RecordedAt.IsZero() {
+		return HistoryCursor{}, ErrInvalidPageToken
+	}
+	return HistoryCursor{RecordedAt: decoded.RecordedAt, ID: decoded.ID}, nil
+}
@@ -0,0 +1,107 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubHistoryReader struct {
+	points  []HistoryPoint
+	err     error
+	filters []HistoryFilter
+}
+
+func (r *stubHistoryReader) LocationHistory(_ context.Context, filter HistoryFilter) ([]HistoryPoint, error) {
+	r.filters = append(r.filters, filter)
+	var out []HistoryPoint
+	for _, point := range r.points {
+		if filter.After != nil && point.ID <= filter.After.ID {
+			continue
+		}
+		if len(out) == filter.Limit {
+			break
+		}
+		out = append(out, point)
+	}
+	return out, r.err
+}
+
+func TestHistoryService_Pages(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	reader := &stubHistoryReader{}
+	for i := 1; i <= 5; i++ {
+		reader.points = append(reader.points, HistoryPoint{
+			ID:       int64(i),
+			Location: Location{DriverID: "driver-1", Lat: float64(i), Timestamp: base.Add(time.Duration(i) * time.Second)},
+		})
+	}
+	service := NewHistoryService(reader)
+
+	query := HistoryQuery{DriverID: "driver-1", From: base, To: base.Add(time.Hour), MaxPoints: 100, PageSize: 2}
+	var got []float64
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("pagination did not terminate")
+		}
+		page, err := service.GetLocationHistory(context.Background(), query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, loc := range page.Points {
+			got = append(got, loc.Lat)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		query.PageToken = page.NextPageToken
+	}
+
+	if len(got) != 5 || got[0] != 1 || got[4] != 5 {
+		t.Fatalf("unexpected points: %v", got)
+	}
+	first, second := reader.filters[0], reader.filters[1]
+	if first.Limit != 3 || first.MaxPoints != 100 || first.After != nil || !first.From.Equal(base) {
+		t.Fatalf("unexpected first filter: %+v", first)
+	}
+	if second.After == nil || second.After.ID != 2 || !second.After.RecordedAt.Equal(base.Add(2*time.Second)) {
+		t.Fatalf("unexpected cursor: %+v", second.After)
+	}
+}
+
+func TestHistoryService_Validation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	reader := &stubHistoryReader{err: errors.New("db down")}
+	service := NewHistoryService(reader)
+
+	for _, tc := range []struct {
+		query HistoryQuery
+		want  error
+	}{
+		{query: HistoryQuery{}, want: ErrHistoryDriverRequired},
+		{query: HistoryQuery{DriverID: "d", From: now, To: now}, want: ErrInvalidHistoryRange},
+		{query: HistoryQuery{DriverID: "d", MaxPoints: -1}, want: ErrInvalidMaxPoints},
+		{query: HistoryQuery{DriverID: "d", PageToken: "%%%"}, want: ErrInvalidPageToken},
+		{query: HistoryQuery{DriverID: "d", PageToken: encodeHistoryToken(HistoryCursor{})}, want: ErrInvalidPageToken},
+		{query: HistoryQuery{DriverID: "d"}, want: reader.err},
+	} {
+		if _, err := service.GetLocationHistory(context.Background(), tc.query); !errors.Is(err, tc.want) {
+			t.Fatalf("query %+v: got %v, want %v", tc.query, err, tc.want)
+		}
+	}
+	if len(reader.filters) != 1 || reader.filters[0].Limit != defaultHistoryPageSize+1 {
+		t.Fatalf("expected default page size, got %+v", reader.filters)
+	}
+
+	if _, err := service.GetLocationHistory(context.Background(), HistoryQuery{DriverID: "d", PageSize: 1 << 20}); err == nil {
+		t.Fatalf("expected reader error")
+	}
+	if got := reader.filters[1].Limit; got != maxHistoryPageSize+1 {
+		t.Fatalf("expected page size cap, got %d", got)
+	}
+}
@@ -73,3 +73,124 @@ func TestIngest_ReturnsPublishError(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestIngest_RejectsDuplicateStaleAndFuturePoints(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	publisher := &recordingPublisher{}
+	service := NewIngestServiceWithConfig(publisher, IngestConfig{MaxFutureSkew: 10 * time.Second})
+	service.now = func() time.Time { return now }
+
+	at := func(driverID string, offset time.Duration) Location {
+		return Location{DriverID: driverID, Lat: 1, Long: 2, Timestamp: now.Add(offset)}
+	}
+	steps := []struct {
+		loc    Location
+		want   error
+		reason string
+	}{
+		{loc: at("driver-1", -time.Minute)},
+		{loc: at("driver-1", -time.Minute), want: ErrDuplicateLocation, reason: RejectDuplicate},
+		{loc: at("driver-1", -2*time.Minute), want: ErrStaleLocation, reason: RejectStale},
+		{loc: at("driver-2", -2*time.Minute)},
+		{loc: at("driver-1", 5*time.Second)},
+		{loc: at("driver-1", 11*time.Second), want: ErrFutureTimestamp, reason: RejectFutureTimestamp},
+	}
+	for i, step := range steps {
+		err := service.Ingest(context.Background(), step.loc)
+		if !errors.Is(err, step.want) {
+			t.Fatalf("step %d: got %v, want %v", i, err, step.want)
+		}
+		if RejectionReason(err) != step.reason || IsRejected(err) != (step.reason != "") {
+			t.Fatalf("step %d: unexpected rejection reason %q", i, RejectionReason(err))
+		}
+	}
+	if len(publisher.published) != 3 {
+		t.Fatalf("expected 3 published points, got %+v", publisher.published)
+	}
+}
+
+func TestIngest_EvictsDriversSilentLongerThanHistoryTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	publisher := &recordingPublisher{}
+	service := NewIngestServiceWithConfig(publisher, IngestConfig{HistoryTTL: time.Minute})
+	service.now = func() time.Time { return now }
+
+	for _, driverID := range []string{"driver-1", "driver-2", "driver-3"} {
+		if err := service.Ingest(context.Background(), Location{DriverID: driverID, Timestamp: now}); err != nil {
+			t.Fatalf("ingest %s: %v", driverID, err)
+		}
+	}
+
+	now = now.Add(45 * time.Second)
+	if err := service.Ingest(context.Background(), Location{DriverID: "driver-1", Timestamp: now}); err != nil {
+		t.Fatalf("ingest driver-1: %v", err)
+	}
+
+	// driver-2 and driver-3 have been silent for longer than the TTL; driver-1 has not.
+	now = now.Add(30 * time.Second)
+	if err := service.Ingest(context.Background(), Location{DriverID: "driver-4", Timestamp: now}); err != nil {
+		t.Fatalf("ingest driver-4: %v", err)
+	}
+	service.mu.Lock()
+	remaining := len(service.last)
+	_, kept := service.last["driver-1"]
+	service.mu.Unlock()
+	if remaining != 2 || !kept {
+		t.Fatalf("expected driver-1 and driver-4 left, got %d entries (driver-1 kept: %v)", remaining, kept)
+	}
+
+	// An evicted driver starts afresh, so even a point older than its last one is accepted.
+	old := Location{DriverID: "driver-2", Timestamp: now.Add(-2 * time.Minute)}
+	if err := service.Ingest(context.Background(), old); err != nil {
+		t.Fatalf("expected evicted driver to start afresh, got %v", err)
+	}
+}
+
+func TestIngest_FillsMissingTimestampWithServerTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	publisher := &recordingPublisher{}
+	service := NewIngestService(publisher)
+	service.now = func() time.Time { return now }
+
+	if err := service.Ingest(context.Background(), Location{DriverID: "driver-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := publisher.published[0].Timestamp; !got.Equal(now) {
+		t.Fatalf("expected server receive time, got %v", got)
+	}
+}
+
+func TestIngest_FailedPublishCanBeRetried(t *testing.T) {
+	t.Parallel()
+
+	publisher := &recordingPublisher{err: errors.New("store down")}
+	service := NewIngestService(publisher)
+	loc := Location{DriverID: "driver-1", Timestamp: time.Now().Add(-time.Second)}
+
+	if err := service.Ingest(context.Background(), loc); err == nil || IsRejected(err) {
+		t.Fatalf("expected publish error, got %v", err)
+	}
+	publisher.err = nil
+	if err := service.Ingest(context.Background(), loc); err != nil {
+		t.Fatalf("expected retry to be accepted, got %v", err)
+	}
+}
+
+type recordingPublisher struct {
+	published []Location
+	err       error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, loc Location) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, loc)
+	return nil
+}
@@ -10,6 +10,9 @@ type Location struct {
 	Lat       float64
 	Long      float64
 	Timestamp time.Time
+	// VehicleType selects per-vehicle validation limits, e.g. "bike" or "car". It is not
+	// persisted.
+	VehicleType string
 }
 
 // NewLocation constructs a Location with validation on input fields.
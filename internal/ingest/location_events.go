@@ -0,0 +1,52 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParseLocationEvent decodes an entry written to the location stream by
+// RedisLocationStore.
+func ParseLocationEvent(values map[string]any) (Location, error) {
+	driverID, _ := values["driver_id"].(string)
+	lat, err := parseStreamFloat(values, "lat")
+	if err != nil {
+		return Location{}, err
+	}
+	long, err := parseStreamFloat(values, "long")
+	if err != nil {
+		return Location{}, err
+	}
+	raw, _ := values["timestamp"].(string)
+	timestamp, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return Location{}, fmt.Errorf("timestamp: %w", err)
+	}
+	return NewLocation(driverID, lat, long, timestamp)
+}
+
+func parseStreamFloat(values map[string]any, field string) (float64, error) {
+	raw, ok := values[field].(string)
+	if !ok {
+		return 0, fmt.Errorf("%s: missing", field)
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", field, err)
+	}
+	return v, nil
+}
+
+// LocationEventHandler adapts a location callback to StreamHandler. Entries that do
+// not decode to a valid Location are rejected as poison messages.
+func LocationEventHandler(fn func(ctx context.Context, loc Location) error) StreamHandler {
+	return StreamHandlerFunc(func(ctx context.Context, msg StreamMessage) error {
+		loc, err := ParseLocationEvent(msg.Values)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrPoisonMessage, err)
+		}
+		return fn(ctx, loc)
+	})
+}
@@ -0,0 +1,65 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLocationEvent(t *testing.T) {
+	t.Parallel()
+
+	loc, err := ParseLocationEvent(map[string]any{
+		"driver_id": "driver-1",
+		"lat":       "12.34",
+		"long":      "56.78",
+		"timestamp": "2024-01-02T03:04:05.5Z",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := Location{DriverID: "driver-1", Lat: 12.34, Long: 56.78, Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 500_000_000, time.UTC)}
+	if loc != want {
+		t.Fatalf("got %+v, want %+v", loc, want)
+	}
+
+	bad := []map[string]any{
+		{"driver_id": "d1", "long": "1", "timestamp": "2024-01-02T03:04:05Z"},
+		{"driver_id": "d1", "lat": "x", "long": "1", "timestamp": "2024-01-02T03:04:05Z"},
+		{"driver_id": "d1", "lat": "1", "long": "1", "timestamp": "yesterday"},
+		{"driver_id": "d1", "lat": "91", "long": "1", "timestamp": "2024-01-02T03:04:05Z"},
+		{"lat": "1", "long": "1", "timestamp": "2024-01-02T03:04:05Z"},
+	}
+	for i, values := range bad {
+		if _, err := ParseLocationEvent(values); err == nil {
+			t.Fatalf("case %d: expected error for %v", i, values)
+		}
+	}
+}
+
+func TestLocationEventHandler(t *testing.T) {
+	t.Parallel()
+
+	var got []Location
+	h := LocationEventHandler(func(ctx context.Context, loc Location) error {
+		got = append(got, loc)
+		return nil
+	})
+	ctx := context.Background()
+	ok := StreamMessage{Values: map[string]any{"driver_id": "d1", "lat": "1", "long": "2", "timestamp": "2024-01-02T03:04:05Z"}}
+	if err := h.HandleStreamMessage(ctx, ok); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if len(got) != 1 || got[0].DriverID != "d1" || got[0].Long != 2 {
+		t.Fatalf("unexpected locations: %+v", got)
+	}
+
+	err := h.HandleStreamMessage(ctx, StreamMessage{Values: map[string]any{"driver_id": "d1"}})
+	if !errors.Is(err, ErrPoisonMessage) {
+		t.Fatalf("expected ErrPoisonMessage, got %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("callback should not run for undecodable entries")
+	}
+}
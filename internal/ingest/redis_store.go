@@ -7,13 +7,17 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-// RedisLocationStore stores latest locations in Redis and appends to a stream.
+// RedisLocationStore stores latest locations in Redis and appends to a stream. Each update
+// also refreshes the driver's entry in the GEO set queried by RedisGeoIndex.
 type RedisLocationStore struct {
 	client    RedisPipelineClient
 	stream    string
 	keyPrefix string
+	geoKey    string
+	seenKey   string
 	ttl       time.Duration
 	maxLen    int64
+	now       func() time.Time
 }
 
 // RedisPipelineClient is the minimal client surface used by RedisLocationStore.
@@ -25,6 +29,8 @@ type RedisPipelineClient interface {
 type RedisPipeliner interface {
 	HSet(ctx context.Context, key string, values ...any) *redis.IntCmd
 	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	GeoAdd(ctx context.Context, key string, geoLocation ...*redis.GeoLocation) *redis.IntCmd
+	ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd
 	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
 	Exec(ctx context.Context) ([]redis.Cmder, error)
 }
@@ -38,8 +44,11 @@ func NewRedisLocationStore(client RedisPipelineClient, stream string, ttl time.D
 		client:    client,
 		stream:    stream,
 		keyPrefix: "driver:",
+		geoKey:    DefaultGeoKey,
+		seenKey:   DefaultGeoKey + seenSuffix,
 		ttl:       ttl,
 		maxLen:    maxLen,
+		now:       time.Now,
 	}
 }
 
@@ -62,6 +71,12 @@ func (r *RedisLocationStore) Update(ctx context.Context, loc Location) error {
 	if r.ttl > 0 {
 		pipe.Expire(ctx, key, r.ttl)
 	}
+	// GEOADD rejects latitudes beyond the Web Mercator limit, so polar fixes are
+	// kept out of the index rather than failing the whole update.
+	if loc.Lat >= -maxGeoLatitude && loc.Lat <= maxGeoLatitude {
+		pipe.GeoAdd(ctx, r.geoKey, &redis.GeoLocation{Name: loc.DriverID, Longitude: loc.Long, Latitude: loc.Lat})
+		pipe.ZAdd(ctx, r.seenKey, redis.Z{Score: float64(r.now().UnixMilli()), Member: loc.DriverID})
+	}
 
 	args := &redis.XAddArgs{
 		Stream: r.stream,
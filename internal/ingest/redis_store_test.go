@@ -39,6 +39,14 @@ func TestRedisLocationStore_UpdatesHashAndStream(t *testing.T) {
 		t.Fatalf("unexpected hash values: %+v", hash)
 	}
 
+	geoAdds := pipe.geoAdds[DefaultGeoKey]
+	if len(geoAdds) != 1 || geoAdds[0].Name != "driver-1" || geoAdds[0].Latitude != 12.34 || geoAdds[0].Longitude != 56.78 {
+		t.Fatalf("unexpected GEOADD: %+v", pipe.geoAdds)
+	}
+	if seen := pipe.zadds[DefaultGeoKey+":seen"]; len(seen) != 1 || seen[0].Member != "driver-1" {
+		t.Fatalf("unexpected seen ZADD: %+v", pipe.zadds)
+	}
+
 	if len(pipe.xadds) != 1 {
 		t.Fatalf("expected 1 XADD, got %d", len(pipe.xadds))
 	}
@@ -98,6 +106,23 @@ func TestRedisLocationStore_TTLMaxLenAndDefaultStream(t *testing.T) {
 	}
 }
 
+func TestRedisLocationStore_SkipsGeoIndexForPolarLatitudes(t *testing.T) {
+	t.Parallel()
+
+	pipe := &stubPipeline{}
+	store := NewRedisLocationStore(&stubRedisClient{pipe: pipe}, "location_events", 0, 0)
+
+	if err := store.Update(context.Background(), Location{DriverID: "driver-pole", Lat: 89.9, Long: 10}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(pipe.geoAdds) != 0 || len(pipe.zadds) != 0 {
+		t.Fatalf("expected no geo index writes, got %+v %+v", pipe.geoAdds, pipe.zadds)
+	}
+	if len(pipe.hsets) != 1 || len(pipe.xadds) != 1 {
+		t.Fatalf("expected hash and stream writes to proceed")
+	}
+}
+
 func TestRedisLocationStore_RespectsCanceledContext(t *testing.T) {
 	t.Parallel()
 
@@ -134,6 +159,8 @@ type stubPipeline struct {
 	}
 	expirations     map[string]time.Duration
 	expirationCalls int
+	geoAdds         map[string][]redis.GeoLocation
+	zadds           map[string][]redis.Z
 	xadds           []redis.XAddArgs
 	execCalled      bool
 	execErr         error
@@ -156,6 +183,24 @@ func (s *stubPipeline) Expire(_ context.Context, key string, ttl time.Duration)
 	return redis.NewBoolCmd(context.Background())
 }
 
+func (s *stubPipeline) GeoAdd(_ context.Context, key string, locs ...*redis.GeoLocation) *redis.IntCmd {
+	if s.geoAdds == nil {
+		s.geoAdds = map[string][]redis.GeoLocation{}
+	}
+	for _, loc := range locs {
+		s.geoAdds[key] = append(s.geoAdds[key], *loc)
+	}
+	return redis.NewIntCmd(context.Background())
+}
+
+func (s *stubPipeline) ZAdd(_ context.Context, key string, members ...redis.Z) *redis.IntCmd {
+	if s.zadds == nil {
+		s.zadds = map[string][]redis.Z{}
+	}
+	s.zadds[key] = append(s.zadds[key], members...)
+	return redis.NewIntCmd(context.Background())
+}
+
 func (s *stubPipeline) XAdd(_ context.Context, a *redis.XAddArgs) *redis.StringCmd {
 	s.xadds = append(s.xadds, *a)
 	return redis.NewStringCmd(context.Background())
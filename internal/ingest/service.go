@@ -1,23 +1,240 @@
 package ingest
 
-import "context"
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
 
 // LocationPublisher abstracts publishing driver location events.
 type LocationPublisher interface {
 	Publish(ctx context.Context, loc Location) error
 }
 
-// IngestService receives location updates and forwards them to a publisher.
+// LocationValidator checks a point against the driver's previous accepted point before
+// it is published. prev is the zero Location for a driver's first point. Validators run
+// while the driver's history is locked and must not block.
+type LocationValidator interface {
+	ValidateLocation(prev, next Location) error
+}
+
+var (
+	// ErrDuplicateLocation rejects a point with the same timestamp as the driver's last
+	// accepted point, typically a client retry.
+	ErrDuplicateLocation = errors.New("duplicate location")
+	// ErrStaleLocation rejects a point older than the driver's last accepted point, e.g. a
+	// delayed packet that would overwrite a newer position.
+	ErrStaleLocation = errors.New("location older than last accepted")
+	// ErrFutureTimestamp rejects a point stamped further ahead than the allowed clock skew.
+	ErrFutureTimestamp = errors.New("location timestamp too far in the future")
+)
+
+// Rejection reasons reported by RejectedError.
+const (
+	RejectDuplicate        = "duplicate"
+	RejectStale            = "stale"
+	RejectFutureTimestamp  = "future_timestamp"
+	RejectImplausibleSpeed = "implausible_speed"
+	RejectInvalid          = "invalid"
+)
+
+// RejectedError reports a point that was dropped by ingest validation. Retrying it will
+// not succeed.
+type RejectedError struct {
+	Reason string
+	Err    error
+}
+
+func (e *RejectedError) Error() string { return e.Err.Error() }
+
+func (e *RejectedError) Unwrap() error { return e.Err }
+
+// IsRejected reports whether err rejects the point itself, as opposed to a publish
+// failure that may succeed on retry.
+func IsRejected(err error) bool {
+	return RejectionReason(err) != ""
+}
+
+// RejectionReason returns the reason a point was rejected, or "" when err is not a
+// rejection.
+func RejectionReason(err error) string {
+	var rejected *RejectedError
+	if errors.As(err, &rejected) {
+		return rejected.Reason
+	}
+	return ""
+}
+
+// IngestConfig tunes point validation in IngestService.
+type IngestConfig struct {
+	// MaxFutureSkew is how far ahead of server time a point may be stamped. Defaults to 30s.
+	MaxFutureSkew time.Duration
+	// HistoryTTL is how long a driver's last accepted point is kept for validation after
+	// it was received. A driver silent for longer starts afresh. Defaults to 10m.
+	HistoryTTL time.Duration
+	// Validators run in order after the ordering checks. Errors that are not already a
+	// RejectedError are reported with reason RejectInvalid.
+	Validators []LocationValidator
+	// RebaseAfter is how many consecutive implausible-speed rejections make the last
+	// rejected point the driver's baseline. A glitch accepted as a driver's first point
+	// would otherwise lock out every real point until HistoryTTL. Defaults to 3.
+	RebaseAfter int
+	// OnReject, when set, is called with the reason of every rejected point.
+	OnReject func(reason string)
+}
+
+// IngestService receives location updates and forwards them to a publisher. It keeps
+// the last accepted point per driver so delayed, retried or implausible points are
+// dropped instead of overwriting newer data. Points older than HistoryTTL are swept
+// out as new points arrive, so drivers that go offline do not accumulate.
 type IngestService struct {
 	publisher LocationPublisher
+	cfg       IngestConfig
+	now       func() time.Time
+
+	mu        sync.Mutex
+	last      map[string]accepted
+	nextSweep time.Time
+}
+
+// accepted is a driver's last accepted point and the server time it was received.
+// rejects counts implausible-speed rejections against loc since then.
+type accepted struct {
+	loc     Location
+	at      time.Time
+	rejects int
 }
 
-// NewIngestService constructs an IngestService with the given publisher.
+// NewIngestService constructs an IngestService with the given publisher and default config.
 func NewIngestService(publisher LocationPublisher) *IngestService {
-	return &IngestService{publisher: publisher}
+	return NewIngestServiceWithConfig(publisher, IngestConfig{})
+}
+
+// NewIngestServiceWithConfig constructs an IngestService with defaults for unset config.
+func NewIngestServiceWithConfig(publisher LocationPublisher, cfg IngestConfig) *IngestService {
+	if cfg.MaxFutureSkew <= 0 {
+		cfg.MaxFutureSkew = 30 * time.Second
+	}
+	if cfg.HistoryTTL <= 0 {
+		cfg.HistoryTTL = 10 * time.Minute
+	}
+	if cfg.RebaseAfter <= 0 {
+		cfg.RebaseAfter = 3
+	}
+	return &IngestService{publisher: publisher, cfg: cfg, now: time.Now, last: make(map[string]accepted)}
 }
 
-// Ingest forwards the location to the configured publisher.
+// Ingest validates the point against the driver's history and forwards it to the
+// configured publisher. A zero timestamp is replaced with the server receive time.
+// Rejected points return a *RejectedError.
 func (s *IngestService) Ingest(ctx context.Context, loc Location) error {
-	return s.publisher.Publish(ctx, loc)
+	now := s.now()
+	if loc.Timestamp.IsZero() {
+		loc.Timestamp = now
+	}
+	if loc.Timestamp.After(now.Add(s.cfg.MaxFutureSkew)) {
+		return s.reject(RejectFutureTimestamp, ErrFutureTimestamp)
+	}
+
+	prev, err := s.reserve(loc, now)
+	if err != nil {
+		return err
+	}
+	if err := s.publisher.Publish(ctx, loc); err != nil {
+		s.release(loc, prev)
+		return err
+	}
+	return nil
+}
+
+// reserve records loc as the driver's latest point before publishing, so a concurrent
+// older point for the same driver is rejected rather than racing it into storage.
+func (s *IngestService) reserve(loc Location, now time.Time) (Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	entry, seen := s.last[loc.DriverID]
+	if seen && now.Sub(entry.at) > s.cfg.HistoryTTL {
+		seen = false
+	}
+	var prev Location
+	if seen {
+		prev = entry.loc
+	}
+	switch {
+	case seen && loc.Timestamp.Equal(prev.Timestamp):
+		return prev, s.reject(RejectDuplicate, ErrDuplicateLocation)
+	case seen && loc.Timestamp.Before(prev.Timestamp):
+		return prev, s.reject(RejectStale, ErrStaleLocation)
+	}
+	for _, validator := range s.cfg.Validators {
+		if err := validator.ValidateLocation(prev, loc); err != nil {
+			reason := RejectionReason(err)
+			if reason == "" {
+				reason = RejectInvalid
+			}
+			if seen && reason == RejectImplausibleSpeed {
+				s.countSpeedReject(entry, loc, now)
+			}
+			return prev, s.reject(reason, err)
+		}
+	}
+	s.last[loc.DriverID] = accepted{loc: loc, at: now}
+	return prev, nil
+}
+
+// countSpeedReject records a speed rejection against entry. Once RebaseAfter points in
+// a row are too far from the baseline, the baseline is the likelier glitch, so loc
+// replaces it, unpublished, and the next point is validated against loc.
+func (s *IngestService) countSpeedReject(entry accepted, loc Location, now time.Time) {
+	entry.rejects++
+	if entry.rejects >= s.cfg.RebaseAfter {
+		entry = accepted{loc: loc, at: now}
+	}
+	s.last[loc.DriverID] = entry
+}
+
+// sweep drops drivers whose last point is older than HistoryTTL. It walks the map at
+// most once per half TTL, so the cost is amortized over the points in between.
+func (s *IngestService) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(s.cfg.HistoryTTL / 2)
+	for driverID, entry := range s.last {
+		if now.Sub(entry.at) > s.cfg.HistoryTTL {
+			delete(s.last, driverID)
+		}
+	}
+}
+
+// release undoes a reservation whose publish failed, unless a newer point has been
+// accepted since.
+func (s *IngestService) release(loc Location, prev Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.last[loc.DriverID]
+	if !entry.loc.Timestamp.Equal(loc.Timestamp) {
+		return
+	}
+	if prev.DriverID == "" {
+		delete(s.last, loc.DriverID)
+		return
+	}
+	// prev's own receive time was not kept; it expires along with the failed point's.
+	s.last[loc.DriverID] = accepted{loc: prev, at: entry.at}
+}
+
+func (s *IngestService) reject(reason string, err error) error {
+	if s.cfg.OnReject != nil {
+		s.cfg.OnReject(reason)
+	}
+	var rejected *RejectedError
+	if errors.As(err, &rejected) {
+		return err
+	}
+	return &RejectedError{Reason: reason, Err: err}
 }
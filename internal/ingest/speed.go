@@ -0,0 +1,64 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+
+	"wayfinder/internal/geo"
+)
+
+// ErrImplausibleSpeed rejects a point that implies the driver moved faster than their
+// vehicle can, usually a GPS glitch.
+var ErrImplausibleSpeed = errors.New("implausible speed")
+
+// SpeedLimit bounds how fast a vehicle may appear to move between accepted points.
+type SpeedLimit struct {
+	// MaxSpeedMps is the highest plausible speed in meters per second.
+	MaxSpeedMps float64
+	// JitterMeters is movement that is always accepted regardless of time delta, so GPS
+	// noise between closely spaced points is not mistaken for speed.
+	JitterMeters float64
+}
+
+// SpeedValidator rejects points whose haversine distance from the previous accepted
+// point over the elapsed time exceeds the vehicle type's limit.
+type SpeedValidator struct {
+	fallback SpeedLimit
+	byType   map[string]SpeedLimit
+}
+
+// DefaultSpeedLimit allows 250 km/h with 100 m of jitter.
+var DefaultSpeedLimit = SpeedLimit{MaxSpeedMps: 250 / 3.6, JitterMeters: 100}
+
+// NewSpeedValidator constructs a SpeedValidator. Points whose VehicleType has no entry in
+// byType use fallback; a zero fallback uses DefaultSpeedLimit.
+func NewSpeedValidator(fallback SpeedLimit, byType map[string]SpeedLimit) *SpeedValidator {
+	if fallback.MaxSpeedMps <= 0 {
+		fallback = DefaultSpeedLimit
+	}
+	return &SpeedValidator{fallback: fallback, byType: byType}
+}
+
+// ValidateLocation implements LocationValidator.
+func (v *SpeedValidator) ValidateLocation(prev, next Location) error {
+	if prev.DriverID == "" {
+		return nil
+	}
+	limit, ok := v.byType[next.VehicleType]
+	if !ok {
+		limit = v.fallback
+	}
+
+	meters := geo.Distance(geo.Point{Lat: prev.Lat, Long: prev.Long}, geo.Point{Lat: next.Lat, Long: next.Long})
+	if meters <= limit.JitterMeters {
+		return nil
+	}
+	seconds := next.Timestamp.Sub(prev.Timestamp).Seconds()
+	if seconds > 0 && meters/seconds <= limit.MaxSpeedMps {
+		return nil
+	}
+	return &RejectedError{
+		Reason: RejectImplausibleSpeed,
+		Err:    fmt.Errorf("%w: moved %.0f m in %.1f s", ErrImplausibleSpeed, meters, seconds),
+	}
+}
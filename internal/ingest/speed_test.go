@@ -0,0 +1,128 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSpeedValidator(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	prev := Location{DriverID: "driver-1", Lat: 52.52, Long: 13.405, Timestamp: base}
+	validator := NewSpeedValidator(SpeedLimit{}, map[string]SpeedLimit{
+		"bike": {MaxSpeedMps: 10, JitterMeters: 20},
+	})
+
+	// ~1.1 km north of prev.
+	moved := func(vehicle string, after time.Duration) Location {
+		return Location{DriverID: "driver-1", Lat: 52.53, Long: 13.405, Timestamp: base.Add(after), VehicleType: vehicle}
+	}
+
+	cases := []struct {
+		name string
+		prev Location
+		next Location
+		ok   bool
+	}{
+		{name: "first point", next: moved("bike", 0), ok: true},
+		{name: "car at 66 km/h", prev: prev, next: moved("", time.Minute), ok: true},
+		{name: "bike at 66 km/h", prev: prev, next: moved("bike", time.Minute)},
+		{name: "bike at 26 km/h", prev: prev, next: moved("bike", 150*time.Second), ok: true},
+		{name: "unknown type uses default", prev: prev, next: moved("truck", time.Minute), ok: true},
+		{name: "teleport", prev: prev, next: Location{DriverID: "driver-1", Lat: 48.137, Long: 11.575, Timestamp: base.Add(time.Minute)}},
+		{name: "jitter without time delta", prev: prev, next: Location{DriverID: "driver-1", Lat: 52.5201, Long: 13.405, Timestamp: base}, ok: true},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := validator.ValidateLocation(tc.prev, tc.next)
+			if tc.ok {
+				if err != nil {
+					t.Fatalf("unexpected rejection: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrImplausibleSpeed) || RejectionReason(err) != RejectImplausibleSpeed {
+				t.Fatalf("expected implausible speed rejection, got %v", err)
+			}
+		})
+	}
+}
+
+func TestIngest_SpeedValidatorKeepsLastGoodPoint(t *testing.T) {
+	t.Parallel()
+
+	base := time.Now().Add(-time.Hour)
+	publisher := &recordingPublisher{}
+	var reasons []string
+	service := NewIngestServiceWithConfig(publisher, IngestConfig{
+		Validators: []LocationValidator{NewSpeedValidator(SpeedLimit{}, nil)},
+		OnReject:   func(reason string) { reasons = append(reasons, reason) },
+	})
+
+	points := []Location{
+		{DriverID: "driver-1", Lat: 52.52, Long: 13.405, Timestamp: base},
+		{DriverID: "driver-1", Lat: 48.137, Long: 11.575, Timestamp: base.Add(10 * time.Second)},
+		{DriverID: "driver-1", Lat: 52.521, Long: 13.405, Timestamp: base.Add(20 * time.Second)},
+	}
+	for _, loc := range points {
+		_ = service.Ingest(context.Background(), loc)
+	}
+
+	if len(publisher.published) != 2 || publisher.published[1].Lat != 52.521 {
+		t.Fatalf("expected glitch to be dropped, got %+v", publisher.published)
+	}
+	if len(reasons) != 1 || reasons[0] != RejectImplausibleSpeed {
+		t.Fatalf("unexpected rejection reasons: %v", reasons)
+	}
+}
+
+func TestIngest_GlitchAsFirstPointDoesNotLockOutDriver(t *testing.T) {
+	t.Parallel()
+
+	base := time.Now().Add(-time.Hour)
+	publisher := &recordingPublisher{}
+	service := NewIngestServiceWithConfig(publisher, IngestConfig{
+		Validators:  []LocationValidator{NewSpeedValidator(SpeedLimit{}, nil)},
+		RebaseAfter: 3,
+	})
+
+	// The first point is a glitch in Munich; the driver is really driving through Berlin.
+	glitch := Location{DriverID: "driver-1", Lat: 48.137, Long: 11.575, Timestamp: base}
+	if err := service.Ingest(context.Background(), glitch); err != nil {
+		t.Fatalf("first point: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		loc := Location{DriverID: "driver-1", Lat: 52.52 + float64(i)*0.001, Long: 13.405, Timestamp: base.Add(time.Duration(i) * 10 * time.Second)}
+		err := service.Ingest(context.Background(), loc)
+		if i <= 3 && RejectionReason(err) != RejectImplausibleSpeed {
+			t.Fatalf("point %d: expected implausible speed against the glitch, got %v", i, err)
+		}
+		if i == 4 && err != nil {
+			t.Fatalf("point %d: expected acceptance once the baseline moved, got %v", i, err)
+		}
+	}
+	if len(publisher.published) != 2 || publisher.published[1].Lat != 52.524 {
+		t.Fatalf("expected the glitch and the first real point after rebasing, got %+v", publisher.published)
+	}
+}
+
+type rejectAll struct{ err error }
+
+func (v rejectAll) ValidateLocation(Location, Location) error { return v.err }
+
+func TestIngest_PlainValidatorErrorsAreInvalid(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("outside service area")
+	service := NewIngestServiceWithConfig(&recordingPublisher{}, IngestConfig{Validators: []LocationValidator{rejectAll{err: cause}}})
+
+	err := service.Ingest(context.Background(), Location{DriverID: "driver-1"})
+	if !errors.Is(err, cause) || RejectionReason(err) != RejectInvalid {
+		t.Fatalf("expected invalid rejection wrapping cause, got %v", err)
+	}
+}
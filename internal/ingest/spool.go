@@ -0,0 +1,544 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wayfinder/internal/logging"
+)
+
+var (
+	// ErrSpoolFull is returned when spooling a location would exceed SpoolConfig.MaxBytes.
+	ErrSpoolFull = errors.New("location spool is full")
+	// ErrSpoolClosed is returned by Append after Close.
+	ErrSpoolClosed = errors.New("location spool closed")
+	// ErrSpoolDirRequired is returned when no spool directory is configured.
+	ErrSpoolDirRequired = errors.New("spool directory is required")
+)
+
+const (
+	spoolSegmentExt  = ".seg"
+	spoolCursorFile  = "cursor"
+	spoolHeaderBytes = 8
+	// spoolMaxRecord bounds a single record so a corrupt length cannot trigger a huge
+	// allocation during replay.
+	spoolMaxRecord = 1 << 20
+	// spoolCursorEvery is how many replayed records may go by before the replay
+	// position is persisted; a crash replays at most this many records twice.
+	spoolCursorEvery = 256
+)
+
+var errSpoolTorn = errors.New("torn spool record")
+
+// SpoolConfig tunes SpoolStore.
+type SpoolConfig struct {
+	// Dir holds the segment files. It is created if missing.
+	Dir string
+	// SegmentBytes is the size at which the active segment is sealed and a new one
+	// started. Defaults to 4 MiB.
+	SegmentBytes int64
+	// MaxBytes caps the spool on disk; further writes fail with ErrSpoolFull.
+	// Defaults to 256 MiB.
+	MaxBytes int64
+	// ReplayInterval is how often Run retries spooled writes. Defaults to 5s.
+	ReplayInterval time.Duration
+	// ReplayTo receives replayed records instead of the store writes go through to.
+	// Replay trusts a nil error as delivered, so backends that queue writes and report
+	// failures later, such as batched writers, need a synchronous store here.
+	ReplayTo LocationStore
+	// OnDepth is called with the number of spooled records and their size on disk
+	// whenever either changes.
+	OnDepth func(records, bytes int64)
+}
+
+type spoolSegment struct {
+	seq     uint64
+	path    string
+	size    int64
+	records int64
+	// offset is where the next record to replay starts.
+	offset int64
+}
+
+// SpoolStore is a LocationStore that writes through to next and, when next fails,
+// appends the location to a local write-ahead spool instead of returning the error.
+// Once anything is spooled, later locations are spooled behind it so the backend
+// sees every location in order; Run replays the spool as soon as next accepts writes
+// again. Replay is at-least-once: a crash mid-replay may resend a few records.
+type SpoolStore struct {
+	next   LocationStore
+	replay LocationStore
+	cfg    SpoolConfig
+	logf   func(format string, args ...any)
+
+	replayMu sync.Mutex
+
+	mu       sync.Mutex
+	segments []*spoolSegment
+	active   *os.File
+	nextSeq  uint64
+	records  int64
+	bytes    int64
+	closed   bool
+}
+
+// OpenSpoolStore opens or creates the spool in cfg.Dir, recovering any records left
+// by a previous process. Torn records at the end of a segment, from a crash mid-write,
+// are discarded.
+func OpenSpoolStore(next LocationStore, cfg SpoolConfig, logf func(format string, args ...any)) (*SpoolStore, error) {
+	if cfg.Dir == "" {
+		return nil, ErrSpoolDirRequired
+	}
+	if cfg.SegmentBytes <= 0 {
+		cfg.SegmentBytes = 4 << 20
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 256 << 20
+	}
+	if cfg.ReplayInterval <= 0 {
+		cfg.ReplayInterval = 5 * time.Second
+	}
+	if logf == nil {
+		logf = logging.Printf(slog.Default(), slog.LevelWarn)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &SpoolStore{next: next, replay: next, cfg: cfg, logf: logf, nextSeq: 1}
+	if cfg.ReplayTo != nil {
+		s.replay = cfg.ReplayTo
+	}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	s.reportDepth()
+	return s, nil
+}
+
+func (s *SpoolStore) recover() error {
+	paths, err := filepath.Glob(filepath.Join(s.cfg.Dir, "*"+spoolSegmentExt))
+	if err != nil {
+		return err
+	}
+	cursorSeq, cursorOffset := s.readCursor()
+
+	var segments []*spoolSegment
+	for _, path := range paths {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, &spoolSegment{seq: seq, path: path})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].seq < segments[j].seq })
+	// Never reuse a sequence the cursor may still point at.
+	s.nextSeq = cursorSeq + 1
+
+	for _, seg := range segments {
+		if seg.seq >= s.nextSeq {
+			s.nextSeq = seg.seq + 1
+		}
+		if seg.seq < cursorSeq {
+			// Fully replayed before the crash, but not yet removed.
+			if err := os.Remove(seg.path); err != nil {
+				return err
+			}
+			continue
+		}
+		if seg.seq == cursorSeq {
+			seg.offset = cursorOffset
+		}
+		if err := s.scanSegment(seg); err != nil {
+			return err
+		}
+		if seg.records == 0 {
+			if err := os.Remove(seg.path); err != nil {
+				return err
+			}
+			continue
+		}
+		s.segments = append(s.segments, seg)
+		s.records += seg.records
+		s.bytes += seg.size
+	}
+	return nil
+}
+
+// scanSegment counts the records after seg.offset and truncates a torn tail.
+func (s *SpoolStore) scanSegment(seg *spoolSegment) error {
+	f, err := os.OpenFile(seg.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if seg.offset > info.Size() {
+		seg.offset = 0
+	}
+	if _, err := f.Seek(seg.offset, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(f)
+	end := seg.offset
+	for {
+		_, n, err := readSpoolRecord(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.logf("spool segment %s: discarding %d bytes after offset %d: %v", seg.path, info.Size()-end, end, err)
+			if err := f.Truncate(end); err != nil {
+				return err
+			}
+			break
+		}
+		end += n
+		seg.records++
+	}
+	seg.size = end
+	return nil
+}
+
+// Update writes loc to the backend, spooling it if the backend fails or earlier
+// locations are still waiting to be replayed. It only returns an error when the
+// location could not be spooled either.
+func (s *SpoolStore) Update(ctx context.Context, loc Location) error {
+	s.mu.Lock()
+	spooling := s.records > 0
+	s.mu.Unlock()
+	if spooling {
+		return s.Append(loc)
+	}
+
+	err := s.next.Update(ctx, loc)
+	if err == nil {
+		return nil
+	}
+	if spoolErr := s.Append(loc); spoolErr != nil {
+		return errors.Join(err, spoolErr)
+	}
+	return nil
+}
+
+// Append writes locs to the spool for later replay, all or nothing. It is exposed for
+// backends that report failures asynchronously, such as batched writers.
+func (s *SpoolStore) Append(locs ...Location) error {
+	var buf []byte
+	for _, loc := range locs {
+		record, err := encodeSpoolRecord(loc)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, record...)
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSpoolClosed
+	}
+	if s.bytes+int64(len(buf)) > s.cfg.MaxBytes {
+		return ErrSpoolFull
+	}
+
+	seg := s.activeSegment()
+	if seg == nil || seg.size >= s.cfg.SegmentBytes {
+		var err error
+		if seg, err = s.rotate(); err != nil {
+			return err
+		}
+	}
+	if err := s.write(buf); err != nil {
+		// Drop whatever part of the write landed so the segment stays readable.
+		_ = s.active.Truncate(seg.size)
+		return err
+	}
+	seg.size += int64(len(buf))
+	seg.records += int64(len(locs))
+	s.bytes += int64(len(buf))
+	s.records += int64(len(locs))
+	s.reportDepth()
+	return nil
+}
+
+// write appends buf to the active segment and syncs it. Callers hold s.mu.
+func (s *SpoolStore) write(buf []byte) error {
+	if _, err := s.active.Write(buf); err != nil {
+		return err
+	}
+	return s.active.Sync()
+}
+
+// activeSegment returns the segment open for appends, if any. Callers hold s.mu.
+func (s *SpoolStore) activeSegment() *spoolSegment {
+	if s.active == nil || len(s.segments) == 0 {
+		return nil
+	}
+	return s.segments[len(s.segments)-1]
+}
+
+// rotate seals the active segment and starts a new one. Callers hold s.mu.
+func (s *SpoolStore) rotate() (*spoolSegment, error) {
+	if err := s.seal(); err != nil {
+		return nil, err
+	}
+	seg := &spoolSegment{seq: s.nextSeq, path: filepath.Join(s.cfg.Dir, fmt.Sprintf("%020d%s", s.nextSeq, spoolSegmentExt))}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.nextSeq++
+	s.active = f
+	s.segments = append(s.segments, seg)
+	return seg, nil
+}
+
+// seal closes the active segment so replay can read it. Callers hold s.mu.
+func (s *SpoolStore) seal() error {
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.active = nil
+	return err
+}
+
+// Depth returns the number of spooled records and their size on disk.
+func (s *SpoolStore) Depth() (records, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records, s.bytes
+}
+
+// Run replays the spool every ReplayInterval until ctx is cancelled.
+func (s *SpoolStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.Replay(ctx); err != nil && ctx.Err() == nil {
+				records, _ := s.Depth()
+				s.logf("spool replay stopped after %d records, %d still spooled: %v", n, records, err)
+			}
+		}
+	}
+}
+
+// Replay writes spooled records to the backend oldest first, deleting each segment
+// once it is fully written. It stops at the first failure so order is preserved, and
+// returns the number of records written.
+func (s *SpoolStore) Replay(ctx context.Context) (int, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	total := 0
+	for {
+		s.mu.Lock()
+		if len(s.segments) == 0 {
+			s.mu.Unlock()
+			return total, nil
+		}
+		seg := s.segments[0]
+		if s.activeSegment() == seg {
+			if err := s.seal(); err != nil {
+				s.mu.Unlock()
+				return total, err
+			}
+		}
+		s.mu.Unlock()
+
+		n, err := s.replaySegment(ctx, seg)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+func (s *SpoolStore) replaySegment(ctx context.Context, seg *spoolSegment) (int, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(seg.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(f)
+	replayed := 0
+	for {
+		loc, n, err := readSpoolRecord(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.logf("spool segment %s: dropping unreadable records after offset %d: %v", seg.path, seg.offset, err)
+			break
+		}
+		if err := s.replay.Update(ctx, loc); err != nil {
+			s.saveCursor(seg)
+			s.mu.Lock()
+			s.reportDepth()
+			s.mu.Unlock()
+			return replayed, err
+		}
+		replayed++
+
+		s.mu.Lock()
+		seg.offset += n
+		seg.records--
+		s.records--
+		s.mu.Unlock()
+		if replayed%spoolCursorEvery == 0 {
+			s.saveCursor(seg)
+		}
+	}
+
+	s.saveCursor(seg)
+	s.mu.Lock()
+	s.segments = s.segments[1:]
+	s.records -= seg.records
+	s.bytes -= seg.size
+	s.reportDepth()
+	s.mu.Unlock()
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return replayed, err
+	}
+	return replayed, nil
+}
+
+// Close seals the active segment. Spooled records stay on disk for the next process.
+func (s *SpoolStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.seal()
+}
+
+func (s *SpoolStore) reportDepth() {
+	if s.cfg.OnDepth != nil {
+		s.cfg.OnDepth(s.records, s.bytes)
+	}
+}
+
+func (s *SpoolStore) readCursor() (uint64, int64) {
+	data, err := os.ReadFile(filepath.Join(s.cfg.Dir, spoolCursorFile))
+	if err != nil {
+		return 0, 0
+	}
+	var (
+		seq    uint64
+		offset int64
+	)
+	if _, err := fmt.Sscanf(string(data), "%d %d", &seq, &offset); err != nil {
+		return 0, 0
+	}
+	return seq, offset
+}
+
+// saveCursor persists the replay position of seg so a restart resumes after the
+// records already written.
+func (s *SpoolStore) saveCursor(seg *spoolSegment) {
+	s.mu.Lock()
+	data := fmt.Sprintf("%d %d\n", seg.seq, seg.offset)
+	s.mu.Unlock()
+
+	path := filepath.Join(s.cfg.Dir, spoolCursorFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		s.logf("spool cursor: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		s.logf("spool cursor: %v", err)
+	}
+}
+
+type spoolRecord struct {
+	DriverID    string    `json:"driver_id"`
+	Lat         float64   `json:"lat"`
+	Long        float64   `json:"long"`
+	Timestamp   time.Time `json:"timestamp"`
+	VehicleType string    `json:"vehicle_type,omitempty"`
+}
+
+// encodeSpoolRecord frames loc as a big-endian length, a CRC-32 of the payload and the
+// JSON payload itself.
+func encodeSpoolRecord(loc Location) ([]byte, error) {
+	payload, err := json.Marshal(spoolRecord{
+		DriverID:    loc.DriverID,
+		Lat:         loc.Lat,
+		Long:        loc.Long,
+		Timestamp:   loc.Timestamp,
+		VehicleType: loc.VehicleType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, spoolHeaderBytes+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[spoolHeaderBytes:], payload)
+	return record, nil
+}
+
+// readSpoolRecord decodes the next record and returns its size on disk. It returns
+// io.EOF at a clean end of segment and errSpoolTorn for a partial or corrupt record.
+func readSpoolRecord(r *bufio.Reader) (Location, int64, error) {
+	var header [spoolHeaderBytes]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Location{}, 0, io.EOF
+		}
+		return Location{}, 0, errSpoolTorn
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > spoolMaxRecord {
+		return Location{}, 0, errSpoolTorn
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return Location{}, 0, errSpoolTorn
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return Location{}, 0, errSpoolTorn
+	}
+	var rec spoolRecord
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return Location{}, 0, errSpoolTorn
+	}
+	loc := Location{
+		DriverID:    rec.DriverID,
+		Lat:         rec.Lat,
+		Long:        rec.Long,
+		Timestamp:   rec.Timestamp,
+		VehicleType: rec.VehicleType,
+	}
+	return loc, int64(spoolHeaderBytes + len(payload)), nil
+}
@@ -0,0 +1,326 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errBackendDown = errors.New("backend down")
+
+type flakyStore struct {
+	mu   sync.Mutex
+	down bool
+	// failAfter lets this many further writes succeed before the store goes down.
+	failAfter int
+	got       []Location
+}
+
+func (s *flakyStore) Update(ctx context.Context, loc Location) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failAfter > 0 {
+		s.failAfter--
+		if s.failAfter == 0 {
+			defer func() { s.down = true }()
+		}
+	} else if s.down {
+		return errBackendDown
+	}
+	s.got = append(s.got, loc)
+	return nil
+}
+
+func (s *flakyStore) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+func (s *flakyStore) drivers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, len(s.got))
+	for i, loc := range s.got {
+		out[i] = loc.DriverID
+	}
+	return out
+}
+
+func spoolLoc(driverID string) Location {
+	return Location{DriverID: driverID, Lat: 52.52, Long: 13.405, Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), VehicleType: "bike"}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	return files
+}
+
+func assertDrivers(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSpoolStore_PassesThroughWhenHealthy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	backend := &flakyStore{}
+	spool, err := OpenSpoolStore(backend, SpoolConfig{Dir: dir}, t.Logf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = spool.Close() })
+
+	if err := spool.Update(context.Background(), spoolLoc("d1")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	assertDrivers(t, backend.drivers(), "d1")
+	if records, bytes := spool.Depth(); records != 0 || bytes != 0 {
+		t.Fatalf("expected empty spool, got %d records %d bytes", records, bytes)
+	}
+	if files := segmentFiles(t, dir); len(files) != 0 {
+		t.Fatalf("expected no segments, got %v", files)
+	}
+}
+
+func TestSpoolStore_SpoolsFailuresAndReplaysInOrder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	backend := &flakyStore{down: true}
+	var depths [][2]int64
+	spool, err := OpenSpoolStore(backend, SpoolConfig{
+		Dir:     dir,
+		OnDepth: func(records, bytes int64) { depths = append(depths, [2]int64{records, bytes}) },
+	}, t.Logf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = spool.Close() })
+	ctx := context.Background()
+
+	if err := spool.Update(ctx, spoolLoc("d1")); err != nil {
+		t.Fatalf("update should spool instead of failing: %v", err)
+	}
+	backend.setDown(false)
+	// The backend is back, but d2 must queue behind d1 to keep order.
+	if err := spool.Update(ctx, spoolLoc("d2")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got := backend.drivers(); len(got) != 0 {
+		t.Fatalf("expected nothing written directly while spooling, got %v", got)
+	}
+	records, bytes := spool.Depth()
+	if records != 2 || bytes == 0 {
+		t.Fatalf("unexpected depth: %d records %d bytes", records, bytes)
+	}
+
+	n, err := spool.Replay(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("replay: n=%d err=%v", n, err)
+	}
+	assertDrivers(t, backend.drivers(), "d1", "d2")
+	if got := backend.got[0]; got != spoolLoc("d1") {
+		t.Fatalf("replayed location mismatch: %+v", got)
+	}
+	if records, bytes := spool.Depth(); records != 0 || bytes != 0 {
+		t.Fatalf("expected drained spool, got %d records %d bytes", records, bytes)
+	}
+	if files := segmentFiles(t, dir); len(files) != 0 {
+		t.Fatalf("expected segments removed, got %v", files)
+	}
+	if last := depths[len(depths)-1]; last != [2]int64{0, 0} {
+		t.Fatalf("expected final depth report of zero, got %v", depths)
+	}
+
+	if err := spool.Update(ctx, spoolLoc("d3")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	assertDrivers(t, backend.drivers(), "d1", "d2", "d3")
+}
+
+func TestSpoolStore_ReplayStopsAtFirstFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	backend := &flakyStore{down: true}
+	spool, err := OpenSpoolStore(backend, SpoolConfig{Dir: dir, SegmentBytes: 1}, t.Logf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = spool.Close() })
+	ctx := context.Background()
+
+	for _, id := range []string{"d1", "d2", "d3"} {
+		if err := spool.Update(ctx, spoolLoc(id)); err != nil {
+			t.Fatalf("update %s: %v", id, err)
+		}
+	}
+	if files := segmentFiles(t, dir); len(files) != 3 {
+		t.Fatalf("expected a segment per record, got %v", files)
+	}
+
+	backend.mu.Lock()
+	backend.failAfter = 1
+	backend.mu.Unlock()
+	n, err := spool.Replay(ctx)
+	if !errors.Is(err, errBackendDown) || n != 1 {
+		t.Fatalf("expected replay to stop after 1 record, got n=%d err=%v", n, err)
+	}
+	if records, _ := spool.Depth(); records != 2 {
+		t.Fatalf("expected 2 records left, got %d", records)
+	}
+
+	backend.setDown(false)
+	if n, err := spool.Replay(ctx); err != nil || n != 2 {
+		t.Fatalf("replay: n=%d err=%v", n, err)
+	}
+	assertDrivers(t, backend.drivers(), "d1", "d2", "d3")
+}
+
+func TestSpoolStore_RecoversAfterRestart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	backend := &flakyStore{down: true}
+	spool, err := OpenSpoolStore(backend, SpoolConfig{Dir: dir}, t.Logf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	ctx := context.Background()
+	if err := spool.Append(spoolLoc("d1"), spoolLoc("d2"), spoolLoc("d3")); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	backend.mu.Lock()
+	backend.down = false
+	backend.failAfter = 1
+	backend.mu.Unlock()
+	if n, err := spool.Replay(ctx); n != 1 || err == nil {
+		t.Fatalf("expected partial replay, got n=%d err=%v", n, err)
+	}
+	if err := spool.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := spool.Append(spoolLoc("late")); !errors.Is(err, ErrSpoolClosed) {
+		t.Fatalf("expected ErrSpoolClosed, got %v", err)
+	}
+
+	// Simulate a crash mid-write with a torn record at the end of the segment.
+	files := segmentFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 segment, got %v", files)
+	}
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 40, 1, 2}); err != nil {
+		t.Fatalf("write torn record: %v", err)
+	}
+	_ = f.Close()
+
+	backend.setDown(false)
+	reopened, err := OpenSpoolStore(backend, SpoolConfig{Dir: dir}, t.Logf)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+	if records, _ := reopened.Depth(); records != 2 {
+		t.Fatalf("expected 2 recovered records, got %d", records)
+	}
+	if err := reopened.Update(ctx, spoolLoc("d4")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if n, err := reopened.Replay(ctx); err != nil || n != 3 {
+		t.Fatalf("replay: n=%d err=%v", n, err)
+	}
+	assertDrivers(t, backend.drivers(), "d1", "d2", "d3", "d4")
+}
+
+func TestSpoolStore_CapsSize(t *testing.T) {
+	t.Parallel()
+
+	record, err := encodeSpoolRecord(spoolLoc("d1"))
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	backend := &flakyStore{down: true}
+	spool, err := OpenSpoolStore(backend, SpoolConfig{Dir: t.TempDir(), MaxBytes: int64(len(record)) + 1}, t.Logf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = spool.Close() })
+	ctx := context.Background()
+
+	if err := spool.Update(ctx, spoolLoc("d1")); err != nil {
+		t.Fatalf("first update should fit: %v", err)
+	}
+	if err := spool.Update(ctx, spoolLoc("d2")); !errors.Is(err, ErrSpoolFull) {
+		t.Fatalf("expected ErrSpoolFull, got %v", err)
+	}
+	if records, bytes := spool.Depth(); records != 1 || bytes != int64(len(record)) {
+		t.Fatalf("unexpected depth: %d records %d bytes", records, bytes)
+	}
+}
+
+func TestSpoolStore_RequiresDir(t *testing.T) {
+	t.Parallel()
+
+	if _, err := OpenSpoolStore(&flakyStore{}, SpoolConfig{}, t.Logf); !errors.Is(err, ErrSpoolDirRequired) {
+		t.Fatalf("expected ErrSpoolDirRequired, got %v", err)
+	}
+}
+
+func TestSpoolStore_RunReplays(t *testing.T) {
+	t.Parallel()
+
+	backend := &flakyStore{down: true}
+	spool, err := OpenSpoolStore(backend, SpoolConfig{Dir: t.TempDir(), ReplayInterval: 5 * time.Millisecond}, t.Logf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = spool.Close() })
+	if err := spool.Update(context.Background(), spoolLoc("d1")); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		spool.Run(ctx)
+		close(done)
+	}()
+
+	backend.setDown(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if records, _ := spool.Depth(); records == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run did not drain the spool")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assertDrivers(t, backend.drivers(), "d1")
+
+	cancel()
+	<-done
+}
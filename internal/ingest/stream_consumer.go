@@ -0,0 +1,346 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"wayfinder/internal/logging"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrPoisonMessage marks a stream message that can never be handled, such as one
+	// that fails to decode. Handlers wrap it to dead-letter the message immediately
+	// instead of waiting for it to exhaust its deliveries.
+	ErrPoisonMessage = errors.New("poison message")
+	// ErrStreamGroupRequired is returned when a consumer has no group name.
+	ErrStreamGroupRequired = errors.New("stream consumer group is required")
+)
+
+// StreamMessage is a single stream entry delivered to a StreamHandler.
+type StreamMessage struct {
+	Stream string
+	ID     string
+	Values map[string]any
+	// Deliveries counts how many times the entry has been delivered to the group,
+	// including this one.
+	Deliveries int64
+}
+
+// StreamHandler processes stream messages. Returning nil acknowledges the message; any
+// other error leaves it pending so it is retried after StreamConsumerConfig.ClaimMinIdle.
+type StreamHandler interface {
+	HandleStreamMessage(ctx context.Context, msg StreamMessage) error
+}
+
+// StreamHandlerFunc adapts a function to StreamHandler.
+type StreamHandlerFunc func(ctx context.Context, msg StreamMessage) error
+
+// HandleStreamMessage calls f.
+func (f StreamHandlerFunc) HandleStreamMessage(ctx context.Context, msg StreamMessage) error {
+	return f(ctx, msg)
+}
+
+// StreamConsumerClient is the Redis surface used by StreamConsumer.
+type StreamConsumerClient interface {
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd
+	XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd
+	XAutoClaim(ctx context.Context, a *redis.XAutoClaimArgs) *redis.XAutoClaimCmd
+	XPendingExt(ctx context.Context, a *redis.XPendingExtArgs) *redis.XPendingExtCmd
+	XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+}
+
+// StreamConsumerConfig tunes StreamConsumer.
+type StreamConsumerConfig struct {
+	// Stream is the stream to read. Defaults to "location_events".
+	Stream string
+	// Consumer names this process within the group. Defaults to the hostname.
+	Consumer string
+	// StartID is where a newly created group starts reading: "$" for new entries
+	// only, "0" for the whole retained stream. Defaults to "$".
+	StartID string
+	// DeadLetterStream receives poison messages. Defaults to Stream + ":dead".
+	DeadLetterStream string
+	// BatchSize caps the entries fetched per read or claim. Defaults to 100.
+	BatchSize int64
+	// Block is how long a read waits for new entries. Defaults to 2s.
+	Block time.Duration
+	// ClaimMinIdle is how long an entry must sit unacknowledged before it is claimed
+	// from its consumer and retried. Defaults to 1m.
+	ClaimMinIdle time.Duration
+	// ClaimInterval is how often Run looks for entries to claim. Defaults to 30s.
+	ClaimInterval time.Duration
+	// MaxDeliveries is how many times an entry is tried before it is dead-lettered.
+	// Defaults to 5.
+	MaxDeliveries int64
+}
+
+// StreamConsumer reads a Redis stream as a member of a consumer group. Entries are
+// acknowledged once the handler succeeds; failed entries stay pending and are claimed
+// again, by this or any other consumer, after ClaimMinIdle. Entries that exceed
+// MaxDeliveries or are rejected with ErrPoisonMessage are copied to the dead-letter
+// stream and acknowledged. Delivery is at-least-once, so handlers must be idempotent.
+type StreamConsumer struct {
+	client  StreamConsumerClient
+	group   string
+	handler StreamHandler
+	cfg     StreamConsumerConfig
+	logf    func(format string, args ...any)
+
+	claimFrom string
+}
+
+// NewStreamConsumer constructs a consumer reading cfg.Stream as part of group.
+func NewStreamConsumer(client StreamConsumerClient, group string, handler StreamHandler, cfg StreamConsumerConfig, logf func(format string, args ...any)) *StreamConsumer {
+	if cfg.Stream == "" {
+		cfg.Stream = "location_events"
+	}
+	if cfg.Consumer == "" {
+		cfg.Consumer = defaultConsumerName()
+	}
+	if cfg.StartID == "" {
+		cfg.StartID = "$"
+	}
+	if cfg.DeadLetterStream == "" {
+		cfg.DeadLetterStream = cfg.Stream + ":dead"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Block <= 0 {
+		cfg.Block = 2 * time.Second
+	}
+	if cfg.ClaimMinIdle <= 0 {
+		cfg.ClaimMinIdle = time.Minute
+	}
+	if cfg.ClaimInterval <= 0 {
+		cfg.ClaimInterval = 30 * time.Second
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = 5
+	}
+	if logf == nil {
+		logf = logging.Printf(slog.Default(), slog.LevelWarn)
+	}
+	return &StreamConsumer{
+		client:    client,
+		group:     group,
+		handler:   handler,
+		cfg:       cfg,
+		logf:      logf,
+		claimFrom: "0-0",
+	}
+}
+
+func defaultConsumerName() string {
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "consumer-" + strconv.Itoa(os.Getpid())
+}
+
+// EnsureGroup creates the stream and consumer group if they do not exist yet.
+func (c *StreamConsumer) EnsureGroup(ctx context.Context) error {
+	if c.group == "" {
+		return ErrStreamGroupRequired
+	}
+	err := c.client.XGroupCreateMkStream(ctx, c.cfg.Stream, c.group, c.cfg.StartID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// Run creates the group, then reads and handles entries until ctx is cancelled,
+// claiming stalled entries every ClaimInterval. A blocked read may delay shutdown by
+// up to Block.
+func (c *StreamConsumer) Run(ctx context.Context) error {
+	if err := c.EnsureGroup(ctx); err != nil {
+		return err
+	}
+	nextClaim := time.Now()
+	for ctx.Err() == nil {
+		if !time.Now().Before(nextClaim) {
+			if _, err := c.Claim(ctx); err != nil && ctx.Err() == nil {
+				c.logf("stream %s claim: %v", c.cfg.Stream, err)
+			}
+			nextClaim = time.Now().Add(c.cfg.ClaimInterval)
+		}
+		if _, err := c.Poll(ctx); err != nil && ctx.Err() == nil {
+			c.logf("stream %s read: %v", c.cfg.Stream, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	return nil
+}
+
+// Poll reads one batch of new entries, waiting up to Block for them, and handles it.
+// It returns the number of entries read.
+func (c *StreamConsumer) Poll(ctx context.Context) (int, error) {
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.group,
+		Consumer: c.cfg.Consumer,
+		Streams:  []string{c.cfg.Stream, ">"},
+		Count:    c.cfg.BatchSize,
+		Block:    c.cfg.Block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			n++
+			c.handle(ctx, StreamMessage{Stream: c.cfg.Stream, ID: msg.ID, Values: msg.Values, Deliveries: 1})
+		}
+	}
+	return n, nil
+}
+
+// Claim takes over one batch of entries that have been pending for at least
+// ClaimMinIdle, from any consumer, and handles them again. Successive calls walk the
+// pending list and wrap around at its end. It returns the number of entries claimed.
+func (c *StreamConsumer) Claim(ctx context.Context) (int, error) {
+	msgs, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   c.cfg.Stream,
+		Group:    c.group,
+		Consumer: c.cfg.Consumer,
+		MinIdle:  c.cfg.ClaimMinIdle,
+		Start:    c.claimFrom,
+		Count:    c.cfg.BatchSize,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	c.claimFrom = next
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	deliveries, err := c.deliveryCounts(ctx, msgs)
+	if err != nil {
+		return 0, err
+	}
+	for _, msg := range msgs {
+		sm := StreamMessage{Stream: c.cfg.Stream, ID: msg.ID, Values: msg.Values, Deliveries: deliveries[msg.ID]}
+		if sm.Deliveries > c.cfg.MaxDeliveries {
+			c.deadLetter(ctx, sm, fmt.Errorf("exceeded %d deliveries", c.cfg.MaxDeliveries))
+			continue
+		}
+		c.handle(ctx, sm)
+	}
+	return len(msgs), nil
+}
+
+// deliveryCounts looks up how often each claimed entry has been delivered; XAUTOCLAIM
+// itself does not report it. The claimed range can also hold this consumer's own
+// entries that were too fresh to claim, so the pending list is paged until every
+// claimed entry is found. An entry still missing, e.g. one acknowledged by its previous
+// consumer in the meantime, is logged and counted as delivered once.
+func (c *StreamConsumer) deliveryCounts(ctx context.Context, msgs []redis.XMessage) (map[string]int64, error) {
+	counts := make(map[string]int64, len(msgs))
+	want := make(map[string]bool, len(msgs))
+	for _, msg := range msgs {
+		want[msg.ID] = true
+	}
+	start := msgs[0].ID
+	for len(want) > 0 {
+		pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream:   c.cfg.Stream,
+			Group:    c.group,
+			Start:    start,
+			End:      msgs[len(msgs)-1].ID,
+			Count:    int64(len(msgs)),
+			Consumer: c.cfg.Consumer,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pending {
+			if want[p.ID] {
+				counts[p.ID] = p.RetryCount
+				delete(want, p.ID)
+			}
+		}
+		next, ok := nextStreamID(pending, len(msgs))
+		if !ok {
+			break
+		}
+		start = next
+	}
+	for id := range want {
+		c.logf("stream %s entry %s: delivery count not found; counting it as delivered once", c.cfg.Stream, id)
+		counts[id] = 1
+	}
+	return counts, nil
+}
+
+// nextStreamID returns the ID just after the last entry of a full page of pending
+// entries, reporting false when the page was short and the range is exhausted.
+func nextStreamID(page []redis.XPendingExt, size int) (string, bool) {
+	if len(page) < size {
+		return "", false
+	}
+	ms, seq, ok := strings.Cut(page[len(page)-1].ID, "-")
+	if !ok {
+		return "", false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil || n == math.MaxUint64 {
+		return "", false
+	}
+	return ms + "-" + strconv.FormatUint(n+1, 10), true
+}
+
+func (c *StreamConsumer) handle(ctx context.Context, msg StreamMessage) {
+	err := c.handler.HandleStreamMessage(ctx, msg)
+	switch {
+	case err == nil:
+		c.ack(ctx, msg.ID)
+	case errors.Is(err, ErrPoisonMessage):
+		c.deadLetter(ctx, msg, err)
+	default:
+		c.logf("stream %s entry %s (delivery %d): %v", msg.Stream, msg.ID, msg.Deliveries, err)
+	}
+}
+
+func (c *StreamConsumer) ack(ctx context.Context, id string) {
+	if err := c.client.XAck(ctx, c.cfg.Stream, c.group, id).Err(); err != nil {
+		c.logf("stream %s ack %s: %v", c.cfg.Stream, id, err)
+	}
+}
+
+// deadLetter copies msg to the dead-letter stream with its origin and failure, then
+// acknowledges it. If the copy fails the entry stays pending and is retried.
+func (c *StreamConsumer) deadLetter(ctx context.Context, msg StreamMessage, cause error) {
+	values := make(map[string]any, len(msg.Values)+5)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["dead_letter_stream"] = msg.Stream
+	values["dead_letter_id"] = msg.ID
+	values["dead_letter_group"] = c.group
+	values["dead_letter_deliveries"] = msg.Deliveries
+	values["dead_letter_error"] = cause.Error()
+	if err := c.client.XAdd(ctx, &redis.XAddArgs{Stream: c.cfg.DeadLetterStream, Values: values}).Err(); err != nil {
+		c.logf("stream %s dead-letter %s: %v", msg.Stream, msg.ID, err)
+		return
+	}
+	c.logf("stream %s entry %s dead-lettered after %d deliveries: %v", msg.Stream, msg.ID, msg.Deliveries, cause)
+	c.ack(ctx, msg.ID)
+}
@@ -0,0 +1,349 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type recordingHandler struct {
+	mu   sync.Mutex
+	msgs []StreamMessage
+	errs []error
+}
+
+func (h *recordingHandler) HandleStreamMessage(ctx context.Context, msg StreamMessage) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgs = append(h.msgs, msg)
+	if len(h.errs) == 0 {
+		return nil
+	}
+	err := h.errs[0]
+	h.errs = h.errs[1:]
+	return err
+}
+
+func (h *recordingHandler) handled() []StreamMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]StreamMessage(nil), h.msgs...)
+}
+
+func newStreamTest(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func testConsumerConfig(consumer string) StreamConsumerConfig {
+	return StreamConsumerConfig{
+		Stream:        "events",
+		Consumer:      consumer,
+		StartID:       "0",
+		Block:         10 * time.Millisecond,
+		ClaimMinIdle:  time.Minute,
+		MaxDeliveries: 2,
+	}
+}
+
+func addEvent(t *testing.T, client *redis.Client, values map[string]any) string {
+	t.Helper()
+	id, err := client.XAdd(context.Background(), &redis.XAddArgs{Stream: "events", Values: values}).Result()
+	if err != nil {
+		t.Fatalf("xadd: %v", err)
+	}
+	return id
+}
+
+func pendingCount(t *testing.T, client *redis.Client) int64 {
+	t.Helper()
+	summary, err := client.XPending(context.Background(), "events", "workers").Result()
+	if err != nil {
+		t.Fatalf("xpending: %v", err)
+	}
+	return summary.Count
+}
+
+func TestStreamConsumer_PollHandlesAndAcks(t *testing.T) {
+	t.Parallel()
+
+	_, client := newStreamTest(t)
+	h := &recordingHandler{}
+	consumer := NewStreamConsumer(client, "workers", h, testConsumerConfig("c1"), t.Logf)
+	ctx := context.Background()
+	if err := consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	if err := consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("ensure group should tolerate an existing group: %v", err)
+	}
+
+	id := addEvent(t, client, map[string]any{"driver_id": "d1"})
+	addEvent(t, client, map[string]any{"driver_id": "d2"})
+
+	n, err := consumer.Poll(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("poll: n=%d err=%v", n, err)
+	}
+	got := h.handled()
+	if len(got) != 2 || got[0].ID != id || got[0].Values["driver_id"] != "d1" || got[0].Deliveries != 1 || got[0].Stream != "events" {
+		t.Fatalf("unexpected messages: %+v", got)
+	}
+	if pending := pendingCount(t, client); pending != 0 {
+		t.Fatalf("expected everything acked, %d pending", pending)
+	}
+
+	if n, err := consumer.Poll(ctx); err != nil || n != 0 {
+		t.Fatalf("expected empty poll, got n=%d err=%v", n, err)
+	}
+}
+
+func TestStreamConsumer_RequiresGroup(t *testing.T) {
+	t.Parallel()
+
+	_, client := newStreamTest(t)
+	consumer := NewStreamConsumer(client, "", &recordingHandler{}, testConsumerConfig("c1"), t.Logf)
+	if err := consumer.Run(context.Background()); !errors.Is(err, ErrStreamGroupRequired) {
+		t.Fatalf("expected ErrStreamGroupRequired, got %v", err)
+	}
+}
+
+func TestStreamConsumer_ClaimsFromStalledConsumer(t *testing.T) {
+	t.Parallel()
+
+	mr, client := newStreamTest(t)
+	ctx := context.Background()
+	dead := NewStreamConsumer(client, "workers", &recordingHandler{errs: []error{errors.New("crashed")}}, testConsumerConfig("dead"), t.Logf)
+	if err := dead.EnsureGroup(ctx); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	id := addEvent(t, client, map[string]any{"driver_id": "d1"})
+	if _, err := dead.Poll(ctx); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if pending := pendingCount(t, client); pending != 1 {
+		t.Fatalf("expected failed entry to stay pending, got %d", pending)
+	}
+
+	h := &recordingHandler{}
+	live := NewStreamConsumer(client, "workers", h, testConsumerConfig("live"), t.Logf)
+	if n, err := live.Claim(ctx); err != nil || n != 0 {
+		t.Fatalf("expected nothing claimable before min idle, got n=%d err=%v", n, err)
+	}
+
+	mr.SetTime(time.Date(2024, 1, 2, 3, 5, 6, 0, time.UTC))
+	if n, err := live.Claim(ctx); err != nil || n != 1 {
+		t.Fatalf("claim: n=%d err=%v", n, err)
+	}
+	got := h.handled()
+	if len(got) != 1 || got[0].ID != id || got[0].Deliveries != 2 {
+		t.Fatalf("unexpected claimed messages: %+v", got)
+	}
+	if pending := pendingCount(t, client); pending != 0 {
+		t.Fatalf("expected claimed entry to be acked, %d pending", pending)
+	}
+}
+
+func TestStreamConsumer_DeadLettersAfterMaxDeliveries(t *testing.T) {
+	t.Parallel()
+
+	mr, client := newStreamTest(t)
+	ctx := context.Background()
+	failing := errors.New("downstream unavailable")
+	h := &recordingHandler{errs: []error{failing, failing, failing}}
+	consumer := NewStreamConsumer(client, "workers", h, testConsumerConfig("c1"), t.Logf)
+	if err := consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	id := addEvent(t, client, map[string]any{"driver_id": "d1"})
+
+	if _, err := consumer.Poll(ctx); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		now = now.Add(2 * time.Minute)
+		mr.SetTime(now)
+		if _, err := consumer.Claim(ctx); err != nil {
+			t.Fatalf("claim %d: %v", i, err)
+		}
+	}
+	if got := h.handled(); len(got) != 2 {
+		t.Fatalf("expected 2 handler calls before dead-lettering, got %d", len(got))
+	}
+	if pending := pendingCount(t, client); pending != 0 {
+		t.Fatalf("expected dead-lettered entry to be acked, %d pending", pending)
+	}
+
+	dead, err := client.XRange(ctx, "events:dead", "-", "+").Result()
+	if err != nil {
+		t.Fatalf("xrange: %v", err)
+	}
+	if len(dead) != 1 {
+		t.Fatalf("expected 1 dead-lettered entry, got %d", len(dead))
+	}
+	v := dead[0].Values
+	if v["driver_id"] != "d1" || v["dead_letter_id"] != id || v["dead_letter_stream"] != "events" ||
+		v["dead_letter_group"] != "workers" || v["dead_letter_deliveries"] != "3" || v["dead_letter_error"] != "exceeded 2 deliveries" {
+		t.Fatalf("unexpected dead-letter entry: %v", v)
+	}
+}
+
+func TestStreamConsumer_PoisonMessageDeadLettersImmediately(t *testing.T) {
+	t.Parallel()
+
+	_, client := newStreamTest(t)
+	ctx := context.Background()
+	cfg := testConsumerConfig("c1")
+	cfg.DeadLetterStream = "events:poison"
+	h := &recordingHandler{errs: []error{ErrPoisonMessage}}
+	consumer := NewStreamConsumer(client, "workers", h, cfg, t.Logf)
+	if err := consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	addEvent(t, client, map[string]any{"garbage": "x"})
+	addEvent(t, client, map[string]any{"driver_id": "d1"})
+
+	if n, err := consumer.Poll(ctx); err != nil || n != 2 {
+		t.Fatalf("poll: n=%d err=%v", n, err)
+	}
+	if pending := pendingCount(t, client); pending != 0 {
+		t.Fatalf("expected nothing pending, got %d", pending)
+	}
+	dead, err := client.XRange(ctx, "events:poison", "-", "+").Result()
+	if err != nil {
+		t.Fatalf("xrange: %v", err)
+	}
+	if len(dead) != 1 || dead[0].Values["garbage"] != "x" || dead[0].Values["dead_letter_error"] != "poison message" {
+		t.Fatalf("unexpected dead-letter entries: %+v", dead)
+	}
+}
+
+func TestStreamConsumer_RunUntilCancelled(t *testing.T) {
+	t.Parallel()
+
+	_, client := newStreamTest(t)
+	h := &recordingHandler{}
+	consumer := NewStreamConsumer(client, "workers", h, testConsumerConfig("c1"), t.Logf)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := client.XAdd(context.Background(), &redis.XAddArgs{Stream: "events", Values: map[string]any{"driver_id": "d1"}}).Result(); err != nil {
+			t.Fatalf("xadd: %v", err)
+		}
+		if len(h.handled()) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run did not handle any entries")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("run did not stop after cancel")
+	}
+}
+
+func TestStreamConsumer_ClaimCountsDeliveriesPastOwnFreshEntries(t *testing.T) {
+	t.Parallel()
+
+	mr, client := newStreamTest(t)
+	ctx := context.Background()
+	failing := errors.New("crashed")
+	deadCfg := testConsumerConfig("dead")
+	deadCfg.BatchSize = 1
+	dead := NewStreamConsumer(client, "workers", &recordingHandler{errs: []error{failing, failing}}, deadCfg, t.Logf)
+	liveCfg := testConsumerConfig("live")
+	liveCfg.BatchSize = 1
+	poller := NewStreamConsumer(client, "workers", &recordingHandler{errs: []error{failing}}, liveCfg, t.Logf)
+	h := &recordingHandler{}
+	live := NewStreamConsumer(client, "workers", h, testConsumerConfig("live"), t.Logf)
+	if err := dead.EnsureGroup(ctx); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	first := addEvent(t, client, map[string]any{"driver_id": "d1"})
+	fresh := addEvent(t, client, map[string]any{"driver_id": "d2"})
+	last := addEvent(t, client, map[string]any{"driver_id": "d3"})
+	for _, c := range []*StreamConsumer{dead, poller, dead} {
+		if _, err := c.Poll(ctx); err != nil {
+			t.Fatalf("poll: %v", err)
+		}
+	}
+
+	// Live still holds its own entry, freshly delivered, between the two stalled ones.
+	mr.SetTime(time.Date(2024, 1, 2, 3, 6, 5, 0, time.UTC))
+	if err := client.XClaim(ctx, &redis.XClaimArgs{Stream: "events", Group: "workers", Consumer: "live", Messages: []string{fresh}}).Err(); err != nil {
+		t.Fatalf("xclaim: %v", err)
+	}
+	if n, err := live.Claim(ctx); err != nil || n != 2 {
+		t.Fatalf("claim: n=%d err=%v", n, err)
+	}
+	got := h.handled()
+	if len(got) != 2 || got[0].ID != first || got[1].ID != last {
+		t.Fatalf("unexpected claimed messages: %+v", got)
+	}
+	for _, msg := range got {
+		if msg.Deliveries != 2 {
+			t.Fatalf("expected entry %s to count 2 deliveries, got %d", msg.ID, msg.Deliveries)
+		}
+	}
+}
+
+// racingAckClient acknowledges every entry right after it is claimed, as a previous
+// consumer finishing late would.
+type racingAckClient struct {
+	*redis.Client
+}
+
+func (c racingAckClient) XAutoClaim(ctx context.Context, a *redis.XAutoClaimArgs) *redis.XAutoClaimCmd {
+	cmd := c.Client.XAutoClaim(ctx, a)
+	msgs, _, _ := cmd.Result()
+	for _, msg := range msgs {
+		c.Client.XAck(ctx, a.Stream, a.Group, msg.ID)
+	}
+	return cmd
+}
+
+func TestStreamConsumer_ClaimCountsUnlistedEntryAsDeliveredOnce(t *testing.T) {
+	t.Parallel()
+
+	mr, client := newStreamTest(t)
+	ctx := context.Background()
+	dead := NewStreamConsumer(client, "workers", &recordingHandler{errs: []error{errors.New("crashed")}}, testConsumerConfig("dead"), t.Logf)
+	if err := dead.EnsureGroup(ctx); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	id := addEvent(t, client, map[string]any{"driver_id": "d1"})
+	if _, err := dead.Poll(ctx); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+
+	h := &recordingHandler{}
+	live := NewStreamConsumer(racingAckClient{client}, "workers", h, testConsumerConfig("live"), t.Logf)
+	mr.SetTime(time.Date(2024, 1, 2, 3, 6, 5, 0, time.UTC))
+	if n, err := live.Claim(ctx); err != nil || n != 1 {
+		t.Fatalf("claim: n=%d err=%v", n, err)
+	}
+	if got := h.handled(); len(got) != 1 || got[0].ID != id || got[0].Deliveries != 1 {
+		t.Fatalf("unexpected claimed messages: %+v", got)
+	}
+}
@@ -0,0 +1,131 @@
+// Package logging builds slog loggers whose records pick up attributes carried in the
+// context, so a request ID or order ID set once appears on every line logged for it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats accepted by Config.Format.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config selects the output format and minimum level of a logger.
+type Config struct {
+	// Format is FormatText or FormatJSON; empty means text.
+	Format string
+	Level  slog.Level
+}
+
+// New returns a logger writing to w. Records logged with a context, e.g. through
+// slog.InfoContext, carry the attributes added by With and the active trace ID.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+type attrsKey struct{}
+
+// With returns a context whose log records carry args, given as in slog.Logger.With.
+// Attributes already on ctx are kept unless args replaces their key.
+func With(ctx context.Context, args ...any) context.Context {
+	// A record turns args into attributes the same way Logger.With does.
+	record := slog.NewRecord(time.Time{}, 0, "", 0)
+	record.Add(args...)
+	added := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		added = append(added, a)
+		return true
+	})
+
+	var attrs []slog.Attr
+next:
+	for _, a := range attrsFrom(ctx) {
+		for _, b := range added {
+			if a.Key == b.Key {
+				continue next
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	return context.WithValue(ctx, attrsKey{}, append(attrs, added...))
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// RequestIDKey is the attribute, and gRPC metadata key, that carries the request ID.
+const RequestIDKey = "request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context that logs id as request_id and reports it from
+// RequestID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return With(context.WithValue(ctx, requestIDKey{}, id), RequestIDKey, id)
+}
+
+// RequestID returns the request ID set by WithRequestID, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Printf adapts logger to the logf callbacks background workers take. Each call logs
+// its formatted message at level.
+func Printf(logger *slog.Logger, level slog.Level) func(format string, args ...any) {
+	return func(format string, args ...any) {
+		logger.Log(context.Background(), level, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	}
+}
+
+// contextHandler adds the attributes carried by a record's context before passing the
+// record on.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := attrsFrom(ctx)
+	if ctx != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			attrs = append(attrs[:len(attrs):len(attrs)], slog.String("trace_id", sc.TraceID().String()))
+		}
+	}
+	if len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
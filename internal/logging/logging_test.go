@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("decode %q: %v", raw, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestContextAttributesReachEveryLine(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = With(ctx, "order_id", "order-1", "idempotency_key", "key-1")
+	logger.InfoContext(ctx, "order created")
+	logger.With("component", "saga").WarnContext(With(ctx, "order_id", "order-2"), "step failed", "step", "charge")
+	logger.Info("no context")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %s", len(lines), buf.String())
+	}
+	if lines[0]["request_id"] != "req-1" || lines[0]["order_id"] != "order-1" || lines[0]["idempotency_key"] != "key-1" {
+		t.Fatalf("missing context attributes: %v", lines[0])
+	}
+	if lines[1]["order_id"] != "order-2" || lines[1]["component"] != "saga" || lines[1]["step"] != "charge" || lines[1]["request_id"] != "req-1" {
+		t.Fatalf("unexpected second line: %v", lines[1])
+	}
+	if strings.Count(buf.String(), `"order_id"`) != 2 {
+		t.Fatalf("a replaced attribute must not be logged twice: %s", buf.String())
+	}
+	if _, ok := lines[2]["request_id"]; ok {
+		t.Fatalf("unexpected request id without context: %v", lines[2])
+	}
+	if RequestID(ctx) != "req-1" || RequestID(context.Background()) != "" {
+		t.Fatalf("unexpected request ids")
+	}
+}
+
+func TestWithDoesNotShareAttributesBetweenContexts(t *testing.T) {
+	base := With(context.Background(), "a", 1, "b", 2)
+	first := With(base, "c", 3)
+	second := With(base, "d", 4)
+	if len(attrsFrom(base)) != 2 || len(attrsFrom(first)) != 3 || len(attrsFrom(second)) != 3 {
+		t.Fatalf("unexpected attrs: %v %v %v", attrsFrom(base), attrsFrom(first), attrsFrom(second))
+	}
+	if attrsFrom(first)[2].Key != "c" || attrsFrom(second)[2].Key != "d" {
+		t.Fatalf("contexts share attributes: %v %v", attrsFrom(first), attrsFrom(second))
+	}
+}
+
+func TestTraceIDIsLogged(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Config{Format: FormatJSON})
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	logger.InfoContext(ctx, "traced")
+	if lines := decodeLines(t, &buf); lines[0]["trace_id"] != traceID.String() {
+		t.Fatalf("missing trace id: %v", lines[0])
+	}
+}
+
+func TestLevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Format: FormatText, Level: slog.LevelWarn})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "hidden")
+	logger.WarnContext(WithRequestID(context.Background(), "req-2"), "shown")
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "level=WARN msg=shown request_id=req-2") {
+		t.Fatalf("unexpected text output: %q", out)
+	}
+
+	if _, err := New(&buf, Config{Format: "xml"}); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Config{Format: FormatJSON})
+	Printf(logger, slog.LevelWarn)("outbox relay: %v\n", "redis down")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 1 || lines[0]["level"] != "WARN" || lines[0]["msg"] != "outbox relay: redis down" {
+		t.Fatalf("unexpected line: %v", lines)
+	}
+}
@@ -5,8 +5,15 @@ import (
 	"net/http"
 )
 
+// Handler serves the JSON snapshot, or the Prometheus text format when the Accept
+// header asks for it.
 func Handler(metrics *Metrics) http.Handler {
+	prometheus := PrometheusHandler(metrics)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantsPrometheus(r.Header.Get("Accept")) {
+			prometheus.ServeHTTP(w, r)
+			return
+		}
 		snap := metrics.Snapshot()
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(snap)
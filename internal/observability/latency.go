@@ -0,0 +1,159 @@
+package observability
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencySnapshot summarises call latencies over one window. Percentiles are estimated
+// from exponential histogram buckets, four per doubling, by interpolating within the
+// bucket and clamping to the fastest and slowest call seen; an estimate is off by at
+// most one bucket width, about 19%.
+type LatencySnapshot struct {
+	Count  int64   `json:"count"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	P999Ms float64 `json:"p999_ms"`
+}
+
+// latencyWindows are the sliding windows latency percentiles are reported over, keyed
+// by the name used in snapshots.
+var latencyWindows = []struct {
+	name string
+	span time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+}
+
+const (
+	// latencySlot is the granularity windows slide by.
+	latencySlot  = 10 * time.Second
+	latencySlots = int64(15 * time.Minute / latencySlot)
+)
+
+// latencyBounds are the bucket upper bounds: 50µs doubling every four buckets to just
+// over two minutes. Slower calls land in a final overflow bucket.
+var latencyBounds = func() []time.Duration {
+	var bounds []time.Duration
+	for b := float64(50 * time.Microsecond); b < float64(150*time.Second); b *= math.Pow(2, 0.25) {
+		bounds = append(bounds, time.Duration(b))
+	}
+	return bounds
+}()
+
+type latencyHistogram struct {
+	counts   []int64
+	total    int64
+	min, max time.Duration
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]int64, len(latencyBounds)+1)
+		h.min, h.max = d, d
+	}
+	h.min = min(h.min, d)
+	h.max = max(h.max, d)
+	i := sort.Search(len(latencyBounds), func(i int) bool { return d <= latencyBounds[i] })
+	h.counts[i]++
+	h.total++
+}
+
+func (h *latencyHistogram) merge(o *latencyHistogram) {
+	if o.total == 0 {
+		return
+	}
+	if h.counts == nil {
+		h.counts = make([]int64, len(latencyBounds)+1)
+		h.min, h.max = o.min, o.max
+	}
+	h.min = min(h.min, o.min)
+	h.max = max(h.max, o.max)
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+}
+
+// quantile estimates the q-th quantile by interpolating linearly within its bucket.
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := q * float64(h.total)
+	var seen int64
+	for i, c := range h.counts {
+		if c == 0 || float64(seen+c) < rank {
+			seen += c
+			continue
+		}
+		if i == len(latencyBounds) {
+			return h.max
+		}
+		lo := time.Duration(0)
+		if i > 0 {
+			lo = latencyBounds[i-1]
+		}
+		frac := (rank - float64(seen)) / float64(c)
+		est := lo + time.Duration(frac*float64(latencyBounds[i]-lo))
+		return min(max(est, h.min), h.max)
+	}
+	return h.max
+}
+
+func (h *latencyHistogram) snapshot() LatencySnapshot {
+	return LatencySnapshot{
+		Count:  h.total,
+		P50Ms:  durationMs(h.quantile(0.5)),
+		P90Ms:  durationMs(h.quantile(0.9)),
+		P99Ms:  durationMs(h.quantile(0.99)),
+		P999Ms: durationMs(h.quantile(0.999)),
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// slidingLatency keeps a ring of per-slot histograms covering the longest window.
+type slidingLatency struct {
+	slots [latencySlots]latencyHistogram
+	// epochs records which slot index each ring entry currently holds.
+	epochs [latencySlots]int64
+}
+
+func (s *slidingLatency) observe(now time.Time, d time.Duration) {
+	epoch := now.UnixNano() / int64(latencySlot)
+	i := epoch % latencySlots
+	if s.epochs[i] != epoch {
+		s.slots[i] = latencyHistogram{}
+		s.epochs[i] = epoch
+	}
+	s.slots[i].observe(d)
+}
+
+// window merges the slots that fall within span of now, including the current one.
+func (s *slidingLatency) window(now time.Time, span time.Duration) latencyHistogram {
+	epoch := now.UnixNano() / int64(latencySlot)
+	oldest := epoch - int64(span/latencySlot) + 1
+	var out latencyHistogram
+	for i := range s.slots {
+		if s.epochs[i] >= oldest && s.epochs[i] <= epoch {
+			out.merge(&s.slots[i])
+		}
+	}
+	return out
+}
+
+func (s *slidingLatency) snapshot(now time.Time) map[string]LatencySnapshot {
+	out := make(map[string]LatencySnapshot, len(latencyWindows))
+	for _, w := range latencyWindows {
+		h := s.window(now, w.span)
+		out[w.name] = h.snapshot()
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func within(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= want*tolerance
+}
+
+func TestLatencyHistogramQuantiles(t *testing.T) {
+	var h latencyHistogram
+	if h.quantile(0.5) != 0 {
+		t.Fatalf("expected 0 for an empty histogram")
+	}
+	for i := 1; i <= 10000; i++ {
+		h.observe(time.Duration(i) * 100 * time.Microsecond)
+	}
+	snap := h.snapshot()
+	if snap.Count != 10000 {
+		t.Fatalf("expected 10000 observations, got %d", snap.Count)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"p50", snap.P50Ms, 500},
+		{"p90", snap.P90Ms, 900},
+		{"p99", snap.P99Ms, 990},
+		{"p999", snap.P999Ms, 999},
+	} {
+		if !within(c.got, c.want, 0.1) {
+			t.Fatalf("%s = %.1fms, want %.0fms ±10%%", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestLatencyHistogramOverflow(t *testing.T) {
+	var h latencyHistogram
+	h.observe(10 * time.Minute)
+	h.observe(0)
+	if got := h.quantile(0.999); got != 10*time.Minute {
+		t.Fatalf("expected overflow to report the slowest call, got %v", got)
+	}
+	if got := h.quantile(0.25); got > latencyBounds[0] {
+		t.Fatalf("expected zero latency in the first bucket, got %v", got)
+	}
+}
+
+func TestSlidingLatencyWindows(t *testing.T) {
+	var s slidingLatency
+	start := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		s.observe(start, 2*time.Second)
+	}
+	later := start.Add(2 * time.Minute)
+	for i := 0; i < 100; i++ {
+		s.observe(later, 10*time.Millisecond)
+	}
+
+	snap := s.snapshot(later)
+	if one := snap["1m"]; one.Count != 100 || !within(one.P99Ms, 10, 0.1) {
+		t.Fatalf("1m window should only see the recent fast calls: %+v", one)
+	}
+	if five := snap["5m"]; five.Count != 200 || !within(five.P99Ms, 2000, 0.1) {
+		t.Fatalf("5m window should see both: %+v", five)
+	}
+
+	if snap := s.snapshot(start.Add(20 * time.Minute)); snap["15m"].Count != 0 {
+		t.Fatalf("expected every window to have expired: %+v", snap)
+	}
+
+	// A slot reused after the ring wraps must not keep its old counts.
+	s.observe(start.Add(15*time.Minute), time.Millisecond)
+	if got := s.snapshot(start.Add(15 * time.Minute))["1m"]; got.Count != 1 {
+		t.Fatalf("expected reused slot to be reset, got %+v", got)
+	}
+}
+
+func TestMetricsReportsLatencyAndCodes(t *testing.T) {
+	metrics := NewMetrics()
+	now := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	metrics.now = func() time.Time { return now }
+
+	method := "/order.OrderService/CreateOrder"
+	for i := 0; i < 98; i++ {
+		metrics.Start(method)
+		metrics.finish(method, 20*time.Millisecond, grpcCode(nil))
+	}
+	metrics.Start(method)
+	metrics.finish(method, 3*time.Second, grpcCode(status.Error(codes.Unavailable, "payments down")))
+	metrics.Start(method)
+	metrics.finish(method, time.Second, grpcCode(context.Canceled))
+
+	stats := metrics.Snapshot().Methods[method]
+	if stats.Count != 100 || stats.Errors != 2 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+	if stats.Codes["OK"] != 98 || stats.Codes["Unavailable"] != 1 || stats.Codes["Canceled"] != 1 {
+		t.Fatalf("unexpected codes: %v", stats.Codes)
+	}
+	one := stats.Latency["1m"]
+	if one.Count != 100 || !within(one.P50Ms, 20, 0.1) || !within(one.P999Ms, 3000, 0.1) {
+		t.Fatalf("unexpected 1m latency: %+v", one)
+	}
+
+	var b strings.Builder
+	if err := metrics.WritePrometheus(&b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	series := validatePromText(t, b.String())
+	if got, ok := findSeries(series, "wayfinder_grpc_request_duration_seconds", map[string]string{"method": method, "quantile": "0.5"}); !ok || !within(got, 0.02, 0.1) {
+		t.Fatalf("unexpected exported p50 %v (found %v)", got, ok)
+	}
+
+	now = now.Add(90 * time.Second)
+	stats = metrics.Snapshot().Methods[method]
+	if stats.Latency["1m"].Count != 0 || stats.Latency["5m"].Count != 100 {
+		t.Fatalf("expected the 1m window to have slid past the calls: %+v", stats.Latency)
+	}
+}
+
+func TestGRPCCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want codes.Code
+	}{
+		{nil, codes.OK},
+		{status.Error(codes.NotFound, "x"), codes.NotFound},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{errors.New("plain"), codes.Unknown},
+	}
+	for _, c := range cases {
+		if got := grpcCode(c.err); got != c.want {
+			t.Fatalf("grpcCode(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
@@ -1,30 +1,97 @@
 package observability
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type MethodSnapshot struct {
-	Count         int64   `json:"count"`
-	Errors        int64   `json:"errors"`
-	InFlight      int64   `json:"in_flight"`
-	AvgLatencyMs  float64 `json:"avg_latency_ms"`
-	MaxLatencyMs  float64 `json:"max_latency_ms"`
-	LastLatencyMs float64 `json:"last_latency_ms"`
+	Count int64 `json:"count"`
+	// Errors is the number of calls that finished with any code but OK.
+	Errors int64 `json:"errors"`
+	// Codes counts finished calls by gRPC status code name.
+	Codes         map[string]int64 `json:"codes,omitempty"`
+	InFlight      int64            `json:"in_flight"`
+	AvgLatencyMs  float64          `json:"avg_latency_ms"`
+	MaxLatencyMs  float64          `json:"max_latency_ms"`
+	LastLatencyMs float64          `json:"last_latency_ms"`
+	// Latency holds percentiles over the sliding windows "1m", "5m" and "15m".
+	Latency map[string]LatencySnapshot `json:"latency,omitempty"`
 }
 
 type Snapshot struct {
-	UptimeSec       int64                     `json:"uptime_sec"`
-	TotalRequests   int64                     `json:"total_requests"`
-	TotalErrors     int64                     `json:"total_errors"`
-	InFlight        int64                     `json:"in_flight"`
-	RateLimitWaits  int64                     `json:"rate_limit_waits"`
-	RateLimitWaitMs int64                     `json:"rate_limit_wait_ms"`
-	Lifecycle       *LifecycleSnapshot        `json:"lifecycle,omitempty"`
-	Methods         map[string]MethodSnapshot `json:"methods"`
+	UptimeSec       int64 `json:"uptime_sec"`
+	TotalRequests   int64 `json:"total_requests"`
+	TotalErrors     int64 `json:"total_errors"`
+	InFlight        int64 `json:"in_flight"`
+	RateLimitWaits  int64 `json:"rate_limit_waits"`
+	RateLimitWaitMs int64 `json:"rate_limit_wait_ms"`
+	// IngestRejections counts dropped location points by rejection reason.
+	IngestRejections map[string]int64 `json:"ingest_rejections,omitempty"`
+	// Spools reports the locations waiting in each local write-ahead spool.
+	Spools map[string]SpoolSnapshot `json:"spools,omitempty"`
+	// Dependencies reports the reliability wrappers around each outbound dependency.
+	Dependencies map[string]DependencySnapshot `json:"dependencies,omitempty"`
+	Lifecycle    *LifecycleSnapshot            `json:"lifecycle,omitempty"`
+	Methods      map[string]MethodSnapshot     `json:"methods"`
+}
+
+// SpoolSnapshot is the depth of one location spool.
+type SpoolSnapshot struct {
+	Records int64 `json:"records"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// DependencySnapshot reports the circuit breaker, retries and rate limiter of one
+// outbound dependency.
+type DependencySnapshot struct {
+	// CircuitState is "closed", "open" or "half_open".
+	CircuitState string `json:"circuit_state"`
+	// CircuitTransitions counts breaker state changes, keyed "from->to".
+	CircuitTransitions map[string]int64 `json:"circuit_transitions,omitempty"`
+	// CircuitOpenMs is the time the breaker has spent open, including the current
+	// open period.
+	CircuitOpenMs int64 `json:"circuit_open_ms"`
+	// CircuitRejections counts calls refused while the breaker was open.
+	CircuitRejections int64 `json:"circuit_rejections"`
+	// RetryAttempts counts attempts by outcome.
+	RetryAttempts   map[string]int64 `json:"retry_attempts,omitempty"`
+	RateLimitWaits  int64            `json:"rate_limit_waits"`
+	RateLimitWaitMs int64            `json:"rate_limit_wait_ms"`
+}
+
+type dependencyStats struct {
+	state          string
+	openSince      time.Time
+	openTime       time.Duration
+	transitions    map[string]int64
+	rejections     int64
+	retries        map[string]int64
+	rateLimitWaits int64
+	rateLimitWait  time.Duration
+}
+
+// openTotal is the time spent open, counting the current open period up to now.
+func (d *dependencyStats) openTotal(now time.Time) time.Duration {
+	total := d.openTime
+	if d.state == circuitStateOpen {
+		total += now.Sub(d.openSince)
+	}
+	return total
 }
 
+// Circuit breaker states as reported to AddCircuitTransition.
+const (
+	circuitStateClosed   = "closed"
+	circuitStateOpen     = "open"
+	circuitStateHalfOpen = "half_open"
+)
+
 type methodStats struct {
 	count        int64
 	errors       int64
@@ -32,14 +99,21 @@ type methodStats struct {
 	totalLatency time.Duration
 	maxLatency   time.Duration
 	lastLatency  time.Duration
+	// codes counts finished calls by gRPC status code name.
+	codes   map[string]int64
+	latency slidingLatency
 }
 
 type Metrics struct {
 	mu             sync.Mutex
+	now            func() time.Time
 	start          time.Time
 	methods        map[string]*methodStats
 	rateLimitWaits int64
 	rateLimitWait  time.Duration
+	rejections     map[string]int64
+	spools         map[string]SpoolSnapshot
+	dependencies   map[string]*dependencyStats
 	lifecycle      lifecycleStats
 }
 
@@ -61,6 +135,7 @@ type LifecycleSnapshot struct {
 
 func NewMetrics() *Metrics {
 	return &Metrics{
+		now:     time.Now,
 		start:   time.Now(),
 		methods: make(map[string]*methodStats),
 	}
@@ -86,7 +161,21 @@ func (s *CallSpan) End(err error) {
 		return
 	}
 	dur := time.Since(s.start)
-	s.metrics.finish(s.method, dur, err != nil)
+	s.metrics.finish(s.method, dur, grpcCode(err))
+}
+
+// grpcCode maps a handler error to the status code a client sees.
+func grpcCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Code()
+	}
+	return codes.Unknown
 }
 
 func (m *Metrics) AddRateLimitWait(d time.Duration) {
@@ -99,6 +188,90 @@ func (m *Metrics) AddRateLimitWait(d time.Duration) {
 	m.mu.Unlock()
 }
 
+// AddIngestRejection counts a location point dropped by ingest validation.
+func (m *Metrics) AddIngestRejection(reason string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	if m.rejections == nil {
+		m.rejections = make(map[string]int64)
+	}
+	m.rejections[reason]++
+	m.mu.Unlock()
+}
+
+// SetSpoolDepth records the current depth of the named location spool.
+func (m *Metrics) SetSpoolDepth(name string, records, bytes int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	if m.spools == nil {
+		m.spools = make(map[string]SpoolSnapshot)
+	}
+	m.spools[name] = SpoolSnapshot{Records: records, Bytes: bytes}
+	m.mu.Unlock()
+}
+
+// AddCircuitTransition records a circuit breaker state change for dependency. openFor
+// is how long the breaker had been open when it leaves the open state.
+func (m *Metrics) AddCircuitTransition(dependency, from, to string, openFor time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	dep := m.ensureDependency(dependency)
+	if dep.transitions == nil {
+		dep.transitions = make(map[string]int64)
+	}
+	dep.transitions[from+"->"+to]++
+	if from == circuitStateOpen && openFor > 0 {
+		dep.openTime += openFor
+	}
+	if to == circuitStateOpen {
+		dep.openSince = m.now()
+	}
+	dep.state = to
+	m.mu.Unlock()
+}
+
+// AddCircuitRejection counts a call to dependency refused by an open circuit breaker.
+func (m *Metrics) AddCircuitRejection(dependency string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.ensureDependency(dependency).rejections++
+	m.mu.Unlock()
+}
+
+// AddRetryAttempt counts an attempt at calling dependency by its outcome.
+func (m *Metrics) AddRetryAttempt(dependency, outcome string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	dep := m.ensureDependency(dependency)
+	if dep.retries == nil {
+		dep.retries = make(map[string]int64)
+	}
+	dep.retries[outcome]++
+	m.mu.Unlock()
+}
+
+// AddEgressRateLimitWait records a call to dependency delayed by its rate limiter.
+func (m *Metrics) AddEgressRateLimitWait(dependency string, d time.Duration) {
+	if m == nil || d <= 0 {
+		return
+	}
+	m.mu.Lock()
+	dep := m.ensureDependency(dependency)
+	dep.rateLimitWaits++
+	dep.rateLimitWait += d
+	m.mu.Unlock()
+}
+
 func (m *Metrics) Snapshot() Snapshot {
 	if m == nil {
 		return Snapshot{}
@@ -107,7 +280,7 @@ func (m *Metrics) Snapshot() Snapshot {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	now := time.Now()
+	now := m.now()
 	snap := Snapshot{
 		UptimeSec:       int64(now.Sub(m.start).Seconds()),
 		Methods:         make(map[string]MethodSnapshot),
@@ -120,19 +293,57 @@ func (m *Metrics) Snapshot() Snapshot {
 		if stats.count > 0 {
 			avg = float64(stats.totalLatency.Milliseconds()) / float64(stats.count)
 		}
+		var byCode map[string]int64
+		if len(stats.codes) > 0 {
+			byCode = make(map[string]int64, len(stats.codes))
+			for code, count := range stats.codes {
+				byCode[code] = count
+			}
+		}
 		snap.Methods[method] = MethodSnapshot{
 			Count:         stats.count,
 			Errors:        stats.errors,
+			Codes:         byCode,
 			InFlight:      stats.inFlight,
 			AvgLatencyMs:  avg,
 			MaxLatencyMs:  float64(stats.maxLatency.Milliseconds()),
 			LastLatencyMs: float64(stats.lastLatency.Milliseconds()),
+			Latency:       stats.latency.snapshot(now),
 		}
 		snap.TotalRequests += stats.count
 		snap.TotalErrors += stats.errors
 		snap.InFlight += stats.inFlight
 	}
 
+	if len(m.rejections) > 0 {
+		snap.IngestRejections = make(map[string]int64, len(m.rejections))
+		for reason, count := range m.rejections {
+			snap.IngestRejections[reason] = count
+		}
+	}
+
+	if len(m.spools) > 0 {
+		snap.Spools = make(map[string]SpoolSnapshot, len(m.spools))
+		for name, depth := range m.spools {
+			snap.Spools[name] = depth
+		}
+	}
+
+	if len(m.dependencies) > 0 {
+		snap.Dependencies = make(map[string]DependencySnapshot, len(m.dependencies))
+		for name, dep := range m.dependencies {
+			snap.Dependencies[name] = DependencySnapshot{
+				CircuitState:       dep.state,
+				CircuitTransitions: copyCounts(dep.transitions),
+				CircuitOpenMs:      int64(dep.openTotal(now) / time.Millisecond),
+				CircuitRejections:  dep.rejections,
+				RetryAttempts:      copyCounts(dep.retries),
+				RateLimitWaits:     dep.rateLimitWaits,
+				RateLimitWaitMs:    int64(dep.rateLimitWait / time.Millisecond),
+			}
+		}
+	}
+
 	if !m.lifecycle.shutdownAt.IsZero() {
 		snap.Lifecycle = &LifecycleSnapshot{
 			ShutdownAt:         m.lifecycle.shutdownAt,
@@ -152,7 +363,32 @@ func (m *Metrics) ensureMethod(method string) *methodStats {
 	return stats
 }
 
-func (m *Metrics) finish(method string, dur time.Duration, failed bool) {
+// ensureDependency returns the stats of dependency; m.mu must be held. A breaker that
+// has not reported a transition yet is closed.
+func (m *Metrics) ensureDependency(dependency string) *dependencyStats {
+	if m.dependencies == nil {
+		m.dependencies = make(map[string]*dependencyStats)
+	}
+	dep, ok := m.dependencies[dependency]
+	if !ok {
+		dep = &dependencyStats{state: circuitStateClosed}
+		m.dependencies[dependency] = dep
+	}
+	return dep
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	if len(counts) == 0 {
+		return nil
+	}
+	out := make(map[string]int64, len(counts))
+	for k, v := range counts {
+		out[k] = v
+	}
+	return out
+}
+
+func (m *Metrics) finish(method string, dur time.Duration, code codes.Code) {
 	if m == nil {
 		return
 	}
@@ -160,14 +396,19 @@ func (m *Metrics) finish(method string, dur time.Duration, failed bool) {
 	stats := m.ensureMethod(method)
 	stats.inFlight--
 	stats.count++
-	if failed {
+	if code != codes.OK {
 		stats.errors++
 	}
+	if stats.codes == nil {
+		stats.codes = make(map[string]int64)
+	}
+	stats.codes[code.String()]++
 	stats.totalLatency += dur
 	if dur > stats.maxLatency {
 		stats.maxLatency = dur
 	}
 	stats.lastLatency = dur
+	stats.latency.observe(m.now(), dur)
 	m.mu.Unlock()
 }
 
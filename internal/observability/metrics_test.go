@@ -49,6 +49,40 @@ func TestMetricsTracksRateLimitWait(t *testing.T) {
 	}
 }
 
+func TestMetricsTracksIngestRejections(t *testing.T) {
+	metrics := NewMetrics()
+	if snap := metrics.Snapshot(); snap.IngestRejections != nil {
+		t.Fatalf("expected no rejections, got %v", snap.IngestRejections)
+	}
+	metrics.AddIngestRejection("implausible_speed")
+	metrics.AddIngestRejection("implausible_speed")
+	metrics.AddIngestRejection("stale")
+
+	snap := metrics.Snapshot()
+	if snap.IngestRejections["implausible_speed"] != 2 || snap.IngestRejections["stale"] != 1 {
+		t.Fatalf("unexpected rejections: %v", snap.IngestRejections)
+	}
+	metrics.AddIngestRejection("stale")
+	if snap.IngestRejections["stale"] != 1 {
+		t.Fatalf("expected snapshot to be a copy")
+	}
+}
+
+func TestMetricsTracksSpoolDepth(t *testing.T) {
+	metrics := NewMetrics()
+	if snap := metrics.Snapshot(); snap.Spools != nil {
+		t.Fatalf("expected no spools, got %v", snap.Spools)
+	}
+	metrics.SetSpoolDepth("redis", 3, 300)
+	metrics.SetSpoolDepth("history", 1, 100)
+	metrics.SetSpoolDepth("redis", 0, 0)
+
+	snap := metrics.Snapshot()
+	if snap.Spools["redis"] != (SpoolSnapshot{}) || snap.Spools["history"] != (SpoolSnapshot{Records: 1, Bytes: 100}) {
+		t.Fatalf("unexpected spools: %v", snap.Spools)
+	}
+}
+
 func TestMetricsMarkShutdown(t *testing.T) {
 	metrics := NewMetrics()
 	metrics.MarkShutdown(5)
@@ -89,10 +123,63 @@ func TestHandlerReturnsJSON(t *testing.T) {
 	}
 }
 
+func TestMetricsTracksDependencies(t *testing.T) {
+	metrics := NewMetrics()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	metrics.now = func() time.Time { return now }
+	if snap := metrics.Snapshot(); snap.Dependencies != nil {
+		t.Fatalf("expected no dependencies, got %v", snap.Dependencies)
+	}
+
+	metrics.AddRetryAttempt("payments", "retry")
+	metrics.AddRetryAttempt("payments", "exhausted")
+	metrics.AddCircuitTransition("payments", "closed", "open", 0)
+	metrics.AddCircuitRejection("payments")
+	metrics.AddCircuitRejection("payments")
+	now = now.Add(2 * time.Second)
+	metrics.AddCircuitTransition("payments", "open", "half_open", 2*time.Second)
+	metrics.AddCircuitTransition("payments", "half_open", "open", 0)
+	now = now.Add(500 * time.Millisecond)
+	metrics.AddEgressRateLimitWait("drivers", 40*time.Millisecond)
+	metrics.AddEgressRateLimitWait("drivers", 0)
+	metrics.AddRetryAttempt("drivers", "success")
+
+	snap := metrics.Snapshot()
+	payments := snap.Dependencies["payments"]
+	if payments.CircuitState != "open" || payments.CircuitRejections != 2 {
+		t.Fatalf("unexpected payments breaker: %+v", payments)
+	}
+	// Two seconds for the first open period and the half second of the current one.
+	if payments.CircuitOpenMs != 2500 {
+		t.Fatalf("expected 2500ms open, got %d", payments.CircuitOpenMs)
+	}
+	if payments.CircuitTransitions["closed->open"] != 1 || payments.CircuitTransitions["open->half_open"] != 1 || payments.CircuitTransitions["half_open->open"] != 1 {
+		t.Fatalf("unexpected transitions: %v", payments.CircuitTransitions)
+	}
+	if payments.RetryAttempts["retry"] != 1 || payments.RetryAttempts["exhausted"] != 1 {
+		t.Fatalf("unexpected retry attempts: %v", payments.RetryAttempts)
+	}
+	drivers := snap.Dependencies["drivers"]
+	if drivers.CircuitState != "closed" || drivers.CircuitTransitions != nil || drivers.RateLimitWaits != 1 || drivers.RateLimitWaitMs != 40 {
+		t.Fatalf("unexpected drivers: %+v", drivers)
+	}
+
+	metrics.AddRetryAttempt("payments", "retry")
+	if snap.Dependencies["payments"].RetryAttempts["retry"] != 1 {
+		t.Fatalf("snapshot shares its maps with the metrics")
+	}
+}
+
 func TestMetricsNilSafePaths(t *testing.T) {
 	var m *Metrics
 	span := m.Start("ignored") // nil-safe
 	span.End(nil)              // should not panic
 
-	m.MarkShutdown(10) // nil-safe
+	m.MarkShutdown(10)                        // nil-safe
+	m.AddIngestRejection("implausible_speed") // nil-safe
+	m.SetSpoolDepth("redis", 1, 1)            // nil-safe
+	m.AddCircuitTransition("payments", "closed", "open", 0)
+	m.AddCircuitRejection("payments")
+	m.AddRetryAttempt("payments", "retry")
+	m.AddEgressRateLimitWait("payments", time.Second)
 }
@@ -0,0 +1,195 @@
+package observability
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusHandler serves metrics in the Prometheus text exposition format.
+func PrometheusHandler(metrics *Metrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", PrometheusContentType)
+		_ = metrics.WritePrometheus(w)
+	})
+}
+
+// wantsPrometheus reports whether an Accept header asks for the text exposition format
+// rather than JSON, as Prometheus scrapers do.
+func wantsPrometheus(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		switch strings.TrimSpace(strings.ToLower(mediaType)) {
+		case "text/plain", "application/openmetrics-text":
+			return true
+		}
+	}
+	return false
+}
+
+type promLabel struct {
+	name, value string
+}
+
+type promSample struct {
+	suffix string
+	labels []promLabel
+	value  float64
+}
+
+type promFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []promSample
+}
+
+func (f *promFamily) add(value float64, labels ...promLabel) {
+	f.samples = append(f.samples, promSample{labels: labels, value: value})
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range m.promFamilies() {
+		writePromFamily(bw, family)
+	}
+	return bw.Flush()
+}
+
+func (m *Metrics) promFamilies() []*promFamily {
+	uptime := &promFamily{name: "wayfinder_uptime_seconds", help: "Seconds since the process started.", kind: "gauge"}
+	requests := &promFamily{name: "wayfinder_grpc_requests_total", help: "Finished gRPC calls by method and status code.", kind: "counter"}
+	inFlight := &promFamily{name: "wayfinder_grpc_requests_in_flight", help: "gRPC calls currently being handled.", kind: "gauge"}
+	duration := &promFamily{name: "wayfinder_grpc_request_duration_seconds", help: "Time spent handling gRPC calls; quantiles cover the last minute.", kind: "summary"}
+	maxDuration := &promFamily{name: "wayfinder_grpc_request_duration_max_seconds", help: "Slowest gRPC call since the process started.", kind: "gauge"}
+	limitWaits := &promFamily{name: "wayfinder_rate_limit_waits_total", help: "Ingress calls delayed by the rate limiter.", kind: "counter"}
+	limitWait := &promFamily{name: "wayfinder_rate_limit_wait_seconds_total", help: "Time ingress calls spent waiting on the rate limiter.", kind: "counter"}
+	rejections := &promFamily{name: "wayfinder_ingest_rejections_total", help: "Location points dropped by ingest validation.", kind: "counter"}
+	spoolRecords := &promFamily{name: "wayfinder_location_spool_records", help: "Locations waiting in the on-disk spool of each store.", kind: "gauge"}
+	spoolBytes := &promFamily{name: "wayfinder_location_spool_bytes", help: "Size on disk of the spool of each store.", kind: "gauge"}
+	breakerState := &promFamily{name: "wayfinder_circuit_breaker_state", help: "Circuit breaker state of each dependency; 1 for the current state.", kind: "gauge"}
+	breakerTransitions := &promFamily{name: "wayfinder_circuit_breaker_transitions_total", help: "Circuit breaker state changes of each dependency.", kind: "counter"}
+	breakerOpen := &promFamily{name: "wayfinder_circuit_breaker_open_seconds_total", help: "Time the circuit breaker of each dependency has spent open.", kind: "counter"}
+	breakerRejections := &promFamily{name: "wayfinder_circuit_breaker_rejections_total", help: "Calls refused because the dependency's circuit breaker was open.", kind: "counter"}
+	retryAttempts := &promFamily{name: "wayfinder_retry_attempts_total", help: "Attempts at calling each dependency by outcome.", kind: "counter"}
+	egressWaits := &promFamily{name: "wayfinder_egress_rate_limit_waits_total", help: "Calls to each dependency delayed by its rate limiter.", kind: "counter"}
+	egressWait := &promFamily{name: "wayfinder_egress_rate_limit_wait_seconds_total", help: "Time calls to each dependency spent waiting on its rate limiter.", kind: "counter"}
+	families := []*promFamily{uptime, requests, inFlight, duration, maxDuration, limitWaits, limitWait, rejections, spoolRecords, spoolBytes,
+		breakerState, breakerTransitions, breakerOpen, breakerRejections, retryAttempts, egressWaits, egressWait}
+	if m == nil {
+		return families
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	uptime.add(now.Sub(m.start).Seconds())
+	for _, method := range sortedKeys(m.methods) {
+		stats := m.methods[method]
+		label := promLabel{"method", method}
+		for _, code := range sortedKeys(stats.codes) {
+			requests.add(float64(stats.codes[code]), label, promLabel{"code", code})
+		}
+		inFlight.add(float64(stats.inFlight), label)
+		window := stats.latency.window(now, latencyWindows[0].span)
+		for _, q := range promQuantiles {
+			duration.add(window.quantile(q.value).Seconds(), label, promLabel{"quantile", q.label})
+		}
+		duration.samples = append(duration.samples,
+			promSample{suffix: "_sum", labels: []promLabel{label}, value: stats.totalLatency.Seconds()},
+			promSample{suffix: "_count", labels: []promLabel{label}, value: float64(stats.count)},
+		)
+		maxDuration.add(stats.maxLatency.Seconds(), label)
+	}
+	limitWaits.add(float64(m.rateLimitWaits))
+	limitWait.add(m.rateLimitWait.Seconds())
+	for _, reason := range sortedKeys(m.rejections) {
+		rejections.add(float64(m.rejections[reason]), promLabel{"reason", reason})
+	}
+	for _, store := range sortedKeys(m.spools) {
+		spoolRecords.add(float64(m.spools[store].Records), promLabel{"store", store})
+		spoolBytes.add(float64(m.spools[store].Bytes), promLabel{"store", store})
+	}
+	for _, name := range sortedKeys(m.dependencies) {
+		dep := m.dependencies[name]
+		label := promLabel{"dependency", name}
+		for _, state := range []string{circuitStateClosed, circuitStateOpen, circuitStateHalfOpen} {
+			current := 0.0
+			if dep.state == state {
+				current = 1
+			}
+			breakerState.add(current, label, promLabel{"state", state})
+		}
+		for _, key := range sortedKeys(dep.transitions) {
+			from, to, _ := strings.Cut(key, "->")
+			breakerTransitions.add(float64(dep.transitions[key]), label, promLabel{"from", from}, promLabel{"to", to})
+		}
+		breakerOpen.add(dep.openTotal(now).Seconds(), label)
+		breakerRejections.add(float64(dep.rejections), label)
+		for _, outcome := range sortedKeys(dep.retries) {
+			retryAttempts.add(float64(dep.retries[outcome]), label, promLabel{"outcome", outcome})
+		}
+		egressWaits.add(float64(dep.rateLimitWaits), label)
+		egressWait.add(dep.rateLimitWait.Seconds(), label)
+	}
+	return families
+}
+
+var promQuantiles = []struct {
+	label string
+	value float64
+}{{"0.5", 0.5}, {"0.9", 0.9}, {"0.99", 0.99}, {"0.999", 0.999}}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writePromFamily(w *bufio.Writer, f *promFamily) {
+	w.WriteString("# HELP " + f.name + " " + promHelpEscaper.Replace(f.help) + "\n")
+	w.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+	for _, s := range f.samples {
+		w.WriteString(f.name + s.suffix)
+		if len(s.labels) > 0 {
+			w.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				w.WriteString(l.name + `="` + promLabelEscaper.Replace(l.value) + `"`)
+			}
+			w.WriteByte('}')
+		}
+		w.WriteString(" " + formatPromValue(s.value) + "\n")
+	}
+}
+
+var (
+	promHelpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	promLabelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatPromValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,305 @@
+package observability
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	promNameRe   = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	promLabelRe  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	promSampleRe = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(.*)\})? (\S+)( -?[0-9]+)?$`)
+)
+
+// parsePromLabels splits the inside of a label set, honouring the \\, \" and \n
+// escapes of the exposition format.
+func parsePromLabels(raw string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for raw != "" {
+		name, rest, ok := strings.Cut(raw, `="`)
+		if !ok || !promLabelRe.MatchString(name) {
+			return nil, fmt.Errorf("bad label name in %q", raw)
+		}
+		if _, dup := labels[name]; dup {
+			return nil, fmt.Errorf("duplicate label %q", name)
+		}
+		var value strings.Builder
+		i := 0
+		for ; i < len(rest); i++ {
+			c := rest[i]
+			if c == '"' {
+				break
+			}
+			if c == '\n' {
+				return nil, errors.New("raw newline in label value")
+			}
+			if c == '\\' {
+				i++
+				if i == len(rest) {
+					return nil, errors.New("dangling escape")
+				}
+				switch rest[i] {
+				case '\\':
+					value.WriteByte('\\')
+				case '"':
+					value.WriteByte('"')
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					return nil, fmt.Errorf("invalid escape \\%c", rest[i])
+				}
+				continue
+			}
+			value.WriteByte(c)
+		}
+		if i == len(rest) {
+			return nil, fmt.Errorf("unterminated label value in %q", raw)
+		}
+		labels[name] = value.String()
+		raw = rest[i+1:]
+		if raw != "" {
+			if raw[0] != ',' {
+				return nil, fmt.Errorf("expected comma in %q", raw)
+			}
+			raw = raw[1:]
+		}
+	}
+	return labels, nil
+}
+
+type promSeries struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// validatePromText checks text against the Prometheus text exposition grammar and
+// returns the samples it contains.
+func validatePromText(t *testing.T, text string) []promSeries {
+	t.Helper()
+	if !strings.HasSuffix(text, "\n") {
+		t.Fatalf("exposition must end with a newline")
+	}
+	types := make(map[string]string)
+	helps := make(map[string]bool)
+	seen := make(map[string]bool)
+	finished := make(map[string]bool)
+	current := ""
+	var series []promSeries
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		fail := func(format string, args ...any) {
+			t.Helper()
+			t.Fatalf("line %d %q: %s", n, line, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case strings.HasPrefix(line, "# HELP "):
+			name, _, _ := strings.Cut(strings.TrimPrefix(line, "# HELP "), " ")
+			if !promNameRe.MatchString(name) || helps[name] {
+				fail("bad or repeated HELP")
+			}
+			helps[name] = true
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# TYPE "))
+			if len(fields) != 2 || !promNameRe.MatchString(fields[0]) {
+				fail("malformed TYPE")
+			}
+			switch fields[1] {
+			case "counter", "gauge", "summary", "histogram", "untyped":
+			default:
+				fail("unknown type %q", fields[1])
+			}
+			if _, dup := types[fields[0]]; dup || finished[fields[0]] {
+				fail("TYPE after samples or repeated")
+			}
+			types[fields[0]] = fields[1]
+		case strings.HasPrefix(line, "#"), line == "":
+			fail("unexpected comment or blank line")
+		default:
+			m := promSampleRe.FindStringSubmatch(line)
+			if m == nil {
+				fail("malformed sample")
+			}
+			name := m[1]
+			family := name
+			for _, suffix := range []string{"_sum", "_count", "_bucket"} {
+				base := strings.TrimSuffix(name, suffix)
+				if kind := types[base]; base != name && (kind == "summary" || kind == "histogram") {
+					family = base
+				}
+			}
+			kind, ok := types[family]
+			if !ok {
+				fail("sample without TYPE")
+			}
+			if family != current {
+				if finished[family] {
+					fail("samples of %s are not contiguous", family)
+				}
+				if current != "" {
+					finished[current] = true
+				}
+				current = family
+			}
+			labels := map[string]string{}
+			if m[3] != "" {
+				var err error
+				if labels, err = parsePromLabels(m[3]); err != nil {
+					fail("%v", err)
+				}
+			}
+			if kind == "counter" && !strings.HasSuffix(name, "_total") {
+				fail("counter names end in _total")
+			}
+			value, err := strconv.ParseFloat(m[4], 64)
+			if err != nil && m[4] != "+Inf" && m[4] != "-Inf" && m[4] != "NaN" {
+				fail("bad value")
+			}
+			key := name + "{" + m[3] + "}"
+			if seen[key] {
+				fail("duplicate series")
+			}
+			seen[key] = true
+			series = append(series, promSeries{name: name, labels: labels, value: value})
+		}
+	}
+	for name := range types {
+		if !helps[name] {
+			t.Fatalf("%s has a TYPE but no HELP", name)
+		}
+	}
+	return series
+}
+
+func findSeries(series []promSeries, name string, labels map[string]string) (float64, bool) {
+next:
+	for _, s := range series {
+		if s.name != name || len(s.labels) != len(labels) {
+			continue
+		}
+		for k, v := range labels {
+			if s.labels[k] != v {
+				continue next
+			}
+		}
+		return s.value, true
+	}
+	return 0, false
+}
+
+func TestWritePrometheusFollowsExpositionGrammar(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.Start("/order.OrderService/CreateOrder").End(nil)
+	metrics.Start("/order.OrderService/CreateOrder").End(status.Error(codes.NotFound, "missing"))
+	metrics.Start("/order.OrderService/CreateOrder").End(context.DeadlineExceeded)
+	metrics.Start("/driver.DriverService/StreamLocations").End(errors.New("boom"))
+	metrics.Start(`/weird"method\` + "\n")
+	metrics.AddRateLimitWait(1500 * time.Millisecond)
+	metrics.AddIngestRejection("stale")
+	metrics.SetSpoolDepth("redis", 3, 120)
+	metrics.AddCircuitTransition("payments", "closed", "open", 0)
+	metrics.AddCircuitTransition("payments", "open", "half_open", 3*time.Second)
+	metrics.AddCircuitRejection("payments")
+	metrics.AddRetryAttempt("payments", "non_retryable")
+	metrics.AddEgressRateLimitWait("drivers", 250*time.Millisecond)
+
+	var b strings.Builder
+	if err := metrics.WritePrometheus(&b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	series := validatePromText(t, b.String())
+
+	create := "/order.OrderService/CreateOrder"
+	checks := []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{"wayfinder_grpc_requests_total", map[string]string{"method": create, "code": "OK"}, 1},
+		{"wayfinder_grpc_requests_total", map[string]string{"method": create, "code": "NotFound"}, 1},
+		{"wayfinder_grpc_requests_total", map[string]string{"method": create, "code": "DeadlineExceeded"}, 1},
+		{"wayfinder_grpc_requests_total", map[string]string{"method": "/driver.DriverService/StreamLocations", "code": "Unknown"}, 1},
+		{"wayfinder_grpc_request_duration_seconds_count", map[string]string{"method": create}, 3},
+		{"wayfinder_grpc_requests_in_flight", map[string]string{"method": `/weird"method\` + "\n"}, 1},
+		{"wayfinder_rate_limit_waits_total", map[string]string{}, 1},
+		{"wayfinder_rate_limit_wait_seconds_total", map[string]string{}, 1.5},
+		{"wayfinder_ingest_rejections_total", map[string]string{"reason": "stale"}, 1},
+		{"wayfinder_location_spool_records", map[string]string{"store": "redis"}, 3},
+		{"wayfinder_location_spool_bytes", map[string]string{"store": "redis"}, 120},
+		{"wayfinder_circuit_breaker_state", map[string]string{"dependency": "payments", "state": "half_open"}, 1},
+		{"wayfinder_circuit_breaker_state", map[string]string{"dependency": "payments", "state": "open"}, 0},
+		{"wayfinder_circuit_breaker_state", map[string]string{"dependency": "drivers", "state": "closed"}, 1},
+		{"wayfinder_circuit_breaker_transitions_total", map[string]string{"dependency": "payments", "from": "open", "to": "half_open"}, 1},
+		{"wayfinder_circuit_breaker_open_seconds_total", map[string]string{"dependency": "payments"}, 3},
+		{"wayfinder_circuit_breaker_rejections_total", map[string]string{"dependency": "payments"}, 1},
+		{"wayfinder_retry_attempts_total", map[string]string{"dependency": "payments", "outcome": "non_retryable"}, 1},
+		{"wayfinder_egress_rate_limit_waits_total", map[string]string{"dependency": "drivers"}, 1},
+		{"wayfinder_egress_rate_limit_wait_seconds_total", map[string]string{"dependency": "drivers"}, 0.25},
+	}
+	for _, c := range checks {
+		got, ok := findSeries(series, c.name, c.labels)
+		if !ok || got != c.want {
+			t.Fatalf("%s%v = %v (found %v), want %v\n%s", c.name, c.labels, got, ok, c.want, b.String())
+		}
+	}
+}
+
+func TestWritePrometheusEmptyAndNil(t *testing.T) {
+	for _, metrics := range []*Metrics{NewMetrics(), nil} {
+		var b strings.Builder
+		if err := metrics.WritePrometheus(&b); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		validatePromText(t, b.String())
+	}
+}
+
+func TestHandlerNegotiatesFormat(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.Start("/test").End(nil)
+
+	cases := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json"},
+		{"application/json", "application/json"},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", "application/json"},
+		{"text/plain;version=0.0.4;q=0.5,*/*;q=0.1", PrometheusContentType},
+		{"application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75", PrometheusContentType},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		rr := httptest.NewRecorder()
+		Handler(metrics).ServeHTTP(rr, req)
+		if got := rr.Header().Get("Content-Type"); got != c.contentType {
+			t.Fatalf("Accept %q: got content type %q, want %q", c.accept, got, c.contentType)
+		}
+		if c.contentType == PrometheusContentType {
+			validatePromText(t, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	PrometheusHandler(metrics).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics/prometheus", nil))
+	if rr.Header().Get("Content-Type") != PrometheusContentType || !strings.Contains(rr.Body.String(), `wayfinder_grpc_requests_total{method="/test",code="OK"} 1`) {
+		t.Fatalf("unexpected prometheus response: %s", rr.Body.String())
+	}
+}
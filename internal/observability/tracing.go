@@ -0,0 +1,126 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Trace exporters accepted by TracingConfig.Exporter.
+const (
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+	TraceExporterOTLP   = "otlp"
+)
+
+// ErrUnknownTraceExporter is returned for an exporter name SetupTracing does not know.
+var ErrUnknownTraceExporter = errors.New("unknown trace exporter")
+
+// TracingConfig selects where spans are exported.
+type TracingConfig struct {
+	// Exporter is one of the TraceExporter names; empty means none.
+	Exporter string
+	// File is appended to by the file exporter, one JSON span per line.
+	File string
+	// OTLPEndpoint is the collector host:port for the otlp exporter. Empty defers to the
+	// OTEL_EXPORTER_OTLP_* environment variables.
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// ServiceName defaults to "wayfinder".
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded; calls that arrive with a
+	// sampled parent are always recorded. Defaults to 1.
+	SampleRatio *float64
+	// Writer replaces stdout for the stdout exporter.
+	Writer io.Writer
+}
+
+// SetupTracing installs a global tracer provider exporting to the configured exporter
+// and the W3C trace context and baggage propagators. The returned function flushes and
+// stops the exporter. With no exporter only propagation is installed, so incoming trace
+// context still reaches outgoing calls.
+func SetupTracing(ctx context.Context, cfg TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closeOutput, err := newTraceExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "wayfinder"
+	}
+	ratio := 1.0
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			err = errors.Join(err, closeOutput())
+		}
+		return err
+	}, nil
+}
+
+func newTraceExporter(ctx context.Context, cfg TracingConfig) (sdktrace.SpanExporter, func() error, error) {
+	switch cfg.Exporter {
+	case "", TraceExporterNone:
+		return nil, nil, nil
+	case TraceExporterStdout:
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		return exporter, nil, err
+	case TraceExporterFile:
+		if cfg.File == "" {
+			return nil, nil, errors.New("file trace exporter requires a file")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+		return exporter, f.Close, nil
+	case TraceExporterOTLP:
+		var opts []otlptracegrpc.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		return exporter, nil, err
+	default:
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownTraceExporter, cfg.Exporter)
+	}
+}
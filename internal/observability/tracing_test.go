@@ -0,0 +1,181 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// exportedSpan is the part of the stdout exporter's JSON that tests look at.
+type exportedSpan struct {
+	Name        string
+	SpanKind    int
+	SpanContext struct{ TraceID, SpanID string }
+	Parent      struct{ TraceID, SpanID string }
+	Resource    []struct {
+		Key   string
+		Value struct{ Value any }
+	}
+}
+
+func decodeSpans(t *testing.T, r io.Reader) []exportedSpan {
+	t.Helper()
+	var spans []exportedSpan
+	dec := json.NewDecoder(r)
+	for {
+		var span exportedSpan
+		if err := dec.Decode(&span); err == io.EOF {
+			return spans
+		} else if err != nil {
+			t.Fatalf("decode span: %v", err)
+		}
+		spans = append(spans, span)
+	}
+}
+
+// restoreTracing puts back the global provider and propagator SetupTracing replaces.
+func restoreTracing(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestSetupTracingFileExporterContinuesIncomingTraces(t *testing.T) {
+	restoreTracing(t)
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := SetupTracing(context.Background(), TracingConfig{Exporter: TraceExporterFile, File: path, ServiceName: "wayfinder-test"})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-"+traceID+"-"+parentID+"-01")
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	// Waits for the handler, and so the server span, to finish.
+	server.GracefulStop()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open spans: %v", err)
+	}
+	defer f.Close()
+	spans := decodeSpans(t, f)
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %+v", spans)
+	}
+	span := spans[0]
+	if span.Name != "grpc.health.v1.Health/Check" || span.SpanKind != 2 {
+		t.Fatalf("expected a server span for the call, got %+v", span)
+	}
+	if span.SpanContext.TraceID != traceID || span.Parent.SpanID != parentID {
+		t.Fatalf("server span did not continue the caller's trace: %+v", span)
+	}
+	service := ""
+	for _, attr := range span.Resource {
+		if attr.Key == "service.name" {
+			service, _ = attr.Value.Value.(string)
+		}
+	}
+	if service != "wayfinder-test" {
+		t.Fatalf("unexpected service name %q", service)
+	}
+}
+
+func TestSetupTracingStdoutExporter(t *testing.T) {
+	restoreTracing(t)
+	var out bytes.Buffer
+	shutdown, err := SetupTracing(context.Background(), TracingConfig{Exporter: TraceExporterStdout, Writer: &out})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if spans := decodeSpans(t, &out); len(spans) != 1 || spans[0].Name != "work" {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+
+	// A zero ratio drops new traces.
+	out.Reset()
+	ratio := 0.0
+	if shutdown, err = SetupTracing(context.Background(), TracingConfig{Exporter: TraceExporterStdout, Writer: &out, SampleRatio: &ratio}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	_, span = otel.Tracer("test").Start(context.Background(), "dropped")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no spans, got %s", out.String())
+	}
+}
+
+func TestSetupTracingWithoutExporter(t *testing.T) {
+	restoreTracing(t)
+	provider := otel.GetTracerProvider()
+	shutdown, err := SetupTracing(context.Background(), TracingConfig{Exporter: TraceExporterNone})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if otel.GetTracerProvider() != provider {
+		t.Fatalf("expected the tracer provider to be left alone")
+	}
+	if fields := otel.GetTextMapPropagator().Fields(); len(fields) == 0 {
+		t.Fatalf("expected propagators to be installed")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestSetupTracingRejectsBadConfig(t *testing.T) {
+	restoreTracing(t)
+	if _, err := SetupTracing(context.Background(), TracingConfig{Exporter: "jaeger"}); !errors.Is(err, ErrUnknownTraceExporter) {
+		t.Fatalf("expected ErrUnknownTraceExporter, got %v", err)
+	}
+	if _, err := SetupTracing(context.Background(), TracingConfig{Exporter: TraceExporterFile}); err == nil {
+		t.Fatalf("expected error for a file exporter without a file")
+	}
+	if _, err := SetupTracing(context.Background(), TracingConfig{Exporter: TraceExporterFile, File: t.TempDir()}); err == nil {
+		t.Fatalf("expected error for an unwritable trace file")
+	}
+}
@@ -2,28 +2,42 @@ package orders
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	ordersdb "wayfinder/internal/db/orders"
+	"wayfinder/internal/db/postgres"
+	"wayfinder/internal/dispatch"
+	"wayfinder/internal/ingest"
+	"wayfinder/internal/logging"
+	"wayfinder/internal/orders/outbox"
+
+	"github.com/redis/go-redis/v9"
 )
 
-var openOrderDB = func(driver, dsn string) (*sql.DB, error) {
-	return sql.Open(driver, dsn)
+var openOrderDB = postgres.Open
+
+// BuildOrderService wires the order service to Postgres and to Redis-backed dispatch,
+// which is required, and starts the outbox relay and saga recovery when configured. A
+// nil logger means slog.Default().
+func BuildOrderService(ctx context.Context, dsn string, logger *slog.Logger) (*OrderService, func(), error) {
+	return BuildOrderServiceWithHooks(ctx, dsn, logger, ReliabilityHooks{})
 }
 
-func BuildOrderService(ctx context.Context, dsn string, logf func(format string, args ...any)) (*OrderService, func(), error) {
-	if logf == nil {
-		logf = log.Printf
+// BuildOrderServiceWithHooks is BuildOrderService with hooks reporting on the
+// reliability wrappers around the payment and driver clients.
+func BuildOrderServiceWithHooks(ctx context.Context, dsn string, logger *slog.Logger, hooks ReliabilityHooks) (*OrderService, func(), error) {
+	if logger == nil {
+		logger = slog.Default()
 	}
+	logf := logging.Printf(logger, slog.LevelWarn)
 
 	if dsn == "" {
 		return nil, nil, fmt.Errorf("DATABASE_URL is required")
 	}
 
-	sqlDB, err := openOrderDB("pgx", dsn)
+	sqlDB, err := openOrderDB(dsn)
 	if err != nil {
 		return nil, nil, fmt.Errorf("postgres open failed: %w", err)
 	}
@@ -60,31 +74,120 @@ func BuildOrderService(ctx context.Context, dsn string, logf func(format string,
 		BaseDelay:   reliabilityCfg.RetryBaseDelay,
 		MaxDelay:    reliabilityCfg.RetryMaxDelay,
 	}
-	paymentLimiter := NewRateLimiter(reliabilityCfg.RateLimitInterval, reliabilityCfg.RateLimitBurst)
-	driverLimiter := NewRateLimiter(reliabilityCfg.RateLimitInterval, reliabilityCfg.RateLimitBurst)
+	paymentRetry, driverRetry := retryPolicy, retryPolicy
+	paymentRetry.OnAttempt = hooks.retryAttempt(DependencyPayments)
+	driverRetry.OnAttempt = hooks.retryAttempt(DependencyDrivers)
+	paymentLimiter := NewRateLimiterWithHook(reliabilityCfg.RateLimitInterval, reliabilityCfg.RateLimitBurst, hooks.limiterWait(DependencyPayments))
+	driverLimiter := NewRateLimiterWithHook(reliabilityCfg.RateLimitInterval, reliabilityCfg.RateLimitBurst, hooks.limiterWait(DependencyDrivers))
 	paymentBreaker := NewCircuitBreaker(CircuitBreakerConfig{
-		MaxFailures:  reliabilityCfg.BreakerMaxFailures,
-		ResetTimeout: reliabilityCfg.BreakerResetTimeout,
+		MaxFailures:   reliabilityCfg.BreakerMaxFailures,
+		ResetTimeout:  reliabilityCfg.BreakerResetTimeout,
+		OnStateChange: hooks.stateChange(DependencyPayments),
+		OnReject:      hooks.reject(DependencyPayments),
 	})
 	driverBreaker := NewCircuitBreaker(CircuitBreakerConfig{
-		MaxFailures:  reliabilityCfg.BreakerMaxFailures,
-		ResetTimeout: reliabilityCfg.BreakerResetTimeout,
+		MaxFailures:   reliabilityCfg.BreakerMaxFailures,
+		ResetTimeout:  reliabilityCfg.BreakerResetTimeout,
+		OnStateChange: hooks.stateChange(DependencyDrivers),
+		OnReject:      hooks.reject(DependencyDrivers),
 	})
 
-	reliablePayments := NewReliablePaymentClient(payments, paymentLimiter, paymentBreaker, retryPolicy)
-	reliableDrivers := NewReliableDriverClient(drivers, driverLimiter, driverBreaker, retryPolicy)
+	recoveryCfg, recoveryEnabled, err := loadRecoveryConfigFromEnv()
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, nil, fmt.Errorf("recovery config: %w", err)
+	}
 
-	cleanup := func() {
-		if err := sqlDB.Close(); err != nil {
-			logf("close postgres: %v", err)
+	outboxCfg, outboxEnabled, err := loadOutboxConfigFromEnv()
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, nil, fmt.Errorf("outbox config: %w", err)
+	}
+	var outboxClient *redis.Client
+	if outboxEnabled {
+		opts, err := redis.ParseURL(outboxCfg.RedisURL)
+		if err != nil {
+			_ = sqlDB.Close()
+			return nil, nil, fmt.Errorf("outbox redis url: %w", err)
 		}
+		outboxClient = redis.NewClient(opts)
 	}
 
-	return NewOrderService(
+	dispatchCfg, err := loadDispatchConfigFromEnv()
+	if err != nil {
+		_ = sqlDB.Close()
+		if outboxClient != nil {
+			_ = outboxClient.Close()
+		}
+		return nil, nil, fmt.Errorf("dispatch config: %w", err)
+	}
+	dispatchOpts, err := redis.ParseURL(dispatchCfg.RedisURL)
+	if err != nil {
+		_ = sqlDB.Close()
+		if outboxClient != nil {
+			_ = outboxClient.Close()
+		}
+		return nil, nil, fmt.Errorf("dispatch redis url: %w", err)
+	}
+	dispatchClient := redis.NewClient(dispatchOpts)
+	index := ingest.NewRedisGeoIndex(dispatchClient, dispatchCfg.LocationTTL)
+
+	reliablePayments := NewReliablePaymentClient(payments, paymentLimiter, paymentBreaker, paymentRetry)
+	reliableDrivers := NewReliableDriverClient(drivers, driverLimiter, driverBreaker, driverRetry)
+	driverSel := dispatch.NewSelector(index, dispatchClient, reliableDrivers, dispatchCfg.Selector).Select
+
+	service := NewOrderService(
 		reliablePayments,
 		reliableDrivers,
 		sagas,
 		newOrderID,
-		newDriverID,
-	), cleanup, nil
+		driverSel,
+	)
+
+	stopRecovery := func() {}
+	if recoveryEnabled {
+		recoveryCtx, cancelRecovery := context.WithCancel(ctx)
+		done := make(chan struct{})
+		worker := NewRecoveryWorker(service, sagas, recoveryCfg, logf)
+		go func() {
+			defer close(done)
+			worker.Run(recoveryCtx)
+		}()
+		stopRecovery = func() {
+			cancelRecovery()
+			<-done
+		}
+	}
+
+	stopRelay := func() {}
+	if outboxClient != nil {
+		relayCtx, cancelRelay := context.WithCancel(ctx)
+		done := make(chan struct{})
+		sink := outbox.NewRedisStreamSink(outboxClient, outboxCfg.Stream, outboxCfg.StreamMaxLen)
+		relay := outbox.NewRelay(ordersdb.NewOutboxStore(sqlDB), sink, outboxCfg.Relay, logf)
+		go func() {
+			defer close(done)
+			relay.Run(relayCtx)
+		}()
+		stopRelay = func() {
+			cancelRelay()
+			<-done
+			if err := outboxClient.Close(); err != nil {
+				logger.Warn("close outbox redis", "error", err)
+			}
+		}
+	}
+
+	cleanup := func() {
+		stopRecovery()
+		stopRelay()
+		if err := dispatchClient.Close(); err != nil {
+			logger.Warn("close dispatch redis", "error", err)
+		}
+		if err := sqlDB.Close(); err != nil {
+			logger.Warn("close postgres", "error", err)
+		}
+	}
+
+	return service, cleanup, nil
 }
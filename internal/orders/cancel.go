@@ -0,0 +1,114 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"wayfinder/internal/logging"
+	"wayfinder/internal/orders/money"
+	"wayfinder/internal/orders/saga"
+)
+
+// ErrOrderNotCancellable signals the order is in a status that cannot be cancelled.
+var ErrOrderNotCancellable = errors.New("order cannot be cancelled")
+
+// CancelOrder releases the driver, refunds the payment and marks the saga cancelled.
+// The saga moves from succeeded to cancelling before anything is compensated, so of
+// concurrent cancels, or a cancel racing CompleteOrder, only one goes ahead. When
+// release or refund fails the order stays cancelling: a repeated cancel or the recovery
+// worker finishes it, since both steps are safe to repeat. The saga is leased while
+// release and refund run, so a cancel that finds another process finishing the same
+// cancellation returns ErrOrderBusy. Cancelling an already cancelled order is a no-op.
+func (s *OrderService) CancelOrder(ctx context.Context, orderID, reason string) error {
+	if orderID == "" {
+		return ErrOrderIDRequired
+	}
+	if s.reader == nil {
+		return ErrOrderReadsDisabled
+	}
+	ctx = logging.With(ctx, "order_id", orderID)
+
+	view, err := s.reader.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	switch view.Status {
+	case saga.SagaStatusCancelled:
+		return nil
+	case saga.SagaStatusCancelling:
+		return s.withLease(ctx, orderID, func(ctx context.Context) error {
+			return s.finishCancel(ctx, orderID, view.Amount, reason, noHold)
+		})
+	case saga.SagaStatusSucceeded:
+	default:
+		return fmt.Errorf("%w: status %s", ErrOrderNotCancellable, view.Status)
+	}
+
+	claimed, err := s.sagas.TransitionStatus(ctx, orderID, saga.SagaStatusSucceeded, saga.SagaStatusCancelling)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return s.lostTransition(ctx, orderID, ErrOrderNotCancellable, saga.SagaStatusCancelling, saga.SagaStatusCancelled)
+	}
+	return s.withLease(ctx, orderID, func(ctx context.Context) error {
+		return s.finishCancel(ctx, orderID, view.Amount, reason, noHold)
+	})
+}
+
+// finishCancel releases and refunds a cancelling order, then marks it cancelled. hold
+// is called before each action, as in recoverSaga.
+func (s *OrderService) finishCancel(ctx context.Context, orderID string, amount money.Money, reason string, hold func(context.Context) error) error {
+	if err := hold(ctx); err != nil {
+		return err
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "cancel", "started", reason)
+	if err := s.drivers.Release(ctx, orderID); err != nil {
+		slog.WarnContext(ctx, "cancel order: release driver failed", "error", err)
+		_ = s.sagas.AddStep(ctx, orderID, "cancel", "failed", err.Error())
+		return fmt.Errorf("release driver: %w", err)
+	}
+
+	if err := hold(ctx); err != nil {
+		return err
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "refund", "started", "")
+	if err := s.payments.Refund(ctx, orderID, amount); err != nil && !errors.Is(err, saga.ErrAlreadyRefunded) {
+		slog.WarnContext(ctx, "cancel order: refund failed", "error", err)
+		_ = s.sagas.AddStep(ctx, orderID, "refund", "failed", err.Error())
+		_ = s.sagas.AddStep(ctx, orderID, "cancel", "failed", err.Error())
+		return fmt.Errorf("refund failed: %w", err)
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "refund", "succeeded", "")
+
+	if err := hold(ctx); err != nil {
+		return err
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "cancel", "succeeded", "")
+	if _, err := s.sagas.TransitionStatus(ctx, orderID, saga.SagaStatusCancelling, saga.SagaStatusCancelled); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "order cancelled", "reason", reason)
+	return nil
+}
+
+// noHold is the hold used outside recovery, where no lease needs renewing.
+func noHold(context.Context) error { return nil }
+
+// lostTransition explains a transition out of succeeded that another caller made
+// first: nil when that caller moved the order to one of the done statuses, notAllowed
+// otherwise.
+func (s *OrderService) lostTransition(ctx context.Context, orderID string, notAllowed error, done ...saga.SagaStatus) error {
+	view, err := s.reader.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, status := range done {
+		if view.Status == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: status %s", notAllowed, view.Status)
+}
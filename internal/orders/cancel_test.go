@@ -0,0 +1,292 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"wayfinder/internal/orders/money"
+	"wayfinder/internal/orders/saga"
+)
+
+func newCancelFixture(status saga.SagaStatus) (*OrderService, *spyPayment, *spyDriver, *spyReaderSagaStore, *[]string) {
+	callLog := []string{}
+	payment := &spyPayment{callLog: &callLog}
+	driver := &spyDriver{callLog: &callLog}
+	store := &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-1", Amount: usd(1500), Status: status}}}
+	return NewOrderService(payment, driver, store, nil, randomDriver), payment, driver, store, &callLog
+}
+
+func TestCancelOrder_ReleasesRefundsAndCancels(t *testing.T) {
+	t.Parallel()
+
+	service, payment, driver, store, callLog := newCancelFixture(saga.SagaStatusSucceeded)
+
+	if err := service.CancelOrder(context.Background(), "order-1", "customer request"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(*callLog) != 2 || (*callLog)[0] != "release" || (*callLog)[1] != "refund" {
+		t.Fatalf("expected call order [release refund], got %v", *callLog)
+	}
+	if driver.releaseOrderID != "order-1" || payment.refundOrderID != "order-1" || payment.refundAmount != usd(1500) {
+		t.Fatalf("unexpected compensation args: driver=%s refund=%s/%s", driver.releaseOrderID, payment.refundOrderID, payment.refundAmount)
+	}
+
+	wantSteps := []sagaStep{
+		{orderID: "order-1", step: "cancel", status: "started", detail: "customer request"},
+		{orderID: "order-1", step: "refund", status: "started"},
+		{orderID: "order-1", step: "refund", status: "succeeded"},
+		{orderID: "order-1", step: "cancel", status: "succeeded"},
+	}
+	if len(store.steps) != len(wantSteps) {
+		t.Fatalf("unexpected steps: %+v", store.steps)
+	}
+	for i, want := range wantSteps {
+		if store.steps[i] != want {
+			t.Fatalf("step %d: got %+v, want %+v", i, store.steps[i], want)
+		}
+	}
+	if len(store.statuses) != 2 || store.statuses[0] != saga.SagaStatusCancelling || store.statuses[1] != saga.SagaStatusCancelled {
+		t.Fatalf("expected cancelling then cancelled, got %v", store.statuses)
+	}
+}
+
+func TestCancelOrder_AlreadyCancelledIsNoop(t *testing.T) {
+	t.Parallel()
+
+	service, payment, driver, store, _ := newCancelFixture(saga.SagaStatusCancelled)
+
+	if err := service.CancelOrder(context.Background(), "order-1", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payment.refundCalled || driver.releaseCalled || len(store.steps) != 0 || len(store.statuses) != 0 {
+		t.Fatalf("expected no side effects for repeated cancel")
+	}
+}
+
+func TestCancelOrder_RejectsNonSucceededStatuses(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []saga.SagaStatus{saga.SagaStatusStarted, saga.SagaStatusFailed, saga.SagaStatusRefunded} {
+		service, payment, driver, _, _ := newCancelFixture(status)
+
+		err := service.CancelOrder(context.Background(), "order-1", "")
+		if !errors.Is(err, ErrOrderNotCancellable) {
+			t.Fatalf("status %s: expected ErrOrderNotCancellable, got %v", status, err)
+		}
+		if payment.refundCalled || driver.releaseCalled {
+			t.Fatalf("status %s: expected no compensation", status)
+		}
+	}
+}
+
+func TestCancelOrder_AlreadyRefundedCompletesCancellation(t *testing.T) {
+	t.Parallel()
+
+	service, payment, _, store, _ := newCancelFixture(saga.SagaStatusSucceeded)
+	payment.refundErr = saga.ErrAlreadyRefunded
+
+	if err := service.CancelOrder(context.Background(), "order-1", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.views[0].Status != saga.SagaStatusCancelled {
+		t.Fatalf("expected cancelled status, got %s", store.views[0].Status)
+	}
+}
+
+func TestCancelOrder_RefundFailureLeavesOrderCancelling(t *testing.T) {
+	t.Parallel()
+
+	service, payment, _, store, _ := newCancelFixture(saga.SagaStatusSucceeded)
+	refundErr := errors.New("gateway down")
+	payment.refundErr = refundErr
+
+	err := service.CancelOrder(context.Background(), "order-1", "")
+	if !errors.Is(err, refundErr) {
+		t.Fatalf("expected refund error, got %v", err)
+	}
+	if store.views[0].Status != saga.SagaStatusCancelling {
+		t.Fatalf("expected the order to stay cancelling, got %s", store.views[0].Status)
+	}
+	last := store.steps[len(store.steps)-1]
+	if last.step != "cancel" || last.status != "failed" {
+		t.Fatalf("expected cancel failed step, got %+v", last)
+	}
+
+	// A repeated cancel finishes the refund the first one missed.
+	payment.refundErr = nil
+	payment.refundCalled = false
+	if err := service.CancelOrder(context.Background(), "order-1", ""); err != nil {
+		t.Fatalf("retry: unexpected error: %v", err)
+	}
+	if !payment.refundCalled || store.views[0].Status != saga.SagaStatusCancelled {
+		t.Fatalf("expected the retry to refund and cancel, got refund=%v status=%s", payment.refundCalled, store.views[0].Status)
+	}
+}
+
+func TestCancelOrder_ReleaseFailureSkipsRefund(t *testing.T) {
+	t.Parallel()
+
+	service, payment, driver, _, _ := newCancelFixture(saga.SagaStatusSucceeded)
+	driver.releaseErr = errors.New("db down")
+
+	if err := service.CancelOrder(context.Background(), "order-1", ""); err == nil {
+		t.Fatalf("expected error")
+	}
+	if payment.refundCalled {
+		t.Fatalf("expected refund to be skipped when release fails")
+	}
+}
+
+func TestCancelOrder_UnknownOrder(t *testing.T) {
+	t.Parallel()
+
+	service, _, _, _, _ := newCancelFixture(saga.SagaStatusSucceeded)
+
+	if err := service.CancelOrder(context.Background(), "missing", ""); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+// lockedCancelStore holds one order behind a mutex. The first two reads wait for each
+// other, so two cancels both see the order succeeded before either claims it.
+type lockedCancelStore struct {
+	mu      sync.Mutex
+	status  saga.SagaStatus
+	reads   int
+	barrier sync.WaitGroup
+}
+
+func (s *lockedCancelStore) Start(ctx context.Context, idempotencyKey string, record saga.SagaRecord) (saga.SagaRecord, bool, error) {
+	return record, true, nil
+}
+
+func (s *lockedCancelStore) UpdateStatus(ctx context.Context, orderID string, status saga.SagaStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	return nil
+}
+
+func (s *lockedCancelStore) TransitionStatus(ctx context.Context, orderID string, from, to saga.SagaStatus) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != from {
+		return false, nil
+	}
+	s.status = to
+	return true, nil
+}
+
+func (s *lockedCancelStore) AddStep(ctx context.Context, orderID, step, status, detail string) error {
+	return nil
+}
+
+func (s *lockedCancelStore) GetOrder(ctx context.Context, orderID string) (saga.OrderView, error) {
+	s.mu.Lock()
+	s.reads++
+	first := s.reads <= 2
+	s.mu.Unlock()
+	if first {
+		s.barrier.Done()
+		s.barrier.Wait()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return saga.OrderView{OrderID: orderID, Amount: usd(1500), Status: s.status}, nil
+}
+
+func (s *lockedCancelStore) ListOrders(ctx context.Context, filter saga.OrderFilter) ([]saga.OrderView, error) {
+	return nil, nil
+}
+
+type countingPayment struct {
+	refunds atomic.Int32
+}
+
+func (p *countingPayment) Charge(ctx context.Context, orderID string, amount money.Money) error {
+	return nil
+}
+
+func (p *countingPayment) Refund(ctx context.Context, orderID string, amount money.Money) error {
+	p.refunds.Add(1)
+	return nil
+}
+
+type countingDriver struct {
+	releases atomic.Int32
+}
+
+func (d *countingDriver) Assign(ctx context.Context, orderID, driverID string) error {
+	return nil
+}
+
+func (d *countingDriver) Release(ctx context.Context, orderID string) error {
+	d.releases.Add(1)
+	return nil
+}
+
+func TestCancelOrder_ConcurrentCancelsRefundOnce(t *testing.T) {
+	t.Parallel()
+
+	store := &lockedCancelStore{status: saga.SagaStatusSucceeded}
+	store.barrier.Add(2)
+	payment, driver := &countingPayment{}, &countingDriver{}
+	service := NewOrderService(payment, driver, store, nil, randomDriver)
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- service.CancelOrder(context.Background(), "order-1", "") }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if payment.refunds.Load() != 1 || driver.releases.Load() != 1 {
+		t.Fatalf("expected one refund and one release, got %d and %d", payment.refunds.Load(), driver.releases.Load())
+	}
+	if store.status != saga.SagaStatusCancelled {
+		t.Fatalf("expected cancelled, got %s", store.status)
+	}
+}
+
+func TestCancelOrder_LosesToConcurrentCompletion(t *testing.T) {
+	t.Parallel()
+
+	service, payment, driver, store, _ := newCancelFixture(saga.SagaStatusSucceeded)
+	// Completion lands between the status check and the claim.
+	service.reader = &racingReader{store: store, then: saga.SagaStatusCompleted}
+
+	err := service.CancelOrder(context.Background(), "order-1", "")
+	if !errors.Is(err, ErrOrderNotCancellable) {
+		t.Fatalf("expected ErrOrderNotCancellable, got %v", err)
+	}
+	if payment.refundCalled || driver.releaseCalled || len(store.steps) != 0 {
+		t.Fatalf("expected no compensation after losing the transition")
+	}
+}
+
+// racingReader returns the stored view on the first read, then moves the order to then,
+// as if another caller had changed it in between.
+type racingReader struct {
+	store *spyReaderSagaStore
+	then  saga.SagaStatus
+	reads int
+}
+
+func (r *racingReader) GetOrder(ctx context.Context, orderID string) (saga.OrderView, error) {
+	view, err := r.store.GetOrder(ctx, orderID)
+	r.reads++
+	if r.reads == 1 {
+		r.store.views[0].Status = r.then
+	}
+	return view, err
+}
+
+func (r *racingReader) ListOrders(ctx context.Context, filter saga.OrderFilter) ([]saga.OrderView, error) {
+	return r.store.ListOrders(ctx, filter)
+}
@@ -0,0 +1,85 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+)
+
+// SagaLeaser leases sagas to the process acting on them. The recovery worker only claims
+// sagas without a live lease, so one held by a running CreateOrder or CancelOrder is
+// left alone however long a step takes.
+type SagaLeaser interface {
+	// AcquireLease leases the saga to owner unless someone else holds a live lease,
+	// reporting whether owner now holds it.
+	AcquireLease(ctx context.Context, orderID, owner string, lease time.Duration) (bool, error)
+	RenewLease(ctx context.Context, orderID, owner string, lease time.Duration) error
+	ReleaseLease(ctx context.Context, orderID, owner string) error
+}
+
+// ErrOrderBusy signals another process is acting on the order; the call can be retried.
+var ErrOrderBusy = errors.New("order is being processed elsewhere")
+
+// defaultLeaseTTL is how long a live order write leases its saga. The lease is renewed
+// every third of it until the write returns.
+const defaultLeaseTTL = time.Minute
+
+// withLease runs fn while holding orderID's lease, or returns ErrOrderBusy without
+// running it when another owner holds the lease. Without a leaser fn runs unleased.
+func (s *OrderService) withLease(ctx context.Context, orderID string, fn func(context.Context) error) error {
+	if s.leaser == nil {
+		return fn(ctx)
+	}
+	acquired, err := s.leaser.AcquireLease(ctx, orderID, s.leaseOwner, s.leaseTTL)
+	if err != nil {
+		return fmt.Errorf("acquire saga lease: %w", err)
+	}
+	if !acquired {
+		return ErrOrderBusy
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.renewLease(ctx, orderID, stop)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+		// A cancelled request must still free the saga for recovery.
+		if err := s.leaser.ReleaseLease(context.WithoutCancel(ctx), orderID, s.leaseOwner); err != nil {
+			slog.WarnContext(ctx, "release saga lease failed", "error", err)
+		}
+	}()
+	return fn(ctx)
+}
+
+// renewLease extends the lease every third of the TTL until stop closes or the lease is
+// lost. The running write is not interrupted when the lease is lost: a half-done charge
+// or refund is better finished than abandoned, and both are safe to repeat.
+func (s *OrderService) renewLease(ctx context.Context, orderID string, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.leaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.leaser.RenewLease(ctx, orderID, s.leaseOwner, s.leaseTTL)
+			if errors.Is(err, saga.ErrLeaseLost) {
+				slog.WarnContext(ctx, "saga lease lost while the order was in progress")
+				return
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "renew saga lease failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/orders/saga"
+)
+
+// leasingSagaStore is a spyReaderSagaStore that also leases sagas. held reports whether a
+// lease is live, and busy makes every acquisition fail as if another owner held it.
+type leasingSagaStore struct {
+	*spyReaderSagaStore
+
+	mu       sync.Mutex
+	busy     bool
+	held     bool
+	acquired int
+	renewals int
+	released int
+}
+
+func (s *leasingSagaStore) AcquireLease(ctx context.Context, orderID, owner string, lease time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.busy {
+		return false, nil
+	}
+	s.acquired++
+	s.held = true
+	return true, nil
+}
+
+func (s *leasingSagaStore) RenewLease(ctx context.Context, orderID, owner string, lease time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renewals++
+	return nil
+}
+
+func (s *leasingSagaStore) ReleaseLease(ctx context.Context, orderID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released++
+	s.held = false
+	return nil
+}
+
+func (s *leasingSagaStore) isHeld() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.held
+}
+
+func TestCreateOrder_HoldsLeaseThroughSlowSteps(t *testing.T) {
+	t.Parallel()
+
+	store := &leasingSagaStore{spyReaderSagaStore: &spyReaderSagaStore{spySagaStore: spySagaStore{created: true}}}
+	var heldDuringAssign bool
+	slowSelector := func(ctx context.Context, orderID string, pickup geo.Point) (string, error) {
+		// Outlast several lease periods, as a slow dispatch would.
+		time.Sleep(100 * time.Millisecond)
+		heldDuringAssign = store.isHeld()
+		return "driver-1", nil
+	}
+	service := NewOrderService(&spyPayment{}, &spyDriver{}, store, func() string { return "order-1" }, slowSelector)
+	service.leaseTTL = 30 * time.Millisecond
+
+	if _, err := service.CreateOrder(context.Background(), "user-1", usd(999), testPickup, "idem-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !heldDuringAssign {
+		t.Fatalf("expected the saga to stay leased while the step ran")
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.acquired != 1 || store.renewals == 0 || store.released != 1 || store.held {
+		t.Fatalf("expected one lease renewed and released, got acquired=%d renewals=%d released=%d", store.acquired, store.renewals, store.released)
+	}
+}
+
+func TestCancelOrder_BusyWhileAnotherOwnerFinishesIt(t *testing.T) {
+	t.Parallel()
+
+	store := &leasingSagaStore{
+		spyReaderSagaStore: &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-1", Amount: usd(1500), Status: saga.SagaStatusCancelling}}},
+		busy:               true,
+	}
+	payment, driver := &spyPayment{}, &spyDriver{}
+	service := NewOrderService(payment, driver, store, nil, randomDriver)
+
+	if err := service.CancelOrder(context.Background(), "order-1", ""); !errors.Is(err, ErrOrderBusy) {
+		t.Fatalf("expected ErrOrderBusy, got %v", err)
+	}
+	if payment.refundCalled || driver.releaseCalled {
+		t.Fatalf("expected no compensation while another owner holds the lease")
+	}
+}
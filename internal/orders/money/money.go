@@ -0,0 +1,92 @@
+// Package money represents order amounts as integer minor units with an ISO 4217 currency.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// DefaultCurrency is assumed for amounts that predate explicit currencies: legacy
+// DOUBLE PRECISION rows and the deprecated float amount on CreateOrderRequest.
+const DefaultCurrency = "USD"
+
+var (
+	ErrInvalidAmount    = errors.New("amount must be greater than zero")
+	ErrInvalidCurrency  = errors.New("currency must be a three-letter ISO 4217 code")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an amount in the currency's minor units, e.g. cents for USD.
+type Money struct {
+	Units    int64
+	Currency string
+}
+
+// Validate rejects non-positive amounts and malformed currency codes.
+func (m Money) Validate() error {
+	if !validCurrency(m.Currency) {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, m.Currency)
+	}
+	if m.Units <= 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// IsZero reports whether m is the zero value.
+func (m Money) IsZero() bool {
+	return m == Money{}
+}
+
+// Major returns the amount in major units. It exists for deprecated float fields only.
+func (m Money) Major() float64 {
+	return float64(m.Units) / math.Pow10(Exponent(m.Currency))
+}
+
+// String formats m as "12.34 USD".
+func (m Money) String() string {
+	exp := Exponent(m.Currency)
+	if exp == 0 {
+		return strconv.FormatInt(m.Units, 10) + " " + m.Currency
+	}
+	sign := ""
+	units := m.Units
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	scale := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, units/scale, exp, units%scale, m.Currency)
+}
+
+// FromMajor converts a major-unit float, rounding half away from zero to the nearest
+// minor unit. It is the migration path for legacy float amounts.
+func FromMajor(amount float64, currency string) Money {
+	return Money{Units: int64(math.Round(amount * math.Pow10(Exponent(currency)))), Currency: currency}
+}
+
+// Exponent returns the number of minor-unit digits for currency.
+func Exponent(currency string) int {
+	switch currency {
+	case "BIF", "CLP", "DJF", "GNF", "ISK", "JPY", "KMF", "KRW", "PYG", "RWF", "UGX", "VND", "VUV", "XAF", "XOF", "XPF":
+		return 0
+	case "BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND":
+		return 3
+	default:
+		return 2
+	}
+}
+
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
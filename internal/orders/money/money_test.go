@@ -0,0 +1,72 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMoney_Validate(t *testing.T) {
+	cases := []struct {
+		name string
+		m    Money
+		want error
+	}{
+		{"valid", Money{Units: 999, Currency: "USD"}, nil},
+		{"zero", Money{Units: 0, Currency: "USD"}, ErrInvalidAmount},
+		{"negative", Money{Units: -1, Currency: "EUR"}, ErrInvalidAmount},
+		{"missing currency", Money{Units: 100}, ErrInvalidCurrency},
+		{"lowercase currency", Money{Units: 100, Currency: "usd"}, ErrInvalidCurrency},
+		{"long currency", Money{Units: 100, Currency: "USDT"}, ErrInvalidCurrency},
+	}
+	for _, tc := range cases {
+		if err := tc.m.Validate(); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestFromMajor_RoundsToMinorUnits(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		currency string
+		want     int64
+	}{
+		{9.99, "USD", 999},
+		{0.1 + 0.2, "USD", 30},
+		{29.995, "EUR", 3000},
+		{1500, "JPY", 1500},
+		{1.2345, "KWD", 1235},
+		{-4.5, "USD", -450},
+	}
+	for _, tc := range cases {
+		got := FromMajor(tc.amount, tc.currency)
+		if got.Units != tc.want || got.Currency != tc.currency {
+			t.Fatalf("FromMajor(%v, %s) = %+v, want %d", tc.amount, tc.currency, got, tc.want)
+		}
+	}
+}
+
+func TestMoney_StringAndMajor(t *testing.T) {
+	cases := []struct {
+		m     Money
+		str   string
+		major float64
+	}{
+		{Money{Units: 1234, Currency: "USD"}, "12.34 USD", 12.34},
+		{Money{Units: 5, Currency: "EUR"}, "0.05 EUR", 0.05},
+		{Money{Units: -250, Currency: "GBP"}, "-2.50 GBP", -2.5},
+		{Money{Units: 1500, Currency: "JPY"}, "1500 JPY", 1500},
+		{Money{Units: 1005, Currency: "KWD"}, "1.005 KWD", 1.005},
+	}
+	for _, tc := range cases {
+		if got := tc.m.String(); got != tc.str {
+			t.Fatalf("String() = %s, want %s", got, tc.str)
+		}
+		if got := tc.m.Major(); got != tc.major {
+			t.Fatalf("Major() = %v, want %v", got, tc.major)
+		}
+	}
+	if !(Money{}).IsZero() || (Money{Units: 1}).IsZero() {
+		t.Fatalf("unexpected IsZero result")
+	}
+}
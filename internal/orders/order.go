@@ -4,7 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"wayfinder/internal/dispatch"
+	"wayfinder/internal/geo"
+	"wayfinder/internal/logging"
+	"wayfinder/internal/orders/money"
 	"wayfinder/internal/orders/saga"
 
 	"github.com/google/uuid"
@@ -12,106 +18,177 @@ import (
 
 // PaymentClient charges a payment instrument for an order.
 type PaymentClient interface {
-	Charge(ctx context.Context, orderID string, amount float64) error
-	Refund(ctx context.Context, orderID string, amount float64) error
+	Charge(ctx context.Context, orderID string, amount money.Money) error
+	Refund(ctx context.Context, orderID string, amount money.Money) error
 }
 
-// DriverClient assigns a driver to an order.
+// DriverClient assigns a driver to an order and releases the assignment on cancellation.
 type DriverClient interface {
 	Assign(ctx context.Context, orderID string, driverID string) error
+	Release(ctx context.Context, orderID string) error
 }
 
 // IDGenerator returns a new order ID.
 type IDGenerator func() string
 
-// DriverSelector returns a driver ID to assign.
-type DriverSelector func() string
+// DriverSelector picks the driver to assign for an order collected at pickup, claiming
+// it so concurrent orders get different drivers. It returns ErrNoDriverAvailable when
+// nobody can take the order.
+type DriverSelector func(ctx context.Context, orderID string, pickup geo.Point) (string, error)
 
 // OrderService coordinates payment and driver assignment.
 type OrderService struct {
 	payments  PaymentClient
 	drivers   DriverClient
 	sagas     saga.SagaStore
+	reader    OrderReader
+	watcher   OrderWatcher
+	feed      *eventFeed
+	trips     TripStore
 	idGen     IDGenerator
 	driverSel DriverSelector
+
+	leaser     SagaLeaser
+	leaseOwner string
+	leaseTTL   time.Duration
 }
 
-// NewOrderService constructs an OrderService.
+// NewOrderService constructs an OrderService. Order reads, watches and trips are enabled
+// when the saga store also implements OrderReader, OrderWatcher and TripStore, and
+// sagas are leased while orders are created or cancelled when it implements SagaLeaser.
+// Without a driver selector orders are refused with ErrDispatchNotConfigured before any
+// charge.
 func NewOrderService(payments PaymentClient, drivers DriverClient, sagas saga.SagaStore, idGen IDGenerator, driverSel DriverSelector) *OrderService {
 	if idGen == nil {
 		idGen = newOrderID
 	}
-	if driverSel == nil {
-		driverSel = newDriverID
+	reader, _ := sagas.(OrderReader)
+	watcher, _ := sagas.(OrderWatcher)
+	trips, _ := sagas.(TripStore)
+	leaser, _ := sagas.(SagaLeaser)
+	var feed *eventFeed
+	if watcher != nil {
+		feed = newEventFeed(watcher)
 	}
 
 	return &OrderService{
 		payments:  payments,
 		drivers:   drivers,
 		sagas:     sagas,
+		reader:    reader,
+		watcher:   watcher,
+		feed:      feed,
+		trips:     trips,
 		idGen:     idGen,
 		driverSel: driverSel,
+
+		leaser:     leaser,
+		leaseOwner: newUUIDString(),
+		leaseTTL:   defaultLeaseTTL,
 	}
 }
 
 var (
 	ErrIdempotencyKeyRequired = errors.New("idempotency key required")
 	ErrIdempotencyConflict    = saga.ErrIdempotencyConflict
+	ErrInvalidAmount          = money.ErrInvalidAmount
+	ErrInvalidCurrency        = money.ErrInvalidCurrency
+	ErrPickupRequired         = geo.ErrPointRequired
+	ErrInvalidPickup          = geo.ErrInvalidPoint
+	ErrNoDriverAvailable      = dispatch.ErrNoDriverAvailable
+	ErrDispatchNotConfigured  = errors.New("dispatch not configured")
 )
 
 // CreateOrder orchestrates the payment and driver assignment steps.
-func (s *OrderService) CreateOrder(ctx context.Context, userID string, amount float64, idempotencyKey string) (string, error) {
+func (s *OrderService) CreateOrder(ctx context.Context, userID string, amount money.Money, pickup geo.Point, idempotencyKey string) (string, error) {
 	if idempotencyKey == "" {
 		return "", ErrIdempotencyKeyRequired
 	}
+	if err := amount.Validate(); err != nil {
+		return "", err
+	}
+	if err := pickup.Validate(); err != nil {
+		return "", err
+	}
+	if s.driverSel == nil {
+		return "", ErrDispatchNotConfigured
+	}
 
 	orderID := s.idGen()
-	driverID := s.driverSel()
+	ctx = logging.With(ctx, "idempotency_key", idempotencyKey)
 
-	record, created, err := s.sagas.Start(ctx, idempotencyKey, orderID, userID, amount)
+	record, created, err := s.sagas.Start(ctx, idempotencyKey, saga.SagaRecord{OrderID: orderID, UserID: userID, Amount: amount, Pickup: pickup})
 	if err != nil {
 		if errors.Is(err, ErrIdempotencyConflict) {
+			slog.WarnContext(ctx, "idempotency key reused for a different order")
 			return "", err
 		}
+		slog.ErrorContext(ctx, "start order saga failed", "error", err)
 		return "", err
 	}
+	// A replayed key refers to the order created on the first attempt.
+	ctx = logging.With(ctx, "order_id", record.OrderID)
 	if !created {
+		slog.InfoContext(ctx, "order request replayed", "status", record.Status)
 		if record.Status == saga.SagaStatusSucceeded {
 			return record.OrderID, nil
 		}
 		return record.OrderID, fmt.Errorf("order already processed with status %s", record.Status)
 	}
 
-	_ = s.sagas.AddStep(ctx, orderID, "charge", "started", "")
-	if err := s.payments.Charge(ctx, orderID, amount); err != nil {
-		_ = s.sagas.AddStep(ctx, orderID, "charge", "failed", err.Error())
-		_ = s.sagas.UpdateStatus(ctx, orderID, saga.SagaStatusFailed)
+	// The lease keeps recovery away from the saga while a slow step is still running.
+	var status saga.SagaStatus
+	err = s.withLease(ctx, orderID, func(ctx context.Context) error {
+		var err error
+		status, err = saga.NewEngine(s.sagas).Run(ctx, orderID, s.createOrderSaga(orderID, amount, pickup))
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "create order failed", "status", status, "error", err)
 		return "", err
 	}
-	_ = s.sagas.AddStep(ctx, orderID, "charge", "succeeded", "")
-
-	_ = s.sagas.AddStep(ctx, orderID, "assign", "started", "")
-	if err := s.drivers.Assign(ctx, orderID, driverID); err != nil {
-		_ = s.sagas.AddStep(ctx, orderID, "assign", "failed", err.Error())
-		// Compensate by refunding the payment if driver assignment fails.
-		_ = s.sagas.AddStep(ctx, orderID, "refund", "started", "")
-		if refundErr := s.payments.Refund(ctx, orderID, amount); refundErr != nil {
-			_ = s.sagas.AddStep(ctx, orderID, "refund", "failed", refundErr.Error())
-			_ = s.sagas.UpdateStatus(ctx, orderID, saga.SagaStatusFailed)
-			return "", fmt.Errorf("driver assignment failed: %w; refund failed: %v", err, refundErr)
-		}
-		_ = s.sagas.AddStep(ctx, orderID, "refund", "succeeded", "")
-		_ = s.sagas.UpdateStatus(ctx, orderID, saga.SagaStatusRefunded)
-		return "", err
+	slog.InfoContext(ctx, "order created")
+	return orderID, nil
+}
+
+// createOrderSaga charges the customer and assigns a driver, refunding the charge if
+// assignment fails. New steps (fraud checks, merchant acceptance) slot in here.
+func (s *OrderService) createOrderSaga(orderID string, amount money.Money, pickup geo.Point) saga.Definition {
+	return saga.Definition{
+		Steps: []saga.StepDef{
+			{
+				Name:             "charge",
+				Action:           func(ctx context.Context) error { return s.payments.Charge(ctx, orderID, amount) },
+				Compensation:     func(ctx context.Context) error { return s.payments.Refund(ctx, orderID, amount) },
+				CompensationName: "refund",
+			},
+			{
+				Name:   "assign",
+				Action: func(ctx context.Context) error { return s.assignDriver(ctx, orderID, pickup) },
+			},
+		},
+		CompensatedStatus: saga.SagaStatusRefunded,
 	}
+}
 
-	_ = s.sagas.AddStep(ctx, orderID, "assign", "succeeded", "")
-	_ = s.sagas.UpdateStatus(ctx, orderID, saga.SagaStatusSucceeded)
-	return orderID, nil
+// assignDriver selects a driver near pickup and assigns them to the order. Selection runs
+// inside the assign step so a failed search is compensated like a failed assignment.
+func (s *OrderService) assignDriver(ctx context.Context, orderID string, pickup geo.Point) error {
+	if s.driverSel == nil {
+		return ErrDispatchNotConfigured
+	}
+	driverID, err := s.driverSel(ctx, orderID, pickup)
+	if err != nil {
+		return err
+	}
+	// The dispatch selector has already stored the assignment when it claimed the
+	// driver, so against Postgres this Assign is an idempotent no-op costing one round
+	// trip. It stays because DriverSelector only promises a driver ID: a selector that
+	// picks without claiming still gets the assignment persisted here.
+	return s.drivers.Assign(ctx, orderID, driverID)
 }
 
-func newOrderID() string  { return newUUIDString() }
-func newDriverID() string { return newUUIDString() }
+func newOrderID() string { return newUUIDString() }
 
 func newUUIDString() string {
 	return uuid.Must(uuid.NewV7()).String()
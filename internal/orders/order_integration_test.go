@@ -2,12 +2,14 @@ package orders_test
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
 	"net"
 	"testing"
 
 	orderpb "wayfinder/api/proto/order"
 	ordersdb "wayfinder/internal/db/orders"
+	"wayfinder/internal/geo"
 	"wayfinder/internal/orders"
 
 	grpcadapter "wayfinder/internal/adapters/grpc"
@@ -28,12 +30,44 @@ func (f failingDriver) Assign(ctx context.Context, orderID string, driverID stri
 	return f.err
 }
 
+func (f failingDriver) Release(ctx context.Context, orderID string) error {
+	return f.err
+}
+
 func bufDialer(lis *bufconn.Listener) func(context.Context, string) (net.Conn, error) {
 	return func(ctx context.Context, _ string) (net.Conn, error) {
 		return lis.Dial()
 	}
 }
 
+// expectSagaWrite expects a saga status write and its outbox row in one transaction.
+func expectSagaWrite(mock sqlmock.Sqlmock, query string, args ...driver.Value) {
+	mock.ExpectBegin()
+	mock.ExpectExec(query).
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_outbox").
+		WithArgs("order-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// expectStepWrite expects a saga step insert, the touch of the saga's updated_at and
+// the step's outbox row in one transaction.
+func expectStepWrite(mock sqlmock.Sqlmock, args ...driver.Value) {
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO order_saga_steps").
+		WithArgs(append([]driver.Value{"order-1"}, args...)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE order_sagas SET updated_at").
+		WithArgs("order-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_outbox").
+		WithArgs("order-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
 func TestOrderService_RefundsOnDriverFailure_WithPostgresPayments(t *testing.T) {
 	ctx := context.Background()
 
@@ -52,42 +86,57 @@ func TestOrderService_RefundsOnDriverFailure_WithPostgresPayments(t *testing.T)
 
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS payments").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE payments").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE payments").
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_sagas").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_saga_steps").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_user_created_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_status_updated_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_outbox").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_pending_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas\\s+ADD COLUMN IF NOT EXISTS amount_units").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas\\s+ADD COLUMN IF NOT EXISTS pickup_lat").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_trips").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_order_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("INSERT INTO order_sagas").
-		WithArgs("order-1", "idem-1", "u1", 9.99, "started").
+		WithArgs("order-1", "idem-1", "u1", int64(999), "USD", 52.52, 13.405, "started").
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectQuery("SELECT order_id, user_id, amount, status").
+	mock.ExpectQuery("SELECT order_id, user_id, amount_units, currency, pickup_lat, pickup_long, status").
 		WithArgs("idem-1").
-		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount", "status"}).AddRow("order-1", "u1", 9.99, "started"))
-	mock.ExpectExec("INSERT INTO order_saga_steps").
-		WithArgs("order-1", "charge", "started", "").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "user_id", "amount_units", "currency", "pickup_lat", "pickup_long", "status"}).
+			AddRow("order-1", "u1", int64(999), "USD", 52.52, 13.405, "started"))
+	mock.ExpectExec("UPDATE order_sagas\\s+SET lease_owner = \\$2").
+		WithArgs("order-1", sqlmock.AnyArg(), 60.0).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectStepWrite(mock, "charge", "started", "")
 	mock.ExpectExec("INSERT INTO payments").
-		WithArgs("order-1", 9.99).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec("INSERT INTO order_saga_steps").
-		WithArgs("order-1", "charge", "succeeded", "").
+		WithArgs("order-1", int64(999), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec("INSERT INTO order_saga_steps").
-		WithArgs("order-1", "assign", "started", "").
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec("INSERT INTO order_saga_steps").
-		WithArgs("order-1", "assign", "failed", sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec("INSERT INTO order_saga_steps").
-		WithArgs("order-1", "refund", "started", "").
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec("UPDATE payments SET refund_amount").
-		WithArgs("order-1", 9.99).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec("INSERT INTO order_saga_steps").
-		WithArgs("order-1", "refund", "succeeded", "").
+	expectStepWrite(mock, "charge", "succeeded", "")
+	expectStepWrite(mock, "assign", "started", "")
+	expectStepWrite(mock, "assign", "failed", sqlmock.AnyArg())
+	expectStepWrite(mock, "refund", "started", "")
+	mock.ExpectExec("UPDATE payments SET refund_units").
+		WithArgs("order-1", int64(999), "USD").
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectExec("UPDATE order_sagas").
-		WithArgs("order-1", "refunded").
+	expectStepWrite(mock, "refund", "succeeded", "")
+	expectSagaWrite(mock, "UPDATE order_sagas", "order-1", "refunded")
+	mock.ExpectExec("UPDATE order_sagas\\s+SET lease_owner = NULL").
+		WithArgs("order-1", sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectClose()
 
@@ -102,7 +151,7 @@ func TestOrderService_RefundsOnDriverFailure_WithPostgresPayments(t *testing.T)
 
 	driver := failingDriver{err: errors.New("assign failed")}
 	idGen := func() string { return "order-1" }
-	driverSel := func() string { return "driver-x" }
+	driverSel := func(context.Context, string, geo.Point) (string, error) { return "driver-x", nil }
 	service := orders.NewOrderService(payments, driver, sagas, idGen, driverSel)
 
 	lis := bufconn.Listen(1024 * 1024)
@@ -138,6 +187,7 @@ func TestOrderService_RefundsOnDriverFailure_WithPostgresPayments(t *testing.T)
 	_, err = client.CreateOrder(ctx, &orderpb.CreateOrderRequest{
 		UserId:         "u1",
 		Amount:         9.99,
+		Pickup:         &orderpb.LatLng{Latitude: 52.52, Longitude: 13.405},
 		IdempotencyKey: "idem-1",
 	})
 	if err == nil {
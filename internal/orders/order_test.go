@@ -1,10 +1,17 @@
 package orders
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"log/slog"
+	"strings"
 	"testing"
 
+	"wayfinder/internal/geo"
+	"wayfinder/internal/logging"
+	"wayfinder/internal/orders/money"
 	"wayfinder/internal/orders/saga"
 
 	"github.com/google/uuid"
@@ -13,16 +20,16 @@ import (
 type spyPayment struct {
 	called        bool
 	orderID       string
-	amount        float64
+	amount        money.Money
 	err           error
 	refundCalled  bool
 	refundOrderID string
-	refundAmount  float64
+	refundAmount  money.Money
 	refundErr     error
 	callLog       *[]string
 }
 
-func (s *spyPayment) Charge(ctx context.Context, orderID string, amount float64) error {
+func (s *spyPayment) Charge(ctx context.Context, orderID string, amount money.Money) error {
 	s.called = true
 	s.orderID = orderID
 	s.amount = amount
@@ -32,7 +39,7 @@ func (s *spyPayment) Charge(ctx context.Context, orderID string, amount float64)
 	return s.err
 }
 
-func (s *spyPayment) Refund(ctx context.Context, orderID string, amount float64) error {
+func (s *spyPayment) Refund(ctx context.Context, orderID string, amount money.Money) error {
 	s.refundCalled = true
 	s.refundOrderID = orderID
 	s.refundAmount = amount
@@ -43,11 +50,14 @@ func (s *spyPayment) Refund(ctx context.Context, orderID string, amount float64)
 }
 
 type spyDriver struct {
-	called   bool
-	orderID  string
-	driverID string
-	err      error
-	callLog  *[]string
+	called         bool
+	orderID        string
+	driverID       string
+	err            error
+	releaseCalled  bool
+	releaseOrderID string
+	releaseErr     error
+	callLog        *[]string
 }
 
 func (s *spyDriver) Assign(ctx context.Context, orderID string, driverID string) error {
@@ -60,6 +70,28 @@ func (s *spyDriver) Assign(ctx context.Context, orderID string, driverID string)
 	return s.err
 }
 
+func (s *spyDriver) Release(ctx context.Context, orderID string) error {
+	s.releaseCalled = true
+	s.releaseOrderID = orderID
+	if s.callLog != nil {
+		*s.callLog = append(*s.callLog, "release")
+	}
+	return s.releaseErr
+}
+
+func usd(units int64) money.Money {
+	return money.Money{Units: units, Currency: "USD"}
+}
+
+var testPickup = geo.Point{Lat: 52.52, Long: 13.405}
+
+func fixedDriver(driverID string) DriverSelector {
+	return func(context.Context, string, geo.Point) (string, error) { return driverID, nil }
+}
+
+// randomDriver assigns a fresh driver ID to every order; production always dispatches.
+func randomDriver(context.Context, string, geo.Point) (string, error) { return newUUIDString(), nil }
+
 type sagaStep struct {
 	orderID string
 	step    string
@@ -72,7 +104,8 @@ type spySagaStore struct {
 	startKey    string
 	startOrder  string
 	startUser   string
-	startAmount float64
+	startAmount money.Money
+	startPickup geo.Point
 	created     bool
 	record      saga.SagaRecord
 	err         error
@@ -80,19 +113,20 @@ type spySagaStore struct {
 	statuses    []saga.SagaStatus
 }
 
-func (s *spySagaStore) Start(ctx context.Context, idempotencyKey, orderID, userID string, amount float64) (saga.SagaRecord, bool, error) {
+func (s *spySagaStore) Start(ctx context.Context, idempotencyKey string, record saga.SagaRecord) (saga.SagaRecord, bool, error) {
 	s.startCalled = true
 	s.startKey = idempotencyKey
-	s.startOrder = orderID
-	s.startUser = userID
-	s.startAmount = amount
+	s.startOrder = record.OrderID
+	s.startUser = record.UserID
+	s.startAmount = record.Amount
+	s.startPickup = record.Pickup
 	if s.err != nil {
 		return saga.SagaRecord{}, false, s.err
 	}
-	record := s.record
-	if record.OrderID == "" {
-		record = saga.SagaRecord{OrderID: orderID, UserID: userID, Amount: amount, Status: saga.SagaStatusStarted}
+	if s.record.OrderID != "" {
+		return s.record, s.created, nil
 	}
+	record.Status = saga.SagaStatusStarted
 	return record, s.created, nil
 }
 
@@ -101,6 +135,11 @@ func (s *spySagaStore) UpdateStatus(ctx context.Context, orderID string, status
 	return nil
 }
 
+func (s *spySagaStore) TransitionStatus(ctx context.Context, orderID string, from, to saga.SagaStatus) (bool, error) {
+	s.statuses = append(s.statuses, to)
+	return true, nil
+}
+
 func (s *spySagaStore) AddStep(ctx context.Context, orderID, step, status, detail string) error {
 	s.steps = append(s.steps, sagaStep{orderID: orderID, step: step, status: status, detail: detail})
 	return nil
@@ -114,12 +153,12 @@ func TestCreateOrder_Success(t *testing.T) {
 	driver := &spyDriver{callLog: &callLog}
 	sagas := &spySagaStore{created: true}
 	idGen := func() string { return "order-123" }
-	driverSel := func() string { return "driver-abc" }
+	driverSel := fixedDriver("driver-abc")
 	service := NewOrderService(payment, driver, sagas, idGen, driverSel)
 
-	amount := 9.99
+	amount := usd(999)
 
-	orderID, err := service.CreateOrder(context.Background(), "user-1", amount, "idem-1")
+	orderID, err := service.CreateOrder(context.Background(), "user-1", amount, testPickup, "idem-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -156,11 +195,11 @@ func TestCreateOrder_Compensates_On_DriverFailure(t *testing.T) {
 	payment := &spyPayment{callLog: &callLog}
 	driver := &spyDriver{err: errors.New("assign failed"), callLog: &callLog}
 	sagas := &spySagaStore{created: true}
-	service := NewOrderService(payment, driver, sagas, func() string { return "order-456" }, func() string { return "driver-def" })
+	service := NewOrderService(payment, driver, sagas, func() string { return "order-456" }, fixedDriver("driver-def"))
 
-	amount := 19.99
+	amount := usd(1999)
 
-	_, err := service.CreateOrder(context.Background(), "user-1", amount, "idem-2")
+	_, err := service.CreateOrder(context.Background(), "user-1", amount, testPickup, "idem-2")
 	if err == nil {
 		t.Fatalf("expected error due to driver failure, got nil")
 	}
@@ -170,7 +209,7 @@ func TestCreateOrder_Compensates_On_DriverFailure(t *testing.T) {
 	}
 
 	if payment.refundOrderID != "order-456" || payment.refundAmount != amount {
-		t.Fatalf("refund called with wrong args: id=%s amount=%f", payment.refundOrderID, payment.refundAmount)
+		t.Fatalf("refund called with wrong args: id=%s amount=%s", payment.refundOrderID, payment.refundAmount)
 	}
 
 	if len(callLog) < 3 || callLog[0] != "charge" || callLog[1] != "assign" || callLog[2] != "refund" {
@@ -182,6 +221,46 @@ func TestCreateOrder_Compensates_On_DriverFailure(t *testing.T) {
 	}
 }
 
+func TestCreateOrder_LogsCarryRequestAndOrder(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Config{Format: logging.FormatJSON, Level: slog.LevelDebug})
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	prev := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	payment := &spyPayment{}
+	driver := &spyDriver{err: errors.New("assign failed")}
+	service := NewOrderService(payment, driver, &spySagaStore{created: true}, func() string { return "order-789" }, fixedDriver("driver-ghi"))
+
+	ctx := logging.WithRequestID(context.Background(), "req-9")
+	if _, err := service.CreateOrder(ctx, "user-1", usd(500), testPickup, "idem-9"); err == nil {
+		t.Fatalf("expected error due to driver failure")
+	}
+
+	var messages []string
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("decode %q: %v", raw, err)
+		}
+		if line["request_id"] != "req-9" || line["order_id"] != "order-789" || line["idempotency_key"] != "idem-9" {
+			t.Fatalf("line is missing correlation ids: %s", raw)
+		}
+		msg := line["msg"].(string)
+		if step, ok := line["step"].(string); ok {
+			msg += " " + step
+		}
+		messages = append(messages, msg)
+	}
+	want := "saga step succeeded charge,saga step failed assign,saga step succeeded refund,create order failed"
+	if strings.Join(messages, ",") != want {
+		t.Fatalf("unexpected log lines: %v", messages)
+	}
+}
+
 func TestCreateOrder_RefundFailureReported(t *testing.T) {
 	t.Parallel()
 
@@ -191,11 +270,11 @@ func TestCreateOrder_RefundFailureReported(t *testing.T) {
 	payment := &spyPayment{refundErr: refundErr, callLog: &callLog}
 	driver := &spyDriver{err: driverErr, callLog: &callLog}
 	sagas := &spySagaStore{created: true}
-	service := NewOrderService(payment, driver, sagas, func() string { return "order-789" }, func() string { return "driver-ghi" })
+	service := NewOrderService(payment, driver, sagas, func() string { return "order-789" }, fixedDriver("driver-ghi"))
 
-	amount := 29.99
+	amount := usd(2999)
 
-	_, err := service.CreateOrder(context.Background(), "user-1", amount, "idem-3")
+	_, err := service.CreateOrder(context.Background(), "user-1", amount, testPickup, "idem-3")
 	if err == nil {
 		t.Fatalf("expected error due to driver and refund failure, got nil")
 	}
@@ -217,15 +296,15 @@ func TestCreateOrder_RefundFailureReported(t *testing.T) {
 	}
 }
 
-func TestCreateOrder_DefaultGeneratorsUsePrefixedRandomIDs(t *testing.T) {
+func TestCreateOrder_DefaultGeneratorUsesRandomOrderIDs(t *testing.T) {
 	t.Parallel()
 
 	payment := &spyPayment{}
 	driver := &spyDriver{}
 	sagas := &spySagaStore{created: true}
-	service := NewOrderService(payment, driver, sagas, nil, nil)
+	service := NewOrderService(payment, driver, sagas, nil, randomDriver)
 
-	orderID, err := service.CreateOrder(context.Background(), "user-1", 12.34, "idem-default")
+	orderID, err := service.CreateOrder(context.Background(), "user-1", usd(1234), testPickup, "idem-default")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -236,8 +315,22 @@ func TestCreateOrder_DefaultGeneratorsUsePrefixedRandomIDs(t *testing.T) {
 	if payment.orderID != orderID {
 		t.Fatalf("payment saw different order id: %s", payment.orderID)
 	}
-	if _, err := uuid.Parse(driver.driverID); err != nil {
-		t.Fatalf("expected driver ID to be a UUID, got %s (%v)", driver.driverID, err)
+}
+
+func TestCreateOrder_RefusedWithoutDispatch(t *testing.T) {
+	t.Parallel()
+
+	payment := &spyPayment{}
+	driver := &spyDriver{}
+	sagas := &spySagaStore{created: true}
+	service := NewOrderService(payment, driver, sagas, nil, nil)
+
+	_, err := service.CreateOrder(context.Background(), "user-1", usd(1234), testPickup, "idem-1")
+	if !errors.Is(err, ErrDispatchNotConfigured) {
+		t.Fatalf("expected ErrDispatchNotConfigured, got %v", err)
+	}
+	if payment.called || driver.called || len(sagas.statuses) != 0 {
+		t.Fatalf("expected the order to be refused before any saga work")
 	}
 }
 
@@ -249,11 +342,11 @@ func TestCreateOrder_PaymentFailureStopsFlow(t *testing.T) {
 	payment := &spyPayment{err: paymentErr, callLog: &callLog}
 	driver := &spyDriver{callLog: &callLog}
 	sagas := &spySagaStore{created: true}
-	service := NewOrderService(payment, driver, sagas, func() string { return "order-999" }, func() string { return "driver-jkl" })
+	service := NewOrderService(payment, driver, sagas, func() string { return "order-999" }, fixedDriver("driver-jkl"))
 
-	amount := 49.99
+	amount := usd(4999)
 
-	_, err := service.CreateOrder(context.Background(), "user-1", amount, "idem-4")
+	_, err := service.CreateOrder(context.Background(), "user-1", amount, testPickup, "idem-4")
 	if err == nil {
 		t.Fatalf("expected error due to payment failure, got nil")
 	}
@@ -281,14 +374,14 @@ func TestCreateOrder_IdempotencyReturnsExisting(t *testing.T) {
 		record: saga.SagaRecord{
 			OrderID: "order-123",
 			UserID:  "user-1",
-			Amount:  10,
+			Amount:  usd(1000),
 			Status:  saga.SagaStatusSucceeded,
 		},
 	}
 
-	service := NewOrderService(payment, driver, sagas, func() string { return "order-999" }, func() string { return "driver-zzz" })
+	service := NewOrderService(payment, driver, sagas, func() string { return "order-999" }, fixedDriver("driver-zzz"))
 
-	orderID, err := service.CreateOrder(context.Background(), "user-1", 10, "idem-5")
+	orderID, err := service.CreateOrder(context.Background(), "user-1", usd(1000), testPickup, "idem-5")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -306,9 +399,9 @@ func TestCreateOrder_RequiresIdempotencyKey(t *testing.T) {
 	payment := &spyPayment{}
 	driver := &spyDriver{}
 	sagas := &spySagaStore{created: true}
-	service := NewOrderService(payment, driver, sagas, func() string { return "order-1" }, func() string { return "driver-1" })
+	service := NewOrderService(payment, driver, sagas, func() string { return "order-1" }, fixedDriver("driver-1"))
 
-	_, err := service.CreateOrder(context.Background(), "user-1", 1.0, "")
+	_, err := service.CreateOrder(context.Background(), "user-1", usd(100), testPickup, "")
 	if !errors.Is(err, ErrIdempotencyKeyRequired) {
 		t.Fatalf("expected ErrIdempotencyKeyRequired, got %v", err)
 	}
@@ -320,10 +413,92 @@ func TestCreateOrder_IdempotencyConflict(t *testing.T) {
 	payment := &spyPayment{}
 	driver := &spyDriver{}
 	sagas := &spySagaStore{err: ErrIdempotencyConflict}
-	service := NewOrderService(payment, driver, sagas, func() string { return "order-1" }, func() string { return "driver-1" })
+	service := NewOrderService(payment, driver, sagas, func() string { return "order-1" }, fixedDriver("driver-1"))
 
-	_, err := service.CreateOrder(context.Background(), "user-1", 1.0, "idem-x")
+	_, err := service.CreateOrder(context.Background(), "user-1", usd(100), testPickup, "idem-x")
 	if !errors.Is(err, ErrIdempotencyConflict) {
 		t.Fatalf("expected ErrIdempotencyConflict, got %v", err)
 	}
 }
+
+func TestCreateOrder_RejectsInvalidAmount(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		amount money.Money
+		want   error
+	}{
+		{usd(0), ErrInvalidAmount},
+		{usd(-100), ErrInvalidAmount},
+		{money.Money{Units: 100, Currency: "dollars"}, ErrInvalidCurrency},
+	}
+	for _, tc := range cases {
+		payment := &spyPayment{}
+		sagas := &spySagaStore{created: true}
+		service := NewOrderService(payment, &spyDriver{}, sagas, nil, randomDriver)
+
+		_, err := service.CreateOrder(context.Background(), "user-1", tc.amount, testPickup, "idem-1")
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%+v: expected %v, got %v", tc.amount, tc.want, err)
+		}
+		if payment.called || sagas.startAmount != (money.Money{}) {
+			t.Fatalf("%+v: expected rejection before saga start", tc.amount)
+		}
+	}
+}
+
+func TestCreateOrder_NoDriverAvailableRefunds(t *testing.T) {
+	t.Parallel()
+
+	callLog := []string{}
+	payment := &spyPayment{callLog: &callLog}
+	driver := &spyDriver{callLog: &callLog}
+	sagas := &spySagaStore{created: true}
+	var selectedAt geo.Point
+	selector := func(ctx context.Context, orderID string, pickup geo.Point) (string, error) {
+		selectedAt = pickup
+		return "", ErrNoDriverAvailable
+	}
+	service := NewOrderService(payment, driver, sagas, func() string { return "order-1" }, selector)
+
+	_, err := service.CreateOrder(context.Background(), "user-1", usd(1500), testPickup, "idem-1")
+	if !errors.Is(err, ErrNoDriverAvailable) {
+		t.Fatalf("expected ErrNoDriverAvailable, got %v", err)
+	}
+	if selectedAt != testPickup || sagas.startPickup != testPickup {
+		t.Fatalf("expected pickup passed to selector and saga, got %+v / %+v", selectedAt, sagas.startPickup)
+	}
+	if driver.called {
+		t.Fatalf("expected no assignment without a driver")
+	}
+	if len(callLog) != 2 || callLog[0] != "charge" || callLog[1] != "refund" {
+		t.Fatalf("expected call order [charge refund], got %v", callLog)
+	}
+	if len(sagas.statuses) == 0 || sagas.statuses[len(sagas.statuses)-1] != saga.SagaStatusRefunded {
+		t.Fatalf("expected saga to end in refunded status, got %v", sagas.statuses)
+	}
+}
+
+func TestCreateOrder_RejectsInvalidPickup(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pickup geo.Point
+		want   error
+	}{
+		{geo.Point{}, ErrPickupRequired},
+		{geo.Point{Lat: 91, Long: 0}, ErrInvalidPickup},
+	}
+	for _, tc := range cases {
+		sagas := &spySagaStore{created: true}
+		service := NewOrderService(&spyPayment{}, &spyDriver{}, sagas, nil, randomDriver)
+
+		_, err := service.CreateOrder(context.Background(), "user-1", usd(100), tc.pickup, "idem-1")
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%+v: expected %v, got %v", tc.pickup, tc.want, err)
+		}
+		if sagas.startCalled {
+			t.Fatalf("%+v: expected rejection before saga start", tc.pickup)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package outbox relays order domain events recorded in the order_outbox table to
+// downstream sinks. Delivery is at-least-once and preserves write order per order.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"wayfinder/internal/logging"
+)
+
+// Event is one persisted order domain event.
+type Event struct {
+	ID        int64
+	OrderID   string
+	Type      string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// Event types written alongside saga writes.
+const (
+	TypeOrderStep   = "order.step"
+	TypeOrderStatus = "order.status"
+)
+
+// Sink receives relayed events. Publish may be called again for an event it already
+// accepted if the relay crashes before recording delivery.
+type Sink interface {
+	Publish(ctx context.Context, ev Event) error
+}
+
+// Store hands pending events to publish in write order and records the ones it accepted.
+// Delivery stops at the first publish error so later events for the same order are not
+// sent ahead of it. Dispatch returns how many events were published.
+type Store interface {
+	Dispatch(ctx context.Context, limit int, publish func(Event) error) (int, error)
+}
+
+// RelayConfig controls polling cadence and batch size.
+type RelayConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Relay polls the outbox and forwards pending events to a sink.
+type Relay struct {
+	store Store
+	sink  Sink
+	cfg   RelayConfig
+	logf  func(format string, args ...any)
+}
+
+// NewRelay constructs a Relay with defaults for unset config.
+func NewRelay(store Store, sink Sink, cfg RelayConfig, logf func(format string, args ...any)) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if logf == nil {
+		logf = logging.Printf(slog.Default(), slog.LevelWarn)
+	}
+	return &Relay{store: store, sink: sink, cfg: cfg, logf: logf}
+}
+
+// Run relays events until ctx ends. Full batches are followed immediately by another
+// poll so a backlog drains without waiting for the ticker.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logf("outbox relay: %v", err)
+			}
+			if err != nil || n < r.cfg.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce relays one batch of pending events and returns how many were published.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	return r.store.Dispatch(ctx, r.cfg.BatchSize, func(ev Event) error {
+		if err := r.sink.Publish(ctx, ev); err != nil {
+			return fmt.Errorf("publish event %d for order %s: %w", ev.ID, ev.OrderID, err)
+		}
+		return nil
+	})
+}
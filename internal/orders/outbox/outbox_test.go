@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryStore mimics the Postgres outbox: pending events are handed out in order and
+// delivery stops at the first publish error.
+type memoryStore struct {
+	pending []Event
+}
+
+func (m *memoryStore) Dispatch(ctx context.Context, limit int, publish func(Event) error) (int, error) {
+	n := 0
+	for n < len(m.pending) && n < limit {
+		if err := publish(m.pending[n]); err != nil {
+			m.pending = m.pending[n:]
+			return n, err
+		}
+		n++
+	}
+	m.pending = m.pending[n:]
+	return n, nil
+}
+
+type flakySink struct {
+	got      []Event
+	failOnce map[int64]bool
+}
+
+func (f *flakySink) Publish(ctx context.Context, ev Event) error {
+	if f.failOnce[ev.ID] {
+		delete(f.failOnce, ev.ID)
+		return errors.New("sink unavailable")
+	}
+	f.got = append(f.got, ev)
+	return nil
+}
+
+func events(orderIDs ...string) []Event {
+	out := make([]Event, len(orderIDs))
+	for i, id := range orderIDs {
+		out[i] = Event{ID: int64(i + 1), OrderID: id, Type: TypeOrderStep}
+	}
+	return out
+}
+
+func TestRelay_RedeliversAfterSinkFailureInOrder(t *testing.T) {
+	store := &memoryStore{pending: events("order-1", "order-2", "order-1")}
+	sink := &flakySink{failOnce: map[int64]bool{2: true}}
+	relay := NewRelay(store, sink, RelayConfig{BatchSize: 10}, func(string, ...any) {})
+
+	n, err := relay.RunOnce(context.Background())
+	if err == nil || n != 1 {
+		t.Fatalf("expected partial delivery with error, n=%d err=%v", n, err)
+	}
+	n, err = relay.RunOnce(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("expected remaining events delivered, n=%d err=%v", n, err)
+	}
+
+	if len(sink.got) != 3 {
+		t.Fatalf("expected 3 events, got %+v", sink.got)
+	}
+	for i, ev := range sink.got {
+		if ev.ID != int64(i+1) {
+			t.Fatalf("events delivered out of order: %+v", sink.got)
+		}
+	}
+}
+
+func TestRelay_RunDrainsBacklogAndStops(t *testing.T) {
+	store := &memoryStore{pending: events("a", "b", "c", "d", "e")}
+	sink := NewChannelSink(5)
+	relay := NewRelay(store, sink, RelayConfig{BatchSize: 2, Interval: time.Hour}, func(string, ...any) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+
+	for i := 1; i <= 5; i++ {
+		select {
+		case ev := <-sink.Events():
+			if ev.ID != int64(i) {
+				t.Fatalf("expected event %d, got %d", i, ev.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	cancel()
+	<-done
+}
+
+func TestChannelSink_RespectsContextAndClose(t *testing.T) {
+	sink := NewChannelSink(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sink.Publish(ctx, Event{ID: 1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	sink.Close()
+	sink.Close()
+	if err := sink.Publish(context.Background(), Event{ID: 2}); !errors.Is(err, ErrSinkClosed) {
+		t.Fatalf("expected ErrSinkClosed, got %v", err)
+	}
+}
+
+func TestRedisStreamSink_AppendsEvent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	sink := NewRedisStreamSink(client, "", 0)
+	ev := Event{
+		ID:        42,
+		OrderID:   "order-1",
+		Type:      TypeOrderStatus,
+		Payload:   []byte(`{"status":"succeeded"}`),
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := sink.Publish(context.Background(), ev); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	msgs, err := client.XRange(context.Background(), "order_events", "-", "+").Result()
+	if err != nil {
+		t.Fatalf("xrange: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 stream entry, got %d", len(msgs))
+	}
+	values := msgs[0].Values
+	if values["event_id"] != "42" || values["order_id"] != "order-1" || values["type"] != TypeOrderStatus ||
+		values["payload"] != `{"status":"succeeded"}` || values["created_at"] != "2024-01-02T03:04:05Z" {
+		t.Fatalf("unexpected stream values: %+v", values)
+	}
+}
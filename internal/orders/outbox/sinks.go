@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSinkClosed is returned by ChannelSink after Close.
+var ErrSinkClosed = errors.New("outbox sink closed")
+
+// ChannelSink delivers events to an in-process channel, blocking while it is full.
+type ChannelSink struct {
+	ch        chan Event
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewChannelSink constructs a ChannelSink with the given buffer.
+func NewChannelSink(buffer int) *ChannelSink {
+	return &ChannelSink{ch: make(chan Event, buffer), done: make(chan struct{})}
+}
+
+// Events returns the channel events are delivered on.
+func (s *ChannelSink) Events() <-chan Event { return s.ch }
+
+// Publish hands ev to the channel or gives up when ctx ends or the sink is closed.
+func (s *ChannelSink) Publish(ctx context.Context, ev Event) error {
+	select {
+	case <-s.done:
+		return ErrSinkClosed
+	default:
+	}
+	select {
+	case s.ch <- ev:
+		return nil
+	case <-s.done:
+		return ErrSinkClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events. The events channel is left open for draining.
+func (s *ChannelSink) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// RedisStreamClient is the minimal client surface used by RedisStreamSink.
+type RedisStreamClient interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+}
+
+// RedisStreamSink appends events to a Redis stream. Consumers that need exactly-once
+// handling should dedupe on the event_id field.
+type RedisStreamSink struct {
+	client RedisStreamClient
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamSink constructs a Redis stream sink.
+func NewRedisStreamSink(client RedisStreamClient, stream string, maxLen int64) *RedisStreamSink {
+	if stream == "" {
+		stream = "order_events"
+	}
+	return &RedisStreamSink{client: client, stream: stream, maxLen: maxLen}
+}
+
+// Publish appends ev to the stream.
+func (s *RedisStreamSink) Publish(ctx context.Context, ev Event) error {
+	args := &redis.XAddArgs{
+		Stream: s.stream,
+		Values: map[string]any{
+			"event_id":   strconv.FormatInt(ev.ID, 10),
+			"order_id":   ev.OrderID,
+			"type":       ev.Type,
+			"payload":    string(ev.Payload),
+			"created_at": ev.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}
+	if s.maxLen > 0 {
+		args.MaxLen = s.maxLen
+		args.Approx = true
+	}
+	return s.client.XAdd(ctx, args).Err()
+}
@@ -0,0 +1,138 @@
+package orders
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+)
+
+// OrderReader reads order views from the saga tables.
+type OrderReader interface {
+	GetOrder(ctx context.Context, orderID string) (saga.OrderView, error)
+	ListOrders(ctx context.Context, filter saga.OrderFilter) ([]saga.OrderView, error)
+}
+
+const (
+	defaultListPageSize = 50
+	maxListPageSize     = 200
+)
+
+var (
+	ErrOrderIDRequired     = errors.New("order id required")
+	ErrOrderNotFound       = saga.ErrOrderNotFound
+	ErrInvalidPageToken    = errors.New("invalid page token")
+	ErrInvalidStatusFilter = errors.New("invalid status filter")
+	ErrInvalidCreatedRange = errors.New("created_after must be before created_before")
+	ErrOrderReadsDisabled  = errors.New("order reads not supported by saga store")
+)
+
+// ListOrdersQuery describes a page of orders to list.
+type ListOrdersQuery struct {
+	UserID        string
+	Status        saga.SagaStatus
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	PageSize      int
+	PageToken     string
+}
+
+// OrderPage is a page of orders plus the token for the next page, if any.
+type OrderPage struct {
+	Orders        []saga.OrderView
+	NextPageToken string
+}
+
+// GetOrder returns the current view of a single order.
+func (s *OrderService) GetOrder(ctx context.Context, orderID string) (saga.OrderView, error) {
+	if orderID == "" {
+		return saga.OrderView{}, ErrOrderIDRequired
+	}
+	if s.reader == nil {
+		return saga.OrderView{}, ErrOrderReadsDisabled
+	}
+	return s.reader.GetOrder(ctx, orderID)
+}
+
+// ListOrders returns orders newest first, paginated with an opaque page token.
+func (s *OrderService) ListOrders(ctx context.Context, query ListOrdersQuery) (OrderPage, error) {
+	if s.reader == nil {
+		return OrderPage{}, ErrOrderReadsDisabled
+	}
+	if query.Status != "" && !knownStatus(query.Status) {
+		return OrderPage{}, ErrInvalidStatusFilter
+	}
+	if !query.CreatedAfter.IsZero() && !query.CreatedBefore.IsZero() && !query.CreatedAfter.Before(query.CreatedBefore) {
+		return OrderPage{}, ErrInvalidCreatedRange
+	}
+
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultListPageSize
+	}
+	if pageSize > maxListPageSize {
+		pageSize = maxListPageSize
+	}
+
+	filter := saga.OrderFilter{
+		UserID:        query.UserID,
+		Status:        query.Status,
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		// Fetch one extra row to learn whether another page exists.
+		Limit: pageSize + 1,
+	}
+	if query.PageToken != "" {
+		cursor, err := decodePageToken(query.PageToken)
+		if err != nil {
+			return OrderPage{}, err
+		}
+		filter.After = &cursor
+	}
+
+	views, err := s.reader.ListOrders(ctx, filter)
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	page := OrderPage{Orders: views}
+	if len(views) > pageSize {
+		page.Orders = views[:pageSize]
+		last := page.Orders[pageSize-1]
+		page.NextPageToken = encodePageToken(saga.OrderCursor{CreatedAt: last.CreatedAt, OrderID: last.OrderID})
+	}
+	return page, nil
+}
+
+type pageToken struct {
+	CreatedAt time.Time `json:"c"`
+	OrderID   string    `json:"o"`
+}
+
+func encodePageToken(cursor saga.OrderCursor) string {
+	data, _ := json.Marshal(pageToken{CreatedAt: cursor.CreatedAt.UTC(), OrderID: cursor.OrderID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token string) (saga.OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return saga.OrderCursor{}, ErrInvalidPageToken
+	}
+	var decoded pageToken
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.OrderID == "" || decoded.CreatedAt.IsZero() {
+		return saga.OrderCursor{}, ErrInvalidPageToken
+	}
+	return saga.OrderCursor{CreatedAt: decoded.CreatedAt, OrderID: decoded.OrderID}, nil
+}
+
+func knownStatus(status saga.SagaStatus) bool {
+	switch status {
+	case saga.SagaStatusStarted, saga.SagaStatusSucceeded, saga.SagaStatusFailed, saga.SagaStatusRefunded, saga.SagaStatusCancelling, saga.SagaStatusCancelled, saga.SagaStatusCompleted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+)
+
+type spyReaderSagaStore struct {
+	spySagaStore
+	views   []saga.OrderView
+	filters []saga.OrderFilter
+	getErr  error
+}
+
+func (s *spyReaderSagaStore) GetOrder(ctx context.Context, orderID string) (saga.OrderView, error) {
+	if s.getErr != nil {
+		return saga.OrderView{}, s.getErr
+	}
+	for _, view := range s.views {
+		if view.OrderID == orderID {
+			return view, nil
+		}
+	}
+	return saga.OrderView{}, saga.ErrOrderNotFound
+}
+
+// TransitionStatus applies the transition to the stored view, so a later GetOrder
+// sees it.
+func (s *spyReaderSagaStore) TransitionStatus(ctx context.Context, orderID string, from, to saga.SagaStatus) (bool, error) {
+	for i := range s.views {
+		if s.views[i].OrderID == orderID {
+			if s.views[i].Status != from {
+				return false, nil
+			}
+			s.views[i].Status = to
+			s.statuses = append(s.statuses, to)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *spyReaderSagaStore) ListOrders(ctx context.Context, filter saga.OrderFilter) ([]saga.OrderView, error) {
+	s.filters = append(s.filters, filter)
+	var out []saga.OrderView
+	for _, view := range s.views {
+		if filter.After != nil && !view.CreatedAt.Before(filter.After.CreatedAt) {
+			continue
+		}
+		out = append(out, view)
+		if filter.Limit > 0 && len(out) == filter.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func newReaderService(store *spyReaderSagaStore) *OrderService {
+	return NewOrderService(&spyPayment{}, &spyDriver{}, store, nil, randomDriver)
+}
+
+func TestGetOrder_ReturnsView(t *testing.T) {
+	t.Parallel()
+
+	store := &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-1", DriverID: "driver-1"}}}
+	service := newReaderService(store)
+
+	view, err := service.GetOrder(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if view.DriverID != "driver-1" {
+		t.Fatalf("unexpected view: %+v", view)
+	}
+
+	if _, err := service.GetOrder(context.Background(), "missing"); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound, got %v", err)
+	}
+	if _, err := service.GetOrder(context.Background(), ""); !errors.Is(err, ErrOrderIDRequired) {
+		t.Fatalf("expected ErrOrderIDRequired, got %v", err)
+	}
+}
+
+func TestGetOrder_WithoutReaderIsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	service := NewOrderService(&spyPayment{}, &spyDriver{}, &spySagaStore{}, nil, randomDriver)
+
+	if _, err := service.GetOrder(context.Background(), "order-1"); !errors.Is(err, ErrOrderReadsDisabled) {
+		t.Fatalf("expected ErrOrderReadsDisabled, got %v", err)
+	}
+	if _, err := service.ListOrders(context.Background(), ListOrdersQuery{}); !errors.Is(err, ErrOrderReadsDisabled) {
+		t.Fatalf("expected ErrOrderReadsDisabled, got %v", err)
+	}
+}
+
+func TestListOrders_PaginatesWithToken(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := &spyReaderSagaStore{views: []saga.OrderView{
+		{OrderID: "order-3", CreatedAt: base.Add(3 * time.Minute)},
+		{OrderID: "order-2", CreatedAt: base.Add(2 * time.Minute)},
+		{OrderID: "order-1", CreatedAt: base.Add(time.Minute)},
+	}}
+	service := newReaderService(store)
+
+	first, err := service.ListOrders(context.Background(), ListOrdersQuery{UserID: "user-1", PageSize: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Orders) != 2 || first.Orders[1].OrderID != "order-2" {
+		t.Fatalf("unexpected first page: %+v", first.Orders)
+	}
+	if first.NextPageToken == "" {
+		t.Fatalf("expected next page token")
+	}
+	if store.filters[0].Limit != 3 || store.filters[0].UserID != "user-1" {
+		t.Fatalf("unexpected filter: %+v", store.filters[0])
+	}
+
+	second, err := service.ListOrders(context.Background(), ListOrdersQuery{UserID: "user-1", PageSize: 2, PageToken: first.NextPageToken})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(second.Orders) != 1 || second.Orders[0].OrderID != "order-1" {
+		t.Fatalf("unexpected second page: %+v", second.Orders)
+	}
+	if second.NextPageToken != "" {
+		t.Fatalf("expected no further pages, got %q", second.NextPageToken)
+	}
+	after := store.filters[1].After
+	if after == nil || after.OrderID != "order-2" || !after.CreatedAt.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("unexpected cursor: %+v", after)
+	}
+}
+
+func TestListOrders_ClampsPageSize(t *testing.T) {
+	t.Parallel()
+
+	store := &spyReaderSagaStore{}
+	service := newReaderService(store)
+
+	if _, err := service.ListOrders(context.Background(), ListOrdersQuery{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.ListOrders(context.Background(), ListOrdersQuery{PageSize: 10_000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.filters[0].Limit != defaultListPageSize+1 || store.filters[1].Limit != maxListPageSize+1 {
+		t.Fatalf("unexpected limits: %d %d", store.filters[0].Limit, store.filters[1].Limit)
+	}
+}
+
+func TestListOrders_RejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		name  string
+		query ListOrdersQuery
+		want  error
+	}{
+		{name: "bad token", query: ListOrdersQuery{PageToken: "not-a-token"}, want: ErrInvalidPageToken},
+		{name: "unknown status", query: ListOrdersQuery{Status: "lost"}, want: ErrInvalidStatusFilter},
+		{name: "inverted range", query: ListOrdersQuery{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}, want: ErrInvalidCreatedRange},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &spyReaderSagaStore{}
+			service := newReaderService(store)
+			if _, err := service.ListOrders(context.Background(), tc.query); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+			if len(store.filters) != 0 {
+				t.Fatalf("expected no store query on invalid input")
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"wayfinder/internal/logging"
+	"wayfinder/internal/orders/saga"
+)
+
+// RecoveryStore leases stale sagas and exposes their recorded steps.
+type RecoveryStore interface {
+	ClaimStale(ctx context.Context, owner string, olderThan, lease time.Duration, limit int) ([]saga.SagaRecord, error)
+	// RenewLease extends owner's lease, or returns saga.ErrLeaseLost once the saga
+	// is leased by someone else or no longer needs recovery.
+	RenewLease(ctx context.Context, orderID, owner string, lease time.Duration) error
+	ReleaseLease(ctx context.Context, orderID, owner string) error
+	ListSteps(ctx context.Context, orderID string) ([]saga.Step, error)
+}
+
+// RecoveryConfig controls how often and how aggressively stuck sagas are recovered.
+type RecoveryConfig struct {
+	Owner      string
+	Interval   time.Duration
+	StaleAfter time.Duration
+	LeaseTTL   time.Duration
+	BatchSize  int
+}
+
+// RecoveryWorker periodically finishes or compensates sagas left in the started state,
+// e.g. when the process died between charge and assign, and finishes interrupted
+// cancellations.
+type RecoveryWorker struct {
+	service *OrderService
+	store   RecoveryStore
+	cfg     RecoveryConfig
+	logf    func(format string, args ...any)
+}
+
+// NewRecoveryWorker constructs a RecoveryWorker with sane defaults for unset config.
+func NewRecoveryWorker(service *OrderService, store RecoveryStore, cfg RecoveryConfig, logf func(format string, args ...any)) *RecoveryWorker {
+	if cfg.Owner == "" {
+		cfg.Owner = newUUIDString()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = 5 * time.Minute
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 20
+	}
+	if logf == nil {
+		logf = logging.Printf(slog.Default(), slog.LevelWarn)
+	}
+	return &RecoveryWorker{service: service, store: store, cfg: cfg, logf: logf}
+}
+
+// Run recovers stale sagas every interval until ctx ends.
+func (w *RecoveryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				w.logf("saga recovery: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce claims one batch of stale sagas and recovers each, returning how many were resolved.
+func (w *RecoveryWorker) RunOnce(ctx context.Context) (int, error) {
+	records, err := w.store.ClaimStale(ctx, w.cfg.Owner, w.cfg.StaleAfter, w.cfg.LeaseTTL, w.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim stale sagas: %w", err)
+	}
+
+	var (
+		resolved int
+		errs     []error
+	)
+	for _, record := range records {
+		status, err := w.recoverOne(ctx, record)
+		if errors.Is(err, saga.ErrLeaseLost) {
+			// Another replica owns the saga now, or it was finished elsewhere.
+			w.logf("saga recovery: order %s: lease lost, stopping", record.OrderID)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", record.OrderID, err))
+		} else {
+			resolved++
+			w.logf("saga recovery: order %s resolved as %s", record.OrderID, status)
+		}
+		// Failed recoveries keep their lease until it expires so a flapping dependency
+		// is not hammered by every replica at once.
+		if err == nil {
+			if relErr := w.store.ReleaseLease(ctx, record.OrderID, w.cfg.Owner); relErr != nil {
+				errs = append(errs, fmt.Errorf("order %s: release lease: %w", record.OrderID, relErr))
+			}
+		}
+	}
+	return resolved, errors.Join(errs...)
+}
+
+func (w *RecoveryWorker) recoverOne(ctx context.Context, record saga.SagaRecord) (saga.SagaStatus, error) {
+	steps, err := w.store.ListSteps(ctx, record.OrderID)
+	if err != nil {
+		return "", fmt.Errorf("list steps: %w", err)
+	}
+	// A recovery can outlast the lease it was claimed with, so it renews the lease
+	// before every action and stops as soon as another replica owns the saga.
+	hold := func(ctx context.Context) error {
+		return w.store.RenewLease(ctx, record.OrderID, w.cfg.Owner, w.cfg.LeaseTTL)
+	}
+	return w.service.recoverSaga(ctx, record, steps, hold)
+}
+
+// RecoverSaga resumes a started saga from its recorded steps. Sagas whose charge
+// completed are driven forward to assignment; anything else is compensated by refund.
+// A cancelling saga has its cancellation finished.
+func (s *OrderService) RecoverSaga(ctx context.Context, record saga.SagaRecord, steps []saga.Step) (saga.SagaStatus, error) {
+	return s.recoverSaga(ctx, record, steps, noHold)
+}
+
+// recoverSaga is RecoverSaga calling hold before each assignment, refund and status
+// update. An error from hold aborts the recovery without acting further.
+func (s *OrderService) recoverSaga(ctx context.Context, record saga.SagaRecord, steps []saga.Step, hold func(context.Context) error) (saga.SagaStatus, error) {
+	orderID := record.OrderID
+	last := lastStepStatuses(steps)
+
+	if err := hold(ctx); err != nil {
+		return "", err
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "recover", "started", "")
+
+	if record.Status == saga.SagaStatusCancelling {
+		if err := s.finishCancel(ctx, orderID, record.Amount, "", hold); err != nil {
+			if !errors.Is(err, saga.ErrLeaseLost) {
+				_ = s.sagas.AddStep(ctx, orderID, "recover", "failed", err.Error())
+			}
+			return "", err
+		}
+		_ = s.sagas.AddStep(ctx, orderID, "recover", "succeeded", string(saga.SagaStatusCancelled))
+		return saga.SagaStatusCancelled, nil
+	}
+
+	switch {
+	case last["refund"] != "":
+		// Compensation was already under way; finish it.
+		return s.recoverRefund(ctx, record, hold)
+	case last["charge"] != "succeeded":
+		// The charge may or may not have gone through; a refund settles either case.
+		return s.recoverRefund(ctx, record, hold)
+	case last["assign"] == "succeeded":
+		return s.finishRecovery(ctx, orderID, saga.SagaStatusSucceeded, hold)
+	case last["assign"] == "failed":
+		return s.recoverRefund(ctx, record, hold)
+	}
+
+	if s.reader != nil {
+		view, err := s.reader.GetOrder(ctx, orderID)
+		if err != nil {
+			return "", err
+		}
+		if view.DriverID != "" {
+			_ = s.sagas.AddStep(ctx, orderID, "assign", "succeeded", "")
+			return s.finishRecovery(ctx, orderID, saga.SagaStatusSucceeded, hold)
+		}
+	}
+
+	// Sagas recorded without a pickup fail dispatch here and fall through to a refund.
+	if err := hold(ctx); err != nil {
+		return "", err
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "assign", "started", "")
+	if err := s.assignDriver(ctx, orderID, record.Pickup); err != nil {
+		_ = s.sagas.AddStep(ctx, orderID, "assign", "failed", err.Error())
+		return s.recoverRefund(ctx, record, hold)
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "assign", "succeeded", "")
+	return s.finishRecovery(ctx, orderID, saga.SagaStatusSucceeded, hold)
+}
+
+func (s *OrderService) recoverRefund(ctx context.Context, record saga.SagaRecord, hold func(context.Context) error) (saga.SagaStatus, error) {
+	orderID := record.OrderID
+
+	if err := hold(ctx); err != nil {
+		return "", err
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "refund", "started", "")
+	err := s.payments.Refund(ctx, orderID, record.Amount)
+	switch {
+	case err == nil, errors.Is(err, saga.ErrAlreadyRefunded):
+		_ = s.sagas.AddStep(ctx, orderID, "refund", "succeeded", "")
+		return s.finishRecovery(ctx, orderID, saga.SagaStatusRefunded, hold)
+	case errors.Is(err, saga.ErrNotCharged):
+		_ = s.sagas.AddStep(ctx, orderID, "refund", "skipped", err.Error())
+		return s.finishRecovery(ctx, orderID, saga.SagaStatusFailed, hold)
+	default:
+		_ = s.sagas.AddStep(ctx, orderID, "refund", "failed", err.Error())
+		_ = s.sagas.AddStep(ctx, orderID, "recover", "failed", err.Error())
+		return "", fmt.Errorf("refund failed: %w", err)
+	}
+}
+
+func (s *OrderService) finishRecovery(ctx context.Context, orderID string, status saga.SagaStatus, hold func(context.Context) error) (saga.SagaStatus, error) {
+	if err := hold(ctx); err != nil {
+		return "", err
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "recover", "succeeded", string(status))
+	if err := s.sagas.UpdateStatus(ctx, orderID, status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+func lastStepStatuses(steps []saga.Step) map[string]string {
+	last := make(map[string]string, len(steps))
+	for _, step := range steps {
+		last[step.Step] = step.Status
+	}
+	return last
+}
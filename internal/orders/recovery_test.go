@@ -0,0 +1,314 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+)
+
+type spyRecoveryStore struct {
+	records    []saga.SagaRecord
+	claimErr   error
+	steps      map[string][]saga.Step
+	claimOwner string
+	claimArgs  []any
+	released   []string
+	// renewals counts RenewLease calls; the lease is lost once it reaches loseAfter.
+	renewals  int
+	loseAfter int
+}
+
+func (s *spyRecoveryStore) ClaimStale(ctx context.Context, owner string, olderThan, lease time.Duration, limit int) ([]saga.SagaRecord, error) {
+	s.claimOwner = owner
+	s.claimArgs = []any{olderThan, lease, limit}
+	return s.records, s.claimErr
+}
+
+func (s *spyRecoveryStore) RenewLease(ctx context.Context, orderID, owner string, lease time.Duration) error {
+	s.renewals++
+	if s.loseAfter > 0 && s.renewals >= s.loseAfter {
+		return saga.ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *spyRecoveryStore) ReleaseLease(ctx context.Context, orderID, owner string) error {
+	s.released = append(s.released, orderID)
+	return nil
+}
+
+func (s *spyRecoveryStore) ListSteps(ctx context.Context, orderID string) ([]saga.Step, error) {
+	return s.steps[orderID], nil
+}
+
+func steps(pairs ...string) []saga.Step {
+	var out []saga.Step
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, saga.Step{Step: pairs[i], Status: pairs[i+1]})
+	}
+	return out
+}
+
+func TestRecoverSaga(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		steps        []saga.Step
+		assigned     string
+		assignErr    error
+		refundErr    error
+		wantStatus   saga.SagaStatus
+		wantErr      bool
+		wantAssign   bool
+		wantRefund   bool
+		wantDriverID string
+	}{
+		{
+			name:       "charge outcome unknown refunds",
+			steps:      steps("charge", "started"),
+			wantStatus: saga.SagaStatusRefunded,
+			wantRefund: true,
+		},
+		{
+			name:       "charge never taken fails",
+			steps:      steps("charge", "started"),
+			refundErr:  saga.ErrNotCharged,
+			wantStatus: saga.SagaStatusFailed,
+			wantRefund: true,
+		},
+		{
+			name:         "charged but unassigned finishes assignment",
+			steps:        steps("charge", "started", "charge", "succeeded"),
+			wantStatus:   saga.SagaStatusSucceeded,
+			wantAssign:   true,
+			wantDriverID: "driver-new",
+		},
+		{
+			name:       "assignment already persisted only updates status",
+			steps:      steps("charge", "succeeded", "assign", "started"),
+			assigned:   "driver-old",
+			wantStatus: saga.SagaStatusSucceeded,
+		},
+		{
+			name:       "assign recorded succeeded only updates status",
+			steps:      steps("charge", "succeeded", "assign", "started", "assign", "succeeded"),
+			wantStatus: saga.SagaStatusSucceeded,
+		},
+		{
+			name:       "assignment failure compensates",
+			steps:      steps("charge", "succeeded"),
+			assignErr:  errors.New("no drivers"),
+			wantStatus: saga.SagaStatusRefunded,
+			wantAssign: true,
+			wantRefund: true,
+		},
+		{
+			name:       "interrupted refund completes",
+			steps:      steps("charge", "succeeded", "assign", "failed", "refund", "started"),
+			refundErr:  saga.ErrAlreadyRefunded,
+			wantStatus: saga.SagaStatusRefunded,
+			wantRefund: true,
+		},
+		{
+			name:       "refund failure leaves saga started",
+			steps:      steps("charge", "succeeded", "assign", "failed"),
+			refundErr:  errors.New("gateway down"),
+			wantErr:    true,
+			wantRefund: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			payment := &spyPayment{refundErr: tc.refundErr}
+			driver := &spyDriver{err: tc.assignErr}
+			store := &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-1", DriverID: tc.assigned}}}
+			service := NewOrderService(payment, driver, store, nil, fixedDriver("driver-new"))
+
+			status, err := service.RecoverSaga(context.Background(), saga.SagaRecord{OrderID: "order-1", Amount: usd(700)}, tc.steps)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				if len(store.statuses) != 0 {
+					t.Fatalf("expected status untouched, got %v", store.statuses)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if status != tc.wantStatus {
+					t.Fatalf("got status %s, want %s", status, tc.wantStatus)
+				}
+				if len(store.statuses) != 1 || store.statuses[0] != tc.wantStatus {
+					t.Fatalf("unexpected persisted statuses: %v", store.statuses)
+				}
+			}
+			if driver.called != tc.wantAssign {
+				t.Fatalf("assign called=%v, want %v", driver.called, tc.wantAssign)
+			}
+			if tc.wantDriverID != "" && driver.driverID != tc.wantDriverID {
+				t.Fatalf("assigned driver %s, want %s", driver.driverID, tc.wantDriverID)
+			}
+			if payment.refundCalled != tc.wantRefund {
+				t.Fatalf("refund called=%v, want %v", payment.refundCalled, tc.wantRefund)
+			}
+			if payment.called {
+				t.Fatalf("recovery must never charge again")
+			}
+			if tc.wantRefund && payment.refundAmount != usd(700) {
+				t.Fatalf("unexpected refund amount %s", payment.refundAmount)
+			}
+		})
+	}
+}
+
+func TestRecoverSaga_FinishesInterruptedCancellation(t *testing.T) {
+	t.Parallel()
+
+	callLog := []string{}
+	payment := &spyPayment{callLog: &callLog}
+	driver := &spyDriver{callLog: &callLog}
+	store := &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-1", Status: saga.SagaStatusCancelling}}}
+	service := NewOrderService(payment, driver, store, nil, randomDriver)
+
+	// The process died after claiming the cancellation, before the refund.
+	record := saga.SagaRecord{OrderID: "order-1", Amount: usd(700), Status: saga.SagaStatusCancelling}
+	status, err := service.RecoverSaga(context.Background(), record, steps("charge", "succeeded", "assign", "succeeded", "cancel", "started"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != saga.SagaStatusCancelled || store.views[0].Status != saga.SagaStatusCancelled {
+		t.Fatalf("expected cancelled, got %s / %s", status, store.views[0].Status)
+	}
+	if len(callLog) != 2 || callLog[0] != "release" || callLog[1] != "refund" || payment.refundAmount != usd(700) {
+		t.Fatalf("expected release then refund of 7.00, got %v %s", callLog, payment.refundAmount)
+	}
+}
+
+func TestRecoveryWorker_RunOnceRecoversAndReleasesLeases(t *testing.T) {
+	t.Parallel()
+
+	payment := &spyPayment{refundErr: errors.New("gateway down")}
+	sagas := &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-ok"}, {OrderID: "order-bad"}}}
+	service := NewOrderService(payment, &spyDriver{}, sagas, nil, randomDriver)
+	store := &spyRecoveryStore{
+		records: []saga.SagaRecord{{OrderID: "order-ok"}, {OrderID: "order-bad"}},
+		steps: map[string][]saga.Step{
+			"order-ok":  steps("charge", "succeeded", "assign", "succeeded"),
+			"order-bad": steps("charge", "started"),
+		},
+	}
+	worker := NewRecoveryWorker(service, store, RecoveryConfig{Owner: "replica-1", StaleAfter: time.Minute, LeaseTTL: time.Second, BatchSize: 5}, func(string, ...any) {})
+
+	resolved, err := worker.RunOnce(context.Background())
+	if err == nil {
+		t.Fatalf("expected error for failed recovery")
+	}
+	if resolved != 1 {
+		t.Fatalf("expected 1 resolved saga, got %d", resolved)
+	}
+	if store.claimOwner != "replica-1" || store.claimArgs[0] != time.Minute || store.claimArgs[1] != time.Second || store.claimArgs[2] != 5 {
+		t.Fatalf("unexpected claim args: %s %v", store.claimOwner, store.claimArgs)
+	}
+	if len(store.released) != 1 || store.released[0] != "order-ok" {
+		t.Fatalf("expected only the recovered saga's lease released, got %v", store.released)
+	}
+}
+
+func TestRecoveryWorker_StopsWhenLeaseIsLost(t *testing.T) {
+	t.Parallel()
+
+	payment := &spyPayment{}
+	driver := &spyDriver{}
+	sagas := &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-1"}}}
+	service := NewOrderService(payment, driver, sagas, nil, fixedDriver("driver-new"))
+	// The claim renews fine at the start, then another replica takes the saga over
+	// before assignment.
+	store := &spyRecoveryStore{
+		records:   []saga.SagaRecord{{OrderID: "order-1", Pickup: testPickup}},
+		steps:     map[string][]saga.Step{"order-1": steps("charge", "succeeded")},
+		loseAfter: 2,
+	}
+	worker := NewRecoveryWorker(service, store, RecoveryConfig{Owner: "replica-1"}, func(string, ...any) {})
+
+	resolved, err := worker.RunOnce(context.Background())
+	if err != nil || resolved != 0 {
+		t.Fatalf("expected the lost saga to be skipped quietly, got %d, %v", resolved, err)
+	}
+	if driver.called || payment.refundCalled || len(sagas.statuses) != 0 {
+		t.Fatalf("expected no action after the lease was lost")
+	}
+	if len(store.released) != 0 {
+		t.Fatalf("a lost lease must not be released, got %v", store.released)
+	}
+}
+
+func TestRecoveryWorker_RenewsLeaseBeforeEachAction(t *testing.T) {
+	t.Parallel()
+
+	sagas := &spyReaderSagaStore{views: []saga.OrderView{{OrderID: "order-1"}}}
+	service := NewOrderService(&spyPayment{}, &spyDriver{}, sagas, nil, fixedDriver("driver-new"))
+	store := &spyRecoveryStore{
+		records: []saga.SagaRecord{{OrderID: "order-1", Pickup: testPickup}},
+		steps:   map[string][]saga.Step{"order-1": steps("charge", "succeeded")},
+	}
+	worker := NewRecoveryWorker(service, store, RecoveryConfig{Owner: "replica-1"}, func(string, ...any) {})
+
+	if resolved, err := worker.RunOnce(context.Background()); err != nil || resolved != 1 {
+		t.Fatalf("unexpected result %d, %v", resolved, err)
+	}
+	// Once on start, before assigning and before the final status update.
+	if store.renewals != 3 {
+		t.Fatalf("expected 3 renewals, got %d", store.renewals)
+	}
+}
+
+func TestRecoveryWorker_ClaimError(t *testing.T) {
+	t.Parallel()
+
+	service := NewOrderService(&spyPayment{}, &spyDriver{}, &spySagaStore{}, nil, randomDriver)
+	store := &spyRecoveryStore{claimErr: errors.New("db down")}
+	worker := NewRecoveryWorker(service, store, RecoveryConfig{}, func(string, ...any) {})
+
+	if _, err := worker.RunOnce(context.Background()); err == nil {
+		t.Fatalf("expected claim error")
+	}
+	if store.claimOwner == "" {
+		t.Fatalf("expected default owner to be generated")
+	}
+}
+
+func TestLoadRecoveryConfigFromEnv(t *testing.T) {
+	t.Setenv("ORDER_RECOVERY_INTERVAL", "10s")
+	t.Setenv("ORDER_RECOVERY_STALE_AFTER", "2m")
+	t.Setenv("ORDER_RECOVERY_LEASE_TTL", "30s")
+	t.Setenv("ORDER_RECOVERY_BATCH_SIZE", "7")
+	t.Setenv("ORDER_RECOVERY_OWNER", "replica-a")
+
+	cfg, enabled, err := loadRecoveryConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !enabled || cfg.Interval != 10*time.Second || cfg.StaleAfter != 2*time.Minute || cfg.LeaseTTL != 30*time.Second || cfg.BatchSize != 7 || cfg.Owner != "replica-a" {
+		t.Fatalf("unexpected config: %+v enabled=%v", cfg, enabled)
+	}
+
+	t.Setenv("ORDER_RECOVERY_INTERVAL", "0")
+	if _, enabled, err := loadRecoveryConfigFromEnv(); err != nil || enabled {
+		t.Fatalf("expected recovery disabled, got enabled=%v err=%v", enabled, err)
+	}
+
+	t.Setenv("ORDER_RECOVERY_INTERVAL", "")
+	t.Setenv("ORDER_RECOVERY_BATCH_SIZE", "nope")
+	if _, _, err := loadRecoveryConfigFromEnv(); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
@@ -6,8 +6,18 @@ import (
 	"math/rand"
 	"sync"
 	"time"
+
+	"wayfinder/internal/dispatch"
+	"wayfinder/internal/orders/money"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "wayfinder/internal/orders"
+
 // ErrCircuitOpen indicates the circuit breaker is open.
 var ErrCircuitOpen = errors.New("circuit breaker open")
 
@@ -19,10 +29,28 @@ type RetryPolicy struct {
 	Jitter      func(time.Duration) time.Duration
 	Sleep       func(context.Context, time.Duration) error
 	ShouldRetry func(error) bool
+	// OnAttempt, when set, is called after each attempt with its outcome, one of the
+	// RetryOutcome constants.
+	OnAttempt func(outcome string)
 }
 
+// Outcomes of a single attempt reported to RetryPolicy.OnAttempt.
+const (
+	RetryOutcomeSuccess      = "success"
+	RetryOutcomeRetry        = "retry"
+	RetryOutcomeExhausted    = "exhausted"
+	RetryOutcomeNonRetryable = "non_retryable"
+)
+
 // Do executes the function with retries according to the policy.
 func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	return p.DoContext(ctx, func(context.Context) error { return fn() })
+}
+
+// DoContext is Do for functions that take a context. Each attempt runs in a
+// "retry.attempt" span and fn receives its context, so calls it makes are traced as
+// children of the attempt.
+func (p RetryPolicy) DoContext(ctx context.Context, fn func(context.Context) error) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -52,13 +80,19 @@ func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		err := fn()
-		if err == nil {
+		err := runAttempt(ctx, attempt, fn)
+		switch {
+		case err == nil:
+			p.report(RetryOutcomeSuccess)
 			return nil
-		}
-		if attempt == attempts || !shouldRetry(err) {
+		case !shouldRetry(err):
+			p.report(RetryOutcomeNonRetryable)
+			return err
+		case attempt == attempts:
+			p.report(RetryOutcomeExhausted)
 			return err
 		}
+		p.report(RetryOutcomeRetry)
 
 		delay := p.BaseDelay
 		if delay > 0 {
@@ -77,11 +111,35 @@ func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
 	return nil
 }
 
+func (p RetryPolicy) report(outcome string) {
+	if p.OnAttempt != nil {
+		p.OnAttempt(outcome)
+	}
+}
+
+func runAttempt(ctx context.Context, attempt int, fn func(context.Context) error) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "retry.attempt",
+		trace.WithAttributes(attribute.Int("retry.attempt", attempt)))
+	defer span.End()
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
 // CircuitBreakerConfig configures a circuit breaker.
 type CircuitBreakerConfig struct {
 	MaxFailures  int
 	ResetTimeout time.Duration
 	Now          func() time.Time
+	// OnStateChange, when set, is called after each state transition with the names of
+	// the states ("closed", "open" or "half_open"). openFor is how long the breaker had
+	// been open when it leaves the open state, and zero otherwise.
+	OnStateChange func(from, to string, openFor time.Duration)
+	// OnReject, when set, is called for each call refused with ErrCircuitOpen.
+	OnReject func()
 }
 
 type circuitState int
@@ -92,6 +150,22 @@ const (
 	circuitHalfOpen
 )
 
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+type circuitTransition struct {
+	from, to circuitState
+	openFor  time.Duration
+}
+
 // CircuitBreaker stops calls after repeated failures.
 type CircuitBreaker struct {
 	mu         sync.Mutex
@@ -99,6 +173,9 @@ type CircuitBreaker struct {
 	resetAfter time.Duration
 	now        func() time.Time
 
+	onStateChange func(from, to string, openFor time.Duration)
+	onReject      func()
+
 	state          circuitState
 	failures       int
 	openedAt       time.Time
@@ -124,6 +201,9 @@ func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
 		resetAfter: resetAfter,
 		now:        now,
 		state:      circuitClosed,
+
+		onStateChange: cfg.OnStateChange,
+		onReject:      cfg.OnReject,
 	}
 }
 
@@ -136,16 +216,19 @@ func (c *CircuitBreaker) Execute(fn func() error) error {
 	now := c.now()
 
 	c.mu.Lock()
+	var change *circuitTransition
 	switch c.state {
 	case circuitOpen:
 		if now.Sub(c.openedAt) < c.resetAfter {
 			c.mu.Unlock()
+			c.reject()
 			return ErrCircuitOpen
 		}
-		c.state = circuitHalfOpen
+		change = c.setState(circuitHalfOpen)
 	case circuitHalfOpen:
 		if c.halfOpenFlight {
 			c.mu.Unlock()
+			c.reject()
 			return ErrCircuitOpen
 		}
 	}
@@ -153,35 +236,70 @@ func (c *CircuitBreaker) Execute(fn func() error) error {
 		c.halfOpenFlight = true
 	}
 	c.mu.Unlock()
+	c.report(change)
 
 	err := fn()
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	change = c.settle(now, err)
+	c.mu.Unlock()
+	c.report(change)
+	return err
+}
 
+// settle updates the breaker with the result of a call started at now. c.mu must be
+// held.
+func (c *CircuitBreaker) settle(now time.Time, err error) *circuitTransition {
 	if c.state == circuitHalfOpen {
 		c.halfOpenFlight = false
 	}
 
 	if err == nil {
-		c.state = circuitClosed
 		c.failures = 0
-		return nil
+		return c.setState(circuitClosed)
 	}
 
 	if c.state == circuitHalfOpen {
-		c.state = circuitOpen
-		c.openedAt = now
 		c.failures = 0
-		return err
+		c.openedAt = now
+		return c.setState(circuitOpen)
 	}
 
 	c.failures++
 	if c.failures >= c.maxFails {
-		c.state = circuitOpen
+		change := c.setState(circuitOpen)
 		c.openedAt = now
+		return change
+	}
+	return nil
+}
+
+// setState moves the breaker to state and returns the transition to report, or nil
+// when there is nothing to report. c.mu must be held.
+func (c *CircuitBreaker) setState(state circuitState) *circuitTransition {
+	if c.state == state || c.onStateChange == nil {
+		c.state = state
+		return nil
+	}
+	change := &circuitTransition{from: c.state, to: state}
+	if c.state == circuitOpen {
+		change.openFor = c.now().Sub(c.openedAt)
+	}
+	c.state = state
+	return change
+}
+
+// report passes a transition to the state change hook; it runs without c.mu held.
+func (c *CircuitBreaker) report(change *circuitTransition) {
+	if change != nil {
+		c.onStateChange(change.from.String(), change.to.String(), change.openFor)
+	}
+}
+
+func (c *CircuitBreaker) reject() {
+	if c.onReject != nil {
+		c.onReject()
 	}
-	return err
 }
 
 // RateLimiter is a token-bucket limiter.
@@ -276,6 +394,50 @@ func (r *RateLimiter) refill(now time.Time) {
 	r.last = r.last.Add(time.Duration(add) * r.rate)
 }
 
+// Dependency names passed to ReliabilityHooks.
+const (
+	DependencyPayments = "payments"
+	DependencyDrivers  = "drivers"
+)
+
+// ReliabilityHooks receive events from the rate limiter, circuit breaker and retry
+// policy wrapped around each outbound dependency, labelled with the dependency name.
+// Nil hooks are skipped.
+type ReliabilityHooks struct {
+	OnLimiterWait  func(dependency string, wait time.Duration)
+	OnStateChange  func(dependency, from, to string, openFor time.Duration)
+	OnReject       func(dependency string)
+	OnRetryAttempt func(dependency, outcome string)
+}
+
+func (h ReliabilityHooks) limiterWait(dependency string) func(time.Duration) {
+	if h.OnLimiterWait == nil {
+		return nil
+	}
+	return func(wait time.Duration) { h.OnLimiterWait(dependency, wait) }
+}
+
+func (h ReliabilityHooks) stateChange(dependency string) func(from, to string, openFor time.Duration) {
+	if h.OnStateChange == nil {
+		return nil
+	}
+	return func(from, to string, openFor time.Duration) { h.OnStateChange(dependency, from, to, openFor) }
+}
+
+func (h ReliabilityHooks) reject(dependency string) func() {
+	if h.OnReject == nil {
+		return nil
+	}
+	return func() { h.OnReject(dependency) }
+}
+
+func (h ReliabilityHooks) retryAttempt(dependency string) func(outcome string) {
+	if h.OnRetryAttempt == nil {
+		return nil
+	}
+	return func(outcome string) { h.OnRetryAttempt(dependency, outcome) }
+}
+
 // ReliablePaymentClient wraps a PaymentClient with reliability controls.
 type ReliablePaymentClient struct {
 	base    PaymentClient
@@ -294,31 +456,31 @@ func NewReliablePaymentClient(base PaymentClient, limiter *RateLimiter, breaker
 	}
 }
 
-func (c *ReliablePaymentClient) Charge(ctx context.Context, orderID string, amount float64) error {
-	return c.do(ctx, func() error {
+func (c *ReliablePaymentClient) Charge(ctx context.Context, orderID string, amount money.Money) error {
+	return c.do(ctx, func(ctx context.Context) error {
 		return c.base.Charge(ctx, orderID, amount)
 	})
 }
 
-func (c *ReliablePaymentClient) Refund(ctx context.Context, orderID string, amount float64) error {
-	return c.do(ctx, func() error {
+func (c *ReliablePaymentClient) Refund(ctx context.Context, orderID string, amount money.Money) error {
+	return c.do(ctx, func(ctx context.Context) error {
 		return c.base.Refund(ctx, orderID, amount)
 	})
 }
 
-func (c *ReliablePaymentClient) do(ctx context.Context, fn func() error) error {
-	attempt := func() error {
+func (c *ReliablePaymentClient) do(ctx context.Context, fn func(context.Context) error) error {
+	attempt := func(ctx context.Context) error {
 		if c.limiter != nil {
 			if err := c.limiter.Wait(ctx); err != nil {
 				return err
 			}
 		}
 		if c.breaker != nil {
-			return c.breaker.Execute(fn)
+			return c.breaker.Execute(func() error { return fn(ctx) })
 		}
-		return fn()
+		return fn(ctx)
 	}
-	return c.retry.Do(ctx, attempt)
+	return c.retry.DoContext(ctx, attempt)
 }
 
 // ReliableDriverClient wraps a DriverClient with reliability controls.
@@ -340,24 +502,65 @@ func NewReliableDriverClient(base DriverClient, limiter *RateLimiter, breaker *C
 }
 
 func (c *ReliableDriverClient) Assign(ctx context.Context, orderID string, driverID string) error {
-	return c.do(ctx, func() error {
+	return c.do(ctx, func(ctx context.Context) error {
 		return c.base.Assign(ctx, orderID, driverID)
 	})
 }
 
-func (c *ReliableDriverClient) do(ctx context.Context, fn func() error) error {
-	attempt := func() error {
+func (c *ReliableDriverClient) Release(ctx context.Context, orderID string) error {
+	return c.do(ctx, func(ctx context.Context) error {
+		return c.base.Release(ctx, orderID)
+	})
+}
+
+// ErrAssignmentsUnsupported signals the wrapped driver client cannot claim drivers.
+var ErrAssignmentsUnsupported = errors.New("driver client does not track assignments")
+
+// ClaimDriver claims through the same limiter, breaker and retry as Assign, so dispatch
+// backs off a failing driver store too. The wrapped client must implement
+// dispatch.Assignments; claiming is idempotent, so retries are safe.
+func (c *ReliableDriverClient) ClaimDriver(ctx context.Context, orderID, driverID string, within time.Duration) (bool, error) {
+	assignments, ok := c.base.(dispatch.Assignments)
+	if !ok {
+		return false, ErrAssignmentsUnsupported
+	}
+	var claimed bool
+	err := c.do(ctx, func(ctx context.Context) error {
+		var err error
+		claimed, err = assignments.ClaimDriver(ctx, orderID, driverID, within)
+		return err
+	})
+	return claimed, err
+}
+
+// BusyDriverIDs reads busy drivers through the same controls as ClaimDriver.
+func (c *ReliableDriverClient) BusyDriverIDs(ctx context.Context, within time.Duration) (map[string]bool, error) {
+	assignments, ok := c.base.(dispatch.Assignments)
+	if !ok {
+		return nil, ErrAssignmentsUnsupported
+	}
+	var busy map[string]bool
+	err := c.do(ctx, func(ctx context.Context) error {
+		var err error
+		busy, err = assignments.BusyDriverIDs(ctx, within)
+		return err
+	})
+	return busy, err
+}
+
+func (c *ReliableDriverClient) do(ctx context.Context, fn func(context.Context) error) error {
+	attempt := func(ctx context.Context) error {
 		if c.limiter != nil {
 			if err := c.limiter.Wait(ctx); err != nil {
 				return err
 			}
 		}
 		if c.breaker != nil {
-			return c.breaker.Execute(fn)
+			return c.breaker.Execute(func() error { return fn(ctx) })
 		}
-		return fn()
+		return fn(ctx)
 	}
-	return c.retry.Do(ctx, attempt)
+	return c.retry.DoContext(ctx, attempt)
 }
 
 func sleepWithContext(ctx context.Context, d time.Duration) error {
@@ -7,6 +7,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"wayfinder/internal/dispatch"
+	"wayfinder/internal/orders/outbox"
 )
 
 type ReliabilityConfig struct {
@@ -48,6 +51,149 @@ func loadReliabilityConfigFromEnv() (ReliabilityConfig, error) {
 	return cfg, nil
 }
 
+// loadRecoveryConfigFromEnv reads optional saga recovery settings. An interval of 0 disables recovery.
+func loadRecoveryConfigFromEnv() (RecoveryConfig, bool, error) {
+	cfg := RecoveryConfig{}
+
+	interval, set, err := parseOptionalDuration("ORDER_RECOVERY_INTERVAL")
+	if err != nil {
+		return cfg, false, err
+	}
+	if set && interval == 0 {
+		return cfg, false, nil
+	}
+	cfg.Interval = interval
+	if cfg.StaleAfter, _, err = parseOptionalDuration("ORDER_RECOVERY_STALE_AFTER"); err != nil {
+		return cfg, false, err
+	}
+	if cfg.LeaseTTL, _, err = parseOptionalDuration("ORDER_RECOVERY_LEASE_TTL"); err != nil {
+		return cfg, false, err
+	}
+	if cfg.BatchSize, _, err = parseOptionalInt("ORDER_RECOVERY_BATCH_SIZE"); err != nil {
+		return cfg, false, err
+	}
+	cfg.Owner = strings.TrimSpace(os.Getenv("ORDER_RECOVERY_OWNER"))
+
+	return cfg, true, nil
+}
+
+// OutboxConfig configures the relay that forwards order_outbox events to a Redis stream.
+type OutboxConfig struct {
+	RedisURL     string
+	Stream       string
+	StreamMaxLen int64
+	Relay        outbox.RelayConfig
+}
+
+// loadOutboxConfigFromEnv reads optional outbox relay settings. Without ORDER_OUTBOX_REDIS_URL
+// the relay is disabled and events accumulate in order_outbox until one is configured.
+func loadOutboxConfigFromEnv() (OutboxConfig, bool, error) {
+	cfg := OutboxConfig{
+		RedisURL: strings.TrimSpace(os.Getenv("ORDER_OUTBOX_REDIS_URL")),
+		Stream:   strings.TrimSpace(os.Getenv("ORDER_OUTBOX_STREAM")),
+	}
+	if cfg.RedisURL == "" {
+		return cfg, false, nil
+	}
+
+	maxLen, _, err := parseOptionalInt("ORDER_OUTBOX_STREAM_MAXLEN")
+	if err != nil {
+		return cfg, false, err
+	}
+	cfg.StreamMaxLen = int64(maxLen)
+	if cfg.Relay.Interval, _, err = parseOptionalDuration("ORDER_OUTBOX_INTERVAL"); err != nil {
+		return cfg, false, err
+	}
+	if cfg.Relay.BatchSize, _, err = parseOptionalInt("ORDER_OUTBOX_BATCH_SIZE"); err != nil {
+		return cfg, false, err
+	}
+
+	return cfg, true, nil
+}
+
+// DispatchConfig configures nearest-driver selection from the driver locations in Redis.
+type DispatchConfig struct {
+	RedisURL string
+	// LocationTTL matches the ingest REDIS_LOCATION_TTL so expired drivers are pruned
+	// from the GEO index. Zero leaves pruning to the ingest side.
+	LocationTTL time.Duration
+	Selector    dispatch.Config
+}
+
+// loadDispatchConfigFromEnv reads dispatch settings. ORDER_DISPATCH_REDIS_URL falls back to
+// REDIS_URL, where ingest writes driver locations; one of them is required because orders
+// cannot be assigned without dispatch.
+func loadDispatchConfigFromEnv() (DispatchConfig, error) {
+	cfg := DispatchConfig{RedisURL: strings.TrimSpace(os.Getenv("ORDER_DISPATCH_REDIS_URL"))}
+	if cfg.RedisURL == "" {
+		cfg.RedisURL = strings.TrimSpace(os.Getenv("REDIS_URL"))
+	}
+	if cfg.RedisURL == "" {
+		return cfg, fmt.Errorf("ORDER_DISPATCH_REDIS_URL or REDIS_URL is required")
+	}
+
+	var err error
+	if cfg.LocationTTL, _, err = parseOptionalDuration("REDIS_LOCATION_TTL"); err != nil {
+		return cfg, err
+	}
+	if cfg.Selector.MaxRadiusMeters, _, err = parseOptionalFloat("ORDER_DISPATCH_MAX_RADIUS_METERS"); err != nil {
+		return cfg, err
+	}
+	if cfg.Selector.OnlineWindow, _, err = parseOptionalDuration("ORDER_DISPATCH_ONLINE_WINDOW"); err != nil {
+		return cfg, err
+	}
+	if cfg.Selector.AssignmentTTL, _, err = parseOptionalDuration("ORDER_DISPATCH_ASSIGNMENT_TTL"); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func parseOptionalFloat(name string) (float64, bool, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0, false, nil
+	}
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", name, err)
+	}
+	if val < 0 {
+		return 0, false, errors.New(name + " must be >= 0")
+	}
+	return val, true, nil
+}
+
+func parseOptionalDuration(name string) (time.Duration, bool, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0, false, nil
+	}
+	val, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", name, err)
+	}
+	if val < 0 {
+		return 0, false, errors.New(name + " must be >= 0")
+	}
+	return val, true, nil
+}
+
+func parseOptionalInt(name string) (int, bool, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0, false, nil
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", name, err)
+	}
+	if val < 0 {
+		return 0, false, errors.New(name + " must be >= 0")
+	}
+	return val, true, nil
+}
+
 func parseRequiredDuration(name string) (time.Duration, error) {
 	raw := strings.TrimSpace(os.Getenv(name))
 	if raw == "" {
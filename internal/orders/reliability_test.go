@@ -7,7 +7,14 @@ import (
 	"testing"
 	"time"
 
+	"wayfinder/internal/orders/money"
+
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type stubPayment struct {
@@ -15,7 +22,7 @@ type stubPayment struct {
 	calls int
 }
 
-func (s *stubPayment) Charge(ctx context.Context, orderID string, amount float64) error {
+func (s *stubPayment) Charge(ctx context.Context, orderID string, amount money.Money) error {
 	s.calls++
 	if s.calls <= len(s.errs) {
 		return s.errs[s.calls-1]
@@ -23,7 +30,7 @@ func (s *stubPayment) Charge(ctx context.Context, orderID string, amount float64
 	return nil
 }
 
-func (s *stubPayment) Refund(ctx context.Context, orderID string, amount float64) error {
+func (s *stubPayment) Refund(ctx context.Context, orderID string, amount money.Money) error {
 	s.calls++
 	if s.calls <= len(s.errs) {
 		return s.errs[s.calls-1]
@@ -41,6 +48,11 @@ func (s *stubDriver) Assign(ctx context.Context, orderID string, driverID string
 	return s.err
 }
 
+func (s *stubDriver) Release(ctx context.Context, orderID string) error {
+	s.calls++
+	return s.err
+}
+
 func TestRetryPolicy_RetriesWithBackoff(t *testing.T) {
 	attempts := 0
 	var delays []time.Duration
@@ -105,6 +117,149 @@ func TestRetryPolicy_StopsOnNonRetryable(t *testing.T) {
 	}
 }
 
+func TestRetryPolicy_ReportsAttemptOutcomes(t *testing.T) {
+	retryable := errors.New("retryable")
+	cases := []struct {
+		name string
+		errs []error
+		want []string
+	}{
+		{"success", nil, []string{RetryOutcomeSuccess}},
+		{"recovers", []error{retryable}, []string{RetryOutcomeRetry, RetryOutcomeSuccess}},
+		{"exhausted", []error{retryable, retryable, retryable}, []string{RetryOutcomeRetry, RetryOutcomeRetry, RetryOutcomeExhausted}},
+		{"non retryable", []error{ErrCircuitOpen}, []string{RetryOutcomeNonRetryable}},
+	}
+	for _, tc := range cases {
+		var outcomes []string
+		policy := RetryPolicy{
+			MaxAttempts: 3,
+			Sleep:       func(context.Context, time.Duration) error { return nil },
+			OnAttempt:   func(outcome string) { outcomes = append(outcomes, outcome) },
+		}
+		calls := 0
+		_ = policy.Do(context.Background(), func() error {
+			calls++
+			if calls <= len(tc.errs) {
+				return tc.errs[calls-1]
+			}
+			return nil
+		})
+		if len(outcomes) != len(tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, outcomes)
+		}
+		for i := range tc.want {
+			if outcomes[i] != tc.want[i] {
+				t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, outcomes)
+			}
+		}
+	}
+}
+
+func TestReliabilityHooks_LabelEventsByDependency(t *testing.T) {
+	var events []string
+	hooks := ReliabilityHooks{
+		OnLimiterWait: func(dependency string, wait time.Duration) {
+			events = append(events, dependency+" wait "+wait.String())
+		},
+		OnStateChange: func(dependency, from, to string, _ time.Duration) {
+			events = append(events, dependency+" "+from+"->"+to)
+		},
+		OnReject:       func(dependency string) { events = append(events, dependency+" rejected") },
+		OnRetryAttempt: func(dependency, outcome string) { events = append(events, dependency+" "+outcome) },
+	}
+
+	now := time.Unix(0, 0)
+	limiter := NewRateLimiterWithHook(100*time.Millisecond, 1, hooks.limiterWait(DependencyDrivers))
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		now = now.Add(d)
+		return nil
+	}
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		MaxFailures:   1,
+		ResetTimeout:  time.Hour,
+		Now:           func() time.Time { return now },
+		OnStateChange: hooks.stateChange(DependencyDrivers),
+		OnReject:      hooks.reject(DependencyDrivers),
+	})
+	policy := RetryPolicy{MaxAttempts: 2, OnAttempt: hooks.retryAttempt(DependencyDrivers)}
+
+	client := NewReliableDriverClient(&stubDriver{err: errors.New("fail")}, limiter, breaker, policy)
+	_ = client.Assign(context.Background(), "order-1", "driver-1")
+
+	want := []string{
+		"drivers closed->open",
+		"drivers retry",
+		"drivers wait 100ms",
+		"drivers rejected",
+		"drivers non_retryable",
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, events)
+		}
+	}
+
+	var none ReliabilityHooks
+	if none.limiterWait(DependencyPayments) != nil || none.stateChange(DependencyPayments) != nil ||
+		none.reject(DependencyPayments) != nil || none.retryAttempt(DependencyPayments) != nil {
+		t.Fatalf("expected nil hooks to stay nil")
+	}
+}
+
+func TestRetryPolicy_TracesEachAttempt(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "saga.charge")
+	policy := RetryPolicy{MaxAttempts: 3, ShouldRetry: func(error) bool { return true }}
+	attempts := 0
+	err := policy.DoContext(ctx, func(ctx context.Context) error {
+		attempts++
+		_, call := otel.Tracer("test").Start(ctx, "payments.charge")
+		call.End()
+		if attempts < 3 {
+			return errors.New("fail")
+		}
+		return nil
+	})
+	parent.End()
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	var tried []int64
+	for i, span := range spans {
+		if span.Name() != "retry.attempt" {
+			continue
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("attempt is not a child of the caller span")
+		}
+		// Each call ends just before the attempt that wraps it.
+		if call := spans[i-1]; call.Name() != "payments.charge" || call.Parent().SpanID() != span.SpanContext().SpanID() {
+			t.Fatalf("call is not a child of its attempt")
+		}
+		attrs := attribute.NewSet(span.Attributes()...)
+		attempt, _ := attrs.Value("retry.attempt")
+		tried = append(tried, attempt.AsInt64())
+		if failed := span.Status().Code == codes.Error; failed != (attempt.AsInt64() < 3) {
+			t.Fatalf("attempt %d has status %v", attempt.AsInt64(), span.Status())
+		}
+	}
+	if len(tried) != 3 || tried[0] != 1 || tried[2] != 3 {
+		t.Fatalf("unexpected attempts: %v", tried)
+	}
+}
+
 func TestCircuitBreaker_OpensAndResets(t *testing.T) {
 	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
 	calls := 0
@@ -145,6 +300,74 @@ func TestCircuitBreaker_OpensAndResets(t *testing.T) {
 	}
 }
 
+func TestCircuitBreaker_ReportsTransitionsAndRejections(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var (
+		transitions []string
+		openFor     []time.Duration
+		rejected    int
+	)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		MaxFailures:  1,
+		ResetTimeout: time.Second,
+		Now:          func() time.Time { return now },
+		OnStateChange: func(from, to string, open time.Duration) {
+			transitions = append(transitions, from+"->"+to)
+			openFor = append(openFor, open)
+		},
+		OnReject: func() { rejected++ },
+	})
+	fail := func() error { return errors.New("fail") }
+
+	_ = breaker.Execute(fail)
+	_ = breaker.Execute(fail)
+	now = now.Add(1500 * time.Millisecond)
+	_ = breaker.Execute(fail)
+	now = now.Add(3 * time.Second)
+	_ = breaker.Execute(func() error { return nil })
+	_ = breaker.Execute(func() error { return nil })
+
+	want := []string{"closed->open", "open->half_open", "half_open->open", "open->half_open", "half_open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("expected transitions %v, got %v", want, transitions)
+		}
+	}
+	if openFor[1] != 1500*time.Millisecond || openFor[3] != 3*time.Second || openFor[0] != 0 || openFor[2] != 0 {
+		t.Fatalf("unexpected open durations: %v", openFor)
+	}
+	if rejected != 1 {
+		t.Fatalf("expected 1 rejection, got %d", rejected)
+	}
+}
+
+func TestCircuitBreaker_RejectsConcurrentHalfOpenCalls(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rejected := 0
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		MaxFailures:  1,
+		ResetTimeout: time.Second,
+		Now:          func() time.Time { return now },
+		OnReject:     func() { rejected++ },
+	})
+	_ = breaker.Execute(func() error { return errors.New("fail") })
+	now = now.Add(2 * time.Second)
+
+	err := breaker.Execute(func() error {
+		// The probe is in flight, so a second call is refused.
+		if err := breaker.Execute(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected circuit open during probe, got %v", err)
+		}
+		return nil
+	})
+	if err != nil || rejected != 1 {
+		t.Fatalf("unexpected probe result %v with %d rejections", err, rejected)
+	}
+}
+
 func TestRateLimiter_WaitsWhenExhausted(t *testing.T) {
 	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
 	var waits []time.Duration
@@ -181,7 +404,7 @@ func TestReliablePaymentClient_ChargeRetries(t *testing.T) {
 	}
 
 	client := NewReliablePaymentClient(base, nil, nil, policy)
-	if err := client.Charge(context.Background(), "order-1", 9.99); err != nil {
+	if err := client.Charge(context.Background(), "order-1", usd(999)); err != nil {
 		t.Fatalf("expected success, got %v", err)
 	}
 	if base.calls != 2 {
@@ -214,6 +437,55 @@ func TestReliableDriverClient_AssignCircuitOpen(t *testing.T) {
 	}
 }
 
+type stubClaimingDriver struct {
+	stubDriver
+	claims int
+}
+
+func (s *stubClaimingDriver) ClaimDriver(ctx context.Context, orderID, driverID string, within time.Duration) (bool, error) {
+	s.claims++
+	return s.err == nil, s.err
+}
+
+func (s *stubClaimingDriver) BusyDriverIDs(ctx context.Context, within time.Duration) (map[string]bool, error) {
+	return map[string]bool{"driver-2": true}, s.err
+}
+
+func TestReliableDriverClient_ClaimDriverSharesBreaker(t *testing.T) {
+	base := &stubClaimingDriver{stubDriver: stubDriver{err: errors.New("fail")}}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		MaxFailures:  1,
+		ResetTimeout: time.Second,
+		Now:          func() time.Time { return now },
+	})
+	policy := RetryPolicy{
+		MaxAttempts: 1,
+		ShouldRetry: func(error) bool { return false },
+	}
+
+	client := NewReliableDriverClient(base, nil, breaker, policy)
+	if err := client.Assign(context.Background(), "order-1", "driver-1"); err == nil {
+		t.Fatalf("expected failure")
+	}
+	if _, err := client.ClaimDriver(context.Background(), "order-1", "driver-1", time.Hour); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the claim to be rejected by the open breaker, got %v", err)
+	}
+	if base.claims != 0 {
+		t.Fatalf("expected no claim to reach the store, got %d", base.claims)
+	}
+}
+
+func TestReliableDriverClient_ClaimDriverRequiresAssignments(t *testing.T) {
+	client := NewReliableDriverClient(&stubDriver{}, nil, nil, RetryPolicy{MaxAttempts: 1})
+	if _, err := client.ClaimDriver(context.Background(), "order-1", "driver-1", time.Hour); !errors.Is(err, ErrAssignmentsUnsupported) {
+		t.Fatalf("expected ErrAssignmentsUnsupported, got %v", err)
+	}
+	if _, err := client.BusyDriverIDs(context.Background(), time.Hour); !errors.Is(err, ErrAssignmentsUnsupported) {
+		t.Fatalf("expected ErrAssignmentsUnsupported, got %v", err)
+	}
+}
+
 func TestRetryPolicy_RespectsCanceledContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -326,7 +598,7 @@ func TestReliablePaymentClient_RefundRetries(t *testing.T) {
 	}
 
 	client := NewReliablePaymentClient(base, nil, nil, policy)
-	if err := client.Refund(context.Background(), "order-1", 9.99); err != nil {
+	if err := client.Refund(context.Background(), "order-1", usd(999)); err != nil {
 		t.Fatalf("expected success, got %v", err)
 	}
 	if base.calls != 2 {
@@ -423,6 +695,64 @@ func TestLoadReliabilityConfigFromEnv_Missing(t *testing.T) {
 	}
 }
 
+func TestLoadOutboxConfigFromEnv(t *testing.T) {
+	t.Setenv("ORDER_OUTBOX_REDIS_URL", "")
+	if _, enabled, err := loadOutboxConfigFromEnv(); err != nil || enabled {
+		t.Fatalf("expected relay disabled without redis url, enabled=%v err=%v", enabled, err)
+	}
+
+	t.Setenv("ORDER_OUTBOX_REDIS_URL", "redis://localhost:6379/0")
+	t.Setenv("ORDER_OUTBOX_STREAM", "orders")
+	t.Setenv("ORDER_OUTBOX_STREAM_MAXLEN", "1000")
+	t.Setenv("ORDER_OUTBOX_INTERVAL", "250ms")
+	t.Setenv("ORDER_OUTBOX_BATCH_SIZE", "50")
+	cfg, enabled, err := loadOutboxConfigFromEnv()
+	if err != nil || !enabled {
+		t.Fatalf("unexpected result: enabled=%v err=%v", enabled, err)
+	}
+	if cfg.Stream != "orders" || cfg.StreamMaxLen != 1000 || cfg.Relay.Interval != 250*time.Millisecond || cfg.Relay.BatchSize != 50 {
+		t.Fatalf("unexpected cfg: %+v", cfg)
+	}
+
+	t.Setenv("ORDER_OUTBOX_INTERVAL", "soon")
+	if _, _, err := loadOutboxConfigFromEnv(); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func TestLoadDispatchConfigFromEnv(t *testing.T) {
+	t.Setenv("ORDER_DISPATCH_REDIS_URL", "")
+	t.Setenv("REDIS_URL", "")
+	if _, err := loadDispatchConfigFromEnv(); err == nil {
+		t.Fatalf("expected an error without a redis url")
+	}
+
+	t.Setenv("REDIS_URL", "redis://localhost:6379/0")
+	cfg, err := loadDispatchConfigFromEnv()
+	if err != nil || cfg.RedisURL != "redis://localhost:6379/0" {
+		t.Fatalf("expected fallback to REDIS_URL, cfg=%+v err=%v", cfg, err)
+	}
+
+	t.Setenv("ORDER_DISPATCH_REDIS_URL", "redis://dispatch:6379/1")
+	t.Setenv("ORDER_DISPATCH_MAX_RADIUS_METERS", "2500")
+	t.Setenv("ORDER_DISPATCH_ONLINE_WINDOW", "90s")
+	t.Setenv("ORDER_DISPATCH_ASSIGNMENT_TTL", "45m")
+	t.Setenv("REDIS_LOCATION_TTL", "5m")
+	cfg, err = loadDispatchConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RedisURL != "redis://dispatch:6379/1" || cfg.LocationTTL != 5*time.Minute || cfg.Selector.MaxRadiusMeters != 2500 ||
+		cfg.Selector.OnlineWindow != 90*time.Second || cfg.Selector.AssignmentTTL != 45*time.Minute {
+		t.Fatalf("unexpected cfg: %+v", cfg)
+	}
+
+	t.Setenv("ORDER_DISPATCH_MAX_RADIUS_METERS", "far")
+	if _, err := loadDispatchConfigFromEnv(); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
 func TestParseRequiredHelpers(t *testing.T) {
 	t.Setenv("ORDER_RETRY_BASE_DELAY", "-1ms")
 	if _, err := parseRequiredDuration("ORDER_RETRY_BASE_DELAY"); err == nil {
@@ -444,7 +774,7 @@ func TestBuildOrderServiceOpenError(t *testing.T) {
 	origOpen := openOrderDB
 	defer func() { openOrderDB = origOpen }()
 
-	openOrderDB = func(driver, dsn string) (*sql.DB, error) {
+	openOrderDB = func(dsn string) (*sql.DB, error) {
 		return nil, errors.New("open fail")
 	}
 
@@ -463,17 +793,39 @@ func TestBuildOrderServiceSuccessWithMocks(t *testing.T) {
 	}
 	// Close expectation handled via cleanup from BuildOrderService.
 
-	openOrderDB = func(driver, dsn string) (*sql.DB, error) {
+	openOrderDB = func(dsn string) (*sql.DB, error) {
 		return db, nil
 	}
 
 	// Schema expectations.
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS payments").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE payments").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE payments").
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_sagas").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_saga_steps").
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_user_created_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_sagas_status_updated_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_outbox").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_pending_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas\\s+ADD COLUMN IF NOT EXISTS amount_units").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE order_sagas\\s+ADD COLUMN IF NOT EXISTS pickup_lat").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_trips").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS order_outbox_order_idx").
+		WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectExec("CREATE TABLE IF NOT EXISTS order_assignments").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
@@ -485,6 +837,8 @@ func TestBuildOrderServiceSuccessWithMocks(t *testing.T) {
 	t.Setenv("ORDER_BREAKER_RESET_TIMEOUT", "1s")
 	t.Setenv("ORDER_RATE_LIMIT_INTERVAL", "1ms")
 	t.Setenv("ORDER_RATE_LIMIT_BURST", "1")
+	t.Setenv("ORDER_DISPATCH_REDIS_URL", "")
+	t.Setenv("REDIS_URL", "redis://localhost:6379/0")
 
 	svc, cleanup, err := BuildOrderService(context.Background(), "dsn", nil)
 	if err != nil {
@@ -512,7 +866,7 @@ func TestBuildOrderServiceSchemaErrorClosesDB(t *testing.T) {
 		t.Fatalf("sqlmock: %v", err)
 	}
 
-	openOrderDB = func(driver, dsn string) (*sql.DB, error) {
+	openOrderDB = func(dsn string) (*sql.DB, error) {
 		return db, nil
 	}
 
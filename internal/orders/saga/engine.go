@@ -0,0 +1,128 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"wayfinder/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "wayfinder/internal/orders/saga"
+
+// StepDef is one forward action of a saga and the optional compensation that undoes it.
+type StepDef struct {
+	// Name is recorded in the step log for the action, e.g. "charge".
+	Name   string
+	Action func(ctx context.Context) error
+	// Compensation undoes a completed Action. Steps without one are skipped while compensating.
+	Compensation func(ctx context.Context) error
+	// CompensationName is recorded for the compensation, e.g. "refund". Defaults to Name + "_compensate".
+	CompensationName string
+}
+
+func (s StepDef) compensationName() string {
+	if s.CompensationName != "" {
+		return s.CompensationName
+	}
+	return s.Name + "_compensate"
+}
+
+// Definition is an ordered list of steps plus the status recorded once compensation completes.
+type Definition struct {
+	Steps []StepDef
+	// CompensatedStatus is stored when a step fails and every completed step was compensated.
+	// Defaults to SagaStatusFailed.
+	CompensatedStatus SagaStatus
+}
+
+// CompensationError reports a failed step whose compensation also failed.
+type CompensationError struct {
+	Step            string
+	Err             error
+	Compensation    string
+	CompensationErr error
+}
+
+func (e *CompensationError) Error() string {
+	return fmt.Sprintf("%s failed: %v; %s failed: %v", e.Step, e.Err, e.Compensation, e.CompensationErr)
+}
+
+func (e *CompensationError) Unwrap() error { return e.Err }
+
+// Engine runs saga definitions against a SagaStore. Step log writes are best-effort:
+// a persistence hiccup must not change the outcome of payments or assignments.
+type Engine struct {
+	store SagaStore
+}
+
+// NewEngine constructs an Engine that records transitions in store.
+func NewEngine(store SagaStore) *Engine {
+	return &Engine{store: store}
+}
+
+// Run executes the steps of def in order for orderID. When a step fails, completed steps are
+// compensated in reverse order and the step's error is returned. The final saga status is
+// persisted and returned. Each action and compensation runs in its own span, named
+// "saga." plus the step name.
+func (e *Engine) Run(ctx context.Context, orderID string, def Definition) (SagaStatus, error) {
+	ctx = logging.With(ctx, "order_id", orderID)
+	for i, step := range def.Steps {
+		_ = e.store.AddStep(ctx, orderID, step.Name, "started", "")
+		if err := runStep(ctx, orderID, step.Name, step.Action); err != nil {
+			_ = e.store.AddStep(ctx, orderID, step.Name, "failed", err.Error())
+			return e.compensate(ctx, orderID, def, def.Steps[:i], step.Name, err)
+		}
+		_ = e.store.AddStep(ctx, orderID, step.Name, "succeeded", "")
+	}
+
+	_ = e.store.UpdateStatus(ctx, orderID, SagaStatusSucceeded)
+	return SagaStatusSucceeded, nil
+}
+
+func (e *Engine) compensate(ctx context.Context, orderID string, def Definition, completed []StepDef, failedStep string, cause error) (SagaStatus, error) {
+	compensated := false
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if step.Compensation == nil {
+			continue
+		}
+		name := step.compensationName()
+		_ = e.store.AddStep(ctx, orderID, name, "started", "")
+		if err := runStep(ctx, orderID, name, step.Compensation); err != nil {
+			slog.ErrorContext(ctx, "saga compensation failed, order left inconsistent", "step", failedStep, "compensation", name, "error", err)
+			_ = e.store.AddStep(ctx, orderID, name, "failed", err.Error())
+			_ = e.store.UpdateStatus(ctx, orderID, SagaStatusFailed)
+			return SagaStatusFailed, &CompensationError{Step: failedStep, Err: cause, Compensation: name, CompensationErr: err}
+		}
+		_ = e.store.AddStep(ctx, orderID, name, "succeeded", "")
+		compensated = true
+	}
+
+	status := SagaStatusFailed
+	if compensated && def.CompensatedStatus != "" {
+		status = def.CompensatedStatus
+	}
+	_ = e.store.UpdateStatus(ctx, orderID, status)
+	return status, cause
+}
+
+func runStep(ctx context.Context, orderID, name string, fn func(context.Context) error) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "saga."+name,
+		trace.WithAttributes(attribute.String("order.id", orderID), attribute.String("saga.step", name)))
+	defer span.End()
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.WarnContext(ctx, "saga step failed", "step", name, "error", err)
+		return err
+	}
+	slog.DebugContext(ctx, "saga step succeeded", "step", name)
+	return nil
+}
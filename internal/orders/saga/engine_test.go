@@ -0,0 +1,210 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type recordingStore struct {
+	steps    []string
+	statuses []SagaStatus
+}
+
+func (r *recordingStore) Start(ctx context.Context, idempotencyKey string, record SagaRecord) (SagaRecord, bool, error) {
+	return record, true, nil
+}
+
+func (r *recordingStore) UpdateStatus(ctx context.Context, orderID string, status SagaStatus) error {
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func (r *recordingStore) TransitionStatus(ctx context.Context, orderID string, from, to SagaStatus) (bool, error) {
+	r.statuses = append(r.statuses, to)
+	return true, nil
+}
+
+func (r *recordingStore) AddStep(ctx context.Context, orderID, step, status, detail string) error {
+	r.steps = append(r.steps, step+":"+status)
+	return nil
+}
+
+func step(name string, log *[]string, err error, compensation string, compErr error) StepDef {
+	def := StepDef{
+		Name: name,
+		Action: func(ctx context.Context) error {
+			*log = append(*log, name)
+			return err
+		},
+	}
+	if compensation != "" {
+		def.CompensationName = compensation
+		def.Compensation = func(ctx context.Context) error {
+			*log = append(*log, compensation)
+			return compErr
+		}
+	}
+	return def
+}
+
+func TestEngine_RunsAllSteps(t *testing.T) {
+	store := &recordingStore{}
+	var calls []string
+
+	status, err := NewEngine(store).Run(context.Background(), "order-1", Definition{Steps: []StepDef{
+		step("charge", &calls, nil, "refund", nil),
+		step("assign", &calls, nil, "", nil),
+	}})
+	if err != nil || status != SagaStatusSucceeded {
+		t.Fatalf("unexpected result: %s %v", status, err)
+	}
+	if strings.Join(calls, ",") != "charge,assign" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	if strings.Join(store.steps, ",") != "charge:started,charge:succeeded,assign:started,assign:succeeded" {
+		t.Fatalf("unexpected steps: %v", store.steps)
+	}
+	if len(store.statuses) != 1 || store.statuses[0] != SagaStatusSucceeded {
+		t.Fatalf("unexpected statuses: %v", store.statuses)
+	}
+}
+
+func TestEngine_CompensatesCompletedStepsInReverse(t *testing.T) {
+	store := &recordingStore{}
+	var calls []string
+	assignErr := errors.New("no drivers")
+
+	status, err := NewEngine(store).Run(context.Background(), "order-1", Definition{
+		Steps: []StepDef{
+			step("fraud", &calls, nil, "", nil),
+			step("charge", &calls, nil, "refund", nil),
+			step("accept", &calls, nil, "reject", nil),
+			step("assign", &calls, assignErr, "release", nil),
+			step("notify", &calls, nil, "", nil),
+		},
+		CompensatedStatus: SagaStatusRefunded,
+	})
+	if !errors.Is(err, assignErr) {
+		t.Fatalf("expected assign error, got %v", err)
+	}
+	if status != SagaStatusRefunded {
+		t.Fatalf("expected refunded, got %s", status)
+	}
+	if strings.Join(calls, ",") != "fraud,charge,accept,assign,reject,refund" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	want := "fraud:started,fraud:succeeded,charge:started,charge:succeeded,accept:started,accept:succeeded," +
+		"assign:started,assign:failed,reject:started,reject:succeeded,refund:started,refund:succeeded"
+	if strings.Join(store.steps, ",") != want {
+		t.Fatalf("unexpected steps: %v", store.steps)
+	}
+	if store.statuses[len(store.statuses)-1] != SagaStatusRefunded {
+		t.Fatalf("unexpected statuses: %v", store.statuses)
+	}
+}
+
+func TestEngine_FirstStepFailureFails(t *testing.T) {
+	store := &recordingStore{}
+	var calls []string
+	chargeErr := errors.New("card declined")
+
+	status, err := NewEngine(store).Run(context.Background(), "order-1", Definition{
+		Steps:             []StepDef{step("charge", &calls, chargeErr, "refund", nil), step("assign", &calls, nil, "", nil)},
+		CompensatedStatus: SagaStatusRefunded,
+	})
+	if !errors.Is(err, chargeErr) || status != SagaStatusFailed {
+		t.Fatalf("unexpected result: %s %v", status, err)
+	}
+	if strings.Join(calls, ",") != "charge" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestEngine_CompensationFailureStops(t *testing.T) {
+	store := &recordingStore{}
+	var calls []string
+	assignErr := errors.New("no drivers")
+	refundErr := errors.New("gateway down")
+
+	status, err := NewEngine(store).Run(context.Background(), "order-1", Definition{
+		Steps: []StepDef{
+			step("charge", &calls, nil, "refund", refundErr),
+			step("reserve", &calls, nil, "unreserve", nil),
+			step("assign", &calls, assignErr, "", nil),
+		},
+		CompensatedStatus: SagaStatusRefunded,
+	})
+	if status != SagaStatusFailed {
+		t.Fatalf("expected failed, got %s", status)
+	}
+	var compErr *CompensationError
+	if !errors.As(err, &compErr) || compErr.Compensation != "refund" || !errors.Is(compErr.CompensationErr, refundErr) {
+		t.Fatalf("expected compensation error, got %v", err)
+	}
+	if !errors.Is(err, assignErr) {
+		t.Fatalf("expected step error to be wrapped: %v", err)
+	}
+	if err.Error() != "assign failed: no drivers; refund failed: gateway down" {
+		t.Fatalf("unexpected message: %s", err)
+	}
+	if strings.Join(calls, ",") != "charge,reserve,assign,unreserve,refund" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	if store.statuses[len(store.statuses)-1] != SagaStatusFailed {
+		t.Fatalf("unexpected statuses: %v", store.statuses)
+	}
+}
+
+func TestStepDef_DefaultCompensationName(t *testing.T) {
+	if got := (StepDef{Name: "reserve"}).compensationName(); got != "reserve_compensate" {
+		t.Fatalf("unexpected compensation name %s", got)
+	}
+}
+
+func TestEngine_TracesEachStepUnderTheCaller(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "CreateOrder")
+	var calls []string
+	_, err := NewEngine(&recordingStore{}).Run(ctx, "order-1", Definition{Steps: []StepDef{
+		step("charge", &calls, nil, "refund", nil),
+		step("assign", &calls, errors.New("no drivers"), "", nil),
+	}})
+	parent.End()
+	if err == nil {
+		t.Fatalf("expected assign failure")
+	}
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		if span.Name() == "CreateOrder" {
+			continue
+		}
+		names = append(names, span.Name())
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("%s is not a child of the caller span", span.Name())
+		}
+		attrs := attribute.NewSet(span.Attributes()...)
+		if v, _ := attrs.Value("order.id"); v.AsString() != "order-1" {
+			t.Fatalf("%s missing order id: %v", span.Name(), span.Attributes())
+		}
+		if failed := span.Status().Code == codes.Error; failed != (span.Name() == "saga.assign") {
+			t.Fatalf("%s has status %v", span.Name(), span.Status())
+		}
+	}
+	if strings.Join(names, ",") != "saga.charge,saga.assign,saga.refund" {
+		t.Fatalf("unexpected spans: %v", names)
+	}
+}
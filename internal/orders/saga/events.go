@@ -0,0 +1,142 @@
+package saga
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind distinguishes step transitions from saga status changes.
+type EventKind string
+
+const (
+	EventKindStep   EventKind = "step"
+	EventKindStatus EventKind = "status"
+)
+
+// Event is a single change to an order saga.
+type Event struct {
+	// ID is the event's position in the shared event log; replayed history has none.
+	ID      int64
+	OrderID string
+	Kind    EventKind
+	Step    string
+	Status  string
+	Detail  string
+	At      time.Time
+	Replay  bool
+}
+
+// Step is a persisted saga step row.
+type Step struct {
+	Step      string
+	Status    string
+	Detail    string
+	CreatedAt time.Time
+}
+
+// Terminal reports whether no further transitions are expected for the status.
+// Succeeded orders can still be cancelled or completed, so they are not terminal.
+func (s SagaStatus) Terminal() bool {
+	switch s {
+	case SagaStatusFailed, SagaStatusRefunded, SagaStatusCancelled, SagaStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// Hub fans saga events out to per-order subscribers within the process. It only
+// caches delivery locally; events reach it from the shared event log, so writes made
+// by other processes arrive too.
+type Hub struct {
+	mu     sync.Mutex
+	buffer int
+	subs   map[string]map[*Subscription]struct{}
+}
+
+// Subscription receives events for a single order until closed. The channel is
+// closed when the subscriber falls behind by more than the hub's buffer.
+type Subscription struct {
+	hub     *Hub
+	orderID string
+	ch      chan Event
+	closed  bool
+}
+
+// NewHub constructs a Hub with the given per-subscriber buffer.
+func NewHub(buffer int) *Hub {
+	if buffer < 1 {
+		buffer = 1
+	}
+	return &Hub{
+		buffer: buffer,
+		subs:   make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers interest in events for the order.
+func (h *Hub) Subscribe(orderID string) *Subscription {
+	sub := &Subscription{hub: h, orderID: orderID, ch: make(chan Event, h.buffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set, ok := h.subs[orderID]
+	if !ok {
+		set = make(map[*Subscription]struct{})
+		h.subs[orderID] = set
+	}
+	set[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers the event to the order's subscribers without blocking.
+// Subscribers whose buffer is full are dropped.
+func (h *Hub) Publish(ev Event) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs[ev.OrderID] {
+		select {
+		case sub.ch <- ev:
+		default:
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// Len returns the number of open subscriptions across all orders.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, set := range h.subs {
+		n += len(set)
+	}
+	return n
+}
+
+// Events returns the channel of events for the subscription.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	s.hub.removeLocked(s)
+	s.hub.mu.Unlock()
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.ch)
+	set := h.subs[sub.orderID]
+	delete(set, sub)
+	if len(set) == 0 {
+		delete(h.subs, sub.orderID)
+	}
+}
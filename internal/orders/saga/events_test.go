@@ -0,0 +1,71 @@
+package saga
+
+import "testing"
+
+func TestHub_DeliversOnlyToOrderSubscribers(t *testing.T) {
+	hub := NewHub(4)
+	sub := hub.Subscribe("order-1")
+	other := hub.Subscribe("order-2")
+	defer sub.Close()
+	defer other.Close()
+
+	hub.Publish(Event{OrderID: "order-1", Kind: EventKindStep, Step: "charge", Status: "started"})
+
+	select {
+	case ev := <-sub.Events():
+		if ev.Step != "charge" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	default:
+		t.Fatalf("expected event for order-1 subscriber")
+	}
+	select {
+	case ev := <-other.Events():
+		t.Fatalf("unexpected event for order-2 subscriber: %+v", ev)
+	default:
+	}
+}
+
+func TestHub_DropsLaggingSubscriber(t *testing.T) {
+	hub := NewHub(1)
+	sub := hub.Subscribe("order-1")
+
+	hub.Publish(Event{OrderID: "order-1", Status: "a"})
+	hub.Publish(Event{OrderID: "order-1", Status: "b"})
+
+	if ev, ok := <-sub.Events(); !ok || ev.Status != "a" {
+		t.Fatalf("expected buffered event, got %+v ok=%v", ev, ok)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Fatalf("expected channel closed after overflow")
+	}
+	sub.Close()
+	if len(hub.subs) != 0 {
+		t.Fatalf("expected no subscribers left, got %d", len(hub.subs))
+	}
+}
+
+func TestHub_CloseIsIdempotentAndNilPublishIsSafe(t *testing.T) {
+	hub := NewHub(1)
+	sub := hub.Subscribe("order-1")
+	sub.Close()
+	sub.Close()
+	hub.Publish(Event{OrderID: "order-1"})
+
+	var nilHub *Hub
+	nilHub.Publish(Event{OrderID: "order-1"})
+}
+
+func TestSagaStatus_Terminal(t *testing.T) {
+	for status, want := range map[SagaStatus]bool{
+		SagaStatusStarted:   false,
+		SagaStatusSucceeded: false,
+		SagaStatusFailed:    true,
+		SagaStatusRefunded:  true,
+		SagaStatusCancelled: true,
+	} {
+		if got := status.Terminal(); got != want {
+			t.Fatalf("%s: got %v, want %v", status, got, want)
+		}
+	}
+}
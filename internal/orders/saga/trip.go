@@ -0,0 +1,50 @@
+package saga
+
+import (
+	"errors"
+	"time"
+
+	"wayfinder/internal/geo"
+)
+
+// Trip end reasons.
+const (
+	TripEndCompleted = "completed"
+	TripEndCancelled = "cancelled"
+)
+
+var ErrTripNotFound = errors.New("trip not found")
+
+// Trip is the driver's journey for an order, from assignment until the order completes
+// or is cancelled. Its points are the driver's recorded locations within that window.
+type Trip struct {
+	OrderID   string
+	DriverID  string
+	StartedAt time.Time
+	// EndedAt is zero while the trip is in progress.
+	EndedAt   time.Time
+	EndReason string
+	// DistanceMeters is stored when the trip ends; for trips in progress it is computed
+	// from the points recorded so far.
+	DistanceMeters float64
+	Points         []TripPoint
+}
+
+// Active reports whether the trip has not ended yet.
+func (t Trip) Active() bool {
+	return t.EndedAt.IsZero()
+}
+
+// Duration returns how long the trip lasted, or has lasted as of now while in progress.
+func (t Trip) Duration(now time.Time) time.Duration {
+	if t.Active() {
+		return now.Sub(t.StartedAt)
+	}
+	return t.EndedAt.Sub(t.StartedAt)
+}
+
+// TripPoint is one recorded location on a trip.
+type TripPoint struct {
+	geo.Point
+	RecordedAt time.Time
+}
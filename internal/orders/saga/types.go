@@ -3,6 +3,9 @@ package saga
 import (
 	"context"
 	"errors"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/orders/money"
 )
 
 // SagaStatus captures the current state of an order saga.
@@ -13,21 +16,49 @@ const (
 	SagaStatusSucceeded SagaStatus = "succeeded"
 	SagaStatusFailed    SagaStatus = "failed"
 	SagaStatusRefunded  SagaStatus = "refunded"
+	SagaStatusCancelled SagaStatus = "cancelled"
+	// SagaStatusCancelling marks a cancellation that was claimed but whose release or
+	// refund has not finished yet. Recovery resumes it, as does a repeated cancel.
+	SagaStatusCancelling SagaStatus = "cancelling"
+	// SagaStatusCompleted marks a delivered order whose trip has ended.
+	SagaStatusCompleted SagaStatus = "completed"
 )
 
 // SagaRecord represents a stored saga entry.
 type SagaRecord struct {
 	OrderID string
 	UserID  string
-	Amount  float64
-	Status  SagaStatus
+	Amount  money.Money
+	// Pickup is where the driver collects the order. Sagas created before dispatch
+	// was location-aware have a zero pickup.
+	Pickup geo.Point
+	Status SagaStatus
 }
 
 // SagaStore persists idempotency keys and saga steps.
 type SagaStore interface {
-	Start(ctx context.Context, idempotencyKey, orderID, userID string, amount float64) (SagaRecord, bool, error)
+	// Start records a new saga for record, or returns the saga already stored under
+	// idempotencyKey. The bool reports whether a new saga was created.
+	Start(ctx context.Context, idempotencyKey string, record SagaRecord) (SagaRecord, bool, error)
 	UpdateStatus(ctx context.Context, orderID string, status SagaStatus) error
+	// TransitionStatus moves the saga from status from to status to, reporting false
+	// without changing anything when the saga is no longer in from. Of several callers
+	// racing to make the same transition, exactly one sees true.
+	TransitionStatus(ctx context.Context, orderID string, from, to SagaStatus) (bool, error)
 	AddStep(ctx context.Context, orderID, step, status, detail string) error
 }
 
 var ErrIdempotencyConflict = errors.New("idempotency key reused with different payload")
+
+// ErrAlreadyCharged signals an order has already been charged.
+var ErrAlreadyCharged = errors.New("order already charged")
+
+// ErrNotCharged signals an order has no recorded charge.
+var ErrNotCharged = errors.New("order not charged")
+
+// ErrAlreadyRefunded signals an order has already been refunded.
+var ErrAlreadyRefunded = errors.New("order already refunded")
+
+// ErrLeaseLost signals a saga is no longer leased by the caller, or no longer needs
+// recovery.
+var ErrLeaseLost = errors.New("saga lease lost")
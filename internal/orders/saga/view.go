@@ -0,0 +1,56 @@
+package saga
+
+import (
+	"errors"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/orders/money"
+)
+
+// OrderView is the read model of an order assembled from the saga, payment and assignment rows.
+type OrderView struct {
+	OrderID      string
+	UserID       string
+	Amount       money.Money
+	Pickup       geo.Point
+	Status       SagaStatus
+	DriverID     string
+	AssignedAt   time.Time
+	ChargedAt    time.Time
+	RefundAmount money.Money
+	RefundedAt   time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Refunded reports whether the order's payment has been refunded.
+func (v OrderView) Refunded() bool {
+	return !v.RefundedAt.IsZero()
+}
+
+// OrderHistory is an order's view and recorded steps read from one snapshot, together
+// with the IDs of the logged events they already reflect.
+type OrderHistory struct {
+	View     OrderView
+	Steps    []Step
+	EventIDs map[int64]bool
+}
+
+// OrderCursor marks the position after which a listing continues.
+type OrderCursor struct {
+	CreatedAt time.Time
+	OrderID   string
+}
+
+// OrderFilter narrows an order listing. Zero values disable the matching filter.
+type OrderFilter struct {
+	UserID        string
+	Status        SagaStatus
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	After         *OrderCursor
+	Limit         int
+}
+
+var ErrOrderNotFound = errors.New("order not found")
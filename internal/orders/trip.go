@@ -0,0 +1,135 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/orders/saga"
+)
+
+// TripStore reads recorded trips and closes them when an order completes.
+type TripStore interface {
+	GetTrip(ctx context.Context, orderID string) (saga.Trip, error)
+	EndTrip(ctx context.Context, orderID, reason string, distanceMeters float64) error
+}
+
+var (
+	ErrTripNotFound             = saga.ErrTripNotFound
+	ErrTripsDisabled            = errors.New("trips not supported by saga store")
+	ErrOrderNotCompletable      = errors.New("order cannot be completed")
+	ErrInvalidSimplifyTolerance = errors.New("simplify tolerance must be a non-negative number")
+)
+
+// CompleteOrder ends the order's trip with its travelled distance and marks the order
+// completed, which frees the driver for dispatch. The saga moves from succeeded to
+// completed first, so a concurrent cancel cannot also go ahead; if the trip cannot be
+// ended the order returns to succeeded. Completing an already completed order is a
+// no-op. Orders assigned before trips were recorded complete without one.
+func (s *OrderService) CompleteOrder(ctx context.Context, orderID string) error {
+	if orderID == "" {
+		return ErrOrderIDRequired
+	}
+	if s.reader == nil {
+		return ErrOrderReadsDisabled
+	}
+
+	view, err := s.reader.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	switch view.Status {
+	case saga.SagaStatusCompleted:
+		return nil
+	case saga.SagaStatusSucceeded:
+	default:
+		return fmt.Errorf("%w: status %s", ErrOrderNotCompletable, view.Status)
+	}
+
+	claimed, err := s.sagas.TransitionStatus(ctx, orderID, saga.SagaStatusSucceeded, saga.SagaStatusCompleted)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return s.lostTransition(ctx, orderID, ErrOrderNotCompletable, saga.SagaStatusCompleted)
+	}
+
+	_ = s.sagas.AddStep(ctx, orderID, "complete", "started", "")
+	if s.trips != nil {
+		if err := s.endTrip(ctx, orderID); err != nil {
+			_ = s.sagas.AddStep(ctx, orderID, "complete", "failed", err.Error())
+			if _, rerr := s.sagas.TransitionStatus(ctx, orderID, saga.SagaStatusCompleted, saga.SagaStatusSucceeded); rerr != nil {
+				slog.ErrorContext(ctx, "complete order: reopen failed", "order_id", orderID, "error", rerr)
+			}
+			return fmt.Errorf("end trip: %w", err)
+		}
+	}
+	_ = s.sagas.AddStep(ctx, orderID, "complete", "succeeded", "")
+	return nil
+}
+
+func (s *OrderService) endTrip(ctx context.Context, orderID string) error {
+	trip, err := s.trips.GetTrip(ctx, orderID)
+	if errors.Is(err, ErrTripNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !trip.Active() {
+		return nil
+	}
+	return s.trips.EndTrip(ctx, orderID, saga.TripEndCompleted, pathLength(trip.Points))
+}
+
+// GetOrderTrip returns the order's trip. Trips still in progress report the distance
+// travelled so far. A positive toleranceMeters simplifies the returned polyline with
+// Douglas–Peucker; the distance is always measured on the full recorded path.
+func (s *OrderService) GetOrderTrip(ctx context.Context, orderID string, toleranceMeters float64) (saga.Trip, error) {
+	if orderID == "" {
+		return saga.Trip{}, ErrOrderIDRequired
+	}
+	if s.trips == nil {
+		return saga.Trip{}, ErrTripsDisabled
+	}
+	if toleranceMeters < 0 || math.IsNaN(toleranceMeters) || math.IsInf(toleranceMeters, 0) {
+		return saga.Trip{}, ErrInvalidSimplifyTolerance
+	}
+
+	trip, err := s.trips.GetTrip(ctx, orderID)
+	if err != nil {
+		return saga.Trip{}, err
+	}
+	if trip.Active() {
+		trip.DistanceMeters = pathLength(trip.Points)
+	}
+	trip.Points = simplifyTrip(trip.Points, toleranceMeters)
+	return trip, nil
+}
+
+func tripPath(points []saga.TripPoint) []geo.Point {
+	path := make([]geo.Point, len(points))
+	for i, p := range points {
+		path[i] = p.Point
+	}
+	return path
+}
+
+func pathLength(points []saga.TripPoint) float64 {
+	return geo.PathLength(tripPath(points))
+}
+
+func simplifyTrip(points []saga.TripPoint, toleranceMeters float64) []saga.TripPoint {
+	if toleranceMeters <= 0 {
+		return points
+	}
+	kept := geo.SimplifyIndices(tripPath(points), toleranceMeters)
+	out := make([]saga.TripPoint, len(kept))
+	for i, idx := range kept {
+		out[i] = points[idx]
+	}
+	return out
+}
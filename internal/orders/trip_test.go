@@ -0,0 +1,187 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"wayfinder/internal/geo"
+	"wayfinder/internal/orders/saga"
+)
+
+type spyTripSagaStore struct {
+	spyReaderSagaStore
+	trip    saga.Trip
+	tripErr error
+	ended   []string
+	endDist float64
+}
+
+func (s *spyTripSagaStore) GetTrip(ctx context.Context, orderID string) (saga.Trip, error) {
+	if s.tripErr != nil {
+		return saga.Trip{}, s.tripErr
+	}
+	return s.trip, nil
+}
+
+func (s *spyTripSagaStore) EndTrip(ctx context.Context, orderID, reason string, distanceMeters float64) error {
+	s.ended = append(s.ended, orderID+":"+reason)
+	s.endDist = distanceMeters
+	return nil
+}
+
+func tripPoints(start time.Time, points ...geo.Point) []saga.TripPoint {
+	out := make([]saga.TripPoint, len(points))
+	for i, p := range points {
+		out[i] = saga.TripPoint{Point: p, RecordedAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+	return out
+}
+
+func newTripFixture(status saga.SagaStatus, trip saga.Trip) (*OrderService, *spyTripSagaStore) {
+	store := &spyTripSagaStore{trip: trip}
+	store.views = []saga.OrderView{{OrderID: "order-1", Status: status}}
+	return NewOrderService(&spyPayment{}, &spyDriver{}, store, nil, randomDriver), store
+}
+
+var (
+	tripA = geo.Point{Lat: 52.5000, Long: 13.4000}
+	tripB = geo.Point{Lat: 52.5010, Long: 13.40007}
+	tripC = geo.Point{Lat: 52.5020, Long: 13.4000}
+	tripD = geo.Point{Lat: 52.5020, Long: 13.4100}
+)
+
+func TestCompleteOrder_EndsTripWithDistance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	service, store := newTripFixture(saga.SagaStatusSucceeded, saga.Trip{OrderID: "order-1", DriverID: "driver-1", StartedAt: start, Points: tripPoints(start, tripA, tripB, tripC)})
+
+	if err := service.CompleteOrder(context.Background(), "order-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.ended) != 1 || store.ended[0] != "order-1:"+saga.TripEndCompleted {
+		t.Fatalf("unexpected ended trips: %v", store.ended)
+	}
+	if want := geo.Distance(tripA, tripB) + geo.Distance(tripB, tripC); math.Abs(store.endDist-want) > 1e-9 {
+		t.Fatalf("got distance %f, want %f", store.endDist, want)
+	}
+	if len(store.statuses) != 1 || store.statuses[0] != saga.SagaStatusCompleted {
+		t.Fatalf("expected completed status, got %v", store.statuses)
+	}
+	if len(store.steps) != 2 || store.steps[0].step != "complete" || store.steps[1].status != "succeeded" {
+		t.Fatalf("unexpected steps: %+v", store.steps)
+	}
+}
+
+func TestCompleteOrder_WithoutTripStillCompletes(t *testing.T) {
+	t.Parallel()
+
+	service, store := newTripFixture(saga.SagaStatusSucceeded, saga.Trip{})
+	store.tripErr = saga.ErrTripNotFound
+
+	if err := service.CompleteOrder(context.Background(), "order-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.ended) != 0 || len(store.statuses) != 1 || store.statuses[0] != saga.SagaStatusCompleted {
+		t.Fatalf("unexpected side effects: ended=%v statuses=%v", store.ended, store.statuses)
+	}
+}
+
+func TestCompleteOrder_TripErrorReopensOrder(t *testing.T) {
+	t.Parallel()
+
+	service, store := newTripFixture(saga.SagaStatusSucceeded, saga.Trip{})
+	store.tripErr = errors.New("db down")
+
+	if err := service.CompleteOrder(context.Background(), "order-1"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if len(store.statuses) != 2 || store.statuses[1] != saga.SagaStatusSucceeded || store.views[0].Status != saga.SagaStatusSucceeded {
+		t.Fatalf("expected the order back in succeeded, got %v", store.statuses)
+	}
+}
+
+func TestCompleteOrder_StatusRules(t *testing.T) {
+	t.Parallel()
+
+	service, store := newTripFixture(saga.SagaStatusCompleted, saga.Trip{})
+	if err := service.CompleteOrder(context.Background(), "order-1"); err != nil || len(store.statuses) != 0 {
+		t.Fatalf("expected repeated completion to be a no-op, got err=%v statuses=%v", err, store.statuses)
+	}
+
+	for _, status := range []saga.SagaStatus{saga.SagaStatusStarted, saga.SagaStatusCancelled, saga.SagaStatusRefunded} {
+		service, _ := newTripFixture(status, saga.Trip{})
+		if err := service.CompleteOrder(context.Background(), "order-1"); !errors.Is(err, ErrOrderNotCompletable) {
+			t.Fatalf("status %s: expected ErrOrderNotCompletable, got %v", status, err)
+		}
+	}
+
+	if err := service.CompleteOrder(context.Background(), ""); !errors.Is(err, ErrOrderIDRequired) {
+		t.Fatalf("expected ErrOrderIDRequired, got %v", err)
+	}
+}
+
+func TestGetOrderTrip_ActiveTripMeasuresFullPathAndSimplifies(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	points := tripPoints(start, tripA, tripB, tripC, tripD)
+	service, _ := newTripFixture(saga.SagaStatusSucceeded, saga.Trip{OrderID: "order-1", StartedAt: start, Points: points})
+
+	trip, err := service.GetOrderTrip(context.Background(), "order-1", 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := geo.Distance(tripA, tripB) + geo.Distance(tripB, tripC) + geo.Distance(tripC, tripD)
+	if math.Abs(trip.DistanceMeters-want) > 1e-9 {
+		t.Fatalf("got distance %f, want %f", trip.DistanceMeters, want)
+	}
+	if len(trip.Points) != 3 || trip.Points[0] != points[0] || trip.Points[1] != points[2] || trip.Points[2] != points[3] {
+		t.Fatalf("expected wobble dropped with timestamps kept, got %+v", trip.Points)
+	}
+
+	full, err := service.GetOrderTrip(context.Background(), "order-1", 0)
+	if err != nil || len(full.Points) != len(points) {
+		t.Fatalf("expected unsimplified points, got %+v err=%v", full.Points, err)
+	}
+}
+
+func TestGetOrderTrip_EndedTripKeepsStoredDistance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	service, _ := newTripFixture(saga.SagaStatusCompleted, saga.Trip{
+		OrderID: "order-1", StartedAt: start, EndedAt: start.Add(time.Hour), EndReason: saga.TripEndCompleted,
+		DistanceMeters: 4200, Points: tripPoints(start, tripA, tripB),
+	})
+
+	trip, err := service.GetOrderTrip(context.Background(), "order-1", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trip.DistanceMeters != 4200 {
+		t.Fatalf("expected stored distance, got %f", trip.DistanceMeters)
+	}
+}
+
+func TestGetOrderTrip_RejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	service, _ := newTripFixture(saga.SagaStatusSucceeded, saga.Trip{})
+	if _, err := service.GetOrderTrip(context.Background(), "", 0); !errors.Is(err, ErrOrderIDRequired) {
+		t.Fatalf("expected ErrOrderIDRequired, got %v", err)
+	}
+	for _, tol := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := service.GetOrderTrip(context.Background(), "order-1", tol); !errors.Is(err, ErrInvalidSimplifyTolerance) {
+			t.Fatalf("tolerance %v: expected ErrInvalidSimplifyTolerance, got %v", tol, err)
+		}
+	}
+
+	noTrips := NewOrderService(&spyPayment{}, &spyDriver{}, &spySagaStore{}, nil, randomDriver)
+	if _, err := noTrips.GetOrderTrip(context.Background(), "order-1", 0); !errors.Is(err, ErrTripsDisabled) {
+		t.Fatalf("expected ErrTripsDisabled, got %v", err)
+	}
+}
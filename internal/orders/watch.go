@@ -0,0 +1,196 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+)
+
+// OrderWatcher exposes saga step history and the event log every step and status
+// write is appended to, whichever process makes it.
+type OrderWatcher interface {
+	// OrderHistory returns the order's view and steps with the IDs of the logged events
+	// they reflect, all as of one point in time.
+	OrderHistory(ctx context.Context, orderID string) (saga.OrderHistory, error)
+	// LatestEventID returns the ID of the newest logged event, or 0 when there is none.
+	LatestEventID(ctx context.Context) (int64, error)
+	// EventsAfter returns up to limit logged events with IDs above afterID, in ID order.
+	EventsAfter(ctx context.Context, afterID int64, limit int) ([]saga.Event, error)
+}
+
+var (
+	ErrOrderWatchDisabled = errors.New("order watch not supported by saga store")
+	ErrWatchLagged        = errors.New("order watcher fell behind; reconnect to replay")
+)
+
+const (
+	defaultWatchPollInterval = 250 * time.Millisecond
+	// defaultWatchGapGrace is how long a missing event ID holds back later events. IDs
+	// are allocated before commit, so a gap is usually a write still committing; one
+	// that stays missing belongs to a transaction that rolled back.
+	defaultWatchGapGrace = 2 * time.Second
+	watchPollBatch       = 100
+)
+
+// eventFeed polls the shared event log and publishes new events to a process-local
+// hub, which fans them out to this process's watchers. It runs only while someone is
+// subscribed.
+type eventFeed struct {
+	log      OrderWatcher
+	hub      *saga.Hub
+	interval time.Duration
+	gapGrace time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	running bool
+}
+
+func newEventFeed(log OrderWatcher) *eventFeed {
+	return &eventFeed{
+		log:      log,
+		hub:      saga.NewHub(64),
+		interval: defaultWatchPollInterval,
+		gapGrace: defaultWatchGapGrace,
+		now:      time.Now,
+	}
+}
+
+// subscribe registers interest in the order's events, starting the poller from the
+// newest logged event when it is not already running. Events logged after subscribe
+// returns are delivered.
+func (f *eventFeed) subscribe(ctx context.Context, orderID string) (*saga.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.running {
+		cursor, err := f.log.LatestEventID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		f.running = true
+		go f.run(cursor)
+	}
+	return f.hub.Subscribe(orderID), nil
+}
+
+// run publishes events logged after cursor until the last subscription closes.
+func (f *eventFeed) run(cursor int64) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	var gapSince time.Time
+	for range ticker.C {
+		f.mu.Lock()
+		if f.hub.Len() == 0 {
+			f.running = false
+			f.mu.Unlock()
+			return
+		}
+		f.mu.Unlock()
+
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			events, err := f.log.EventsAfter(ctx, cursor, watchPollBatch)
+			cancel()
+			if err != nil {
+				slog.Warn("order watch: read event log failed", "error", err)
+				break
+			}
+			published := 0
+			for _, ev := range events {
+				if ev.ID != cursor+1 {
+					if gapSince.IsZero() {
+						gapSince = f.now()
+					}
+					if f.now().Sub(gapSince) < f.gapGrace {
+						break
+					}
+				}
+				gapSince = time.Time{}
+				cursor = ev.ID
+				f.hub.Publish(ev)
+				published++
+			}
+			if published < watchPollBatch {
+				break
+			}
+		}
+	}
+}
+
+// WatchOrder replays the order's recorded steps and current status, then streams live
+// events to send until the order reaches a terminal status or ctx ends. Live events
+// come from the shared event log, so writes made by other replicas are streamed too.
+func (s *OrderService) WatchOrder(ctx context.Context, orderID string, send func(saga.Event) error) error {
+	if orderID == "" {
+		return ErrOrderIDRequired
+	}
+	if s.watcher == nil {
+		return ErrOrderWatchDisabled
+	}
+
+	// Subscribe before reading history so no write between the two is missed.
+	sub, err := s.feed.subscribe(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	history, err := s.watcher.OrderHistory(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	view := history.View
+
+	for _, step := range history.Steps {
+		if err := send(saga.Event{
+			OrderID: orderID,
+			Kind:    saga.EventKindStep,
+			Step:    step.Step,
+			Status:  step.Status,
+			Detail:  step.Detail,
+			At:      step.CreatedAt,
+			Replay:  true,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := send(saga.Event{
+		OrderID: orderID,
+		Kind:    saga.EventKindStatus,
+		Status:  string(view.Status),
+		At:      view.UpdatedAt,
+		Replay:  true,
+	}); err != nil {
+		return err
+	}
+	if view.Status.Terminal() {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return ErrWatchLagged
+			}
+			// Events logged before history was read are already part of the replay.
+			// Matching by ID keeps a genuinely repeated step, e.g. a retry, live.
+			if history.EventIDs[ev.ID] {
+				continue
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+			if ev.Kind == saga.EventKindStatus && saga.SagaStatus(ev.Status).Terminal() {
+				return nil
+			}
+		}
+	}
+}
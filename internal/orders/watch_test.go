@@ -0,0 +1,366 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"wayfinder/internal/orders/saga"
+)
+
+// memOrderDB stands in for the tables replicas share: one order's view, its step
+// history and the event log every write is appended to.
+// beforeHistory and afterHistory run around the snapshot OrderHistory takes.
+type memOrderDB struct {
+	mu            sync.Mutex
+	view          saga.OrderView
+	history       []saga.Step
+	log           []saga.Event
+	beforeHistory func()
+	afterHistory  func()
+}
+
+// memWatchStore is one process's saga store over a memOrderDB.
+type memWatchStore struct {
+	db *memOrderDB
+}
+
+func newWatchStore(status saga.SagaStatus, history ...saga.Step) *memWatchStore {
+	return &memWatchStore{db: &memOrderDB{
+		view:    saga.OrderView{OrderID: "order-1", Status: status},
+		history: history,
+	}}
+}
+
+// record appends ev to the event log, as a committed write would.
+func (d *memOrderDB) record(ev saga.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ev.ID = int64(len(d.log) + 1)
+	d.log = append(d.log, ev)
+}
+
+func (s *memWatchStore) Start(ctx context.Context, idempotencyKey string, record saga.SagaRecord) (saga.SagaRecord, bool, error) {
+	return record, true, nil
+}
+
+func (s *memWatchStore) UpdateStatus(ctx context.Context, orderID string, status saga.SagaStatus) error {
+	s.db.mu.Lock()
+	s.db.view.Status = status
+	s.db.mu.Unlock()
+	s.db.record(saga.Event{OrderID: orderID, Kind: saga.EventKindStatus, Status: string(status)})
+	return nil
+}
+
+func (s *memWatchStore) TransitionStatus(ctx context.Context, orderID string, from, to saga.SagaStatus) (bool, error) {
+	s.db.mu.Lock()
+	if s.db.view.Status != from {
+		s.db.mu.Unlock()
+		return false, nil
+	}
+	s.db.view.Status = to
+	s.db.mu.Unlock()
+	s.db.record(saga.Event{OrderID: orderID, Kind: saga.EventKindStatus, Status: string(to)})
+	return true, nil
+}
+
+func (s *memWatchStore) AddStep(ctx context.Context, orderID, step, status, detail string) error {
+	s.db.mu.Lock()
+	s.db.history = append(s.db.history, saga.Step{Step: step, Status: status, Detail: detail})
+	s.db.mu.Unlock()
+	s.db.record(saga.Event{OrderID: orderID, Kind: saga.EventKindStep, Step: step, Status: status, Detail: detail})
+	return nil
+}
+
+func (s *memWatchStore) GetOrder(ctx context.Context, orderID string) (saga.OrderView, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if orderID != s.db.view.OrderID {
+		return saga.OrderView{}, saga.ErrOrderNotFound
+	}
+	return s.db.view, nil
+}
+
+func (s *memWatchStore) ListOrders(ctx context.Context, filter saga.OrderFilter) ([]saga.OrderView, error) {
+	return nil, nil
+}
+
+func (s *memWatchStore) OrderHistory(ctx context.Context, orderID string) (saga.OrderHistory, error) {
+	if s.db.beforeHistory != nil {
+		s.db.beforeHistory()
+	}
+	s.db.mu.Lock()
+	if orderID != s.db.view.OrderID {
+		s.db.mu.Unlock()
+		return saga.OrderHistory{}, saga.ErrOrderNotFound
+	}
+	history := saga.OrderHistory{
+		View:     s.db.view,
+		Steps:    append([]saga.Step(nil), s.db.history...),
+		EventIDs: make(map[int64]bool, len(s.db.log)),
+	}
+	for _, ev := range s.db.log {
+		history.EventIDs[ev.ID] = true
+	}
+	s.db.mu.Unlock()
+	if s.db.afterHistory != nil {
+		s.db.afterHistory()
+	}
+	return history, nil
+}
+
+func (s *memWatchStore) LatestEventID(ctx context.Context) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return int64(len(s.db.log)), nil
+}
+
+func (s *memWatchStore) EventsAfter(ctx context.Context, afterID int64, limit int) ([]saga.Event, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	var out []saga.Event
+	for _, ev := range s.db.log {
+		if ev.ID > afterID && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+// newWatchService returns a service over store whose event feed polls every millisecond.
+func newWatchService(store *memWatchStore) *OrderService {
+	service := NewOrderService(&spyPayment{}, &spyDriver{}, store, nil, randomDriver)
+	service.feed.interval = time.Millisecond
+	return service
+}
+
+// waitFor polls cond until it holds or a second passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func collectEvents(events *[]saga.Event) func(saga.Event) error {
+	return func(ev saga.Event) error {
+		*events = append(*events, ev)
+		return nil
+	}
+}
+
+func TestWatchOrder_ReplaysHistoryAndStopsOnTerminalStatus(t *testing.T) {
+	t.Parallel()
+
+	store := newWatchStore(saga.SagaStatusRefunded,
+		saga.Step{Step: "charge", Status: "succeeded"},
+		saga.Step{Step: "refund", Status: "succeeded"},
+	)
+	service := newWatchService(store)
+
+	var events []saga.Event
+	if err := service.WatchOrder(context.Background(), "order-1", collectEvents(&events)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 replayed events, got %+v", events)
+	}
+	for _, ev := range events {
+		if !ev.Replay || ev.OrderID != "order-1" {
+			t.Fatalf("expected replayed event for order-1, got %+v", ev)
+		}
+	}
+	if events[2].Kind != saga.EventKindStatus || events[2].Status != "refunded" {
+		t.Fatalf("expected final status event, got %+v", events[2])
+	}
+}
+
+func TestWatchOrder_StreamsLiveEventsWithoutDuplicatingReplay(t *testing.T) {
+	t.Parallel()
+
+	store := newWatchStore(saga.SagaStatusStarted, saga.Step{Step: "charge", Status: "started"})
+	ctx := context.Background()
+	// Writes between Subscribe and the history read show up both in history and live.
+	store.db.beforeHistory = func() {
+		_ = store.AddStep(ctx, "order-1", "charge", "succeeded", "")
+		_ = store.AddStep(ctx, "order-1", "assign", "started", "")
+	}
+	// A retried assignment repeats the last replayed step word for word, but is new.
+	store.db.afterHistory = func() {
+		_ = store.AddStep(ctx, "order-1", "assign", "started", "")
+		_ = store.UpdateStatus(ctx, "order-1", saga.SagaStatusCancelled)
+	}
+	service := newWatchService(store)
+
+	var events []saga.Event
+	if err := service.WatchOrder(ctx, "order-1", collectEvents(&events)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		step, status string
+		replay       bool
+	}{
+		{"charge", "started", true},
+		{"charge", "succeeded", true},
+		{"assign", "started", true},
+		{"", "started", true},
+		{"assign", "started", false},
+		{"", "cancelled", false},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	for i, w := range want {
+		if events[i].Step != w.step || events[i].Status != w.status || events[i].Replay != w.replay {
+			t.Fatalf("event %d: got %+v, want %+v", i, events[i], w)
+		}
+	}
+}
+
+func TestWatchOrder_ReturnsLaggedWhenSubscriptionDropped(t *testing.T) {
+	t.Parallel()
+
+	store := newWatchStore(saga.SagaStatusSucceeded)
+	service := newWatchService(store)
+	service.feed.hub = saga.NewHub(1)
+	// The watcher is still reading history when two events arrive, overflowing its buffer.
+	store.db.beforeHistory = func() {
+		store.db.record(saga.Event{OrderID: "order-1", Kind: saga.EventKindStep, Step: "cancel", Status: "started"})
+		store.db.record(saga.Event{OrderID: "order-1", Kind: saga.EventKindStep, Step: "refund", Status: "started"})
+		waitFor(t, func() bool { return service.feed.hub.Len() == 0 })
+	}
+
+	var events []saga.Event
+	err := service.WatchOrder(context.Background(), "order-1", collectEvents(&events))
+	if !errors.Is(err, ErrWatchLagged) {
+		t.Fatalf("expected ErrWatchLagged, got %v", err)
+	}
+}
+
+func TestWatchOrder_StopsOnContextCancel(t *testing.T) {
+	t.Parallel()
+
+	store := newWatchStore(saga.SagaStatusSucceeded)
+	service := newWatchService(store)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var events []saga.Event
+	err := service.WatchOrder(ctx, "order-1", collectEvents(&events))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if len(events) != 1 || events[0].Status != "succeeded" {
+		t.Fatalf("expected current status replay, got %+v", events)
+	}
+}
+
+func TestWatchOrder_RequiresWatcher(t *testing.T) {
+	t.Parallel()
+
+	service := NewOrderService(&spyPayment{}, &spyDriver{}, &spyReaderSagaStore{}, nil, randomDriver)
+
+	err := service.WatchOrder(context.Background(), "order-1", func(saga.Event) error { return nil })
+	if !errors.Is(err, ErrOrderWatchDisabled) {
+		t.Fatalf("expected ErrOrderWatchDisabled, got %v", err)
+	}
+}
+
+func TestWatchOrder_StreamsWritesFromAnotherService(t *testing.T) {
+	t.Parallel()
+
+	// Two replicas: the watcher's service never sees the writer's calls in process.
+	shared := newWatchStore(saga.SagaStatusSucceeded, saga.Step{Step: "assign", Status: "succeeded"})
+	writer := newWatchService(&memWatchStore{db: shared.db})
+	watcher := newWatchService(&memWatchStore{db: shared.db})
+
+	done := make(chan error, 1)
+	var events []saga.Event
+	go func() { done <- watcher.WatchOrder(context.Background(), "order-1", collectEvents(&events)) }()
+	waitFor(t, func() bool { return watcher.feed.hub.Len() == 1 })
+
+	if err := writer.CancelOrder(context.Background(), "order-1", "customer request"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("watch: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("watcher never saw the other service's writes")
+	}
+	last := events[len(events)-1]
+	if last.Replay || last.Kind != saga.EventKindStatus || last.Status != "cancelled" || last.ID == 0 {
+		t.Fatalf("expected a live cancelled status from the event log, got %+v", last)
+	}
+}
+
+func TestEventFeed_HoldsBackEventsBehindAGap(t *testing.T) {
+	t.Parallel()
+
+	store := newWatchStore(saga.SagaStatusSucceeded)
+	service := newWatchService(store)
+	now := time.Unix(0, 0)
+	var mu sync.Mutex
+	service.feed.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	sub, err := service.feed.subscribe(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	// Event 1 is still committing when event 2 becomes visible.
+	store.db.mu.Lock()
+	store.db.log = append(store.db.log, saga.Event{ID: 2, OrderID: "order-1", Kind: saga.EventKindStep, Step: "refund", Status: "started"})
+	store.db.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case ev := <-sub.Events():
+		t.Fatalf("expected event 2 to wait for event 1, got %+v", ev)
+	default:
+	}
+
+	// Once the gap outlives the grace period it is taken to be a rolled back write.
+	mu.Lock()
+	now = now.Add(defaultWatchGapGrace)
+	mu.Unlock()
+	select {
+	case ev := <-sub.Events():
+		if ev.ID != 2 {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("event behind an expired gap was never delivered")
+	}
+}
+
+func TestEventFeed_StopsWithoutSubscribers(t *testing.T) {
+	t.Parallel()
+
+	service := newWatchService(newWatchStore(saga.SagaStatusSucceeded))
+	sub, err := service.feed.subscribe(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	sub.Close()
+	waitFor(t, func() bool {
+		service.feed.mu.Lock()
+		defer service.feed.mu.Unlock()
+		return !service.feed.running
+	})
+}
@@ -19,7 +19,7 @@ seq "$N" | xargs -n1 -P"$CONCURRENCY" -I{} sh -c '
 
 # Order creations (unique idempotency keys per request)
 seq "$N" | xargs -n1 -P"$CONCURRENCY" -I{} sh -c '
-  grpcurl -plaintext -d "{\"userId\":\"bench-user\",\"amount\":9.99,\"idempotencyKey\":\"bench-idem-{}\"}" \
+  grpcurl -plaintext -d "{\"userId\":\"bench-user\",\"total\":{\"units\":999,\"currency\":\"USD\"},\"pickup\":{\"latitude\":52.52,\"longitude\":13.405},\"idempotencyKey\":\"bench-idem-{}\"}" \
     localhost:50051 order.OrderService/CreateOrder >/dev/null
 '
 